@@ -3,6 +3,7 @@ package ai
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/bitop-dev/ai/internal/provider"
@@ -112,3 +113,81 @@ func TestAgent_MultiStepWhenConfigured(t *testing.T) {
 		t.Fatalf("provider calls=%d", got)
 	}
 }
+
+func TestPrompt_SingleTurn(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		return provider.Response{
+			Message: provider.Message{
+				Role:    provider.RoleAssistant,
+				Content: []provider.ContentPart{provider.TextPart{Text: "pong"}},
+			},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	out, err := Prompt(context.Background(), testModel{provider: providerName, name: "m"}, "ping", WithSystem("sys"), WithMaxTokens(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "pong" {
+		t.Fatalf("out=%q", out)
+	}
+	req := fp.Requests()[0]
+	if len(req.Messages) != 2 || req.Messages[0].Role != provider.RoleSystem || req.Messages[1].Role != provider.RoleUser {
+		t.Fatalf("messages=%#v", req.Messages)
+	}
+	if req.MaxTokens == nil || *req.MaxTokens != 10 {
+		t.Fatalf("MaxTokens=%v", req.MaxTokens)
+	}
+}
+
+func TestAgent_ForwardsGenerationOptions(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		return provider.Response{
+			Message: provider.Message{
+				Role:    provider.RoleAssistant,
+				Content: []provider.ContentPart{provider.TextPart{Text: "secret answer"}},
+			},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	maxTokens := 64
+	temp := float32(0.2)
+	a := Agent{
+		Model:           testModel{provider: providerName, name: "m"},
+		System:          "be brief",
+		MaxTokens:       &maxTokens,
+		Temperature:     &temp,
+		Stop:            []string{"END"},
+		ReasoningEffort: "low",
+		Metadata:        map[string]string{"team": "core"},
+		RedactOutput:    func(s string) string { return strings.ReplaceAll(s, "secret", "[redacted]") },
+	}
+
+	resp, err := a.Generate(context.Background(), AgentGenerateRequest{Prompt: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "[redacted] answer" {
+		t.Fatalf("Text=%q", resp.Text)
+	}
+	reqs := fp.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("provider calls=%d", len(reqs))
+	}
+	got := reqs[0]
+	if got.MaxTokens == nil || *got.MaxTokens != 64 || got.Temperature == nil || *got.Temperature != temp {
+		t.Fatalf("sampling params not forwarded: %#v", got)
+	}
+	if len(got.Stop) != 1 || got.Stop[0] != "END" || got.ReasoningEffort != "low" || got.Metadata["team"] != "core" {
+		t.Fatalf("request options not forwarded: %#v", got)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Role != provider.RoleSystem {
+		t.Fatalf("messages=%#v", got.Messages)
+	}
+}
@@ -0,0 +1,159 @@
+package ai
+
+import (
+	"context"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// customProvider adapts a public Provider to the internal provider interface.
+type customProvider struct {
+	p Provider
+}
+
+func (c *customProvider) Generate(ctx context.Context, req provider.Request) (provider.Response, error) {
+	preq, err := fromInternalRequest(req)
+	if err != nil {
+		return provider.Response{}, err
+	}
+	resp, err := c.p.Generate(ctx, preq)
+	if err != nil {
+		return provider.Response{}, err
+	}
+	return toInternalResponse(resp)
+}
+
+func (c *customProvider) Stream(ctx context.Context, req provider.Request) (provider.Stream, error) {
+	preq, err := fromInternalRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	s, err := c.p.Stream(ctx, preq)
+	if err != nil {
+		return nil, err
+	}
+	return &customStream{s: s}, nil
+}
+
+var _ provider.Provider = (*customProvider)(nil)
+
+type customStream struct {
+	s     ProviderStream
+	final *provider.Response
+	err   error
+}
+
+func (s *customStream) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	return s.s.Next()
+}
+
+func (s *customStream) Delta() provider.Delta {
+	d := s.s.Delta()
+	out := provider.Delta{Text: d.Text}
+	for _, tc := range d.ToolCalls {
+		out.ToolCalls = append(out.ToolCalls, provider.ToolCallDelta{
+			Index:          tc.Index,
+			ID:             tc.ID,
+			Name:           tc.Name,
+			ArgumentsDelta: tc.ArgumentsDelta,
+		})
+	}
+	if d.Usage != nil {
+		out.Usage = &provider.Usage{
+			PromptTokens:     d.Usage.PromptTokens,
+			CompletionTokens: d.Usage.CompletionTokens,
+			TotalTokens:      d.Usage.TotalTokens,
+		}
+	}
+	return out
+}
+
+func (s *customStream) Final() *provider.Response {
+	if s.final != nil {
+		return s.final
+	}
+	f := s.s.Final()
+	if f == nil {
+		return nil
+	}
+	r, err := toInternalResponse(*f)
+	if err != nil {
+		s.err = err
+		return nil
+	}
+	s.final = &r
+	return s.final
+}
+
+func (s *customStream) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.s.Err()
+}
+
+func (s *customStream) Close() error { return s.s.Close() }
+
+var _ provider.Stream = (*customStream)(nil)
+
+func fromInternalRequest(req provider.Request) (ProviderRequest, error) {
+	msgs, err := messagesFromProviderMessages(req.Messages)
+	if err != nil {
+		return ProviderRequest{}, err
+	}
+	var tools []ToolDefinition
+	for _, t := range req.Tools {
+		tools = append(tools, ToolDefinition{
+			Name:        t.Name,
+			Description: t.Description,
+			InputSchema: JSONSchema(t.InputSchema),
+			Strict:      t.Strict,
+			Hosted:      t.Hosted,
+			Config:      t.Config,
+		})
+	}
+	return ProviderRequest{
+		Model:       req.Model,
+		Messages:    msgs,
+		Tools:       tools,
+		Headers:     cloneStringMap(req.Headers),
+		MaxRetries:  req.MaxRetries,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stop:        append([]string(nil), req.Stop...),
+		Metadata:    cloneStringMap(req.Metadata),
+
+		ReasoningEffort: req.ReasoningEffort,
+		Verbosity:       req.Verbosity,
+		AssistantPrefix: req.AssistantPrefix,
+		Prediction:      req.Prediction,
+		BaseURLOverride: req.BaseURLOverride,
+	}, nil
+}
+
+func toInternalResponse(resp ProviderResponse) (provider.Response, error) {
+	if resp.Message.Role == "" {
+		resp.Message.Role = RoleAssistant
+	}
+	msg, err := toProviderMessage(resp.Message)
+	if err != nil {
+		return provider.Response{}, err
+	}
+	return provider.Response{
+		Message: msg,
+		Usage: provider.Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+		FinishReason:      provider.FinishReason(resp.FinishReason),
+		ModelID:           resp.ModelID,
+		SystemFingerprint: resp.SystemFingerprint,
+		ResponseID:        resp.ResponseID,
+		RequestID:         resp.RequestID,
+	}, nil
+}
@@ -15,6 +15,8 @@ func mapProviderError(err error) error {
 		return &Error{
 			Provider:  pe.Provider,
 			Code:      pe.Code,
+			Type:      pe.Type,
+			Param:     pe.Param,
 			Status:    pe.Status,
 			Message:   pe.Message,
 			Retryable: pe.Retryable,
@@ -33,6 +33,7 @@ func fromProviderMessage(m provider.Message) (Message, error) {
 		Content:    parts,
 		Name:       m.Name,
 		ToolCallID: m.ToolCallID,
+		Metadata:   m.Metadata,
 	}, nil
 }
 
@@ -66,6 +67,8 @@ func fromProviderContentParts(parts []provider.ContentPart) ([]ContentPart, erro
 			out = append(out, ImagePart{URL: v.URL, MediaType: v.MediaType, Bytes: append([]byte(nil), v.Bytes...), Base64: v.Base64})
 		case provider.AudioPart:
 			out = append(out, AudioPart{Format: v.Format, Bytes: append([]byte(nil), v.Bytes...), Base64: v.Base64})
+		case provider.ReasoningPart:
+			out = append(out, ReasoningPart{Text: v.Text})
 		default:
 			return nil, fmt.Errorf("unknown provider content part type %T", p)
 		}
@@ -1,9 +1,14 @@
 package ai
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
 
 	"github.com/bitop-dev/ai/internal/provider"
+	internalSchema "github.com/bitop-dev/ai/internal/schema"
 	"github.com/bitop-dev/ai/openai"
 )
 
@@ -12,10 +17,25 @@ func toProviderRequest(req BaseRequest) (provider.Request, error) {
 		return provider.Request{}, fmt.Errorf("model is required")
 	}
 	if req.Model.Name() == "" {
+		if _, ok := req.Model.(openai.ModelRef); ok {
+			return provider.Request{}, fmt.Errorf("model name is required (pass one to openai.Chat or set openai.Config.DefaultModel)")
+		}
 		return provider.Request{}, fmt.Errorf("model name is required")
 	}
+	if !req.SkipParamValidation {
+		if err := validateSamplingParams(req); err != nil {
+			return provider.Request{}, err
+		}
+	}
 
-	msgs, err := toProviderMessages(req.Messages)
+	messages, err := messagesWithSystem(req)
+	if err != nil {
+		return provider.Request{}, err
+	}
+	if err := checkToolResults(messages); err != nil {
+		return provider.Request{}, err
+	}
+	msgs, err := toProviderMessages(messages)
 	if err != nil {
 		return provider.Request{}, err
 	}
@@ -24,6 +44,9 @@ func toProviderRequest(req BaseRequest) (provider.Request, error) {
 	if err != nil {
 		return provider.Request{}, err
 	}
+	if err := overrideToolDescriptions(tools, req.ToolDescriptions); err != nil {
+		return provider.Request{}, err
+	}
 
 	var providerData any
 	if c, ok := openAIClientFromModel(req.Model); ok {
@@ -42,9 +65,28 @@ func toProviderRequest(req BaseRequest) (provider.Request, error) {
 		TopP:         req.TopP,
 		Stop:         append([]string(nil), req.Stop...),
 		Metadata:     cloneStringMap(req.Metadata),
+
+		ReasoningEffort: req.ReasoningEffort,
+		Verbosity:       req.Verbosity,
+		AssistantPrefix: req.AssistantPrefix,
+		Prediction:      req.Prediction,
+		BaseURLOverride: req.BaseURLOverride,
 	}, nil
 }
 
+// validateSamplingParams rejects sampling parameters outside the ranges
+// accepted by OpenAI-compatible APIs, which would otherwise fail with a 400
+// after the request was sent.
+func validateSamplingParams(req BaseRequest) error {
+	if t := req.Temperature; t != nil && (math.IsNaN(float64(*t)) || *t < 0 || *t > 2) {
+		return fmt.Errorf("Temperature %v is out of range [0, 2] (set SkipParamValidation for providers with other ranges)", *t)
+	}
+	if p := req.TopP; p != nil && (math.IsNaN(float64(*p)) || *p < 0 || *p > 1) {
+		return fmt.Errorf("TopP %v is out of range [0, 1] (set SkipParamValidation for providers with other ranges)", *p)
+	}
+	return nil
+}
+
 type openAIClientModel interface {
 	Client() *openai.Client
 }
@@ -57,6 +99,113 @@ func openAIClientFromModel(m ModelRef) (*openai.Client, bool) {
 	return v.Client(), true
 }
 
+// checkToolResults reports tool messages that do not answer a tool call made
+// by an earlier assistant message. Providers reject such histories with
+// unhelpful errors; this usually means the assistant message was dropped or
+// reordered when history was managed by hand.
+func checkToolResults(msgs []Message) error {
+	calls := map[string]bool{}
+	for _, m := range msgs {
+		switch m.Role {
+		case RoleAssistant:
+			for _, p := range m.Content {
+				switch v := p.(type) {
+				case ToolCallPart:
+					calls[v.ID] = true
+				case *ToolCallPart:
+					if v != nil {
+						calls[v.ID] = true
+					}
+				}
+			}
+		case RoleTool:
+			if m.ToolCallID != "" && !calls[m.ToolCallID] {
+				return fmt.Errorf("orphaned tool result: ToolCallID %q does not match a tool call in an earlier assistant message", m.ToolCallID)
+			}
+		}
+	}
+	return nil
+}
+
+// messagesWithSystem applies BaseRequest.System to the request messages.
+func messagesWithSystem(req BaseRequest) ([]Message, error) {
+	if req.System == "" {
+		return req.Messages, nil
+	}
+	hasSystem := false
+	for _, m := range req.Messages {
+		if m.Role == RoleSystem {
+			hasSystem = true
+			break
+		}
+	}
+	if !hasSystem {
+		return append([]Message{System(req.System)}, req.Messages...), nil
+	}
+	if !req.MergeSystem {
+		return nil, fmt.Errorf("System is set but Messages already contain a system message (set MergeSystem to combine them)")
+	}
+	if !startsWithSystem(req.Messages) {
+		return append([]Message{System(req.System)}, req.Messages...), nil
+	}
+	msgs := append([]Message(nil), req.Messages...)
+	first := msgs[0]
+	if tp, ok := firstTextPart(first); ok {
+		content := append([]ContentPart(nil), first.Content...)
+		content[0] = TextPart{Text: req.System + "\n\n" + tp.Text}
+		first.Content = content
+	} else {
+		first.Content = append([]ContentPart{TextPart{Text: req.System}}, first.Content...)
+	}
+	msgs[0] = first
+	return msgs, nil
+}
+
+func firstTextPart(m Message) (TextPart, bool) {
+	if len(m.Content) == 0 {
+		return TextPart{}, false
+	}
+	switch v := m.Content[0].(type) {
+	case TextPart:
+		return v, true
+	case *TextPart:
+		if v != nil {
+			return *v, true
+		}
+	}
+	return TextPart{}, false
+}
+
+// overrideToolDescriptions sets the description of each tool named in
+// descriptions.
+func overrideToolDescriptions(tools []provider.ToolDefinition, descriptions map[string]string) error {
+	if len(descriptions) == 0 {
+		return nil
+	}
+	found := 0
+	for i := range tools {
+		if d, ok := descriptions[tools[i].Name]; ok {
+			tools[i].Description = d
+			found++
+		}
+	}
+	if found == len(descriptions) {
+		return nil
+	}
+	names := make(map[string]bool, len(tools))
+	for _, t := range tools {
+		names[t.Name] = true
+	}
+	var unknown []string
+	for name := range descriptions {
+		if !names[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("tool descriptions given for unknown tools: %s", strings.Join(unknown, ", "))
+}
+
 func toProviderTools(tools []Tool) ([]provider.ToolDefinition, error) {
 	if len(tools) == 0 {
 		return nil, nil
@@ -66,10 +215,34 @@ func toProviderTools(tools []Tool) ([]provider.ToolDefinition, error) {
 		if t.Name == "" {
 			return nil, fmt.Errorf("tool name is required")
 		}
+		if t.Hosted {
+			def := provider.ToolDefinition{Name: t.Name, Description: t.Description, Hosted: true}
+			if t.HostedConfig != nil {
+				config, err := json.Marshal(t.HostedConfig)
+				if err != nil {
+					return nil, fmt.Errorf("hosted tool %q: %w", t.Name, err)
+				}
+				if len(config) == 0 || config[0] != '{' {
+					return nil, fmt.Errorf("hosted tool %q: config must encode to a JSON object", t.Name)
+				}
+				def.Config = config
+			}
+			out = append(out, def)
+			continue
+		}
+		schemaJSON := t.InputSchema.JSON
+		if t.Strict {
+			strict, err := internalSchema.MakeStrict(schemaJSON)
+			if err != nil {
+				return nil, fmt.Errorf("tool %q: %w", t.Name, err)
+			}
+			schemaJSON = strict
+		}
 		out = append(out, provider.ToolDefinition{
 			Name:        t.Name,
 			Description: t.Description,
-			InputSchema: t.InputSchema.JSON,
+			InputSchema: schemaJSON,
+			Strict:      t.Strict,
 		})
 	}
 	return out, nil
@@ -104,6 +277,7 @@ func toProviderMessage(m Message) (provider.Message, error) {
 		Content:    content,
 		Name:       m.Name,
 		ToolCallID: m.ToolCallID,
+		Metadata:   m.Metadata,
 	}, nil
 }
 
@@ -137,6 +311,8 @@ func toProviderContentParts(parts []ContentPart) ([]provider.ContentPart, error)
 			out = append(out, provider.ImagePart{URL: v.URL, MediaType: v.MediaType, Bytes: append([]byte(nil), v.Bytes...), Base64: v.Base64})
 		case AudioPart:
 			out = append(out, provider.AudioPart{Format: v.Format, Bytes: append([]byte(nil), v.Bytes...), Base64: v.Base64})
+		case ReasoningPart:
+			out = append(out, provider.ReasoningPart{Text: v.Text})
 		default:
 			return nil, fmt.Errorf("unknown content part type %T", p)
 		}
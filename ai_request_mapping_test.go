@@ -1,8 +1,13 @@
 package ai
 
 import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
 	"testing"
 
+	"github.com/bitop-dev/ai/internal/provider"
 	"github.com/bitop-dev/ai/openai"
 )
 
@@ -102,3 +107,369 @@ func TestToProviderRequestMapping(t *testing.T) {
 		t.Fatalf("Tools mismatch: %#v", req.Tools)
 	}
 }
+
+func TestToProviderRequest_ToolDescriptions(t *testing.T) {
+	tools := []Tool{{Name: "search", Description: "Search."}, {Name: "fetch", Description: "Fetch a URL."}}
+	req := BaseRequest{
+		Model:            openai.Chat("gpt-test"),
+		Messages:         []Message{User("hi")},
+		Tools:            tools,
+		ToolDescriptions: map[string]string{"search": "Search the product catalog by keyword."},
+	}
+	preq, err := toProviderRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preq.Tools[0].Description != "Search the product catalog by keyword." || preq.Tools[1].Description != "Fetch a URL." {
+		t.Fatalf("tools=%#v", preq.Tools)
+	}
+	if tools[0].Description != "Search." {
+		t.Fatal("Tool value was modified")
+	}
+
+	req.ToolDescriptions["serach"] = "typo"
+	if _, err := toProviderRequest(req); err == nil || !strings.Contains(err.Error(), "serach") {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestToProviderToolsStrict(t *testing.T) {
+	tools, err := toProviderTools([]Tool{{
+		Name:        "lookup",
+		InputSchema: JSONSchema([]byte(`{"type":"object","properties":{"b":{"type":"string"},"a":{"type":"object","properties":{"x":{"type":"integer"}}}},"required":["b"]}`)),
+		Strict:      true,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tools[0].Strict {
+		t.Fatalf("expected Strict on provider tool")
+	}
+	var got map[string]any
+	if err := json.Unmarshal(tools[0].InputSchema, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["additionalProperties"] != false {
+		t.Fatalf("additionalProperties=%v", got["additionalProperties"])
+	}
+	if req, _ := got["required"].([]any); len(req) != 2 || req[0] != "a" || req[1] != "b" {
+		t.Fatalf("required=%v", got["required"])
+	}
+	props := got["properties"].(map[string]any)
+	if b := props["b"].(map[string]any); b["type"] != "string" {
+		t.Fatalf("required property b=%v", b)
+	}
+	// Optional a is nullable so the model need not invent a value.
+	nested := props["a"].(map[string]any)
+	if typ, _ := nested["type"].([]any); len(typ) != 2 || typ[0] != "object" || typ[1] != "null" {
+		t.Fatalf("optional property a type=%v", nested["type"])
+	}
+	if nested["additionalProperties"] != false {
+		t.Fatalf("nested additionalProperties=%v", nested["additionalProperties"])
+	}
+	x := nested["properties"].(map[string]any)["x"].(map[string]any)
+	if typ, _ := x["type"].([]any); len(typ) != 2 || typ[1] != "null" {
+		t.Fatalf("nested optional x type=%v", x["type"])
+	}
+
+	_, err = toProviderTools([]Tool{{
+		Name:        "open",
+		InputSchema: JSONSchema([]byte(`{"type":"object","additionalProperties":true}`)),
+		Strict:      true,
+	}})
+	if err == nil {
+		t.Fatalf("expected error for schema that cannot be made strict")
+	}
+}
+
+func TestAssistantToolCallHelpers(t *testing.T) {
+	msgs := []Message{
+		User("weather?"),
+		AssistantToolCall("call_1", "weather", map[string]string{"city": "Paris"}),
+		ToolResultForCall("call_1", "weather", "sunny"),
+		AssistantWithToolCalls(
+			ToolCall("call_2", "a", nil),
+			ToolCall("call_3", "b", json.RawMessage(`{"x":1}`)),
+		),
+	}
+	pmsgs, err := toProviderMessages(msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc, ok := pmsgs[1].Content[0].(provider.ToolCallPart)
+	if !ok || pmsgs[1].Role != provider.RoleAssistant || tc.ID != "call_1" || tc.Name != "weather" || string(tc.Args) != `{"city":"Paris"}` {
+		t.Fatalf("msg=%#v", pmsgs[1])
+	}
+	if got := len(pmsgs[3].Content); got != 2 {
+		t.Fatalf("tool calls=%d", got)
+	}
+	if a := pmsgs[3].Content[0].(provider.ToolCallPart).Args; string(a) != `{}` {
+		t.Fatalf("nil args=%s", a)
+	}
+	if a := pmsgs[3].Content[1].(provider.ToolCallPart).Args; string(a) != `{"x":1}` {
+		t.Fatalf("raw args=%s", a)
+	}
+}
+
+func TestToProviderRequestOrphanedToolResult(t *testing.T) {
+	model := openai.Chat("gpt-test")
+
+	_, err := toProviderRequest(BaseRequest{
+		Model: model,
+		Messages: []Message{
+			User("weather?"),
+			AssistantToolCall("call_1", "weather", nil),
+			ToolResultForCall("call_1", "weather", "sunny"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("valid history rejected: %v", err)
+	}
+
+	for name, msgs := range map[string][]Message{
+		"unknown id": {
+			User("weather?"),
+			AssistantToolCall("call_1", "weather", nil),
+			ToolResultForCall("call_2", "weather", "sunny"),
+		},
+		"result before call": {
+			User("weather?"),
+			ToolResultForCall("call_1", "weather", "sunny"),
+			AssistantToolCall("call_1", "weather", nil),
+		},
+	} {
+		_, err := toProviderRequest(BaseRequest{Model: model, Messages: msgs})
+		if err == nil || !strings.Contains(err.Error(), "orphaned tool result") || !strings.Contains(err.Error(), `"call_`) {
+			t.Fatalf("%s: err=%v", name, err)
+		}
+	}
+}
+
+func TestToProviderRequestSystem(t *testing.T) {
+	model := openai.Chat("gpt-test")
+
+	preq, err := toProviderRequest(BaseRequest{Model: model, System: "be brief", Messages: []Message{User("hi")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preq.Messages) != 2 || preq.Messages[0].Role != provider.RoleSystem {
+		t.Fatalf("messages=%#v", preq.Messages)
+	}
+	if tp := preq.Messages[0].Content[0].(provider.TextPart); tp.Text != "be brief" {
+		t.Fatalf("system=%q", tp.Text)
+	}
+
+	withSystem := []Message{System("you are a bot"), User("hi")}
+	if _, err := toProviderRequest(BaseRequest{Model: model, System: "be brief", Messages: withSystem}); err == nil {
+		t.Fatalf("expected error for duplicate system message")
+	}
+
+	preq, err = toProviderRequest(BaseRequest{Model: model, System: "be brief", MergeSystem: true, Messages: withSystem})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preq.Messages) != 2 {
+		t.Fatalf("messages=%#v", preq.Messages)
+	}
+	if tp := preq.Messages[0].Content[0].(provider.TextPart); tp.Text != "be brief\n\nyou are a bot" {
+		t.Fatalf("merged system=%q", tp.Text)
+	}
+	if tp := withSystem[0].Content[0].(TextPart); tp.Text != "you are a bot" {
+		t.Fatalf("caller messages mutated: %q", tp.Text)
+	}
+}
+
+func TestAssistantPrefix_FirstStepOnly(t *testing.T) {
+	toolCall := provider.Response{
+		Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.ToolCallPart{ID: "c1", Name: "noop", Args: []byte(`{}`)}}},
+		FinishReason: "tool_calls",
+	}
+	done := provider.Response{
+		Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "}"}}},
+		FinishReason: "stop",
+	}
+	fp := &fakeProvider{
+		generate: func(call int, req provider.Request) (provider.Response, error) {
+			if call == 0 {
+				return toolCall, nil
+			}
+			return done, nil
+		},
+		stream: func(call int, req provider.Request) (provider.Stream, error) {
+			if call == 0 {
+				return &fakeStream{final: &toolCall}, nil
+			}
+			return &fakeStream{deltas: []provider.Delta{{Text: "}"}}, final: &done}, nil
+		},
+	}
+	providerName := registerFakeProvider(t, fp)
+	noop := NewDynamicTool("noop", DynamicToolSpec{
+		Execute: func(ctx context.Context, input json.RawMessage, meta ToolExecutionMeta) (any, error) {
+			return "ok", nil
+		},
+	})
+	base := BaseRequest{
+		Model:           testModel{provider: providerName, name: "m"},
+		Messages:        []Message{User("hi")},
+		Tools:           []Tool{noop},
+		AssistantPrefix: "{",
+	}
+	check := func(api string) {
+		t.Helper()
+		reqs := fp.Requests()
+		if len(reqs) != 2 || reqs[0].AssistantPrefix != "{" || reqs[1].AssistantPrefix != "" {
+			t.Fatalf("%s: requests=%d prefixes=%q", api, len(reqs), []string{reqs[0].AssistantPrefix, reqs[len(reqs)-1].AssistantPrefix})
+		}
+	}
+
+	if _, err := GenerateText(context.Background(), GenerateTextRequest{BaseRequest: base}); err != nil {
+		t.Fatal(err)
+	}
+	check("GenerateText")
+
+	fp.requests = nil
+	stream, err := StreamText(context.Background(), StreamTextRequest{BaseRequest: base})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for stream.Next() {
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	check("StreamText")
+}
+
+func TestCustomProviderReceivesAssistantPrefix(t *testing.T) {
+	preq, err := toProviderRequest(BaseRequest{Model: openai.Chat("gpt-test"), Messages: []Message{User("hi")}, AssistantPrefix: "{", Prediction: "{}", BaseURLOverride: "https://eu.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom, err := fromInternalRequest(preq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preq.AssistantPrefix != "{" || custom.AssistantPrefix != "{" {
+		t.Fatalf("prefix not mapped: %q / %q", preq.AssistantPrefix, custom.AssistantPrefix)
+	}
+	if preq.Prediction != "{}" || custom.Prediction != "{}" {
+		t.Fatalf("prediction not mapped: %q / %q", preq.Prediction, custom.Prediction)
+	}
+	if preq.BaseURLOverride != "https://eu.example.com" || custom.BaseURLOverride != "https://eu.example.com" {
+		t.Fatalf("base URL override not mapped: %q / %q", preq.BaseURLOverride, custom.BaseURLOverride)
+	}
+}
+
+func TestMessageMetadataPassthrough(t *testing.T) {
+	msg := User("hi")
+	msg.Metadata = map[string]any{"speaker": "alice"}
+	preq, err := toProviderRequest(BaseRequest{Model: openai.Chat("gpt-test"), Messages: []Message{msg}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preq.Messages[0].Metadata["speaker"] != "alice" {
+		t.Fatalf("metadata not mapped: %#v", preq.Messages[0])
+	}
+	custom, err := fromInternalRequest(preq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custom.Messages[0].Metadata["speaker"] != "alice" {
+		t.Fatalf("custom provider metadata: %#v", custom.Messages[0])
+	}
+
+	// OpenAI does not use it; the wire format is unchanged.
+	inspected, err := BuildRequest(GenerateTextRequest{BaseRequest: BaseRequest{Model: openai.Chat("gpt-test"), Messages: []Message{msg}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(inspected.Body), "alice") {
+		t.Fatalf("body=%s", inspected.Body)
+	}
+}
+
+func TestReasoningPartRoundTrip(t *testing.T) {
+	msg := AssistantWithReasoning("think", "answer")
+	parts, err := toProviderContentParts(msg.Content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rp, ok := parts[0].(provider.ReasoningPart); !ok || rp.Text != "think" {
+		t.Fatalf("parts=%#v", parts)
+	}
+	back, err := fromProviderContentParts(parts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back[0] != (ReasoningPart{Text: "think"}) || back[1] != (TextPart{Text: "answer"}) {
+		t.Fatalf("back=%#v", back)
+	}
+	if got := extractTextFromMessage(Message{Role: RoleAssistant, Content: back}); got != "answer" {
+		t.Fatalf("text=%q", got)
+	}
+}
+
+func TestToProviderRequest_SamplingParamRanges(t *testing.T) {
+	f := func(v float32) *float32 { return &v }
+	cases := []struct {
+		name        string
+		temperature *float32
+		topP        *float32
+		wantErr     string
+	}{
+		{name: "in range", temperature: f(2), topP: f(0)},
+		{name: "temperature high", temperature: f(2.5), wantErr: "Temperature 2.5 is out of range [0, 2]"},
+		{name: "temperature negative", temperature: f(-0.1), wantErr: "Temperature"},
+		{name: "top_p high", topP: f(1.2), wantErr: "TopP 1.2 is out of range [0, 1]"},
+		{name: "temperature NaN", temperature: f(float32(math.NaN())), wantErr: "Temperature NaN is out of range"},
+		{name: "temperature Inf", temperature: f(float32(math.Inf(1))), wantErr: "Temperature +Inf is out of range"},
+		{name: "top_p NaN", topP: f(float32(math.NaN())), wantErr: "TopP NaN is out of range"},
+		{name: "top_p -Inf", topP: f(float32(math.Inf(-1))), wantErr: "TopP -Inf is out of range"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			base := BaseRequest{
+				Model:       openai.Chat("gpt-test"),
+				Messages:    []Message{User("hi")},
+				Temperature: tc.temperature,
+				TopP:        tc.topP,
+			}
+			_, err := toProviderRequest(base)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected err: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err=%v, want %q", err, tc.wantErr)
+			}
+			base.SkipParamValidation = true
+			if _, err := toProviderRequest(base); err != nil {
+				t.Fatalf("SkipParamValidation: unexpected err: %v", err)
+			}
+		})
+	}
+}
+
+func TestToProviderRequest_DefaultModel(t *testing.T) {
+	c := openai.NewClient(openai.Config{DefaultModel: "gpt-default"})
+	for _, m := range []openai.ModelRef{c.DefaultChat(), c.Chat("")} {
+		preq, err := toProviderRequest(BaseRequest{Model: m, Messages: []Message{User("hi")}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if preq.Model != "gpt-default" {
+			t.Fatalf("model=%q", preq.Model)
+		}
+	}
+	if m := c.Chat("gpt-other"); m.Name() != "gpt-other" {
+		t.Fatalf("explicit name overridden: %q", m.Name())
+	}
+
+	_, err := toProviderRequest(BaseRequest{Model: openai.NewClient(openai.Config{}).DefaultChat(), Messages: []Message{User("hi")}})
+	if err == nil || !strings.Contains(err.Error(), "DefaultModel") {
+		t.Fatalf("err=%v", err)
+	}
+}
@@ -8,6 +8,10 @@ import (
 
 // Agent is a reusable configuration wrapper for agentic loops (LLM + tools + loop control).
 //
+// Generate and Stream wrap GenerateText and StreamText: every configured field
+// is copied into the BaseRequest of each call, so an Agent behaves exactly like
+// the equivalent request built by hand.
+//
 // By default, when neither MaxIterations nor StopWhen are set, the agent runs for a single step.
 type Agent struct {
 	Model  ModelRef
@@ -17,18 +21,43 @@ type Agent struct {
 	// Loop controls. If both are unset, Agent defaults to 1 step.
 	MaxIterations int
 	StopWhen      StopCondition
+	// StopWhenEveryStep, DedupeToolCalls and ToolResultCache mirror the
+	// ToolLoopOptions fields (ResultCache for the latter).
+	StopWhenEveryStep bool
+	DedupeToolCalls   bool
+	ToolResultCache   *ToolResultCache
 
 	// Request controls.
-	Headers    map[string]string
-	MaxRetries *int
-	Timeout    time.Duration
+	Headers        map[string]string
+	MaxRetries     *int
+	Timeout        time.Duration
+	PerCallTimeout time.Duration
+
+	// Generation parameters; see the BaseRequest fields of the same name.
+	MaxTokens          *int
+	Temperature        *float32
+	TopP               *float32
+	Stop               []string
+	ReasoningEffort    string
+	Verbosity          string
+	RedactOutput       func(text string) string
+	MaxOutputChars     int
+	Metadata           map[string]string
+	ResumeOnDisconnect bool
+	AutoContinue       bool
+	MaxContinuations   int
+	CoalesceDeltas     time.Duration
+
+	SkipParamValidation bool
 
 	// Optional hooks.
-	OnToolProgress func(event ToolProgressEvent)
-	OnStepFinish   func(event StepFinishEvent)
-	PrepareStep    func(event PrepareStepEvent) (PrepareStepResult, error)
+	OnToolProgress  func(event ToolProgressEvent)
+	OnStepFinish    func(event StepFinishEvent)
+	OnStepToolCalls func(event StepToolCallsEvent)
+	PrepareStep     func(event PrepareStepEvent) (PrepareStepResult, error)
 }
 
+// AgentGenerateRequest is the per-call input to Agent.Generate and Agent.Stream.
 type AgentGenerateRequest struct {
 	Prompt string
 
@@ -37,6 +66,7 @@ type AgentGenerateRequest struct {
 	Messages []Message
 }
 
+// Generate runs the agent to completion via GenerateText.
 func (a Agent) Generate(ctx context.Context, req AgentGenerateRequest) (*GenerateTextResponse, error) {
 	base, err := a.baseRequest(req)
 	if err != nil {
@@ -47,6 +77,7 @@ func (a Agent) Generate(ctx context.Context, req AgentGenerateRequest) (*Generat
 
 type AgentStreamRequest = AgentGenerateRequest
 
+// Stream runs the agent via StreamText.
 func (a Agent) Stream(ctx context.Context, req AgentStreamRequest) (*TextStream, error) {
 	base, err := a.baseRequest(req)
 	if err != nil {
@@ -77,21 +108,42 @@ func (a Agent) baseRequest(req AgentGenerateRequest) (BaseRequest, error) {
 	}
 
 	toolLoop := &ToolLoopOptions{
-		MaxIterations: maxIter,
-		StopWhen:      a.StopWhen,
+		MaxIterations:     maxIter,
+		StopWhen:          a.StopWhen,
+		StopWhenEveryStep: a.StopWhenEveryStep,
+		DedupeToolCalls:   a.DedupeToolCalls,
+		ResultCache:       a.ToolResultCache,
 	}
 
 	return BaseRequest{
-		Model:          a.Model,
-		Messages:       msgs,
-		Tools:          append([]Tool(nil), a.Tools...),
-		ToolLoop:       toolLoop,
-		Headers:        cloneStringMap(a.Headers),
-		MaxRetries:     a.MaxRetries,
-		Timeout:        a.Timeout,
-		OnToolProgress: a.OnToolProgress,
-		OnStepFinish:   a.OnStepFinish,
-		PrepareStep:    a.PrepareStep,
+		Model:           a.Model,
+		Messages:        msgs,
+		Tools:           append([]Tool(nil), a.Tools...),
+		ToolLoop:        toolLoop,
+		Headers:         cloneStringMap(a.Headers),
+		MaxRetries:      a.MaxRetries,
+		Timeout:         a.Timeout,
+		PerCallTimeout:  a.PerCallTimeout,
+		OnToolProgress:  a.OnToolProgress,
+		OnStepFinish:    a.OnStepFinish,
+		OnStepToolCalls: a.OnStepToolCalls,
+		PrepareStep:     a.PrepareStep,
+
+		MaxTokens:          a.MaxTokens,
+		Temperature:        a.Temperature,
+		TopP:               a.TopP,
+		Stop:               append([]string(nil), a.Stop...),
+		ReasoningEffort:    a.ReasoningEffort,
+		Verbosity:          a.Verbosity,
+		RedactOutput:       a.RedactOutput,
+		MaxOutputChars:     a.MaxOutputChars,
+		Metadata:           cloneStringMap(a.Metadata),
+		ResumeOnDisconnect: a.ResumeOnDisconnect,
+		AutoContinue:       a.AutoContinue,
+		MaxContinuations:   a.MaxContinuations,
+		CoalesceDeltas:     a.CoalesceDeltas,
+
+		SkipParamValidation: a.SkipParamValidation,
 	}, nil
 }
 
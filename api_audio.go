@@ -3,6 +3,8 @@ package ai
 import (
 	"context"
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	internalAudio "github.com/bitop-dev/ai/internal/audio"
@@ -27,6 +29,9 @@ type Transcript struct {
 
 	ProviderMetadata map[string]any
 	RawResponse      []byte
+	// RequestID is as in GenerateTextResponse; for chunked audio it is the
+	// first piece's.
+	RequestID string
 }
 
 type TranscribeRequest struct {
@@ -43,9 +48,25 @@ type TranscribeRequest struct {
 	MaxRetries *int
 	Timeout    time.Duration
 
+	// AutoChunk transcribes audio larger than MaxChunkBytes in pieces and
+	// stitches the results, shifting segment timestamps to the position of
+	// each piece. Pieces are cut at the quietest point near the size limit.
+	// Only PCM WAV audio can be split; other formats over the limit fail.
+	AutoChunk bool
+	// MaxChunkBytes is the size limit per request when AutoChunk is set
+	// (default 24 MiB, below OpenAI's 25 MB upload limit).
+	MaxChunkBytes int
+	// ChunkOverlap repeats this much audio at the start of each piece so words
+	// at a cut are heard whole. Segments repeated in the overlap are dropped;
+	// without segments, words repeated at the joint are.
+	ChunkOverlap time.Duration
+
 	ProviderOptions map[string]any
 }
 
+// defaultMaxChunkBytes keeps chunks under OpenAI's 25 MB upload limit.
+const defaultMaxChunkBytes = 24 << 20
+
 type NoTranscriptGeneratedError struct {
 	Provider    string
 	Cause       error
@@ -77,7 +98,7 @@ func Transcribe(ctx context.Context, req TranscribeRequest) (*Transcript, error)
 	if err != nil {
 		return nil, err
 	}
-	tp, ok := p.(provider.TranscriptionProvider)
+	tp, ok := provider.As[provider.TranscriptionProvider](p)
 	if !ok {
 		return nil, fmt.Errorf("provider %q does not support transcription", req.Model.Provider())
 	}
@@ -87,21 +108,16 @@ func Transcribe(ctx context.Context, req TranscribeRequest) (*Transcript, error)
 		return nil, err
 	}
 
-	preq := provider.TranscriptionRequest{
-		Model:           req.Model.Name(),
-		AudioBytes:      audio,
-		MediaType:       mediaType,
-		Filename:        filename,
-		Headers:         cloneStringMap(req.Headers),
-		MaxRetries:      req.MaxRetries,
-		ProviderOptions: req.ProviderOptions,
-		ProviderData:    nil,
+	maxBytes := req.MaxChunkBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxChunkBytes
 	}
-	if c, ok := openAIClientFromModel(req.Model); ok {
-		preq.ProviderData = c
+	var out provider.TranscriptionResponse
+	if req.AutoChunk && len(audio) > maxBytes {
+		out, err = transcribeChunked(ctx, tp, req, audio, mediaType, filename, maxBytes)
+	} else {
+		out, err = tp.Transcribe(ctx, transcriptionRequest(req, audio, mediaType, filename))
 	}
-
-	out, err := tp.Transcribe(ctx, preq)
 	if err != nil {
 		return nil, mapProviderError(err)
 	}
@@ -116,6 +132,7 @@ func Transcribe(ctx context.Context, req TranscribeRequest) (*Transcript, error)
 		Warnings:          out.Warnings,
 		ProviderMetadata:  out.ProviderMetadata,
 		RawResponse:       out.RawResponse,
+		RequestID:         out.RequestID,
 	}
 	if len(out.Segments) > 0 {
 		t.Segments = make([]TranscriptSegment, len(out.Segments))
@@ -131,6 +148,85 @@ func Transcribe(ctx context.Context, req TranscribeRequest) (*Transcript, error)
 	return t, nil
 }
 
+func transcriptionRequest(req TranscribeRequest, audio []byte, mediaType, filename string) provider.TranscriptionRequest {
+	preq := provider.TranscriptionRequest{
+		Model:           req.Model.Name(),
+		AudioBytes:      audio,
+		MediaType:       mediaType,
+		Filename:        filename,
+		Headers:         cloneStringMap(req.Headers),
+		MaxRetries:      req.MaxRetries,
+		ProviderOptions: req.ProviderOptions,
+		ProviderData:    nil,
+	}
+	if c, ok := openAIClientFromModel(req.Model); ok {
+		preq.ProviderData = c
+	}
+	return preq
+}
+
+// transcribeChunked splits audio into pieces of at most maxBytes, transcribes
+// them in order and merges the results into one response. Metadata, raw
+// response and request ID are those of the first piece.
+func transcribeChunked(ctx context.Context, tp provider.TranscriptionProvider, req TranscribeRequest, audio []byte, mediaType, filename string, maxBytes int) (provider.TranscriptionResponse, error) {
+	chunks, err := internalAudio.SplitWAV(audio, maxBytes, req.ChunkOverlap)
+	if err != nil {
+		return provider.TranscriptionResponse{}, fmt.Errorf("audio is %d bytes, over the %d byte chunk limit: %w", len(audio), maxBytes, err)
+	}
+
+	var merged provider.TranscriptionResponse
+	var texts []string
+	lastEnd := 0.0
+	for i, c := range chunks {
+		out, err := tp.Transcribe(ctx, transcriptionRequest(req, c.Data, mediaType, filename))
+		if err != nil {
+			return provider.TranscriptionResponse{}, err
+		}
+		if i == 0 {
+			merged.Language = out.Language
+			merged.ProviderMetadata = out.ProviderMetadata
+			merged.RawResponse = out.RawResponse
+			merged.RequestID = out.RequestID
+		}
+		merged.Warnings = append(merged.Warnings, out.Warnings...)
+		if len(out.Segments) == 0 {
+			// Without timings the overlap can only be matched by its words.
+			text := strings.TrimSpace(out.Text)
+			if len(texts) > 0 && req.ChunkOverlap > 0 {
+				text = internalAudio.TrimOverlap(texts[len(texts)-1], text)
+			}
+			if text != "" {
+				texts = append(texts, text)
+			}
+			continue
+		}
+		// Segments within the overlap were already transcribed at the end of
+		// the previous piece.
+		cutoff := lastEnd
+		if i > 0 {
+			cutoff = max(cutoff, c.Offset+req.ChunkOverlap.Seconds())
+		}
+		for _, s := range out.Segments {
+			s.Start += c.Offset
+			s.End += c.Offset
+			if i > 0 && s.End <= cutoff {
+				continue
+			}
+			s.ID = len(merged.Segments)
+			merged.Segments = append(merged.Segments, s)
+			lastEnd = s.End
+			if text := strings.TrimSpace(s.Text); text != "" {
+				texts = append(texts, text)
+			}
+		}
+	}
+	merged.Text = strings.Join(texts, " ")
+	last := chunks[len(chunks)-1]
+	total := last.Offset + last.Duration
+	merged.DurationInSeconds = &total
+	return merged, nil
+}
+
 func resolveAudio(ctx context.Context, req TranscribeRequest) ([]byte, string, string, error) {
 	return internalAudio.ResolveInput(ctx, req.AudioBytes, req.AudioBase64, req.AudioURL, req.MediaType, req.Filename)
 }
@@ -143,6 +239,8 @@ type SpeechAudio struct {
 
 	ProviderMetadata map[string]any
 	RawResponse      []byte
+	// RequestID is as in GenerateTextResponse.
+	RequestID string
 }
 
 type GenerateSpeechRequest struct {
@@ -198,26 +296,12 @@ func GenerateSpeech(ctx context.Context, req GenerateSpeechRequest) (*SpeechAudi
 	if err != nil {
 		return nil, err
 	}
-	sp, ok := p.(provider.SpeechProvider)
+	sp, ok := provider.As[provider.SpeechProvider](p)
 	if !ok {
 		return nil, fmt.Errorf("provider %q does not support speech generation", req.Model.Provider())
 	}
 
-	preq := provider.SpeechRequest{
-		Model:           req.Model.Name(),
-		Text:            req.Text,
-		Voice:           req.Voice,
-		Language:        req.Language,
-		Headers:         cloneStringMap(req.Headers),
-		MaxRetries:      req.MaxRetries,
-		ProviderOptions: req.ProviderOptions,
-		ProviderData:    nil,
-	}
-	if c, ok := openAIClientFromModel(req.Model); ok {
-		preq.ProviderData = c
-	}
-
-	out, err := sp.GenerateSpeech(ctx, preq)
+	out, err := sp.GenerateSpeech(ctx, speechRequest(req))
 	if err != nil {
 		return nil, mapProviderError(err)
 	}
@@ -235,5 +319,68 @@ func GenerateSpeech(ctx context.Context, req GenerateSpeechRequest) (*SpeechAudi
 		Warnings:         out.Warnings,
 		ProviderMetadata: out.ProviderMetadata,
 		RawResponse:      out.RawResponse,
+		RequestID:        out.RequestID,
 	}, nil
 }
+
+// StreamSpeech is like GenerateSpeech but returns the audio as it arrives, so
+// playback can start before synthesis finishes. The caller must close the
+// returned reader; Timeout (if set) covers the whole read.
+func StreamSpeech(ctx context.Context, req GenerateSpeechRequest) (io.ReadCloser, error) {
+	if req.Text == "" {
+		return nil, fmt.Errorf("text is required")
+	}
+	if req.Voice == "" {
+		return nil, fmt.Errorf("voice is required")
+	}
+
+	p, err := providerForModel(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	sp, ok := provider.As[provider.SpeechStreamProvider](p)
+	if !ok {
+		return nil, fmt.Errorf("provider %q does not support speech streaming", req.Model.Provider())
+	}
+
+	ctx, cancel := applyTimeout(ctx, req.Timeout)
+	out, err := sp.StreamSpeech(ctx, speechRequest(req))
+	if err != nil {
+		cancel()
+		return nil, mapProviderError(err)
+	}
+	if out.Body == nil {
+		cancel()
+		return nil, &NoSpeechGeneratedError{Provider: req.Model.Provider()}
+	}
+	return &speechStream{ReadCloser: out.Body, cancel: cancel}, nil
+}
+
+func speechRequest(req GenerateSpeechRequest) provider.SpeechRequest {
+	preq := provider.SpeechRequest{
+		Model:           req.Model.Name(),
+		Text:            req.Text,
+		Voice:           req.Voice,
+		Language:        req.Language,
+		Headers:         cloneStringMap(req.Headers),
+		MaxRetries:      req.MaxRetries,
+		ProviderOptions: req.ProviderOptions,
+		ProviderData:    nil,
+	}
+	if c, ok := openAIClientFromModel(req.Model); ok {
+		preq.ProviderData = c
+	}
+	return preq
+}
+
+// speechStream releases the request timeout when the body is closed.
+type speechStream struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (s *speechStream) Close() error {
+	err := s.ReadCloser.Close()
+	s.cancel()
+	return err
+}
@@ -0,0 +1,142 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+type RealtimeSpeechRequest struct {
+	Model ModelRef
+
+	Voice string
+	// Instructions replaces the provider's default instruction to read the
+	// text aloud verbatim (e.g. to set a tone or accent).
+	Instructions string
+
+	Headers map[string]string
+
+	ProviderOptions map[string]any
+}
+
+// RealtimeSpeechSession speaks text as it is fed in, over a persistent
+// connection. A producer calls SendText (e.g. with StreamText deltas) and then
+// CloseSend, while a consumer reads audio chunks with Next/Audio; the two may
+// run on different goroutines.
+//
+// Text is buffered and sent a sentence at a time, so the voice sounds natural
+// and does not stall on tiny fragments; Flush sends a partial sentence early.
+type RealtimeSpeechSession struct {
+	impl provider.RealtimeSpeechSession
+
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+// RealtimeSpeech opens a realtime speech session. The session ends when ctx is
+// done; always Close it.
+func RealtimeSpeech(ctx context.Context, req RealtimeSpeechRequest) (*RealtimeSpeechSession, error) {
+	if req.Voice == "" {
+		return nil, fmt.Errorf("voice is required")
+	}
+	p, err := providerForModel(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	rp, ok := provider.As[provider.RealtimeSpeechProvider](p)
+	if !ok {
+		return nil, fmt.Errorf("provider %q does not support realtime speech", req.Model.Provider())
+	}
+
+	preq := provider.RealtimeSpeechRequest{
+		Model:           req.Model.Name(),
+		Voice:           req.Voice,
+		Instructions:    req.Instructions,
+		Headers:         cloneStringMap(req.Headers),
+		ProviderOptions: req.ProviderOptions,
+	}
+	if c, ok := openAIClientFromModel(req.Model); ok {
+		preq.ProviderData = c
+	}
+	impl, err := rp.RealtimeSpeech(ctx, preq)
+	if err != nil {
+		return nil, mapProviderError(err)
+	}
+	return &RealtimeSpeechSession{impl: impl}, nil
+}
+
+// SendText queues text to be spoken. Complete sentences are sent right away;
+// the rest waits for more text, Flush or CloseSend.
+func (s *RealtimeSpeechSession) SendText(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf.WriteString(text)
+	buffered := s.buf.String()
+	end := sentenceEnd(buffered)
+	if end == 0 {
+		return nil
+	}
+	s.buf.Reset()
+	s.buf.WriteString(buffered[end:])
+	return mapProviderError(s.impl.SendText(buffered[:end]))
+}
+
+// Flush sends any buffered partial sentence.
+func (s *RealtimeSpeechSession) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flushLocked()
+}
+
+func (s *RealtimeSpeechSession) flushLocked() error {
+	text := s.buf.String()
+	s.buf.Reset()
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	return mapProviderError(s.impl.SendText(text))
+}
+
+// CloseSend flushes buffered text and signals that no more follows. Next
+// returns false once everything has been spoken.
+func (s *RealtimeSpeechSession) CloseSend() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.flushLocked(); err != nil {
+		return err
+	}
+	return mapProviderError(s.impl.CloseSend())
+}
+
+// Next waits for the next audio chunk. It returns false when the session has
+// ended; check Err.
+func (s *RealtimeSpeechSession) Next() bool { return s.impl.Next() }
+
+// Audio returns the chunk read by the last Next, in MediaType's encoding
+// (OpenAI default: "audio/pcm", 16-bit 24 kHz mono).
+func (s *RealtimeSpeechSession) Audio() []byte      { return s.impl.Audio() }
+func (s *RealtimeSpeechSession) MediaType() string  { return s.impl.MediaType() }
+func (s *RealtimeSpeechSession) Warnings() []string { return s.impl.Warnings() }
+func (s *RealtimeSpeechSession) Err() error         { return mapProviderError(s.impl.Err()) }
+func (s *RealtimeSpeechSession) Close() error       { return s.impl.Close() }
+
+// sentenceEnd returns the length of the longest prefix of text ending in a
+// sentence terminator followed by whitespace (or a newline), or 0.
+func sentenceEnd(text string) int {
+	for i := len(text) - 1; i > 0; i-- {
+		if text[i] == '\n' {
+			return i + 1
+		}
+		if text[i] != ' ' && text[i] != '\t' {
+			continue
+		}
+		switch text[i-1] {
+		case '.', '!', '?', ':', ';':
+			return i + 1
+		}
+	}
+	return 0
+}
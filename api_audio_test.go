@@ -1,8 +1,15 @@
 package ai
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/bitop-dev/ai/internal/provider"
 )
@@ -70,6 +77,127 @@ func TestTranscribe_Success(t *testing.T) {
 	}
 }
 
+// testWAV builds a 16-bit mono WAV at 1000 samples/s: a tone with a silent
+// gap at each of the given seconds.
+func testWAV(seconds int, silentAt ...float64) []byte {
+	pcm := make([]byte, seconds*2000)
+	for i := 0; i < len(pcm); i += 2 {
+		v := int16(8000)
+		if i%4 == 0 {
+			v = -8000
+		}
+		for _, at := range silentAt {
+			if sec := float64(i) / 2000; sec >= at && sec < at+0.05 {
+				v = 0
+			}
+		}
+		binary.LittleEndian.PutUint16(pcm[i:], uint16(v))
+	}
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(36+len(pcm)))
+	b.WriteString("WAVEfmt ")
+	for _, v := range []any{uint32(16), uint16(1), uint16(1), uint32(1000), uint32(2000), uint16(2), uint16(16)} {
+		_ = binary.Write(&b, binary.LittleEndian, v)
+	}
+	b.WriteString("data")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(len(pcm)))
+	b.Write(pcm)
+	return b.Bytes()
+}
+
+func TestTranscribe_AutoChunk(t *testing.T) {
+	tp := &fakeTranscriptionProvider{}
+	var sizes []int
+	tp.fn = func(call int, req provider.TranscriptionRequest) (provider.TranscriptionResponse, error) {
+		sizes = append(sizes, len(req.AudioBytes))
+		dur := float64(len(req.AudioBytes)-44) / 2000
+		segs := []provider.TranscriptSegment{{Start: 0, End: dur - 0.2, Text: fmt.Sprintf("part %d", call)}}
+		if call > 0 {
+			// The first 0.1s repeats the end of the previous piece.
+			segs = append([]provider.TranscriptSegment{{Start: 0, End: 0.05, Text: "echo"}}, segs...)
+			segs[1].Start = 0.1
+		}
+		return provider.TranscriptionResponse{Text: "ignored", Language: "en", Segments: segs}, nil
+	}
+	providerName := registerFakeProvider(t, tp)
+
+	audio := testWAV(5, 1.8, 3.5)
+	out, err := Transcribe(context.Background(), TranscribeRequest{
+		Model:         testModel{provider: providerName, name: "whisper-1"},
+		AudioBytes:    audio,
+		Filename:      "long.wav",
+		AutoChunk:     true,
+		MaxChunkBytes: 44 + 4000, // 2s per piece
+		ChunkOverlap:  100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range sizes {
+		if n > 44+4000 {
+			t.Fatalf("piece of %d bytes exceeds the limit", n)
+		}
+	}
+	if len(sizes) != 3 {
+		t.Fatalf("pieces=%d", len(sizes))
+	}
+	if out.Text != "part 0 part 1 part 2" || len(out.Segments) != 3 {
+		t.Fatalf("out=%#v", out)
+	}
+	// Cuts land in the silent gaps at 1.8s and 3.5s; later pieces start
+	// ChunkOverlap before the cut.
+	if s := out.Segments[1]; s.ID != 1 || math.Abs(s.Start-1.8) > 0.001 {
+		t.Fatalf("segment 1=%#v", s)
+	}
+	if s := out.Segments[2]; s.ID != 2 || math.Abs(s.Start-3.5) > 0.001 {
+		t.Fatalf("segment 2=%#v", s)
+	}
+	if out.DurationInSeconds == nil || *out.DurationInSeconds != 5 {
+		t.Fatalf("duration=%v", out.DurationInSeconds)
+	}
+
+	// Small audio is sent as is; formats that cannot be split fail.
+	sizes = nil
+	if _, err := Transcribe(context.Background(), TranscribeRequest{
+		Model: testModel{provider: providerName, name: "whisper-1"}, AudioBytes: testWAV(1), AutoChunk: true, MaxChunkBytes: 44 + 4000,
+	}); err != nil || len(sizes) != 1 {
+		t.Fatalf("err=%v pieces=%d", err, len(sizes))
+	}
+	_, err = Transcribe(context.Background(), TranscribeRequest{
+		Model: testModel{provider: providerName, name: "whisper-1"}, AudioBytes: make([]byte, 5000), Filename: "a.mp3", AutoChunk: true, MaxChunkBytes: 4000,
+	})
+	if err == nil || !strings.Contains(err.Error(), "PCM WAV") {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestTranscribe_AutoChunkWithoutSegments(t *testing.T) {
+	tp := &fakeTranscriptionProvider{}
+	replies := []string{"One two three.", "three, four five", "Five six"}
+	tp.fn = func(call int, req provider.TranscriptionRequest) (provider.TranscriptionResponse, error) {
+		return provider.TranscriptionResponse{Text: replies[call]}, nil
+	}
+	providerName := registerFakeProvider(t, tp)
+
+	req := TranscribeRequest{
+		Model:         testModel{provider: providerName, name: "whisper-1"},
+		AudioBytes:    testWAV(5, 1.8, 3.5),
+		Filename:      "long.wav",
+		AutoChunk:     true,
+		MaxChunkBytes: 44 + 4000,
+		ChunkOverlap:  100 * time.Millisecond,
+	}
+	out, err := Transcribe(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Words repeated in the overlap are kept once.
+	if out.Text != "One two three. four five six" {
+		t.Fatalf("Text=%q", out.Text)
+	}
+}
+
 func TestGenerateSpeech_Success(t *testing.T) {
 	sp := &fakeSpeechProvider{}
 	sp.fn = func(call int, req provider.SpeechRequest) (provider.SpeechResponse, error) {
@@ -93,3 +221,114 @@ func TestGenerateSpeech_Success(t *testing.T) {
 		t.Fatalf("audio len=%d", len(out.AudioData))
 	}
 }
+
+type fakeSpeechStreamProvider struct {
+	*fakeProvider
+	body io.ReadCloser
+}
+
+func (p *fakeSpeechStreamProvider) StreamSpeech(ctx context.Context, req provider.SpeechRequest) (provider.SpeechStream, error) {
+	_ = ctx
+	_ = req
+	return provider.SpeechStream{Body: p.body, MediaType: "audio/mpeg"}, nil
+}
+
+func TestStreamSpeech_Success(t *testing.T) {
+	sp := &fakeSpeechStreamProvider{body: io.NopCloser(strings.NewReader("audio"))}
+	providerName := registerFakeProvider(t, sp)
+
+	rc, err := StreamSpeech(context.Background(), GenerateSpeechRequest{
+		Model:   testModel{provider: providerName, name: "tts-1"},
+		Text:    "hi",
+		Voice:   "alloy",
+		Timeout: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "audio" {
+		t.Fatalf("audio=%q", b)
+	}
+}
+
+func TestStreamSpeech_ProviderNotSupported(t *testing.T) {
+	providerName := registerFakeProvider(t, &fakeSpeechProvider{})
+	if _, err := StreamSpeech(context.Background(), GenerateSpeechRequest{
+		Model: testModel{provider: providerName, name: "tts-1"},
+		Text:  "hi",
+		Voice: "alloy",
+	}); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+type fakeRealtimeProvider struct {
+	*fakeProvider
+	sent []string
+}
+
+func (p *fakeRealtimeProvider) RealtimeSpeech(ctx context.Context, req provider.RealtimeSpeechRequest) (provider.RealtimeSpeechSession, error) {
+	return &fakeRealtimeSession{p: p}, nil
+}
+
+type fakeRealtimeSession struct {
+	p   *fakeRealtimeProvider
+	i   int
+	cur []byte
+}
+
+func (s *fakeRealtimeSession) SendText(text string) error {
+	s.p.sent = append(s.p.sent, text)
+	return nil
+}
+func (s *fakeRealtimeSession) CloseSend() error { return nil }
+func (s *fakeRealtimeSession) Next() bool {
+	if s.i >= len(s.p.sent) {
+		return false
+	}
+	s.cur = []byte(s.p.sent[s.i])
+	s.i++
+	return true
+}
+func (s *fakeRealtimeSession) Audio() []byte      { return s.cur }
+func (s *fakeRealtimeSession) MediaType() string  { return "audio/pcm" }
+func (s *fakeRealtimeSession) Warnings() []string { return nil }
+func (s *fakeRealtimeSession) Err() error         { return nil }
+func (s *fakeRealtimeSession) Close() error       { return nil }
+
+func TestRealtimeSpeech_SendsWholeSentences(t *testing.T) {
+	rp := &fakeRealtimeProvider{}
+	providerName := registerFakeProvider(t, rp)
+
+	s, err := RealtimeSpeech(context.Background(), RealtimeSpeechRequest{Model: testModel{provider: providerName, name: "rt"}, Voice: "alloy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for _, delta := range []string{"Hel", "lo there. How", " are you? I", "'m fine", "\nBye"} {
+		if err := s.SendText(delta); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Hello there. ", "How are you? ", "I'm fine\n", "Bye"}
+	if strings.Join(rp.sent, "|") != strings.Join(want, "|") {
+		t.Fatalf("sent=%q", rp.sent)
+	}
+	var n int
+	for s.Next() {
+		n++
+	}
+	if n != len(want) || s.MediaType() != "audio/pcm" {
+		t.Fatalf("chunks=%d", n)
+	}
+}
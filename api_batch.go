@@ -0,0 +1,191 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// BatchRequest is one line of an offline batch job. The embedded request is
+// sent as-is; tool handlers are not executed (tool calls are returned in the
+// result message instead).
+type BatchRequest struct {
+	// CustomID identifies the request in BatchResults. Defaults to "request-<index>".
+	CustomID string
+
+	GenerateTextRequest
+}
+
+// Batch describes the state of a submitted batch job.
+type Batch struct {
+	ID string
+	// Status is the provider status (e.g. "validating", "in_progress", "completed", "failed").
+	Status string
+
+	Total     int
+	Completed int
+	Failed    int
+}
+
+// BatchResult is the outcome of one BatchRequest.
+type BatchResult struct {
+	CustomID string
+
+	Text         string
+	Message      Message
+	Usage        Usage
+	FinishReason FinishReason
+
+	// Err is set when this individual request failed.
+	Err error
+}
+
+// submittedBatches maps the IDs returned by SubmitBatch to the model whose
+// provider and client submitted them, for BatchStatus and BatchResults. An
+// entry is dropped once its results were downloaded or the batch failed.
+var submittedBatches sync.Map
+
+// SubmitBatch uploads reqs as a provider batch job (e.g. the OpenAI Batch API,
+// processed within 24h at reduced cost) and returns the batch id.
+//
+// All requests must target the same provider and client.
+func SubmitBatch(ctx context.Context, reqs []BatchRequest) (string, error) {
+	if len(reqs) == 0 {
+		return "", fmt.Errorf("at least one batch request is required")
+	}
+	model := reqs[0].Model
+	bp, providerData, err := batchProviderForModel(model)
+	if err != nil {
+		return "", err
+	}
+
+	items := make([]provider.BatchItem, 0, len(reqs))
+	for i, r := range reqs {
+		if r.Model == nil || r.Model.Provider() != model.Provider() {
+			return "", fmt.Errorf("batch request %d: all requests must use provider %q", i, model.Provider())
+		}
+		if _, pd, err := batchProviderForModel(r.Model); err != nil || pd != providerData {
+			return "", fmt.Errorf("batch request %d: all requests must use the same client", i)
+		}
+		preq, err := toProviderRequest(r.BaseRequest)
+		if err != nil {
+			return "", fmt.Errorf("batch request %d: %w", i, err)
+		}
+		items = append(items, provider.BatchItem{CustomID: r.CustomID, Request: preq})
+	}
+
+	info, err := bp.SubmitBatch(ctx, provider.BatchSubmitRequest{
+		Items:        items,
+		Headers:      cloneStringMap(reqs[0].Headers),
+		MaxRetries:   reqs[0].MaxRetries,
+		ProviderData: providerData,
+	})
+	if err != nil {
+		return "", mapProviderError(err)
+	}
+	submittedBatches.Store(info.ID, model)
+	return info.ID, nil
+}
+
+// BatchStatus returns the current state of a batch submitted by this process,
+// using the provider and client it was submitted with. The process forgets the
+// batch once BatchResults succeeds or the batch has failed. For other batches
+// (e.g. submitted before a restart), use BatchStatusFor.
+func BatchStatus(ctx context.Context, batchID string) (*Batch, error) {
+	model, err := submittedBatchModel(batchID)
+	if err != nil {
+		return nil, err
+	}
+	return BatchStatusFor(ctx, model, batchID)
+}
+
+// BatchStatusFor is BatchStatus with the model whose provider and client
+// credentials the batch was submitted with given explicitly.
+func BatchStatusFor(ctx context.Context, model ModelRef, batchID string) (*Batch, error) {
+	bp, providerData, err := batchProviderForModel(model)
+	if err != nil {
+		return nil, err
+	}
+	info, err := bp.BatchStatus(ctx, provider.BatchLookupRequest{BatchID: batchID, ProviderData: providerData})
+	if err != nil {
+		return nil, mapProviderError(err)
+	}
+	if info.Status == "failed" {
+		// A failed batch has no results to download.
+		submittedBatches.Delete(batchID)
+	}
+	return &Batch{
+		ID:        info.ID,
+		Status:    info.Status,
+		Total:     info.Total,
+		Completed: info.Completed,
+		Failed:    info.Failed,
+	}, nil
+}
+
+// BatchResults downloads the results of a completed batch submitted by this
+// process. Results are returned in provider output order; match them to
+// requests by CustomID. For batches submitted elsewhere, use BatchResultsFor.
+func BatchResults(ctx context.Context, batchID string) ([]BatchResult, error) {
+	model, err := submittedBatchModel(batchID)
+	if err != nil {
+		return nil, err
+	}
+	return BatchResultsFor(ctx, model, batchID)
+}
+
+// BatchResultsFor is BatchResults with the model whose provider and client
+// credentials the batch was submitted with given explicitly.
+func BatchResultsFor(ctx context.Context, model ModelRef, batchID string) ([]BatchResult, error) {
+	bp, providerData, err := batchProviderForModel(model)
+	if err != nil {
+		return nil, err
+	}
+	results, err := bp.BatchResults(ctx, provider.BatchLookupRequest{BatchID: batchID, ProviderData: providerData})
+	if err != nil {
+		return nil, mapProviderError(err)
+	}
+	submittedBatches.Delete(batchID)
+	out := make([]BatchResult, 0, len(results))
+	for _, r := range results {
+		br := BatchResult{CustomID: r.CustomID, Err: mapProviderError(r.Err)}
+		if r.Response != nil {
+			msg, usage, finish, err := fromProviderResponse(*r.Response)
+			if err != nil {
+				br.Err = err
+			} else {
+				br.Message = msg
+				br.Text = extractTextFromMessage(msg)
+				br.Usage = usage
+				br.FinishReason = finish
+			}
+		}
+		out = append(out, br)
+	}
+	return out, nil
+}
+
+func submittedBatchModel(batchID string) (ModelRef, error) {
+	if m, ok := submittedBatches.Load(batchID); ok {
+		return m.(ModelRef), nil
+	}
+	return nil, fmt.Errorf("batch %q was not submitted by this process; use BatchStatusFor or BatchResultsFor with its model", batchID)
+}
+
+func batchProviderForModel(m ModelRef) (provider.BatchProvider, any, error) {
+	p, err := providerForModel(m)
+	if err != nil {
+		return nil, nil, err
+	}
+	bp, ok := provider.As[provider.BatchProvider](p)
+	if !ok {
+		return nil, nil, fmt.Errorf("provider %q does not support batches", m.Provider())
+	}
+	var providerData any
+	if c, ok := openAIClientFromModel(m); ok {
+		providerData = c
+	}
+	return bp, providerData, nil
+}
@@ -0,0 +1,131 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+	"github.com/bitop-dev/ai/openai"
+)
+
+// batchingProvider is a fake provider that also runs batches.
+type batchingProvider struct {
+	*fakeProvider
+	submitted [][]provider.BatchItem
+	lookups   []provider.BatchLookupRequest
+	status    string // reported by BatchStatus; "completed" when empty
+}
+
+func (p *batchingProvider) SubmitBatch(ctx context.Context, req provider.BatchSubmitRequest) (provider.BatchInfo, error) {
+	p.submitted = append(p.submitted, req.Items)
+	return provider.BatchInfo{ID: "batch_1", Status: "validating", Total: len(req.Items)}, nil
+}
+
+func (p *batchingProvider) BatchStatus(ctx context.Context, req provider.BatchLookupRequest) (provider.BatchInfo, error) {
+	p.lookups = append(p.lookups, req)
+	status := p.status
+	if status == "" {
+		status = "completed"
+	}
+	return provider.BatchInfo{ID: req.BatchID, Status: status, Total: 1, Completed: 1}, nil
+}
+
+func (p *batchingProvider) BatchResults(ctx context.Context, req provider.BatchLookupRequest) ([]provider.BatchResult, error) {
+	p.lookups = append(p.lookups, req)
+	return []provider.BatchResult{{
+		CustomID: "a",
+		Response: &provider.Response{
+			Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "positive"}}},
+			FinishReason: "stop",
+		},
+	}}, nil
+}
+
+// clientModel is a test model bound to an OpenAI client, standing in for
+// models created from different credentials.
+type clientModel struct {
+	testModel
+	client *openai.Client
+}
+
+func (m clientModel) Client() *openai.Client { return m.client }
+
+func TestBatch_LooksUpSubmittedBatch(t *testing.T) {
+	bp := &batchingProvider{fakeProvider: &fakeProvider{}}
+	providerName := registerFakeProvider(t, bp)
+	client := openai.NewClient(openai.Config{APIKey: "k"})
+	model := clientModel{testModel: testModel{provider: providerName, name: "m"}, client: client}
+
+	id, err := SubmitBatch(context.Background(), []BatchRequest{
+		{CustomID: "a", GenerateTextRequest: GenerateTextRequest{BaseRequest: BaseRequest{Model: model, Messages: []Message{User("great!")}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := BatchStatus(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ID != "batch_1" || status.Status != "completed" {
+		t.Fatalf("status=%+v", status)
+	}
+	results, err := BatchResults(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].CustomID != "a" || results[0].Text != "positive" {
+		t.Fatalf("results=%+v", results)
+	}
+	// Lookups use the client the batch was submitted with.
+	for _, l := range bp.lookups {
+		if l.ProviderData != client {
+			t.Fatalf("lookup ProviderData=%v", l.ProviderData)
+		}
+	}
+
+	if _, err := BatchStatus(context.Background(), "batch_unknown"); err == nil || !strings.Contains(err.Error(), "BatchStatusFor") {
+		t.Fatalf("err=%v", err)
+	}
+	if _, err := BatchStatusFor(context.Background(), model, "batch_unknown"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The batch is forgotten once its results were downloaded, or once it
+	// failed.
+	if _, err := BatchStatus(context.Background(), id); err == nil {
+		t.Fatal("batch still tracked after BatchResults")
+	}
+	bp.status = "failed"
+	batchReq := BatchRequest{GenerateTextRequest: GenerateTextRequest{BaseRequest: BaseRequest{Model: model, Messages: []Message{User("hi")}}}}
+	if id, err = SubmitBatch(context.Background(), []BatchRequest{batchReq}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := BatchStatus(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := BatchStatus(context.Background(), id); err == nil {
+		t.Fatal("failed batch still tracked")
+	}
+}
+
+func TestSubmitBatch_RequiresSameClient(t *testing.T) {
+	bp := &batchingProvider{fakeProvider: &fakeProvider{}}
+	providerName := registerFakeProvider(t, bp)
+	req := func(c *openai.Client) BatchRequest {
+		return BatchRequest{GenerateTextRequest: GenerateTextRequest{BaseRequest: BaseRequest{
+			Model:    clientModel{testModel: testModel{provider: providerName, name: "m"}, client: c},
+			Messages: []Message{User("hi")},
+		}}}
+	}
+	a := openai.NewClient(openai.Config{APIKey: "a"})
+	b := openai.NewClient(openai.Config{APIKey: "b"})
+
+	_, err := SubmitBatch(context.Background(), []BatchRequest{req(a), req(b)})
+	if err == nil || !strings.Contains(err.Error(), "batch request 1: all requests must use the same client") {
+		t.Fatalf("err=%v", err)
+	}
+	if len(bp.submitted) != 0 {
+		t.Fatal("batch was submitted")
+	}
+}
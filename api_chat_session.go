@@ -0,0 +1,133 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ChatSession is a multi-turn conversation built on an Agent configuration.
+//
+// Each turn sends the accumulated history plus the new user message, runs the
+// tool loop, and appends the turn's response messages (assistant tool calls,
+// tool results and the final assistant message) to the history. Failed or
+// canceled turns leave the history unchanged, as do turns that were in flight
+// when Reset was called.
+//
+// Turns should not overlap; History and Reset are safe to call concurrently.
+type ChatSession struct {
+	agent Agent
+
+	mu      sync.Mutex
+	history []Message
+	// resets counts Reset calls, so a turn started before one is not
+	// committed on top of the cleared history.
+	resets uint64
+}
+
+// NewChatSession creates a session. Unlike Agent, the tool loop is enabled by
+// default: when neither MaxIterations nor StopWhen are set, up to 5 steps run
+// per turn.
+func NewChatSession(agent Agent) *ChatSession {
+	if agent.MaxIterations <= 0 && agent.StopWhen == nil {
+		agent.MaxIterations = 5
+	}
+	agent.Tools = append([]Tool(nil), agent.Tools...)
+	agent.Headers = cloneStringMap(agent.Headers)
+	agent.Stop = append([]string(nil), agent.Stop...)
+	agent.Metadata = cloneStringMap(agent.Metadata)
+	return &ChatSession{agent: agent}
+}
+
+// Ask sends text as a user message and returns the final response of the turn.
+func (s *ChatSession) Ask(ctx context.Context, text string) (*GenerateTextResponse, error) {
+	msgs, gen, err := s.turnMessages(text)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.agent.Generate(ctx, AgentGenerateRequest{Messages: msgs})
+	if err != nil {
+		return nil, err
+	}
+	s.commit(gen, msgs, resp.Response.Messages)
+	return resp, nil
+}
+
+// AskStream is the streaming variant of Ask. The turn is recorded in the
+// history once the stream has been fully consumed without error.
+func (s *ChatSession) AskStream(ctx context.Context, text string) (*TextStream, error) {
+	msgs, gen, err := s.turnMessages(text)
+	if err != nil {
+		return nil, err
+	}
+	inner, err := s.agent.Stream(ctx, AgentStreamRequest{Messages: msgs})
+	if err != nil {
+		return nil, err
+	}
+
+	committed := false
+	return newTextStream(
+		func() bool {
+			if inner.Next() {
+				return true
+			}
+			if !committed && inner.Err() == nil {
+				committed = true
+				s.commit(gen, msgs, inner.Response().Messages)
+			}
+			return false
+		},
+		inner.Delta,
+		inner.Message,
+		inner.Usage,
+		inner.FinishReason,
+		inner.Steps,
+		inner.Response,
+		inner.streamInfo,
+		inner.Err,
+		inner.Close,
+	), nil
+}
+
+// History returns a copy of the conversation so far (excluding the agent's
+// system prompt).
+func (s *ChatSession) History() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.history...)
+}
+
+// Reset clears the conversation history. A turn in flight is not recorded.
+func (s *ChatSession) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = nil
+	s.resets++
+}
+
+// turnMessages returns the messages to send for a turn, and the Reset count
+// to pass to commit.
+func (s *ChatSession) turnMessages(text string) ([]Message, uint64, error) {
+	if s == nil {
+		return nil, 0, fmt.Errorf("chat session is nil")
+	}
+	if text == "" {
+		return nil, 0, fmt.Errorf("message text is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msgs := make([]Message, 0, len(s.history)+1)
+	msgs = append(msgs, s.history...)
+	return append(msgs, User(text)), s.resets, nil
+}
+
+func (s *ChatSession) commit(gen uint64, sent []Message, response []Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.resets != gen {
+		return
+	}
+	history := make([]Message, 0, len(sent)+len(response))
+	history = append(history, sent...)
+	s.history = append(history, response...)
+}
@@ -0,0 +1,157 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+func TestChatSession_PersistsToolTurns(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		switch call {
+		case 0:
+			return provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.ToolCallPart{ID: "call_1", Name: "add", Args: []byte(`{"a":1,"b":2}`)}},
+				},
+				FinishReason: "tool_calls",
+			}, nil
+		default:
+			return provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.TextPart{Text: "done"}},
+				},
+				FinishReason: "stop",
+			}, nil
+		}
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	add := NewTool("add", ToolSpec[struct{ A, B int }, int]{
+		Execute: func(ctx context.Context, in struct{ A, B int }, meta ToolExecutionMeta) (int, error) {
+			return in.A + in.B, nil
+		},
+	})
+	s := NewChatSession(Agent{
+		Model:  testModel{provider: providerName, name: "m"},
+		System: "sys",
+		Tools:  []Tool{add},
+	})
+
+	if _, err := s.Ask(context.Background(), "first"); err != nil {
+		t.Fatal(err)
+	}
+	// user, assistant tool call, tool result, assistant text
+	if got := len(s.History()); got != 4 {
+		t.Fatalf("history len=%d", got)
+	}
+
+	if _, err := s.Ask(context.Background(), "second"); err != nil {
+		t.Fatal(err)
+	}
+	reqs := fp.Requests()
+	if len(reqs) != 3 {
+		t.Fatalf("provider calls=%d", len(reqs))
+	}
+	msgs := reqs[2].Messages
+	wantRoles := []provider.Role{provider.RoleSystem, provider.RoleUser, provider.RoleAssistant, provider.RoleTool, provider.RoleAssistant, provider.RoleUser}
+	if len(msgs) != len(wantRoles) {
+		t.Fatalf("messages=%d", len(msgs))
+	}
+	for i, r := range wantRoles {
+		if msgs[i].Role != r {
+			t.Fatalf("message %d role=%q want %q", i, msgs[i].Role, r)
+		}
+	}
+	if msgs[3].ToolCallID != "call_1" {
+		t.Fatalf("tool result id=%q", msgs[3].ToolCallID)
+	}
+	if got := len(s.History()); got != 6 {
+		t.Fatalf("history len=%d", got)
+	}
+
+	s.Reset()
+	if got := len(s.History()); got != 0 {
+		t.Fatalf("history len after reset=%d", got)
+	}
+}
+
+func TestChatSession_AskStreamCommitsOnCompletion(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		return &fakeStream{
+			deltas: []provider.Delta{{Text: "he"}, {Text: "llo"}},
+			final: &provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.TextPart{Text: "hello"}},
+				},
+				FinishReason: "stop",
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	s := NewChatSession(Agent{Model: testModel{provider: providerName, name: "m"}})
+	stream, err := s.AskStream(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	if got := len(s.History()); got != 0 {
+		t.Fatalf("history committed before stream finished: %d", got)
+	}
+	for stream.Next() {
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	h := s.History()
+	if len(h) != 2 || h[0].Role != RoleUser || h[1].Role != RoleAssistant {
+		b, _ := json.Marshal(h)
+		t.Fatalf("history=%s", b)
+	}
+}
+
+func TestChatSession_ResetDuringTurnDropsTurn(t *testing.T) {
+	var s *ChatSession
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		if call == 1 {
+			s.Reset()
+		}
+		return provider.Response{
+			Message: provider.Message{
+				Role:    provider.RoleAssistant,
+				Content: []provider.ContentPart{provider.TextPart{Text: "ok"}},
+			},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	s = NewChatSession(Agent{Model: testModel{provider: providerName, name: "m"}})
+	if _, err := s.Ask(context.Background(), "first"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Ask(context.Background(), "second"); err != nil {
+		t.Fatal(err)
+	}
+	if h := s.History(); len(h) != 0 {
+		b, _ := json.Marshal(h)
+		t.Fatalf("turn committed over Reset: %s", b)
+	}
+
+	if _, err := s.Ask(context.Background(), "third"); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(s.History()); got != 2 {
+		t.Fatalf("history len=%d", got)
+	}
+}
@@ -7,12 +7,31 @@ import (
 
 	internalEmbeddings "github.com/bitop-dev/ai/internal/embeddings"
 	"github.com/bitop-dev/ai/internal/provider"
+	"github.com/bitop-dev/ai/openai"
 )
 
+// EmbedCache is a content-addressed store for embedding vectors. Keys are
+// SHA256 hashes of provider, model, dimensions and input text.
+//
+// Implementations must be safe for concurrent use.
+type EmbedCache interface {
+	Get(key string) ([]float32, bool)
+	Set(key string, vector []float32)
+}
+
+// NewMemoryEmbedCache returns an in-memory EmbedCache.
+func NewMemoryEmbedCache() EmbedCache {
+	return internalEmbeddings.NewMemoryCache()
+}
+
 type EmbedRequest struct {
 	Model ModelRef
 	Input string
 
+	// Dimensions requests a reduced output vector size from models that
+	// support it. When set it takes precedence over provider options.
+	Dimensions *int
+
 	Metadata map[string]string
 
 	Headers    map[string]string
@@ -20,19 +39,32 @@ type EmbedRequest struct {
 	Timeout    time.Duration
 
 	ProviderOptions map[string]any
+
+	// Cache, when set, is consulted before calling the provider.
+	Cache EmbedCache
 }
 
 type EmbedResponse struct {
 	Vector []float32
 	Usage  Usage
 
+	// Warnings reports request settings the provider ignored, such as
+	// ProviderOptions entries for other providers.
+	Warnings []string
+
 	RawResponse []byte
+	// RequestID is as in GenerateTextResponse.
+	RequestID string
 }
 
 type EmbedManyRequest struct {
 	Model ModelRef
 	Input []string
 
+	// Dimensions requests a reduced output vector size from models that
+	// support it. When set it takes precedence over provider options.
+	Dimensions *int
+
 	Metadata map[string]string
 
 	Headers          map[string]string
@@ -40,14 +72,31 @@ type EmbedManyRequest struct {
 	Timeout          time.Duration
 	MaxParallelCalls int
 
+	// OnProgress, if set, is called as batches complete with the number of
+	// inputs embedded so far and the number sent to the provider (uncached
+	// inputs when Cache is set). Calls are serialized.
+	OnProgress func(done, total int)
+
 	ProviderOptions map[string]any
+
+	// Cache, when set, is consulted per input before calling the provider. Only
+	// uncached inputs are sent; results are merged back in input order. Usage
+	// reflects provider calls only.
+	Cache EmbedCache
 }
 
 type EmbedManyResponse struct {
 	Vectors [][]float32
 	Usage   Usage
 
+	// Warnings reports request settings the provider ignored, such as
+	// ProviderOptions entries for other providers.
+	Warnings []string
+
 	RawResponse []byte
+	// RequestID is as in GenerateTextResponse; when inputs were split across
+	// calls it is the first call's.
+	RequestID string
 }
 
 func Embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error) {
@@ -60,11 +109,13 @@ func Embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error) {
 	resp, err := EmbedMany(ctx, EmbedManyRequest{
 		Model:           req.Model,
 		Input:           []string{req.Input},
+		Dimensions:      req.Dimensions,
 		Metadata:        req.Metadata,
 		Headers:         req.Headers,
 		MaxRetries:      req.MaxRetries,
 		Timeout:         req.Timeout,
 		ProviderOptions: req.ProviderOptions,
+		Cache:           req.Cache,
 	})
 	if err != nil {
 		return nil, err
@@ -72,7 +123,7 @@ func Embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error) {
 	if len(resp.Vectors) != 1 {
 		return nil, fmt.Errorf("expected 1 embedding, got %d", len(resp.Vectors))
 	}
-	return &EmbedResponse{Vector: resp.Vectors[0], Usage: resp.Usage, RawResponse: resp.RawResponse}, nil
+	return &EmbedResponse{Vector: resp.Vectors[0], Usage: resp.Usage, Warnings: resp.Warnings, RawResponse: resp.RawResponse, RequestID: resp.RequestID}, nil
 }
 
 func EmbedMany(ctx context.Context, req EmbedManyRequest) (*EmbedManyResponse, error) {
@@ -83,7 +134,7 @@ func EmbedMany(ctx context.Context, req EmbedManyRequest) (*EmbedManyResponse, e
 	if err != nil {
 		return nil, err
 	}
-	ep, ok := p.(provider.EmbeddingProvider)
+	ep, ok := provider.As[provider.EmbeddingProvider](p)
 	if !ok {
 		return nil, fmt.Errorf("provider %q does not support embeddings", req.Model.Provider())
 	}
@@ -94,6 +145,7 @@ func EmbedMany(ctx context.Context, req EmbedManyRequest) (*EmbedManyResponse, e
 	preq := provider.EmbeddingRequest{
 		Model:           req.Model.Name(),
 		Inputs:          append([]string(nil), req.Input...),
+		Dimensions:      req.Dimensions,
 		Metadata:        cloneStringMap(req.Metadata),
 		Headers:         cloneStringMap(req.Headers),
 		MaxRetries:      req.MaxRetries,
@@ -105,17 +157,68 @@ func EmbedMany(ctx context.Context, req EmbedManyRequest) (*EmbedManyResponse, e
 		preq.ProviderData = c
 	}
 
-	if req.MaxParallelCalls <= 1 || len(req.Input) <= 1 {
-		out, err := internalEmbeddings.EmbedMany(ctx, ep, preq, 1)
-		if err != nil {
-			return nil, mapProviderError(err)
-		}
-		return &EmbedManyResponse{Vectors: out.Vectors, Usage: Usage{PromptTokens: out.Usage.PromptTokens, CompletionTokens: out.Usage.CompletionTokens, TotalTokens: out.Usage.TotalTokens}, RawResponse: out.RawResponse}, nil
+	if req.Cache != nil {
+		return embedManyCached(ctx, ep, preq, req)
+	}
+
+	out, err := internalEmbeddings.EmbedMany(ctx, ep, preq, req.MaxParallelCalls, req.OnProgress)
+	if err != nil {
+		return nil, mapProviderError(err)
+	}
+	return &EmbedManyResponse{Vectors: out.Vectors, Usage: Usage{PromptTokens: out.Usage.PromptTokens, CompletionTokens: out.Usage.CompletionTokens, TotalTokens: out.Usage.TotalTokens}, Warnings: out.Warnings, RawResponse: out.RawResponse, RequestID: out.RequestID}, nil
+}
+
+func embedManyCached(ctx context.Context, ep provider.EmbeddingProvider, preq provider.EmbeddingRequest, req EmbedManyRequest) (*EmbedManyResponse, error) {
+	dims := embeddingDimensions(req.Dimensions, req.ProviderOptions)
+	keys := make([]string, len(req.Input))
+	for i, in := range req.Input {
+		keys[i] = internalEmbeddings.CacheKey(req.Model.Provider(), req.Model.Name(), dims, in)
+	}
+
+	vectors, missing, missingIdx := internalEmbeddings.Lookup(req.Cache.Get, keys, req.Input)
+	if len(missing) == 0 {
+		return &EmbedManyResponse{Vectors: vectors}, nil
 	}
 
-	out, err := internalEmbeddings.EmbedMany(ctx, ep, preq, req.MaxParallelCalls)
+	preq.Inputs = missing
+	out, err := internalEmbeddings.EmbedMany(ctx, ep, preq, req.MaxParallelCalls, req.OnProgress)
 	if err != nil {
 		return nil, mapProviderError(err)
 	}
-	return &EmbedManyResponse{Vectors: out.Vectors, Usage: Usage{PromptTokens: out.Usage.PromptTokens, CompletionTokens: out.Usage.CompletionTokens, TotalTokens: out.Usage.TotalTokens}, RawResponse: out.RawResponse}, nil
+	if len(out.Vectors) != len(missing) {
+		return nil, fmt.Errorf("embedding response count mismatch: got %d want %d", len(out.Vectors), len(missing))
+	}
+	for j, vec := range out.Vectors {
+		for _, i := range missingIdx[j] {
+			vectors[i] = vec
+		}
+		req.Cache.Set(keys[missingIdx[j][0]], vec)
+	}
+	return &EmbedManyResponse{Vectors: vectors, Usage: Usage{PromptTokens: out.Usage.PromptTokens, CompletionTokens: out.Usage.CompletionTokens, TotalTokens: out.Usage.TotalTokens}, Warnings: out.Warnings, RawResponse: out.RawResponse, RequestID: out.RequestID}, nil
+}
+
+// embeddingDimensions resolves the requested output dimensions (0 = model
+// default) for cache keying. An explicit value wins over provider options.
+func embeddingDimensions(explicit *int, opts map[string]any) int {
+	if explicit != nil {
+		return *explicit
+	}
+	switch o := opts["openai"].(type) {
+	case openai.EmbeddingOptions:
+		if o.Dimensions != nil {
+			return *o.Dimensions
+		}
+	case *openai.EmbeddingOptions:
+		if o != nil && o.Dimensions != nil {
+			return *o.Dimensions
+		}
+	case map[string]any:
+		if d, ok := o["dimensions"].(float64); ok {
+			return int(d)
+		}
+		if d, ok := o["dimensions"].(int); ok {
+			return d
+		}
+	}
+	return 0
 }
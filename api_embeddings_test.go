@@ -2,6 +2,7 @@ package ai
 
 import (
 	"context"
+	"sync"
 	"testing"
 
 	"github.com/bitop-dev/ai/internal/provider"
@@ -28,13 +29,16 @@ func TestEmbedMany_ProviderNotSupported(t *testing.T) {
 type fakeEmbeddingProvider struct {
 	*fakeProvider
 	embed func(call int, req provider.EmbeddingRequest) (provider.EmbeddingResponse, error)
+	mu    sync.Mutex
 	n     int
 }
 
 func (p *fakeEmbeddingProvider) Embed(ctx context.Context, req provider.EmbeddingRequest) (provider.EmbeddingResponse, error) {
 	_ = ctx
+	p.mu.Lock()
 	call := p.n
 	p.n++
+	p.mu.Unlock()
 	if p.embed == nil {
 		return provider.EmbeddingResponse{}, nil
 	}
@@ -110,3 +114,139 @@ func TestEmbedMany_ParallelPreservesOrder(t *testing.T) {
 		}
 	}
 }
+
+func TestEmbedMany_ParallelRequestIDFromFirstBatch(t *testing.T) {
+	lastDone := make(chan struct{})
+	ep := &fakeEmbeddingProvider{}
+	ep.embed = func(call int, req provider.EmbeddingRequest) (provider.EmbeddingResponse, error) {
+		// The first batch finishes last.
+		if req.Inputs[0] == "v0" {
+			<-lastDone
+		} else {
+			defer close(lastDone)
+		}
+		return provider.EmbeddingResponse{
+			Vectors:     make([][]float32, len(req.Inputs)),
+			RequestID:   "req_" + req.Inputs[0],
+			RawResponse: []byte(req.Inputs[0]),
+		}, nil
+	}
+	providerName := registerFakeProvider(t, ep)
+
+	resp, err := EmbedMany(context.Background(), EmbedManyRequest{
+		Model:            testModel{provider: providerName, name: "text-embedding-test"},
+		Input:            []string{"v0", "v1"},
+		MaxParallelCalls: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RequestID != "req_v0" || string(resp.RawResponse) != "v0" {
+		t.Fatalf("RequestID=%q RawResponse=%q", resp.RequestID, resp.RawResponse)
+	}
+}
+
+func TestEmbedMany_OnProgress(t *testing.T) {
+	ep := &fakeEmbeddingProvider{}
+	ep.embed = func(call int, req provider.EmbeddingRequest) (provider.EmbeddingResponse, error) {
+		_ = call
+		vecs := make([][]float32, len(req.Inputs))
+		for i := range req.Inputs {
+			vecs[i] = []float32{1}
+		}
+		return provider.EmbeddingResponse{Vectors: vecs}, nil
+	}
+	providerName := registerFakeProvider(t, ep)
+
+	var progress [][2]int
+	_, err := EmbedMany(context.Background(), EmbedManyRequest{
+		Model:            testModel{provider: providerName, name: "text-embedding-test"},
+		Input:            []string{"a", "b", "c", "d", "e"},
+		MaxParallelCalls: 2,
+		OnProgress:       func(done, total int) { progress = append(progress, [2]int{done, total}) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(progress) != 2 || progress[1] != [2]int{5, 5} || (progress[0] != [2]int{2, 5} && progress[0] != [2]int{3, 5}) {
+		t.Fatalf("progress=%v", progress)
+	}
+}
+
+func TestEmbedMany_CacheSkipsCachedInputs(t *testing.T) {
+	var sent [][]string
+	ep := &fakeEmbeddingProvider{}
+	ep.embed = func(call int, req provider.EmbeddingRequest) (provider.EmbeddingResponse, error) {
+		_ = call
+		sent = append(sent, append([]string(nil), req.Inputs...))
+		vecs := make([][]float32, len(req.Inputs))
+		for i, in := range req.Inputs {
+			vecs[i] = []float32{float32(len(in))}
+		}
+		return provider.EmbeddingResponse{Vectors: vecs, Usage: provider.Usage{TotalTokens: len(req.Inputs)}}, nil
+	}
+	providerName := registerFakeProvider(t, ep)
+	model := testModel{provider: providerName, name: "m"}
+	cache := NewMemoryEmbedCache()
+
+	first, err := EmbedMany(context.Background(), EmbedManyRequest{Model: model, Input: []string{"a", "bb", "a"}, Cache: cache})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || len(sent[0]) != 2 {
+		t.Fatalf("sent=%v", sent)
+	}
+	if first.Vectors[0][0] != 1 || first.Vectors[1][0] != 2 || first.Vectors[2][0] != 1 {
+		t.Fatalf("vectors=%v", first.Vectors)
+	}
+
+	second, err := EmbedMany(context.Background(), EmbedManyRequest{Model: model, Input: []string{"bb", "ccc", "a"}, Cache: cache})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 || len(sent[1]) != 1 || sent[1][0] != "ccc" {
+		t.Fatalf("sent=%v", sent)
+	}
+	if second.Vectors[0][0] != 2 || second.Vectors[1][0] != 3 || second.Vectors[2][0] != 1 {
+		t.Fatalf("vectors=%v", second.Vectors)
+	}
+	if second.Usage.TotalTokens != 1 {
+		t.Fatalf("usage=%#v", second.Usage)
+	}
+
+	if _, err := EmbedMany(context.Background(), EmbedManyRequest{Model: model, Input: []string{"a", "ccc"}, Cache: cache}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 {
+		t.Fatalf("expected full cache hit, sent=%v", sent)
+	}
+}
+
+func TestEmbed_DimensionsThreadedAndKeyed(t *testing.T) {
+	var got []*int
+	ep := &fakeEmbeddingProvider{}
+	ep.embed = func(call int, req provider.EmbeddingRequest) (provider.EmbeddingResponse, error) {
+		_ = call
+		got = append(got, req.Dimensions)
+		return provider.EmbeddingResponse{Vectors: [][]float32{{1}}}, nil
+	}
+	providerName := registerFakeProvider(t, ep)
+	model := testModel{provider: providerName, name: "m"}
+	cache := NewMemoryEmbedCache()
+
+	dims := 256
+	if _, err := Embed(context.Background(), EmbedRequest{Model: model, Input: "a", Dimensions: &dims, Cache: cache}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] == nil || *got[0] != 256 {
+		t.Fatalf("dimensions=%v", got)
+	}
+
+	// Same input at the model default dimensions must not hit the cached entry.
+	if _, err := Embed(context.Background(), EmbedRequest{Model: model, Input: "a", Cache: cache}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1] != nil {
+		t.Fatalf("dimensions=%v", got)
+	}
+}
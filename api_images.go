@@ -14,6 +14,12 @@ type Image struct {
 	Base64     string
 	Uint8Array []byte
 	MediaType  string
+
+	// RevisedPrompt is the prompt the provider actually used, when it rewrites
+	// prompts (e.g. OpenAI dall-e-3).
+	RevisedPrompt string
+	// Seed is the seed the provider reports having used, if any.
+	Seed *int64
 }
 
 type GenerateImageRequest struct {
@@ -28,6 +34,20 @@ type GenerateImageRequest struct {
 	MaxParallelCalls int
 	Seed             *int64
 
+	// ReferenceImages are input images (raw PNG/JPEG/WebP bytes) that guide
+	// generation, e.g. for style transfer. Unlike an edit, the output is a new
+	// image. Supported by OpenAI gpt-image models; other models return an
+	// error.
+	ReferenceImages [][]byte
+
+	// Background ("transparent", "opaque" or "auto"), OutputFormat ("png",
+	// "jpeg" or "webp") and OutputCompression (0-100, for jpeg and webp)
+	// control the encoded image. Supported by OpenAI gpt-image models; other
+	// models ignore them with a warning. Image.MediaType follows the format.
+	Background        string
+	OutputFormat      string
+	OutputCompression *int
+
 	Headers    map[string]string
 	MaxRetries *int
 	Timeout    time.Duration
@@ -43,6 +63,9 @@ type GenerateImageResponse struct {
 	ProviderMetadata map[string]any
 
 	RawResponse []byte
+	// RequestID is as in GenerateTextResponse; when the images took several
+	// calls it is the first call's.
+	RequestID string
 }
 
 type NoImageGeneratedError struct {
@@ -79,7 +102,7 @@ func GenerateImage(ctx context.Context, req GenerateImageRequest) (*GenerateImag
 	if err != nil {
 		return nil, err
 	}
-	ip, ok := p.(provider.ImageProvider)
+	ip, ok := provider.As[provider.ImageProvider](p)
 	if !ok {
 		return nil, fmt.Errorf("provider %q does not support image generation", req.Model.Provider())
 	}
@@ -110,6 +133,12 @@ func GenerateImage(ctx context.Context, req GenerateImageRequest) (*GenerateImag
 		Size:            req.Size,
 		AspectRatio:     req.AspectRatio,
 		Seed:            req.Seed,
+		ReferenceImages: req.ReferenceImages,
+
+		Background:        req.Background,
+		OutputFormat:      req.OutputFormat,
+		OutputCompression: req.OutputCompression,
+
 		Headers:         cloneStringMap(req.Headers),
 		MaxRetries:      req.MaxRetries,
 		ProviderOptions: req.ProviderOptions,
@@ -119,24 +148,25 @@ func GenerateImage(ctx context.Context, req GenerateImageRequest) (*GenerateImag
 		preqBase.ProviderData = c
 	}
 
-	provImages, warnings, metadata, raw, err := internalImages.GenerateBatched(ctx, ip, preqBase, n, maxPerCall, maxParallel)
+	batched, err := internalImages.GenerateBatched(ctx, ip, preqBase, n, maxPerCall, maxParallel)
 	if err != nil {
 		return nil, mapProviderError(err)
 	}
-	if len(provImages) == 0 {
-		return nil, &NoImageGeneratedError{Provider: req.Model.Provider(), RawResponse: raw}
+	if len(batched.Images) == 0 {
+		return nil, &NoImageGeneratedError{Provider: req.Model.Provider(), RawResponse: batched.RawResponse}
 	}
 
-	images := make([]Image, len(provImages))
-	for i, img := range provImages {
+	images := make([]Image, len(batched.Images))
+	for i, img := range batched.Images {
 		images[i] = fromProviderImage(img)
 	}
 
 	out := &GenerateImageResponse{
 		Images:           images,
-		Warnings:         warnings,
-		ProviderMetadata: metadata,
-		RawResponse:      raw,
+		Warnings:         batched.Warnings,
+		ProviderMetadata: batched.ProviderMetadata,
+		RawResponse:      batched.RawResponse,
+		RequestID:        batched.RequestID,
 	}
 	out.Image = images[0]
 	return out, nil
@@ -144,8 +174,10 @@ func GenerateImage(ctx context.Context, req GenerateImageRequest) (*GenerateImag
 
 func fromProviderImage(img provider.Image) Image {
 	out := Image{
-		Base64:    img.Base64,
-		MediaType: img.MediaType,
+		Base64:        img.Base64,
+		MediaType:     img.MediaType,
+		RevisedPrompt: img.RevisedPrompt,
+		Seed:          img.Seed,
 	}
 	if out.MediaType == "" {
 		out.MediaType = "image/png"
@@ -2,6 +2,7 @@ package ai
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"testing"
 
@@ -11,13 +12,16 @@ import (
 type fakeImageProvider struct {
 	*fakeProvider
 	gen func(call int, req provider.GenerateImageRequest) (provider.GenerateImageResponse, error)
+	mu  sync.Mutex
 	n   int
 }
 
 func (p *fakeImageProvider) GenerateImage(ctx context.Context, req provider.GenerateImageRequest) (provider.GenerateImageResponse, error) {
 	_ = ctx
+	p.mu.Lock()
 	call := p.n
 	p.n++
+	p.mu.Unlock()
 	if p.gen == nil {
 		return provider.GenerateImageResponse{}, nil
 	}
@@ -34,8 +38,9 @@ func TestGenerateImage_Batched(t *testing.T) {
 			t.Fatalf("expected N=1 for dall-e-3, got %d", req.N)
 		}
 		return provider.GenerateImageResponse{
-			N:      req.N,
-			Images: []provider.Image{{Base64: "aGVsbG8="}},
+			N:         req.N,
+			Images:    []provider.Image{{Base64: "aGVsbG8="}},
+			RequestID: "req_1",
 		}, nil
 	}
 	providerName := registerFakeProvider(t, ip)
@@ -51,8 +56,8 @@ func TestGenerateImage_Batched(t *testing.T) {
 		t.Fatal(err)
 	}
 	wg.Wait()
-	if len(resp.Images) != 5 {
-		t.Fatalf("images=%d", len(resp.Images))
+	if len(resp.Images) != 5 || resp.RequestID != "req_1" {
+		t.Fatalf("images=%d RequestID=%q", len(resp.Images), resp.RequestID)
 	}
 	for _, img := range resp.Images {
 		if img.Base64 == "" || len(img.Uint8Array) == 0 {
@@ -60,3 +65,37 @@ func TestGenerateImage_Batched(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateImage_BatchedRequestIDFromFirstBatch(t *testing.T) {
+	lastDone := make(chan struct{})
+	ip := &fakeImageProvider{}
+	ip.gen = func(call int, req provider.GenerateImageRequest) (provider.GenerateImageResponse, error) {
+		// The first batch (N=2) finishes after the second (N=1).
+		if req.N == 2 {
+			<-lastDone
+		} else {
+			defer close(lastDone)
+		}
+		id := fmt.Sprintf("req_n%d", req.N)
+		images := make([]provider.Image, req.N)
+		for i := range images {
+			images[i] = provider.Image{Base64: "aGVsbG8="}
+		}
+		return provider.GenerateImageResponse{N: req.N, Images: images, RequestID: id, RawResponse: []byte(id)}, nil
+	}
+	providerName := registerFakeProvider(t, ip)
+
+	resp, err := GenerateImage(context.Background(), GenerateImageRequest{
+		Model:            testModel{provider: providerName, name: "dall-e-2"},
+		Prompt:           "x",
+		N:                3,
+		MaxImagesPerCall: 2,
+		MaxParallelCalls: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RequestID != "req_n2" || string(resp.RawResponse) != "req_n2" {
+		t.Fatalf("RequestID=%q RawResponse=%q", resp.RequestID, resp.RawResponse)
+	}
+}
@@ -0,0 +1,53 @@
+package ai
+
+import (
+	"encoding/json"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// InspectedRequest is the fully-resolved request GenerateText would send for
+// its first step: messages after System injection, tool definitions as the
+// model sees them, and sampling parameters.
+//
+// Headers only contains BaseRequest.Headers; client-level headers and API keys
+// are never included.
+type InspectedRequest struct {
+	Provider string
+	ProviderRequest
+
+	// Body is the JSON payload the provider would put on the wire, when the
+	// provider supports rendering it (the built-in OpenAI provider does; custom
+	// providers leave it nil).
+	Body json.RawMessage
+}
+
+// BuildRequest resolves req the same way GenerateText does without sending it.
+// Use it to check what will hit the wire, estimate tokens or snapshot requests
+// in tests. PrepareStep is not invoked.
+func BuildRequest(req GenerateTextRequest) (InspectedRequest, error) {
+	p, err := providerForModel(req.Model)
+	if err != nil {
+		return InspectedRequest{}, err
+	}
+	preq, err := toProviderRequest(req.BaseRequest)
+	if err != nil {
+		return InspectedRequest{}, err
+	}
+	resolved, err := fromInternalRequest(preq)
+	if err != nil {
+		return InspectedRequest{}, err
+	}
+	out := InspectedRequest{
+		Provider:        req.Model.Provider(),
+		ProviderRequest: resolved,
+	}
+	if b, ok := provider.As[provider.RequestBodyBuilder](p); ok {
+		body, err := b.BuildRequestBody(preq, false)
+		if err != nil {
+			return InspectedRequest{}, mapProviderError(err)
+		}
+		out.Body = body
+	}
+	return out, nil
+}
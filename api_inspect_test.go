@@ -0,0 +1,80 @@
+package ai
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bitop-dev/ai/openai"
+)
+
+func TestBuildRequest_OpenAIBody(t *testing.T) {
+	client := openai.NewClient(openai.Config{APIKey: "sk-secret"})
+	temp := float32(0.3)
+
+	got, err := BuildRequest(GenerateTextRequest{BaseRequest: BaseRequest{
+		Model:       client.Chat("gpt-test"),
+		System:      "be brief",
+		Messages:    []Message{User("hi")},
+		Temperature: &temp,
+		Tools: []Tool{{
+			Name:        "lookup",
+			Description: "Look up a thing.",
+			InputSchema: JSONSchema(json.RawMessage(`{"type":"object","properties":{"q":{"type":"string"}}}`)),
+		}},
+		Headers: map[string]string{"X-Trace": "1"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Provider != "openai" || got.Model != "gpt-test" {
+		t.Fatalf("provider=%q model=%q", got.Provider, got.Model)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Role != RoleSystem {
+		t.Fatalf("messages=%#v", got.Messages)
+	}
+	if len(got.Tools) != 1 || got.Tools[0].Name != "lookup" {
+		t.Fatalf("tools=%#v", got.Tools)
+	}
+	if got.Temperature == nil || *got.Temperature != temp || got.Headers["X-Trace"] != "1" {
+		t.Fatalf("params not resolved: %#v", got.ProviderRequest)
+	}
+
+	var body struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Role string `json:"role"`
+		} `json:"messages"`
+		Tools  []json.RawMessage `json:"tools"`
+		Stream bool              `json:"stream"`
+	}
+	if err := json.Unmarshal(got.Body, &body); err != nil {
+		t.Fatalf("body: %v (%s)", err, got.Body)
+	}
+	if body.Model != "gpt-test" || len(body.Messages) != 2 || body.Messages[0].Role != "system" || len(body.Tools) != 1 || body.Stream {
+		t.Fatalf("body=%s", got.Body)
+	}
+	if strings.Contains(string(got.Body), "sk-secret") {
+		t.Fatalf("body leaks API key: %s", got.Body)
+	}
+}
+
+func TestBuildRequest_CustomProviderHasNoBody(t *testing.T) {
+	fp := &fakeProvider{}
+	providerName := registerFakeProvider(t, fp)
+
+	got, err := BuildRequest(GenerateTextRequest{BaseRequest: BaseRequest{
+		Model:    testModel{provider: providerName, name: "m"},
+		Messages: []Message{User("hi")},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Body != nil || len(got.Messages) != 1 {
+		t.Fatalf("got=%#v", got)
+	}
+	if len(fp.Requests()) != 0 {
+		t.Fatalf("request was sent")
+	}
+}
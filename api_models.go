@@ -0,0 +1,46 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// ModelInfo describes a model available from a provider.
+type ModelInfo struct {
+	ID      string
+	OwnedBy string
+	// Created is when the model was published, zero when unknown.
+	Created time.Time
+}
+
+// ListModels returns the models available to the provider and credentials of
+// model, sorted by ID; the model's name is not used. Use it to fill model
+// pickers or check that a configured model exists:
+//
+//	models, err := ai.ListModels(ctx, openai.Chat(""))
+func ListModels(ctx context.Context, model ModelRef) ([]ModelInfo, error) {
+	p, err := providerForModel(model)
+	if err != nil {
+		return nil, err
+	}
+	ml, ok := provider.As[provider.ModelLister](p)
+	if !ok {
+		return nil, fmt.Errorf("provider %q does not support listing models", model.Provider())
+	}
+	var providerData any
+	if c, ok := openAIClientFromModel(model); ok {
+		providerData = c
+	}
+	models, err := ml.ListModels(ctx, provider.ListModelsRequest{ProviderData: providerData})
+	if err != nil {
+		return nil, mapProviderError(err)
+	}
+	out := make([]ModelInfo, len(models))
+	for i, m := range models {
+		out[i] = ModelInfo(m)
+	}
+	return out, nil
+}
@@ -0,0 +1,47 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// listingProvider is a fake provider that also lists models.
+type listingProvider struct {
+	*fakeProvider
+	models []provider.ModelInfo
+}
+
+func (p *listingProvider) ListModels(ctx context.Context, req provider.ListModelsRequest) ([]provider.ModelInfo, error) {
+	return p.models, nil
+}
+
+func TestListModels(t *testing.T) {
+	lp := &listingProvider{fakeProvider: &fakeProvider{}, models: []provider.ModelInfo{
+		{ID: "small", OwnedBy: "acme", Created: time.Unix(1700000000, 0)},
+		{ID: "large"},
+	}}
+	providerName := registerFakeProvider(t, lp)
+
+	models, err := ListModels(context.Background(), testModel{provider: providerName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 2 || models[0].ID != "small" || models[0].OwnedBy != "acme" {
+		t.Fatalf("models=%+v", models)
+	}
+	if !models[0].Created.Equal(time.Unix(1700000000, 0)) || !models[1].Created.IsZero() {
+		t.Fatalf("created=%v, %v", models[0].Created, models[1].Created)
+	}
+
+	// Providers without a model listing are reported as unsupported.
+	if err := provider.Register(providerName+"_plain", &fakeProvider{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ListModels(context.Background(), testModel{provider: providerName + "_plain"}); err == nil || !strings.Contains(err.Error(), "does not support listing models") {
+		t.Fatalf("err=%v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package ai
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -8,6 +9,7 @@ import (
 
 	internalObject "github.com/bitop-dev/ai/internal/object"
 	"github.com/bitop-dev/ai/internal/provider"
+	internalSchema "github.com/bitop-dev/ai/internal/schema"
 )
 
 func GenerateObject[T any](ctx context.Context, req GenerateObjectRequest[T]) (*GenerateObjectResponse[T], error) {
@@ -18,12 +20,13 @@ func GenerateObject[T any](ctx context.Context, req GenerateObjectRequest[T]) (*
 	if err != nil {
 		return nil, err
 	}
+	p = withPerCallTimeout(p, req.PerCallTimeout)
 
 	if len(req.Schema.JSON) == 0 && req.Example == nil {
 		return nil, fmt.Errorf("schema (or example) is required")
 	}
 	if req.Example != nil && len(req.Schema.JSON) == 0 {
-		return nil, fmt.Errorf("schema derivation from example is not implemented in v0")
+		req.Schema = SchemaFromExample(*req.Example)
 	}
 
 	strict := true
@@ -54,9 +57,12 @@ func GenerateObject[T any](ctx context.Context, req GenerateObjectRequest[T]) (*
 		return nil, err
 	}
 
+	cache := req.ToolLoop.resultCache()
 	exec := func(ctx context.Context, calls []provider.ToolCallPart) ([]provider.Message, error) {
 		return executeToolCallsProviderWithOptions(ctx, req.Tools, calls, toolExecOptions{
 			onProgress: callReq.OnToolProgress,
+			dedupe:     req.ToolLoop.dedupeToolCalls(),
+			cache:      cache,
 		})
 	}
 
@@ -64,8 +70,17 @@ func GenerateObject[T any](ctx context.Context, req GenerateObjectRequest[T]) (*
 		Strict:        strict,
 		MaxRetries:    maxRetries,
 		MaxIterations: maxIter,
+		OnProgress:    objectProgress(req.OnProgress, req.OnField),
+		OnRawDelta:    req.OnRawDelta,
+		PatchRepair:   req.PatchRepair,
+
+		CorrectionTemplate: req.CorrectionTemplate,
 	})
 
+	// The return tool call carries the result (Object/RawJSON); the reserved
+	// name never surfaces in the response message.
+	out.LastResponse.Message = internalObject.StripReturnCall(out.LastResponse.Message)
+
 	if genErr != nil {
 		var pe *provider.Error
 		if errors.As(genErr, &pe) {
@@ -112,12 +127,13 @@ func StreamObject[T any](ctx context.Context, req StreamObjectRequest[T]) (*Obje
 	if err != nil {
 		return nil, err
 	}
+	p = withPerCallTimeout(p, req.PerCallTimeout)
 
 	if len(req.Schema.JSON) == 0 && req.Example == nil {
 		return nil, fmt.Errorf("schema (or example) is required")
 	}
 	if req.Example != nil && len(req.Schema.JSON) == 0 {
-		return nil, fmt.Errorf("schema derivation from example is not implemented in v0")
+		req.Schema = SchemaFromExample(*req.Example)
 	}
 
 	strict := true
@@ -148,9 +164,12 @@ func StreamObject[T any](ctx context.Context, req StreamObjectRequest[T]) (*Obje
 		return nil, err
 	}
 
+	cache := req.ToolLoop.resultCache()
 	exec := func(ctx context.Context, calls []provider.ToolCallPart) ([]provider.Message, error) {
 		return executeToolCallsProviderWithOptions(ctx, req.Tools, calls, toolExecOptions{
 			onProgress: callReq.OnToolProgress,
+			dedupe:     req.ToolLoop.dedupeToolCalls(),
+			cache:      cache,
 		})
 	}
 
@@ -158,14 +177,68 @@ func StreamObject[T any](ctx context.Context, req StreamObjectRequest[T]) (*Obje
 		Strict:        strict,
 		MaxRetries:    maxRetries,
 		MaxIterations: maxIter,
+		OnRawDelta:    req.OnRawDelta,
+
+		AbortOnSchemaViolation: req.AbortOnSchemaViolation,
 	})
 
+	onProgress := objectProgress(req.OnProgress, req.OnField)
+	var reported json.RawMessage
 	return newObjectStream[T](
-		func() bool { return impl.Next() },
+		func() bool {
+			if !impl.Next() {
+				return false
+			}
+			// Deltas that do not extend the JSON (e.g. the {"value": of a
+			// wrapped schema) are not progress.
+			if raw := impl.Raw(); onProgress != nil && len(raw) > 0 && !bytes.Equal(raw, reported) {
+				reported = raw
+				onProgress(raw)
+			}
+			return true
+		},
 		func() json.RawMessage { return impl.Raw() },
 		func() map[string]any { return impl.Partial() },
+		func() *T { return impl.PartialTyped() },
 		func() *T { return impl.Object() },
-		func() error { return mapProviderError(impl.Err()) },
+		func() error { return mapObjectStreamError(impl.Err()) },
 		func() error { return impl.Close() },
 	), nil
 }
+
+// SchemaViolationError is returned by an ObjectStream with
+// AbortOnSchemaViolation set when a field violates the schema. Path is the
+// field's dot-separated path (see GenerateObjectRequest.OnField).
+type SchemaViolationError struct {
+	Path  string
+	Cause error
+}
+
+func (e *SchemaViolationError) Error() string {
+	return fmt.Sprintf("schema violation at %q: %v", e.Path, e.Cause)
+}
+
+func (e *SchemaViolationError) Unwrap() error { return e.Cause }
+
+func mapObjectStreamError(err error) error {
+	var fe *internalSchema.FieldError
+	if errors.As(err, &fe) {
+		return &SchemaViolationError{Path: fe.Path, Cause: fe.Err}
+	}
+	return mapProviderError(err)
+}
+
+// objectProgress combines OnProgress and OnField into the single raw JSON
+// progress callback the object engine reports to.
+func objectProgress(onProgress func(raw json.RawMessage), onField func(path string, value any)) func(raw json.RawMessage) {
+	if onField == nil {
+		return onProgress
+	}
+	fields := internalObject.NewFieldScanner(onField)
+	return func(raw json.RawMessage) {
+		if onProgress != nil {
+			onProgress(raw)
+		}
+		fields.Feed(raw)
+	}
+}
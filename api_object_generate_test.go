@@ -2,6 +2,8 @@ package ai
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -112,6 +114,143 @@ func TestGenerateObject_RetryOnInvalidJSON(t *testing.T) {
 	}
 }
 
+func TestGenerateObject_CorrectionTemplate(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		args := `{"x":"no"}`
+		if call == 1 {
+			last := req.Messages[len(req.Messages)-1]
+			if last.Role != provider.RoleSystem || last.Content[0].(provider.TextPart).Text != `JSON ungültig: {"x":"no"}` {
+				t.Fatalf("correction message=%#v", last)
+			}
+			args = `{"x":2}`
+		}
+		return provider.Response{
+			Message: provider.Message{
+				Role:    provider.RoleAssistant,
+				Content: []provider.ContentPart{provider.ToolCallPart{ID: "c1", Name: "__ai_return_json", Args: []byte(args)}},
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	type out struct {
+		X int `json:"x"`
+	}
+	var gotErr error
+	resp, err := GenerateObject[out](context.Background(), GenerateObjectRequest[out]{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("gib x")},
+		},
+		Schema: JSONSchema([]byte(`{"type":"object","properties":{"x":{"type":"integer"}},"required":["x"],"additionalProperties":false}`)),
+		CorrectionTemplate: func(err error, raw json.RawMessage) string {
+			gotErr = err
+			return "JSON ungültig: " + string(raw)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Object.X != 2 || gotErr == nil {
+		t.Fatalf("X=%d err=%v", resp.Object.X, gotErr)
+	}
+}
+
+func TestGenerateObject_PatchRepair(t *testing.T) {
+	returnCall := func(args string) provider.Response {
+		return provider.Response{
+			Message: provider.Message{
+				Role:    provider.RoleAssistant,
+				Content: []provider.ContentPart{provider.ToolCallPart{ID: "c1", Name: "__ai_return_json", Args: []byte(args)}},
+			},
+			Usage: provider.Usage{TotalTokens: 100},
+		}
+	}
+	patchCall := func(patch string) provider.Response {
+		return provider.Response{
+			Message: provider.Message{
+				Role:    provider.RoleAssistant,
+				Content: []provider.ContentPart{provider.ToolCallPart{ID: "p1", Name: "__ai_return_patch", Args: []byte(`{"patch":` + patch + `}`)}},
+			},
+			Usage: provider.Usage{TotalTokens: 10},
+		}
+	}
+	schema := JSONSchema([]byte(`{"type":"object","properties":{
+		"title":{"type":"string"},
+		"items":{"type":"array","items":{"type":"object","properties":{"qty":{"type":"integer"}},"required":["qty"]}}
+	},"required":["title","items"],"additionalProperties":false}`))
+	type out struct {
+		Title string `json:"title"`
+		Items []struct {
+			Qty int `json:"qty"`
+		} `json:"items"`
+	}
+	invalid := `{"title":"order","items":[{"qty":1},{"qty":"two"}]}`
+
+	cases := []struct {
+		name      string
+		responses []provider.Response
+		wantQty   int
+		wantErr   bool
+	}{
+		{name: "patched", responses: []provider.Response{returnCall(invalid), patchCall(`[{"op":"replace","path":"/items/1/qty","value":2}]`)}, wantQty: 2},
+		{name: "bad patch falls back", responses: []provider.Response{returnCall(invalid), patchCall(`[{"op":"replace","path":"/nope/0","value":2}]`), returnCall(`{"title":"order","items":[{"qty":1},{"qty":3}]}`)}, wantQty: 3},
+		{name: "still invalid", responses: []provider.Response{returnCall(invalid), patchCall(`[{"op":"add","path":"/extra","value":1}]`), patchCall(`[{"op":"remove","path":"/title"}]`)}, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+				if call >= len(tc.responses) {
+					t.Fatalf("unexpected call %d", call)
+				}
+				return tc.responses[call], nil
+			}
+			providerName := registerFakeProvider(t, fp)
+
+			retries := 2
+			resp, err := GenerateObject[out](context.Background(), GenerateObjectRequest[out]{
+				BaseRequest: BaseRequest{
+					Model:    testModel{provider: providerName, name: "m"},
+					Messages: []Message{User("order")},
+				},
+				Schema:      schema,
+				MaxRetries:  &retries,
+				PatchRepair: true,
+			})
+			reqs := fp.Requests()
+			if len(reqs) != len(tc.responses) {
+				t.Fatalf("provider calls=%d", len(reqs))
+			}
+			// The patch call offers only the patch tool and points at the bad field.
+			patchReq := reqs[1]
+			if len(patchReq.Tools) != 1 || patchReq.Tools[0].Name != "__ai_return_patch" {
+				t.Fatalf("patch tools=%+v", patchReq.Tools)
+			}
+			last := patchReq.Messages[len(patchReq.Messages)-1]
+			if text := last.Content[0].(provider.TextPart).Text; !strings.Contains(text, "- /items/1/qty") {
+				t.Fatalf("patch prompt=%s", text)
+			}
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Object.Items) != 2 || resp.Object.Items[1].Qty != tc.wantQty {
+				t.Fatalf("object=%+v", resp.Object)
+			}
+			if tc.name == "patched" && resp.Usage.TotalTokens != 110 {
+				t.Fatalf("usage=%+v", resp.Usage)
+			}
+		})
+	}
+}
+
 func TestGenerateObject_FallbackJSONOnlyOnToolsUnsupported(t *testing.T) {
 	fp := &fakeProvider{}
 	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
@@ -156,3 +295,376 @@ func TestGenerateObject_FallbackJSONOnlyOnToolsUnsupported(t *testing.T) {
 		t.Fatalf("provider calls=%d", len(fp.Requests()))
 	}
 }
+
+func TestGenerateObject_OnProgress(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		return &fakeStream{
+			deltas: []provider.Delta{
+				{ToolCalls: []provider.ToolCallDelta{{Index: 0, ID: "c1", Name: "__ai_return_json"}}},
+				{ToolCalls: []provider.ToolCallDelta{{Index: 0, ArgumentsDelta: `{"x"`}}},
+				{ToolCalls: []provider.ToolCallDelta{{Index: 0, ArgumentsDelta: `:1}`}}},
+			},
+			final: &provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.ToolCallPart{ID: "c1", Name: "__ai_return_json", Args: []byte(`{"x":1}`)}},
+				},
+				FinishReason: "tool_calls",
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	type out struct {
+		X int `json:"x"`
+	}
+	var progress []string
+	resp, err := GenerateObject[out](context.Background(), GenerateObjectRequest[out]{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("give x")},
+		},
+		Schema:     JSONSchema([]byte(`{"type":"object","properties":{"x":{"type":"integer"}},"required":["x"]}`)),
+		OnProgress: func(raw json.RawMessage) { progress = append(progress, string(raw)) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Object.X != 1 {
+		t.Fatalf("X=%d", resp.Object.X)
+	}
+	if strings.Join(progress, "|") != `{"x"|{"x":1}` {
+		t.Fatalf("progress=%q", progress)
+	}
+}
+
+func TestGenerateObject_OnProgressNonStreamingFiresOnce(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		return &fakeStream{
+			final: &provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.ToolCallPart{ID: "c1", Name: "__ai_return_json", Args: []byte(`{"x":2}`)}},
+				},
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	type out struct {
+		X int `json:"x"`
+	}
+	var progress []string
+	_, err := GenerateObject[out](context.Background(), GenerateObjectRequest[out]{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("give x")},
+		},
+		Schema:     JSONSchema([]byte(`{"type":"object"}`)),
+		OnProgress: func(raw json.RawMessage) { progress = append(progress, string(raw)) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(progress) != 1 || progress[0] != `{"x":2}` {
+		t.Fatalf("progress=%q", progress)
+	}
+}
+
+func TestGenerateObject_OnProgressWithoutStreaming(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		return nil, fmt.Errorf("local backend: %w", ErrStreamingUnsupported)
+	}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		return provider.Response{
+			Message: provider.Message{
+				Role:    provider.RoleAssistant,
+				Content: []provider.ContentPart{provider.ToolCallPart{ID: "c1", Name: "__ai_return_json", Args: []byte(`{"x":3}`)}},
+			},
+			FinishReason: "tool_calls",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	type out struct {
+		X int `json:"x"`
+	}
+	var progress []string
+	resp, err := GenerateObject[out](context.Background(), GenerateObjectRequest[out]{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("give x")},
+		},
+		Schema:     JSONSchema([]byte(`{"type":"object"}`)),
+		OnProgress: func(raw json.RawMessage) { progress = append(progress, string(raw)) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Object.X != 3 || len(progress) != 1 || progress[0] != `{"x":3}` {
+		t.Fatalf("X=%d progress=%q", resp.Object.X, progress)
+	}
+}
+
+func TestGenerateObject_SchemaRefs(t *testing.T) {
+	const refSchema = `{"type":"object","properties":{"home":{"$ref":"#/$defs/addr"},"work":{"$ref":"#/$defs/addr","description":"office"}},"required":["home"],"$defs":{"addr":{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}}}`
+
+	var sent json.RawMessage
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		for _, td := range req.Tools {
+			if td.Name == "__ai_return_json" {
+				sent = td.InputSchema
+			}
+		}
+		args := `{"home":{"city":"Paris"},"work":{"city":"Lyon"}}`
+		if call == 0 {
+			args = `{"home":{}}`
+		}
+		return provider.Response{
+			Message: provider.Message{
+				Role:    provider.RoleAssistant,
+				Content: []provider.ContentPart{provider.ToolCallPart{ID: "c1", Name: "__ai_return_json", Args: []byte(args)}},
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	type addr struct {
+		City string `json:"city"`
+	}
+	type out struct {
+		Home addr `json:"home"`
+		Work addr `json:"work"`
+	}
+	retries := 1
+	resp, err := GenerateObject[out](context.Background(), GenerateObjectRequest[out]{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("addresses")},
+		},
+		Schema:     JSONSchema([]byte(refSchema)),
+		MaxRetries: &retries,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Object.Home.City != "Paris" || resp.Object.Work.City != "Lyon" {
+		t.Fatalf("object=%#v", resp.Object)
+	}
+
+	// The fake provider does not declare $ref support, so refs are inlined.
+	if strings.Contains(string(sent), "$ref") || strings.Contains(string(sent), "$defs") {
+		t.Fatalf("schema sent with refs: %s", sent)
+	}
+	var s struct {
+		Properties map[string]struct {
+			Type        string `json:"type"`
+			Description string `json:"description"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(sent, &s); err != nil {
+		t.Fatal(err)
+	}
+	if w := s.Properties["work"]; w.Type != "object" || w.Description != "office" {
+		t.Fatalf("work=%#v", w)
+	}
+}
+
+func TestValidateJSONAgainstSchema_RecursiveRef(t *testing.T) {
+	schema := JSONSchema([]byte(`{"$ref":"#/$defs/node","$defs":{"node":{"type":"object","properties":{"v":{"type":"integer"},"children":{"type":"array","items":{"$ref":"#/$defs/node"}}},"required":["v"]}}}`))
+	if err := validateJSONAgainstSchema(schema, []byte(`{"v":1,"children":[{"v":2,"children":[]}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateJSONAgainstSchema(schema, []byte(`{"v":1,"children":[{"children":[]}]}`)); err == nil {
+		t.Fatalf("expected nested validation error")
+	}
+}
+
+func TestGenerateObject_ReturnToolNeverExecuted(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		if call == 0 {
+			return provider.Response{
+				Message: provider.Message{
+					Role: provider.RoleAssistant,
+					Content: []provider.ContentPart{
+						provider.ToolCallPart{ID: "s1", Name: "side", Args: []byte(`{}`)},
+					},
+				},
+				FinishReason: "tool_calls",
+			}, nil
+		}
+		return provider.Response{
+			Message: provider.Message{
+				Role: provider.RoleAssistant,
+				Content: []provider.ContentPart{
+					provider.TextPart{Text: "done"},
+					provider.ToolCallPart{ID: "r1", Name: "__ai_return_json", Args: []byte(`{"x":2}`)},
+				},
+			},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	type out struct {
+		X int `json:"x"`
+	}
+
+	sideCalls := 0
+	resp, err := GenerateObject[out](context.Background(), GenerateObjectRequest[out]{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("give x")},
+			Tools: []Tool{{
+				Name: "side",
+				Handler: func(ctx context.Context, input json.RawMessage) (any, error) {
+					sideCalls++
+					return "ok", nil
+				},
+			}},
+		},
+		Schema: JSONSchema([]byte(`{"type":"object","properties":{"x":{"type":"integer"}},"required":["x"],"additionalProperties":false}`)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Object.X != 2 {
+		t.Fatalf("X=%d", resp.Object.X)
+	}
+	if sideCalls != 1 {
+		t.Fatalf("side tool calls=%d", sideCalls)
+	}
+	for _, req := range fp.Requests() {
+		for _, m := range req.Messages {
+			if m.Role == provider.RoleTool && m.ToolCallID == "r1" {
+				t.Fatalf("return tool was executed: %+v", m)
+			}
+		}
+	}
+	for _, p := range resp.Message.Content {
+		if tc, ok := p.(ToolCallPart); ok && tc.Name == "__ai_return_json" {
+			t.Fatalf("reserved tool call leaked into Message: %+v", resp.Message)
+		}
+	}
+	if got := extractTextFromMessage(resp.Message); got != "done" {
+		t.Fatalf("text=%q", got)
+	}
+}
+
+func TestObject_ReservedToolNameRejected(t *testing.T) {
+	fp := &fakeProvider{}
+	providerName := registerFakeProvider(t, fp)
+
+	type out struct {
+		X int `json:"x"`
+	}
+	base := BaseRequest{
+		Model:    testModel{provider: providerName, name: "m"},
+		Messages: []Message{User("give x")},
+		Tools: []Tool{{
+			Name: "__ai_return_json",
+			Handler: func(ctx context.Context, input json.RawMessage) (any, error) {
+				t.Fatal("reserved tool handler invoked")
+				return nil, nil
+			},
+		}},
+	}
+	schema := JSONSchema([]byte(`{"type":"object","properties":{"x":{"type":"integer"}},"required":["x"]}`))
+
+	if _, err := GenerateObject[out](context.Background(), GenerateObjectRequest[out]{BaseRequest: base, Schema: schema}); err == nil || !strings.Contains(err.Error(), "reserved") {
+		t.Fatalf("GenerateObject err=%v", err)
+	}
+
+	s, err := StreamObject[out](context.Background(), StreamObjectRequest[out]{BaseRequest: base, Schema: schema})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for s.Next() {
+	}
+	if err := s.Err(); err == nil || !strings.Contains(err.Error(), "reserved") {
+		t.Fatalf("StreamObject err=%v", err)
+	}
+	if n := len(fp.Requests()); n != 0 {
+		t.Fatalf("provider calls=%d", n)
+	}
+}
+
+type unionAction interface{ kind() string }
+
+type unionSearch struct {
+	Query string `json:"query"`
+}
+
+type unionAnswer struct {
+	Text string `json:"text"`
+}
+
+func (*unionSearch) kind() string { return "search" }
+func (*unionAnswer) kind() string { return "answer" }
+
+type unionResult struct{ Action unionAction }
+
+func (r *unionResult) UnmarshalJSON(b []byte) error {
+	v, err := DecodeUnion[unionAction](b, "type", map[string]func() unionAction{
+		"search": func() unionAction { return &unionSearch{} },
+		"answer": func() unionAction { return &unionAnswer{} },
+	})
+	r.Action = v
+	return err
+}
+
+func TestGenerateObject_UnionSchema(t *testing.T) {
+	args := []string{`{"value":{"type":"search","query":"go","text":"x"}}`, `{"value":{"type":"search","query":"go"}}`}
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		var params struct {
+			Type       string                     `json:"type"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		}
+		_ = json.Unmarshal(req.Tools[len(req.Tools)-1].InputSchema, &params)
+		if params.Type != "object" || !strings.Contains(string(params.Properties["value"]), `"oneOf"`) {
+			t.Fatalf("return tool schema=%s", req.Tools[len(req.Tools)-1].InputSchema)
+		}
+		return provider.Response{
+			Message: provider.Message{
+				Role:    provider.RoleAssistant,
+				Content: []provider.ContentPart{provider.ToolCallPart{ID: "c1", Name: "__ai_return_json", Args: []byte(args[call])}},
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	variant := func(name, field string) string {
+		return `{"type":"object","properties":{"type":{"const":"` + name + `"},"` + field + `":{"type":"string"}},"required":["type","` + field + `"],"additionalProperties":false}`
+	}
+	resp, err := GenerateObject[unionResult](context.Background(), GenerateObjectRequest[unionResult]{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("act")},
+		},
+		Schema: JSONSchema([]byte(`{"oneOf":[` + variant("search", "query") + `,` + variant("answer", "text") + `]}`)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fp.requests) != 2 {
+		t.Fatalf("calls=%d, want a retry after the invalid first union value", len(fp.requests))
+	}
+	s, ok := resp.Object.Action.(*unionSearch)
+	if !ok || s.Query != "go" {
+		t.Fatalf("Action=%#v", resp.Object.Action)
+	}
+	if string(resp.RawJSON) != `{"type":"search","query":"go"}` {
+		t.Fatalf("RawJSON=%s", resp.RawJSON)
+	}
+
+	if _, err := DecodeUnion[unionAction]([]byte(`{"type":"other"}`), "type", map[string]func() unionAction{}); err == nil || !strings.Contains(err.Error(), `unknown type "other"`) {
+		t.Fatalf("err=%v", err)
+	}
+}
@@ -3,8 +3,12 @@ package ai
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 
+	internalObject "github.com/bitop-dev/ai/internal/object"
 	"github.com/bitop-dev/ai/internal/provider"
 )
 
@@ -104,3 +108,380 @@ func TestStreamObject_PartialAndFinal(t *testing.T) {
 		t.Fatalf("final object=%#v", obj)
 	}
 }
+
+func TestStreamObject_PartialTyped(t *testing.T) {
+	chunks := []string{`{"name":"Ada`, `","age":3`, `6,"tags":["math",`, `"eng`, `ines"],"address":{"city":"London"`, `,"zip":"N1"}}`}
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		var deltas []provider.Delta
+		for _, c := range chunks {
+			deltas = append(deltas, provider.Delta{ToolCalls: []provider.ToolCallDelta{{Index: 0, Name: "__ai_return_json", ArgumentsDelta: c}}})
+		}
+		return &fakeStream{deltas: deltas, final: &provider.Response{
+			Message: provider.Message{
+				Role:    provider.RoleAssistant,
+				Content: []provider.ContentPart{provider.ToolCallPart{ID: "c1", Name: "__ai_return_json", Args: []byte(strings.Join(chunks, ""))}},
+			},
+			FinishReason: "stop",
+		}}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	type address struct {
+		City string `json:"city"`
+		Zip  string `json:"zip"`
+	}
+	type person struct {
+		Name    string   `json:"name"`
+		Age     int      `json:"age"`
+		Tags    []string `json:"tags"`
+		Address address  `json:"address"`
+	}
+	stream, err := StreamObject[person](context.Background(), StreamObjectRequest[person]{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("a person")},
+		},
+		Schema: JSONSchema([]byte(`{"type":"object"}`)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var got []person
+	for stream.Next() {
+		if p := stream.PartialTyped(); p != nil {
+			got = append(got, *p)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []person{
+		{},            // name still streaming
+		{Name: "Ada"}, // age may still grow
+		{Name: "Ada", Age: 36, Tags: []string{"math"}},
+		{Name: "Ada", Age: 36, Tags: []string{"math"}},
+		{Name: "Ada", Age: 36, Tags: []string{"math", "engines"}, Address: address{City: "London"}},
+		{Name: "Ada", Age: 36, Tags: []string{"math", "engines"}, Address: address{City: "London", Zip: "N1"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("partials:\n%+v\nwant\n%+v", got, want)
+	}
+	if obj := stream.Object(); obj == nil || stream.PartialTyped() != obj {
+		t.Fatalf("PartialTyped after completion should be the final object")
+	}
+}
+
+func TestStreamObject_OnField(t *testing.T) {
+	chunks := []string{`{"name":"Ad`, `a","address":{"city":"Lon`, `don","zip":12`, `3},"tags":["a",`, `"b\"c"],"ok":tr`, `ue}`}
+	full := `{"name":"Ada","address":{"city":"London","zip":123},"tags":["a","b\"c"],"ok":true}`
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		deltas := make([]provider.Delta, 0, len(chunks))
+		for i, c := range chunks {
+			d := provider.ToolCallDelta{Index: 0, ArgumentsDelta: c}
+			if i == 0 {
+				d.Name = "__ai_return_json"
+			}
+			deltas = append(deltas, provider.Delta{ToolCalls: []provider.ToolCallDelta{d}})
+		}
+		return &fakeStream{
+			deltas: deltas,
+			final: &provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.ToolCallPart{ID: "c1", Name: "__ai_return_json", Args: []byte(full)}},
+				},
+				FinishReason: "stop",
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	type out struct {
+		Name string `json:"name"`
+	}
+
+	type field struct {
+		path  string
+		value any
+	}
+	var got []field
+	stream, err := StreamObject[out](context.Background(), StreamObjectRequest[out]{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("x")},
+		},
+		Schema: JSONSchema([]byte(`{"type":"object"}`)),
+		OnField: func(path string, value any) {
+			got = append(got, field{path, value})
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for stream.Next() {
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []field{
+		{"name", "Ada"},
+		{"address.city", "London"},
+		{"address.zip", float64(123)},
+		{"address", map[string]any{"city": "London", "zip": float64(123)}},
+		{"tags.0", "a"},
+		{"tags.1", `b"c`},
+		{"tags", []any{"a", `b"c`}},
+		{"ok", true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("fields=%#v", got)
+	}
+}
+
+func TestStreamObject_AbortOnSchemaViolation(t *testing.T) {
+	schema := JSONSchema([]byte(`{"type":"object","properties":{
+		"role":{"type":"string","enum":["user","admin"]},
+		"tags":{"type":"array","items":{"type":"string"}},
+		"note":{"type":"string"}
+	},"required":["role"],"additionalProperties":false}`))
+
+	cases := []struct {
+		name     string
+		chunks   []string
+		wantPath string
+		wantRead int
+	}{
+		{name: "valid", chunks: []string{`{"role":"adm`, `in","tags":["a"],`, `"note":"x"}`}},
+		{name: "enum", chunks: []string{`{"role":"own`, `er","tags":["a"],`, `"note":"x"}`}, wantPath: "role", wantRead: 2},
+		{name: "item type", chunks: []string{`{"role":"user","tags":["a",`, `3],`, `"note":"x"}`}, wantPath: "tags.1", wantRead: 2},
+		{name: "extra property", chunks: []string{`{"role":"user","ex`, `tra":1,`, `"note":"x"}`}, wantPath: "extra", wantRead: 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			full := tc.chunks[0] + tc.chunks[1] + tc.chunks[2]
+			fs := &fakeStream{final: &provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.ToolCallPart{ID: "c1", Name: "__ai_return_json", Args: []byte(full)}},
+				},
+				FinishReason: "stop",
+			}}
+			for i, c := range tc.chunks {
+				d := provider.ToolCallDelta{Index: 0, ArgumentsDelta: c}
+				if i == 0 {
+					d.Name = "__ai_return_json"
+				}
+				fs.deltas = append(fs.deltas, provider.Delta{ToolCalls: []provider.ToolCallDelta{d}})
+			}
+			fp := &fakeProvider{}
+			fp.stream = func(call int, req provider.Request) (provider.Stream, error) { return fs, nil }
+			providerName := registerFakeProvider(t, fp)
+
+			type out struct {
+				Role string `json:"role"`
+			}
+			stream, err := StreamObject[out](context.Background(), StreamObjectRequest[out]{
+				BaseRequest: BaseRequest{
+					Model:    testModel{provider: providerName, name: "m"},
+					Messages: []Message{User("x")},
+				},
+				Schema:                 schema,
+				AbortOnSchemaViolation: true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stream.Close()
+			for stream.Next() {
+			}
+
+			if tc.wantPath == "" {
+				if err := stream.Err(); err != nil || stream.Object() == nil {
+					t.Fatalf("err=%v object=%v", err, stream.Object())
+				}
+				return
+			}
+			var sv *SchemaViolationError
+			if !errors.As(stream.Err(), &sv) || sv.Path != tc.wantPath {
+				t.Fatalf("err=%v, want violation at %q", stream.Err(), tc.wantPath)
+			}
+			if fs.i != tc.wantRead {
+				t.Fatalf("read %d deltas, want %d", fs.i, tc.wantRead)
+			}
+		})
+	}
+}
+
+func TestStreamObject_OnRawDelta(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		return &fakeStream{
+			deltas: []provider.Delta{
+				{Text: "Sure, "},
+				{Text: "here it is."},
+				{ToolCalls: []provider.ToolCallDelta{{Index: 0, Name: "__ai_return_json", ArgumentsDelta: `{"x":1}`}}},
+			},
+			final: &provider.Response{
+				Message: provider.Message{
+					Role: provider.RoleAssistant,
+					Content: []provider.ContentPart{
+						provider.TextPart{Text: "Sure, here it is."},
+						provider.ToolCallPart{ID: "c1", Name: "__ai_return_json", Args: []byte(`{"x":1}`)},
+					},
+				},
+				FinishReason: "stop",
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	type out struct {
+		X int `json:"x"`
+	}
+
+	var raw []string
+	stream, err := StreamObject[out](context.Background(), StreamObjectRequest[out]{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("x")},
+		},
+		Schema:     JSONSchema([]byte(`{"type":"object","properties":{"x":{"type":"integer"}},"required":["x"]}`)),
+		OnRawDelta: func(text string) { raw = append(raw, text) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for stream.Next() {
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(raw, []string{"Sure, ", "here it is."}) {
+		t.Fatalf("raw deltas=%q", raw)
+	}
+}
+
+func TestStreamObject_OnRawDeltaJSONOnlyFallback(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		return nil, provider.ErrToolsUnsupported
+	}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		return provider.Response{
+			Message: provider.Message{
+				Role:    provider.RoleAssistant,
+				Content: []provider.ContentPart{provider.TextPart{Text: `{"x":5}`}},
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	type out struct {
+		X int `json:"x"`
+	}
+
+	var raw []string
+	stream, err := StreamObject[out](context.Background(), StreamObjectRequest[out]{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("x")},
+		},
+		Schema:     JSONSchema([]byte(`{"type":"object","properties":{"x":{"type":"integer"}},"required":["x"]}`)),
+		OnRawDelta: func(text string) { raw = append(raw, text) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for stream.Next() {
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if obj := stream.Object(); obj == nil || obj.X != 5 {
+		t.Fatalf("object=%#v", obj)
+	}
+	if !reflect.DeepEqual(raw, []string{`{"x":5}`}) {
+		t.Fatalf("raw deltas=%q", raw)
+	}
+}
+
+func TestFieldScanner_IgnoresTextOutsideContainer(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{name: "leading prose", raw: `Sure {"a":1}`, want: []string{"a"}},
+		{name: "scalar root", raw: `123 `},
+		{name: "string root", raw: `"x", `},
+		{name: "trailing text", raw: `{"a":[1]} done.`, want: []string{"a.0", "a"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			s := internalObject.NewFieldScanner(func(path string, value any) { got = append(got, path) })
+			for i := 1; i <= len(tc.raw); i++ {
+				s.Feed([]byte(tc.raw[:i]))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("fields=%q", got)
+			}
+		})
+	}
+}
+
+func TestStreamObject_OnProgressOnlyWhenJSONGrows(t *testing.T) {
+	chunks := []string{`{"value":`, `4`, `2`, `}`}
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		deltas := make([]provider.Delta, 0, len(chunks))
+		for i, c := range chunks {
+			d := provider.ToolCallDelta{Index: 0, ArgumentsDelta: c}
+			if i == 0 {
+				d.Name = "__ai_return_json"
+			}
+			deltas = append(deltas, provider.Delta{ToolCalls: []provider.ToolCallDelta{d}})
+		}
+		return &fakeStream{
+			deltas: deltas,
+			final: &provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.ToolCallPart{ID: "c1", Name: "__ai_return_json", Args: []byte(`{"value":42}`)}},
+				},
+				FinishReason: "stop",
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	var progress []string
+	stream, err := StreamObject[int](context.Background(), StreamObjectRequest[int]{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("x")},
+		},
+		Schema:     JSONSchema([]byte(`{"type":"integer"}`)),
+		OnProgress: func(raw json.RawMessage) { progress = append(progress, string(raw)) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for stream.Next() {
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"4", "42"}; !reflect.DeepEqual(progress, want) {
+		t.Fatalf("progress=%q want %q", progress, want)
+	}
+}
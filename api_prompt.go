@@ -0,0 +1,51 @@
+package ai
+
+import "context"
+
+// PromptOption customizes the request built by Prompt and PromptStream.
+type PromptOption func(req *BaseRequest)
+
+// WithSystem prepends a system message.
+func WithSystem(text string) PromptOption {
+	return func(req *BaseRequest) {
+		req.Messages = append([]Message{System(text)}, req.Messages...)
+	}
+}
+
+// WithMaxTokens sets BaseRequest.MaxTokens.
+func WithMaxTokens(n int) PromptOption {
+	return func(req *BaseRequest) { req.MaxTokens = &n }
+}
+
+// WithTemperature sets BaseRequest.Temperature.
+func WithTemperature(t float32) PromptOption {
+	return func(req *BaseRequest) { req.Temperature = &t }
+}
+
+// Prompt is a single-turn shortcut for GenerateText: it sends text as a user
+// message and returns the response text.
+func Prompt(ctx context.Context, model ModelRef, text string, opts ...PromptOption) (string, error) {
+	resp, err := GenerateText(ctx, GenerateTextRequest{BaseRequest: promptRequest(model, text, opts)})
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+// PromptStream is the streaming variant of Prompt.
+func PromptStream(ctx context.Context, model ModelRef, text string, opts ...PromptOption) (*TextStream, error) {
+	return StreamText(ctx, StreamTextRequest{BaseRequest: promptRequest(model, text, opts)})
+}
+
+func promptRequest(model ModelRef, text string, opts []PromptOption) BaseRequest {
+	req := BaseRequest{
+		Model:    model,
+		Messages: []Message{User(text)},
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&req)
+		}
+	}
+	return req
+}
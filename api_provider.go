@@ -0,0 +1,33 @@
+package ai
+
+import (
+	"fmt"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// RegisterProvider registers a custom Provider under name. Models returned by
+// CustomModel(name, ...) are routed to it.
+//
+// Registering a name twice (including built-in names like "openai") is an error.
+func RegisterProvider(name string, p Provider) error {
+	if p == nil {
+		return fmt.Errorf("provider %q is nil", name)
+	}
+	return provider.Register(name, &customProvider{p: p})
+}
+
+// CustomModel returns a ModelRef for a model served by a provider registered via
+// RegisterProvider.
+func CustomModel(providerName, modelName string) ModelRef {
+	return customModel{provider: providerName, name: modelName}
+}
+
+type customModel struct {
+	provider string
+	name     string
+}
+
+func (m customModel) Provider() string { return m.provider }
+func (m customModel) Name() string     { return m.name }
+func (m customModel) String() string   { return ModelString(m) }
@@ -0,0 +1,117 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bitop-dev/ai/openai"
+)
+
+type echoProvider struct {
+	lastReq ProviderRequest
+}
+
+func (p *echoProvider) Generate(ctx context.Context, req ProviderRequest) (ProviderResponse, error) {
+	_ = ctx
+	p.lastReq = req
+	return ProviderResponse{
+		Message:      Assistant("echo: " + extractTextFromMessage(req.Messages[len(req.Messages)-1])),
+		Usage:        Usage{PromptTokens: 1, CompletionTokens: 1, TotalTokens: 2},
+		FinishReason: FinishStop,
+	}, nil
+}
+
+func (p *echoProvider) Stream(ctx context.Context, req ProviderRequest) (ProviderStream, error) {
+	_ = ctx
+	p.lastReq = req
+	return &echoStream{chunks: []string{"he", "llo"}}, nil
+}
+
+type echoStream struct {
+	chunks []string
+	i      int
+}
+
+func (s *echoStream) Next() bool {
+	if s.i >= len(s.chunks) {
+		return false
+	}
+	s.i++
+	return true
+}
+func (s *echoStream) Delta() ProviderDelta { return ProviderDelta{Text: s.chunks[s.i-1]} }
+func (s *echoStream) Final() *ProviderResponse {
+	return &ProviderResponse{Message: Message{Content: []ContentPart{TextPart{Text: strings.Join(s.chunks, "")}}}, FinishReason: FinishStop}
+}
+func (s *echoStream) Err() error   { return nil }
+func (s *echoStream) Close() error { return nil }
+
+func TestRegisterProvider_CustomModelRoutesToProvider(t *testing.T) {
+	p := &echoProvider{}
+	name := "custom_" + t.Name()
+	if err := RegisterProvider(name, p); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterProvider(name, p); err == nil {
+		t.Fatalf("expected duplicate registration error")
+	}
+
+	resp, err := GenerateText(context.Background(), GenerateTextRequest{
+		BaseRequest: BaseRequest{
+			Model:    CustomModel(name, "local-llama"),
+			Messages: []Message{User("hi")},
+			Tools:    []Tool{{Name: "t", InputSchema: JSONSchema([]byte(`{"type":"object"}`))}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "echo: hi" {
+		t.Fatalf("Text=%q", resp.Text)
+	}
+	if p.lastReq.Model != "local-llama" || len(p.lastReq.Tools) != 1 || p.lastReq.Tools[0].Name != "t" {
+		t.Fatalf("req=%#v", p.lastReq)
+	}
+
+	stream, err := StreamText(context.Background(), StreamTextRequest{
+		BaseRequest: BaseRequest{
+			Model:    CustomModel(name, "local-llama"),
+			Messages: []Message{User("hi")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	var b strings.Builder
+	for stream.Next() {
+		b.WriteString(stream.Delta())
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "hello" {
+		t.Fatalf("streamed=%q", b.String())
+	}
+	if m := stream.Message(); m == nil || m.Role != RoleAssistant {
+		t.Fatalf("message=%#v", m)
+	}
+}
+
+func TestModelString(t *testing.T) {
+	m := CustomModel("llamacpp", "llama-3-8b")
+	if got := ModelString(m); got != "llamacpp:llama-3-8b" {
+		t.Fatalf("ModelString=%q", got)
+	}
+	if got := fmt.Sprint(m); got != "llamacpp:llama-3-8b" {
+		t.Fatalf("String=%q", got)
+	}
+	if got := fmt.Sprint(openai.Chat("gpt-4o-mini")); got != "openai:gpt-4o-mini" {
+		t.Fatalf("openai String=%q", got)
+	}
+	if ModelString(m) != ModelString(CustomModel("llamacpp", "llama-3-8b")) || ModelString(nil) != "" {
+		t.Fatal("ModelString is not canonical")
+	}
+}
@@ -0,0 +1,528 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/bitop-dev/ai/internal/provider"
+	"github.com/bitop-dev/ai/openai"
+)
+
+// RecordTo returns a ModelRef that calls model as usual and writes every
+// generation call it makes (request messages, response or error, and stream
+// deltas) to w, one JSON object per line. Read the transcript back with
+// ReplayFrom to rerun the same conversation offline.
+//
+// Only text and object generation is recorded; the returned model does not
+// serve embeddings, images or audio. A failure to write the transcript fails
+// the call.
+func RecordTo(w io.Writer, model ModelRef) ModelRef {
+	if model == nil {
+		return nil
+	}
+	return &recordingModel{ModelRef: model, rec: &recorder{enc: json.NewEncoder(w)}}
+}
+
+// ReplayFrom reads a transcript written by RecordTo and returns a ModelRef
+// whose provider answers from it instead of the network. Calls are replayed
+// in recorded order; each must send the same messages as the recorded one
+// (tools must therefore return the same results), or it fails with a
+// *ReplayMismatchError. Recorded errors are returned as they were.
+func ReplayFrom(r io.Reader) (ModelRef, error) {
+	dec := json.NewDecoder(r)
+	var calls []recordedCall
+	for {
+		var c recordedCall
+		if err := dec.Decode(&c); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("replay: read transcript: %w", err)
+		}
+		calls = append(calls, c)
+	}
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("replay: transcript is empty")
+	}
+	return &replayModel{
+		provider: calls[0].Provider,
+		name:     calls[0].Request.Model,
+		p:        &replayProvider{calls: calls},
+	}, nil
+}
+
+// ReplayMismatchError reports a call that differs from the recorded one at
+// the same position in the transcript.
+type ReplayMismatchError struct {
+	// Call is the 0-based position of the call in the transcript.
+	Call   int
+	Reason string
+}
+
+func (e *ReplayMismatchError) Error() string {
+	return fmt.Sprintf("replay: call %d does not match the recording: %s", e.Call, e.Reason)
+}
+
+// modelProvider is implemented by model refs that carry their own provider
+// instead of naming a registered one.
+type modelProvider interface {
+	modelProvider() (provider.Provider, error)
+}
+
+type recordingModel struct {
+	ModelRef
+	rec *recorder
+}
+
+func (m *recordingModel) String() string { return ModelString(m) }
+
+// Client keeps the OpenAI client wiring of the wrapped model.
+func (m *recordingModel) Client() *openai.Client {
+	c, _ := openAIClientFromModel(m.ModelRef)
+	return c
+}
+
+func (m *recordingModel) modelProvider() (provider.Provider, error) {
+	p, err := providerForModel(m.ModelRef)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingProvider{p: p, name: m.Provider(), rec: m.rec}, nil
+}
+
+type recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (r *recorder) write(c recordedCall) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(c); err != nil {
+		return fmt.Errorf("record transcript: %w", err)
+	}
+	return nil
+}
+
+type recordingProvider struct {
+	p    provider.Provider
+	name string
+	rec  *recorder
+}
+
+func (p *recordingProvider) Generate(ctx context.Context, req provider.Request) (provider.Response, error) {
+	resp, err := p.p.Generate(ctx, req)
+	c := recordedCall{Provider: p.name, Request: recordRequest(req)}
+	if err != nil {
+		c.Error = recordError(err)
+	} else {
+		c.Response = recordResponse(resp)
+	}
+	if werr := p.rec.write(c); werr != nil && err == nil {
+		return provider.Response{}, werr
+	}
+	return resp, err
+}
+
+func (p *recordingProvider) Stream(ctx context.Context, req provider.Request) (provider.Stream, error) {
+	c := recordedCall{Provider: p.name, Stream: true, Request: recordRequest(req)}
+	s, err := p.p.Stream(ctx, req)
+	if err != nil {
+		c.Error = recordError(err)
+		_ = p.rec.write(c)
+		return nil, err
+	}
+	return &recordingStream{Stream: s, rec: p.rec, call: c}, nil
+}
+
+// SupportsSchemaRefs forwards the wrapped provider's capability.
+func (p *recordingProvider) SupportsSchemaRefs() bool {
+	rp, ok := p.p.(provider.SchemaRefsProvider)
+	return ok && rp.SupportsSchemaRefs()
+}
+
+// recordingStream collects the deltas of a stream and writes the call once
+// the stream ends (or is closed early).
+type recordingStream struct {
+	provider.Stream
+	rec     *recorder
+	call    recordedCall
+	written bool
+	err     error
+}
+
+func (s *recordingStream) Next() bool {
+	if s.Stream.Next() {
+		s.call.Deltas = append(s.call.Deltas, recordDelta(s.Stream.Delta()))
+		return true
+	}
+	if err := s.Stream.Err(); err != nil {
+		s.call.StreamError = recordError(err)
+	} else if final := s.Stream.Final(); final != nil {
+		s.call.Response = recordResponse(*final)
+	}
+	s.flush()
+	return false
+}
+
+func (s *recordingStream) Err() error {
+	if err := s.Stream.Err(); err != nil {
+		return err
+	}
+	return s.err
+}
+
+func (s *recordingStream) Close() error {
+	s.flush()
+	return s.Stream.Close()
+}
+
+func (s *recordingStream) flush() {
+	if s.written {
+		return
+	}
+	s.written = true
+	s.err = s.rec.write(s.call)
+}
+
+type replayModel struct {
+	provider string
+	name     string
+	p        *replayProvider
+}
+
+func (m *replayModel) Provider() string { return m.provider }
+func (m *replayModel) Name() string     { return m.name }
+func (m *replayModel) String() string   { return ModelString(m) }
+
+func (m *replayModel) modelProvider() (provider.Provider, error) { return m.p, nil }
+
+type replayProvider struct {
+	mu    sync.Mutex
+	calls []recordedCall
+	next  int
+}
+
+func (p *replayProvider) take(req provider.Request, stream bool) (recordedCall, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	i := p.next
+	if i >= len(p.calls) {
+		return recordedCall{}, &ReplayMismatchError{Call: i, Reason: fmt.Sprintf("the transcript has only %d calls", len(p.calls))}
+	}
+	p.next++
+	c := p.calls[i]
+	if c.Stream != stream {
+		return recordedCall{}, &ReplayMismatchError{Call: i, Reason: fmt.Sprintf("recorded with stream=%v", c.Stream)}
+	}
+	if reason := diffMessages(c.Request.Messages, recordRequest(req).Messages); reason != "" {
+		return recordedCall{}, &ReplayMismatchError{Call: i, Reason: reason}
+	}
+	return c, nil
+}
+
+func (p *replayProvider) Generate(ctx context.Context, req provider.Request) (provider.Response, error) {
+	c, err := p.take(req, false)
+	if err != nil {
+		return provider.Response{}, err
+	}
+	if c.Error != nil {
+		return provider.Response{}, c.Error.err()
+	}
+	if c.Response == nil {
+		return provider.Response{}, fmt.Errorf("replay: recorded call has no response")
+	}
+	return c.Response.response()
+}
+
+func (p *replayProvider) Stream(ctx context.Context, req provider.Request) (provider.Stream, error) {
+	c, err := p.take(req, true)
+	if err != nil {
+		return nil, err
+	}
+	if c.Error != nil {
+		return nil, c.Error.err()
+	}
+	s := &replayStream{deltas: c.Deltas, i: -1}
+	if c.StreamError != nil {
+		s.err = c.StreamError.err()
+	}
+	if c.Response != nil {
+		final, err := c.Response.response()
+		if err != nil {
+			return nil, err
+		}
+		s.final = &final
+	}
+	return s, nil
+}
+
+type replayStream struct {
+	deltas []recordedDelta
+	i      int
+	final  *provider.Response
+	err    error
+}
+
+func (s *replayStream) Next() bool {
+	if s.i+1 >= len(s.deltas) {
+		return false
+	}
+	s.i++
+	return true
+}
+
+func (s *replayStream) Delta() provider.Delta {
+	if s.i < 0 || s.i >= len(s.deltas) {
+		return provider.Delta{}
+	}
+	return s.deltas[s.i].delta()
+}
+
+func (s *replayStream) Final() *provider.Response {
+	if s.i+1 < len(s.deltas) {
+		return nil
+	}
+	return s.final
+}
+
+func (s *replayStream) Err() error {
+	if s.i+1 < len(s.deltas) {
+		return nil
+	}
+	return s.err
+}
+
+func (s *replayStream) Close() error { return nil }
+
+// diffMessages describes the first difference between recorded and sent
+// messages, or returns "" when they match.
+func diffMessages(recorded, sent []recordedMessage) string {
+	for i := 0; i < len(recorded) && i < len(sent); i++ {
+		a, _ := json.Marshal(recorded[i])
+		b, _ := json.Marshal(sent[i])
+		if !bytes.Equal(a, b) {
+			return fmt.Sprintf("message %d differs: recorded %s, sent %s", i, a, b)
+		}
+	}
+	if len(recorded) != len(sent) {
+		return fmt.Sprintf("recorded %d messages, sent %d", len(recorded), len(sent))
+	}
+	return ""
+}
+
+// Transcript format.
+
+type recordedCall struct {
+	Provider    string            `json:"provider"`
+	Stream      bool              `json:"stream,omitempty"`
+	Request     recordedRequest   `json:"request"`
+	Deltas      []recordedDelta   `json:"deltas,omitempty"`
+	Response    *recordedResponse `json:"response,omitempty"`
+	Error       *recordedError    `json:"error,omitempty"`
+	StreamError *recordedError    `json:"stream_error,omitempty"`
+}
+
+type recordedRequest struct {
+	Model    string            `json:"model"`
+	Messages []recordedMessage `json:"messages"`
+	Tools    []string          `json:"tools,omitempty"`
+}
+
+type recordedMessage struct {
+	Role       string         `json:"role"`
+	Name       string         `json:"name,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+	Metadata   map[string]any `json:"metadata,omitempty"`
+	Content    []recordedPart `json:"content"`
+}
+
+type recordedPart struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Args      json.RawMessage `json:"args,omitempty"`
+	URL       string          `json:"url,omitempty"`
+	MediaType string          `json:"media_type,omitempty"`
+	Format    string          `json:"format,omitempty"`
+	Bytes     []byte          `json:"bytes,omitempty"`
+	Base64    string          `json:"base64,omitempty"`
+}
+
+type recordedResponse struct {
+	Message           recordedMessage `json:"message"`
+	FinishReason      string          `json:"finish_reason,omitempty"`
+	Usage             recordedUsage   `json:"usage"`
+	ModelID           string          `json:"model_id,omitempty"`
+	SystemFingerprint string          `json:"system_fingerprint,omitempty"`
+	ResponseID        string          `json:"response_id,omitempty"`
+	RequestID         string          `json:"request_id,omitempty"`
+}
+
+type recordedUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type recordedDelta struct {
+	Text      string                  `json:"text,omitempty"`
+	ToolCalls []recordedToolCallDelta `json:"tool_calls,omitempty"`
+	Usage     *recordedUsage          `json:"usage,omitempty"`
+}
+
+type recordedToolCallDelta struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Args  string `json:"args,omitempty"`
+}
+
+type recordedError struct {
+	Provider  string `json:"provider,omitempty"`
+	Code      string `json:"code,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Param     string `json:"param,omitempty"`
+	Status    int    `json:"status,omitempty"`
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable,omitempty"`
+}
+
+func recordRequest(req provider.Request) recordedRequest {
+	out := recordedRequest{Model: req.Model, Messages: make([]recordedMessage, len(req.Messages))}
+	for i, m := range req.Messages {
+		out.Messages[i] = recordMessage(m)
+	}
+	for _, t := range req.Tools {
+		out.Tools = append(out.Tools, t.Name)
+	}
+	return out
+}
+
+func recordMessage(m provider.Message) recordedMessage {
+	out := recordedMessage{Role: string(m.Role), Name: m.Name, ToolCallID: m.ToolCallID, Metadata: m.Metadata, Content: []recordedPart{}}
+	for _, p := range m.Content {
+		switch p := p.(type) {
+		case provider.TextPart:
+			out.Content = append(out.Content, recordedPart{Type: "text", Text: p.Text})
+		case provider.ReasoningPart:
+			out.Content = append(out.Content, recordedPart{Type: "reasoning", Text: p.Text})
+		case provider.ToolCallPart:
+			out.Content = append(out.Content, recordedPart{Type: "tool_call", ID: p.ID, Name: p.Name, Args: p.Args})
+		case provider.ImagePart:
+			out.Content = append(out.Content, recordedPart{Type: "image", URL: p.URL, MediaType: p.MediaType, Bytes: p.Bytes, Base64: p.Base64})
+		case provider.AudioPart:
+			out.Content = append(out.Content, recordedPart{Type: "audio", Format: p.Format, Bytes: p.Bytes, Base64: p.Base64})
+		}
+	}
+	return out
+}
+
+func (m recordedMessage) message() (provider.Message, error) {
+	out := provider.Message{Role: provider.Role(m.Role), Name: m.Name, ToolCallID: m.ToolCallID, Metadata: m.Metadata}
+	for _, p := range m.Content {
+		switch p.Type {
+		case "text":
+			out.Content = append(out.Content, provider.TextPart{Text: p.Text})
+		case "reasoning":
+			out.Content = append(out.Content, provider.ReasoningPart{Text: p.Text})
+		case "tool_call":
+			out.Content = append(out.Content, provider.ToolCallPart{ID: p.ID, Name: p.Name, Args: p.Args})
+		case "image":
+			out.Content = append(out.Content, provider.ImagePart{URL: p.URL, MediaType: p.MediaType, Bytes: p.Bytes, Base64: p.Base64})
+		case "audio":
+			out.Content = append(out.Content, provider.AudioPart{Format: p.Format, Bytes: p.Bytes, Base64: p.Base64})
+		default:
+			return provider.Message{}, fmt.Errorf("replay: unknown content part type %q", p.Type)
+		}
+	}
+	return out, nil
+}
+
+func recordResponse(resp provider.Response) *recordedResponse {
+	return &recordedResponse{
+		Message:           recordMessage(resp.Message),
+		FinishReason:      string(resp.FinishReason),
+		Usage:             recordedUsage(resp.Usage),
+		ModelID:           resp.ModelID,
+		SystemFingerprint: resp.SystemFingerprint,
+		ResponseID:        resp.ResponseID,
+		RequestID:         resp.RequestID,
+	}
+}
+
+func (r *recordedResponse) response() (provider.Response, error) {
+	msg, err := r.Message.message()
+	if err != nil {
+		return provider.Response{}, err
+	}
+	return provider.Response{
+		Message:           msg,
+		Usage:             provider.Usage(r.Usage),
+		FinishReason:      provider.FinishReason(r.FinishReason),
+		ModelID:           r.ModelID,
+		SystemFingerprint: r.SystemFingerprint,
+		ResponseID:        r.ResponseID,
+		RequestID:         r.RequestID,
+	}, nil
+}
+
+func recordDelta(d provider.Delta) recordedDelta {
+	out := recordedDelta{Text: d.Text}
+	for _, tc := range d.ToolCalls {
+		out.ToolCalls = append(out.ToolCalls, recordedToolCallDelta{Index: tc.Index, ID: tc.ID, Name: tc.Name, Args: tc.ArgumentsDelta})
+	}
+	if d.Usage != nil {
+		u := recordedUsage(*d.Usage)
+		out.Usage = &u
+	}
+	return out
+}
+
+func (d recordedDelta) delta() provider.Delta {
+	out := provider.Delta{Text: d.Text}
+	for _, tc := range d.ToolCalls {
+		out.ToolCalls = append(out.ToolCalls, provider.ToolCallDelta{Index: tc.Index, ID: tc.ID, Name: tc.Name, ArgumentsDelta: tc.Args})
+	}
+	if d.Usage != nil {
+		u := provider.Usage(*d.Usage)
+		out.Usage = &u
+	}
+	return out
+}
+
+func recordError(err error) *recordedError {
+	var pe *provider.Error
+	if errors.As(err, &pe) {
+		return &recordedError{
+			Provider:  pe.Provider,
+			Code:      pe.Code,
+			Type:      pe.Type,
+			Param:     pe.Param,
+			Status:    pe.Status,
+			Message:   pe.Message,
+			Retryable: pe.Retryable,
+		}
+	}
+	return &recordedError{Message: err.Error()}
+}
+
+func (e *recordedError) err() error {
+	if e.Provider == "" && e.Code == "" && e.Status == 0 {
+		return errors.New(e.Message)
+	}
+	return &provider.Error{
+		Provider:  e.Provider,
+		Code:      e.Code,
+		Type:      e.Type,
+		Param:     e.Param,
+		Status:    e.Status,
+		Message:   e.Message,
+		Retryable: e.Retryable,
+	}
+}
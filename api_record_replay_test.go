@@ -0,0 +1,152 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+func TestRecordToAndReplayFrom(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		if call == 0 {
+			return provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.ToolCallPart{ID: "call_1", Name: "add", Args: []byte(`{"a":1,"b":2}`)}},
+				},
+				FinishReason: "tool_calls",
+				Usage:        provider.Usage{PromptTokens: 5, CompletionTokens: 3, TotalTokens: 8},
+			}, nil
+		}
+		return provider.Response{
+			Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "3"}}},
+			FinishReason: "stop",
+			Usage:        provider.Usage{PromptTokens: 9, CompletionTokens: 1, TotalTokens: 10},
+			ResponseID:   "resp_2",
+		}, nil
+	}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		return &fakeStream{
+			deltas: []provider.Delta{{Text: "hel"}, {Text: "lo"}},
+			final: &provider.Response{
+				Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "hello"}}},
+				FinishReason: "stop",
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	add := NewTool("add", ToolSpec[struct {
+		A int `json:"a"`
+		B int `json:"b"`
+	}, int]{
+		Execute: func(ctx context.Context, in struct {
+			A int `json:"a"`
+			B int `json:"b"`
+		}, meta ToolExecutionMeta) (int, error) {
+			return in.A + in.B, nil
+		},
+	})
+	run := func(model ModelRef, prompt string) (*GenerateTextResponse, string, error) {
+		resp, err := GenerateText(context.Background(), GenerateTextRequest{
+			BaseRequest: BaseRequest{Model: model, Messages: []Message{User(prompt)}, Tools: []Tool{add}},
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		s, err := StreamText(context.Background(), StreamTextRequest{
+			BaseRequest: BaseRequest{Model: model, Messages: []Message{User("greet")}},
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		defer s.Close()
+		var streamed strings.Builder
+		for s.Next() {
+			streamed.WriteString(s.Delta())
+		}
+		return resp, streamed.String(), s.Err()
+	}
+
+	var transcript bytes.Buffer
+	recorded, streamed, err := run(RecordTo(&transcript, testModel{provider: providerName, name: "m"}), "1+2?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorded.Text != "3" || streamed != "hello" {
+		t.Fatalf("Text=%q streamed=%q", recorded.Text, streamed)
+	}
+	if lines := strings.Count(transcript.String(), "\n"); lines != 3 {
+		t.Fatalf("transcript has %d lines:\n%s", lines, transcript.String())
+	}
+	calls := len(fp.Requests())
+
+	model, err := ReplayFrom(bytes.NewReader(transcript.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.Provider() != providerName || model.Name() != "m" {
+		t.Fatalf("model=%s/%s", model.Provider(), model.Name())
+	}
+	replayed, streamed, err := run(model, "1+2?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fp.Requests()) != calls {
+		t.Fatal("replay called the provider")
+	}
+	if replayed.Text != "3" || streamed != "hello" || !reflect.DeepEqual(replayed.Usage, recorded.Usage) || replayed.ResponseID != "resp_2" {
+		t.Fatalf("replayed=%#v streamed=%q", replayed, streamed)
+	}
+	if !reflect.DeepEqual(replayed.Response.Messages, recorded.Response.Messages) {
+		t.Fatalf("messages differ:\n%#v\n%#v", replayed.Response.Messages, recorded.Response.Messages)
+	}
+
+	// A conversation that diverges from the recording fails.
+	model, _ = ReplayFrom(bytes.NewReader(transcript.Bytes()))
+	_, _, err = run(model, "2+2?")
+	var mismatch *ReplayMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Call != 0 {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestRecordTo_RecordsErrors(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		return provider.Response{}, &provider.Error{Provider: "fake", Code: "rate_limited", Status: 429, Message: "slow down"}
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	var transcript bytes.Buffer
+	zero := 0
+	req := GenerateTextRequest{BaseRequest: BaseRequest{
+		Model:      RecordTo(&transcript, testModel{provider: providerName, name: "m"}),
+		Messages:   []Message{User("hi")},
+		MaxRetries: &zero,
+	}}
+	if _, err := GenerateText(context.Background(), req); err == nil {
+		t.Fatal("expected error")
+	}
+	var c recordedCall
+	if err := json.Unmarshal(transcript.Bytes(), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Error == nil || c.Error.Status != 429 || c.Response != nil {
+		t.Fatalf("call=%#v", c)
+	}
+
+	req.Model, _ = ReplayFrom(&transcript)
+	_, err := GenerateText(context.Background(), req)
+	var aerr *Error
+	if !errors.As(err, &aerr) || aerr.Status != 429 || aerr.Code != "rate_limited" {
+		t.Fatalf("err=%v", err)
+	}
+}
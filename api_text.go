@@ -25,6 +25,7 @@ func generateTextFromBaseRequest(ctx context.Context, base BaseRequest) (*Genera
 	if err != nil {
 		return nil, err
 	}
+	p = withPerCallTimeout(p, base.PerCallTimeout)
 
 	maxIter := 5
 	if base.ToolLoop != nil && base.ToolLoop.MaxIterations > 0 {
@@ -36,27 +37,35 @@ func generateTextFromBaseRequest(ctx context.Context, base BaseRequest) (*Genera
 		return nil, err
 	}
 
+	timings := newToolTimings()
+	cache := base.ToolLoop.resultCache()
 	exec := func(ctx context.Context, calls []provider.ToolCallPart) ([]provider.Message, error) {
 		return executeToolCallsProviderWithOptions(ctx, base.Tools, calls, toolExecOptions{
-			onProgress: base.OnToolProgress,
+			onProgress:   base.OnToolProgress,
+			onToolTiming: timings.record,
+			dedupe:       base.ToolLoop.dedupeToolCalls(),
+			cache:        cache,
 		})
 	}
 
 	opts := text.Options{
-		MaxIterations: maxIter,
+		MaxIterations:    maxIter,
+		AutoContinue:     base.AutoContinue,
+		MaxContinuations: base.MaxContinuations,
 	}
 	if base.ToolLoop != nil && base.ToolLoop.StopWhen != nil {
+		opts.StopWhenEveryStep = base.ToolLoop.StopWhenEveryStep
 		opts.StopWhen = func(event text.StopWhenEvent) bool {
-			steps, err := stepsFromProviderSteps(event.Steps)
+			steps, err := timings.steps(event.Steps)
 			if err != nil {
 				return false
 			}
-			return base.ToolLoop.StopWhen(StopConditionEvent{Steps: steps})
+			return base.ToolLoop.StopWhen(StopConditionEvent{Steps: redactSteps(steps, base.RedactOutput)})
 		}
 	}
 	if base.PrepareStep != nil {
 		opts.PrepareStep = func(event text.PrepareStepEvent) (text.PrepareStepResult, error) {
-			steps, err := stepsFromProviderSteps(event.Steps)
+			steps, err := timings.steps(event.Steps)
 			if err != nil {
 				return text.PrepareStepResult{}, err
 			}
@@ -66,7 +75,7 @@ func generateTextFromBaseRequest(ctx context.Context, base BaseRequest) (*Genera
 			}
 			res, err := base.PrepareStep(PrepareStepEvent{
 				StepNumber: event.StepNumber,
-				Steps:      steps,
+				Steps:      redactSteps(steps, base.RedactOutput),
 				Messages:   msgs,
 			})
 			if err != nil {
@@ -95,11 +104,20 @@ func generateTextFromBaseRequest(ctx context.Context, base BaseRequest) (*Genera
 	}
 	if base.OnStepFinish != nil {
 		opts.OnStepFinish = func(event text.StepFinishEvent) {
-			step, err := stepFromProviderStep(event.Step)
+			step, err := timings.step(event.Step)
 			if err != nil {
 				return
 			}
-			base.OnStepFinish(StepFinishEvent{Step: step})
+			base.OnStepFinish(StepFinishEvent{Step: redactStep(step, base.RedactOutput)})
+		}
+	}
+	if base.OnStepToolCalls != nil {
+		opts.OnStepToolCalls = func(event text.StepToolCallsEvent) {
+			step, err := timings.step(event.Step)
+			if err != nil {
+				return
+			}
+			base.OnStepToolCalls(StepToolCallsEvent{Step: redactStep(step, base.RedactOutput)})
 		}
 	}
 
@@ -119,7 +137,7 @@ func generateTextFromBaseRequest(ctx context.Context, base BaseRequest) (*Genera
 		TotalTokens:      out.AggregatedUsage.TotalTokens,
 	}
 
-	steps, err := stepsFromProviderSteps(out.Steps)
+	steps, err := timings.steps(out.Steps)
 	if err != nil {
 		return nil, err
 	}
@@ -128,6 +146,12 @@ func generateTextFromBaseRequest(ctx context.Context, base BaseRequest) (*Genera
 		return nil, err
 	}
 
+	if base.RedactOutput != nil {
+		msg = redactMessage(msg, base.RedactOutput)
+		steps = redactSteps(steps, base.RedactOutput)
+		respMsgs = redactMessages(respMsgs, base.RedactOutput)
+	}
+
 	return &GenerateTextResponse{
 		Text:         extractTextFromMessage(msg),
 		Message:      msg,
@@ -135,6 +159,11 @@ func generateTextFromBaseRequest(ctx context.Context, base BaseRequest) (*Genera
 		FinishReason: FinishReason(out.Response.FinishReason),
 		Steps:        steps,
 		Response:     Response{Messages: respMsgs},
+
+		ModelID:           out.Response.ModelID,
+		SystemFingerprint: out.Response.SystemFingerprint,
+		ResponseID:        out.Response.ResponseID,
+		RequestID:         out.Response.RequestID,
 	}, nil
 }
 
@@ -146,6 +175,7 @@ func streamTextFromBaseRequest(ctx context.Context, base BaseRequest) (*TextStre
 	if err != nil {
 		return nil, err
 	}
+	p = withPerCallTimeout(p, base.PerCallTimeout)
 
 	maxIter := 5
 	if base.ToolLoop != nil && base.ToolLoop.MaxIterations > 0 {
@@ -158,28 +188,41 @@ func streamTextFromBaseRequest(ctx context.Context, base BaseRequest) (*TextStre
 	}
 
 	lifecycle := newToolInputLifecycle(base.Tools)
+	timings := newToolTimings()
+	cache := base.ToolLoop.resultCache()
 
 	exec := func(ctx context.Context, calls []provider.ToolCallPart) ([]provider.Message, error) {
 		return executeToolCallsProviderWithOptions(ctx, base.Tools, calls, toolExecOptions{
 			toolCallIndexByID: lifecycle.toolCallIndexByID,
 			onInputAvailable:  lifecycle.onInputAvailable,
 			onProgress:        base.OnToolProgress,
+			onToolTiming:      timings.record,
+			dedupe:            base.ToolLoop.dedupeToolCalls(),
+			cache:             cache,
 		})
 	}
 
-	opts := text.Options{MaxIterations: maxIter}
+	opts := text.Options{
+		MaxIterations:      maxIter,
+		MaxOutputChars:     base.MaxOutputChars,
+		ResumeOnDisconnect: base.ResumeOnDisconnect,
+		EmptyStreamRetries: emptyStreamRetries(base),
+		AutoContinue:       base.AutoContinue,
+		MaxContinuations:   base.MaxContinuations,
+	}
 	if base.ToolLoop != nil && base.ToolLoop.StopWhen != nil {
+		opts.StopWhenEveryStep = base.ToolLoop.StopWhenEveryStep
 		opts.StopWhen = func(event text.StopWhenEvent) bool {
-			steps, err := stepsFromProviderSteps(event.Steps)
+			steps, err := timings.steps(event.Steps)
 			if err != nil {
 				return false
 			}
-			return base.ToolLoop.StopWhen(StopConditionEvent{Steps: steps})
+			return base.ToolLoop.StopWhen(StopConditionEvent{Steps: redactSteps(steps, base.RedactOutput)})
 		}
 	}
 	if base.PrepareStep != nil {
 		opts.PrepareStep = func(event text.PrepareStepEvent) (text.PrepareStepResult, error) {
-			steps, err := stepsFromProviderSteps(event.Steps)
+			steps, err := timings.steps(event.Steps)
 			if err != nil {
 				return text.PrepareStepResult{}, err
 			}
@@ -189,7 +232,7 @@ func streamTextFromBaseRequest(ctx context.Context, base BaseRequest) (*TextStre
 			}
 			res, err := base.PrepareStep(PrepareStepEvent{
 				StepNumber: event.StepNumber,
-				Steps:      steps,
+				Steps:      redactSteps(steps, base.RedactOutput),
 				Messages:   msgs,
 			})
 			if err != nil {
@@ -218,20 +261,32 @@ func streamTextFromBaseRequest(ctx context.Context, base BaseRequest) (*TextStre
 	}
 	if base.OnStepFinish != nil {
 		opts.OnStepFinish = func(event text.StepFinishEvent) {
-			step, err := stepFromProviderStep(event.Step)
+			step, err := timings.step(event.Step)
+			if err != nil {
+				return
+			}
+			base.OnStepFinish(StepFinishEvent{Step: redactStep(step, base.RedactOutput)})
+		}
+	}
+	if base.OnStepToolCalls != nil {
+		opts.OnStepToolCalls = func(event text.StepToolCallsEvent) {
+			step, err := timings.step(event.Step)
 			if err != nil {
 				return
 			}
-			base.OnStepFinish(StepFinishEvent{Step: step})
+			base.OnStepToolCalls(StepToolCallsEvent{Step: redactStep(step, base.RedactOutput)})
 		}
 	}
 
+	// interrupt lets Close cut short a read in flight on another goroutine
+	// (see coalesceTextStream).
+	ctx, interrupt := context.WithCancel(ctx)
 	impl := agents.NewStream(ctx, p, preq, exec, opts, lifecycle.onDelta)
 
 	var finalMsg *Message
 	var cachedSteps []Step
 	var cachedResp []Message
-	return newTextStream(
+	stream := newTextStream(
 		func() bool { return impl.Next() },
 		func() string { return impl.Delta() },
 		func() *Message {
@@ -256,7 +311,7 @@ func streamTextFromBaseRequest(ctx context.Context, base BaseRequest) (*TextStre
 		},
 		func() FinishReason {
 			final := impl.Final()
-			if final == nil {
+			if final == nil || final.FinishReason == "" {
 				return FinishUnknown
 			}
 			return FinishReason(final.FinishReason)
@@ -266,7 +321,7 @@ func streamTextFromBaseRequest(ctx context.Context, base BaseRequest) (*TextStre
 				return append([]Step(nil), cachedSteps...)
 			}
 			ps := impl.Steps()
-			steps, err := stepsFromProviderSteps(ps)
+			steps, err := timings.steps(ps)
 			if err != nil {
 				return nil
 			}
@@ -284,15 +339,40 @@ func streamTextFromBaseRequest(ctx context.Context, base BaseRequest) (*TextStre
 			cachedResp = msgs
 			return Response{Messages: append([]Message(nil), cachedResp...)}
 		},
+		func() textStreamInfo {
+			final := impl.Final()
+			if final == nil {
+				return textStreamInfo{}
+			}
+			return textStreamInfo{
+				modelID:           final.ModelID,
+				systemFingerprint: final.SystemFingerprint,
+				responseID:        final.ResponseID,
+				requestID:         final.RequestID,
+			}
+		},
 		func() error { return mapProviderError(impl.Err()) },
-		func() error { return impl.Close() },
-	), nil
+		func() error {
+			defer interrupt()
+			return impl.Close()
+		},
+	)
+	if base.RedactOutput != nil {
+		stream = redactTextStream(stream, base.RedactOutput)
+	}
+	if base.CoalesceDeltas > 0 {
+		stream = coalesceTextStream(stream, base.CoalesceDeltas, interrupt)
+	}
+	return stream, nil
 }
 
 func providerForModel(m ModelRef) (provider.Provider, error) {
 	if m == nil {
 		return nil, fmt.Errorf("model is required")
 	}
+	if mp, ok := m.(modelProvider); ok {
+		return mp.modelProvider()
+	}
 	name := m.Provider()
 	if name == "" {
 		return nil, fmt.Errorf("model provider is required")
@@ -303,3 +383,19 @@ func providerForModel(m ModelRef) (provider.Provider, error) {
 	}
 	return p, nil
 }
+
+// defaultEmptyStreamRetries matches the default retries of the OpenAI client.
+const defaultEmptyStreamRetries = 2
+
+// emptyStreamRetries is how often a StreamText step that produced nothing is
+// re-issued: zero unless RetryEmptyStream is set, then MaxRetries or the
+// default.
+func emptyStreamRetries(base BaseRequest) int {
+	if !base.RetryEmptyStream {
+		return 0
+	}
+	if base.MaxRetries != nil {
+		return *base.MaxRetries
+	}
+	return defaultEmptyStreamRetries
+}
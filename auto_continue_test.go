@@ -0,0 +1,125 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// truncatingProvider replies in parts, finishing with "length" until the last.
+func truncatingProvider(parts []string) func(call int, req provider.Request) (provider.Response, error) {
+	return func(call int, req provider.Request) (provider.Response, error) {
+		finish := provider.FinishReason("length")
+		if call == len(parts)-1 {
+			finish = "stop"
+		}
+		return provider.Response{
+			Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: parts[call]}}},
+			FinishReason: finish,
+			Usage:        provider.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		}, nil
+	}
+}
+
+func TestGenerateText_AutoContinue(t *testing.T) {
+	fp := &fakeProvider{generate: truncatingProvider([]string{"The quick ", "brown fox ", "jumps."})}
+	providerName := registerFakeProvider(t, fp)
+
+	resp, err := GenerateText(context.Background(), GenerateTextRequest{
+		BaseRequest: BaseRequest{
+			Model:        testModel{provider: providerName, name: "m"},
+			Messages:     []Message{User("go")},
+			AutoContinue: true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "The quick brown fox jumps." {
+		t.Fatalf("Text=%q", resp.Text)
+	}
+	if resp.FinishReason != FinishStop {
+		t.Fatalf("FinishReason=%q", resp.FinishReason)
+	}
+	if resp.Usage.TotalTokens != 45 {
+		t.Fatalf("Usage=%+v", resp.Usage)
+	}
+	if len(resp.Steps) != 1 {
+		t.Fatalf("steps=%d", len(resp.Steps))
+	}
+	reqs := fp.Requests()
+	if len(reqs) != 3 || reqs[1].AssistantPrefix != "The quick " || reqs[2].AssistantPrefix != "The quick brown fox " {
+		t.Fatalf("requests=%d prefixes=%q", len(reqs), []string{reqs[1].AssistantPrefix, reqs[2].AssistantPrefix})
+	}
+}
+
+func TestGenerateText_AutoContinueLimit(t *testing.T) {
+	fp := &fakeProvider{generate: truncatingProvider([]string{"a", "b", "c", "d"})}
+	providerName := registerFakeProvider(t, fp)
+
+	resp, err := GenerateText(context.Background(), GenerateTextRequest{
+		BaseRequest: BaseRequest{
+			Model:            testModel{provider: providerName, name: "m"},
+			Messages:         []Message{User("go")},
+			AutoContinue:     true,
+			MaxContinuations: 1,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "ab" || resp.FinishReason != FinishLength {
+		t.Fatalf("Text=%q FinishReason=%q", resp.Text, resp.FinishReason)
+	}
+	if n := len(fp.Requests()); n != 2 {
+		t.Fatalf("requests=%d", n)
+	}
+}
+
+func TestStreamText_AutoContinue(t *testing.T) {
+	parts := []string{"The quick ", "brown fox ", "jumps."}
+	gen := truncatingProvider(parts)
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		final, _ := gen(call, req)
+		return &fakeStream{deltas: []provider.Delta{{Text: parts[call]}}, final: &final}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	stream, err := StreamText(context.Background(), StreamTextRequest{
+		BaseRequest: BaseRequest{
+			Model:        testModel{provider: providerName, name: "m"},
+			Messages:     []Message{User("go")},
+			AutoContinue: true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var sb strings.Builder
+	for stream.Next() {
+		sb.WriteString(stream.Delta())
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sb.String(); got != "The quick brown fox jumps." {
+		t.Fatalf("streamed=%q", got)
+	}
+	if m := stream.Message(); m == nil || extractTextFromMessage(*m) != "The quick brown fox jumps." {
+		t.Fatalf("Message=%#v", m)
+	}
+	if got := stream.FinishReason(); got != FinishStop {
+		t.Fatalf("FinishReason=%q", got)
+	}
+	if u := stream.Usage(); u.TotalTokens != 45 {
+		t.Fatalf("Usage=%+v", u)
+	}
+	if got := len(stream.Steps()); got != 1 {
+		t.Fatalf("steps=%d", got)
+	}
+}
@@ -0,0 +1,71 @@
+package ai
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ImageFile reads an image from disk into an ImagePart. The media type is
+// sniffed from the content, falling back to the file extension; files that are
+// not recognizably images return an error.
+func ImageFile(path string) (ImagePart, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ImagePart{}, err
+	}
+	mediaType := sniffMediaType(b)
+	if !strings.HasPrefix(mediaType, "image/") {
+		mediaType = extensionMediaType(path)
+	}
+	if !strings.HasPrefix(mediaType, "image/") {
+		return ImagePart{}, fmt.Errorf("%s: unrecognized image type", path)
+	}
+	return ImageBytes(mediaType, b), nil
+}
+
+// AudioFile reads an audio file from disk into an AudioPart. The format (e.g.
+// "wav", "mp3") is taken from the file extension, falling back to content
+// sniffing.
+func AudioFile(path string) (AudioPart, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return AudioPart{}, err
+	}
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if !audioFormats[format] {
+		format = audioFormatByMediaType[sniffMediaType(b)]
+	}
+	if format == "" {
+		return AudioPart{}, fmt.Errorf("%s: unrecognized audio format", path)
+	}
+	return AudioBytes(format, b), nil
+}
+
+var audioFormats = map[string]bool{
+	"mp3": true, "wav": true, "m4a": true, "mp4": true, "mpeg": true, "mpga": true,
+	"ogg": true, "oga": true, "flac": true, "webm": true, "aac": true, "opus": true,
+}
+
+var audioFormatByMediaType = map[string]string{
+	"audio/wave":      "wav",
+	"audio/mpeg":      "mp3",
+	"audio/ogg":       "ogg",
+	"application/ogg": "ogg",
+	"audio/aiff":      "aiff",
+	"audio/midi":      "midi",
+	"video/webm":      "webm",
+}
+
+func sniffMediaType(b []byte) string {
+	mt, _, _ := mime.ParseMediaType(http.DetectContentType(b))
+	return mt
+}
+
+func extensionMediaType(path string) string {
+	mt, _, _ := mime.ParseMediaType(mime.TypeByExtension(strings.ToLower(filepath.Ext(path))))
+	return mt
+}
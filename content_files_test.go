@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImageFile(t *testing.T) {
+	dir := t.TempDir()
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	// Content wins over a misleading extension.
+	path := filepath.Join(dir, "photo.jpg")
+	if err := os.WriteFile(path, png, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	img, err := ImageFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.MediaType != "image/png" || len(img.Bytes) != len(png) {
+		t.Fatalf("img=%#v", img)
+	}
+
+	svg := filepath.Join(dir, "icon.svg")
+	if err := os.WriteFile(svg, []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if img, err := ImageFile(svg); err != nil || img.MediaType != "image/svg+xml" {
+		t.Fatalf("img=%#v err=%v", img, err)
+	}
+
+	txt := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(txt, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ImageFile(txt); err == nil {
+		t.Fatalf("expected error for non-image")
+	}
+}
+
+func TestAudioFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "clip.MP3")
+	if err := os.WriteFile(path, []byte("ID3fake"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a, err := AudioFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Format != "mp3" {
+		t.Fatalf("format=%q", a.Format)
+	}
+
+	wav := filepath.Join(dir, "recording")
+	if err := os.WriteFile(wav, []byte("RIFF\x00\x00\x00\x00WAVEfmt "), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if a, err := AudioFile(wav); err != nil || a.Format != "wav" {
+		t.Fatalf("audio=%#v err=%v", a, err)
+	}
+}
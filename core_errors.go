@@ -5,9 +5,14 @@ import (
 	"errors"
 )
 
+// Error is returned for provider failures. For errors reported by the
+// provider's API, Type and Param carry the structured details (e.g. Param is
+// "temperature" for an invalid temperature value).
 type Error struct {
 	Provider  string
 	Code      string
+	Type      string
+	Param     string
 	Status    int
 	Message   string
 	Retryable bool
@@ -32,6 +37,20 @@ func (e *Error) Error() string {
 
 func (e *Error) Unwrap() error { return e.Cause }
 
+// APIError is an alias of Error, for callers that branch on API error details
+// (Code, Type, Param, Status, Message).
+type APIError = Error
+
+// AsAPIError reports whether err is (or wraps) an error returned by a
+// provider's API, i.e. one with an HTTP status or an API error type.
+func AsAPIError(err error) (*APIError, bool) {
+	var e *Error
+	if errors.As(err, &e) && (e.Status != 0 || e.Type != "") {
+		return e, true
+	}
+	return nil, false
+}
+
 func IsRateLimited(err error) bool {
 	var e *Error
 	return errors.As(err, &e) && (e.Status == 429 || e.Code == "rate_limited")
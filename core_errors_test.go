@@ -0,0 +1,40 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+func TestGenerateText_APIErrorDetails(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		return provider.Response{}, &provider.Error{
+			Provider: "fake",
+			Code:     "invalid_value",
+			Type:     "invalid_request_error",
+			Param:    "temperature",
+			Status:   400,
+			Message:  "Invalid value for 'temperature'",
+		}
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	_, err := GenerateText(context.Background(), GenerateTextRequest{BaseRequest: BaseRequest{
+		Model:    testModel{provider: providerName, name: "m"},
+		Messages: []Message{User("hi")},
+	}})
+	apiErr, ok := AsAPIError(fmt.Errorf("wrapped: %w", err))
+	if !ok {
+		t.Fatalf("err=%#v", err)
+	}
+	if apiErr.Param != "temperature" || apiErr.Type != "invalid_request_error" || apiErr.Code != "invalid_value" || apiErr.Status != 400 {
+		t.Fatalf("apiErr=%#v", apiErr)
+	}
+
+	if _, ok := AsAPIError(&Error{Provider: "fake", Code: "timeout"}); ok {
+		t.Fatalf("expected client-side error not to be an API error")
+	}
+}
@@ -0,0 +1,111 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// Provider is the public provider interface for plugging custom model backends
+// (e.g. a local llama.cpp server) into GenerateText/StreamText and friends.
+//
+// Register implementations with RegisterProvider and address them with
+// CustomModel.
+type Provider interface {
+	Generate(ctx context.Context, req ProviderRequest) (ProviderResponse, error)
+	Stream(ctx context.Context, req ProviderRequest) (ProviderStream, error)
+}
+
+// ProviderRequest is a single model call as seen by a custom Provider.
+type ProviderRequest struct {
+	Model string
+
+	Messages []Message
+	Tools    []ToolDefinition
+
+	Headers    map[string]string
+	MaxRetries *int
+
+	MaxTokens   *int
+	Temperature *float32
+	TopP        *float32
+	Stop        []string
+
+	ReasoningEffort string
+	Verbosity       string
+
+	// AssistantPrefix is a partial assistant reply the model should continue
+	// from. Providers with native prefill should send it as such; others can
+	// append it as a trailing assistant message.
+	AssistantPrefix string
+
+	// Prediction is text the reply is expected to largely match. Providers
+	// without predicted outputs can ignore it.
+	Prediction string
+
+	// BaseURLOverride, when set, replaces the provider's base URL for this
+	// call.
+	BaseURLOverride string
+
+	Metadata map[string]string
+}
+
+// ToolDefinition is the model-facing description of a tool (no handler).
+type ToolDefinition struct {
+	Name        string
+	Description string
+	InputSchema Schema
+	Strict      bool
+
+	// Hosted marks a provider-executed tool (see HostedTool); Name is the tool
+	// type and Config its JSON settings. Providers that do not support it
+	// should return an error.
+	Hosted bool
+	Config json.RawMessage
+}
+
+type ProviderResponse struct {
+	Message      Message
+	Usage        Usage
+	FinishReason FinishReason
+
+	// ModelID, SystemFingerprint, ResponseID and RequestID are optional
+	// provider-reported metadata.
+	ModelID           string
+	SystemFingerprint string
+	ResponseID        string
+	RequestID         string
+}
+
+// ErrStreamingUnsupported may be returned (or wrapped) by Provider.Stream
+// when the backend cannot stream. GenerateObject then serves OnProgress and
+// OnRawDelta from a Generate call instead of failing.
+var ErrStreamingUnsupported = provider.ErrStreamingUnsupported
+
+// ProviderStream is returned by Provider.Stream. Final must return the
+// assembled response (including tool calls) once Next returns false.
+type ProviderStream interface {
+	Next() bool
+	Delta() ProviderDelta
+	Final() *ProviderResponse
+	Err() error
+	Close() error
+}
+
+type ProviderDelta struct {
+	Text      string
+	ToolCalls []ToolCallDelta
+
+	// Usage optionally reports the running usage of the current call.
+	Usage *Usage
+}
+
+type ToolCallDelta struct {
+	Index int
+	ID    string
+	Name  string
+	// ArgumentsDelta is a fragment of the JSON arguments string (not valid JSON
+	// by itself).
+	ArgumentsDelta string
+}
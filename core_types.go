@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -13,16 +16,42 @@ type ModelRef interface {
 	Name() string
 }
 
+// ModelString returns the canonical "provider:name" form of m, for logs and
+// map keys. Two refs naming the same model have the same string; nil is "".
+func ModelString(m ModelRef) string {
+	if m == nil {
+		return ""
+	}
+	return m.Provider() + ":" + m.Name()
+}
+
 type BaseRequest struct {
 	Model ModelRef
 
+	// System, when set, is sent as the first system message. It is an error to
+	// combine it with a system message in Messages unless MergeSystem is set,
+	// in which case it is prepended to the leading system message's text.
+	System      string
+	MergeSystem bool
+
 	Messages []Message
 	Tools    []Tool
 	ToolLoop *ToolLoopOptions
 
+	// ToolDescriptions replaces the Description of tools, by tool name, in
+	// what is sent to the model for this request (e.g. wording tuned for a
+	// particular model). The Tool values are not modified. Naming a tool that
+	// is not in Tools is an error.
+	ToolDescriptions map[string]string
+
 	Headers    map[string]string
 	MaxRetries *int
-	Timeout    time.Duration
+	// Timeout bounds the whole call, including every step of the tool loop.
+	Timeout time.Duration
+	// PerCallTimeout bounds each individual provider request (one step of the
+	// loop, including provider retries and reading a streamed response). It
+	// composes with Timeout: each call gets whichever deadline is earlier.
+	PerCallTimeout time.Duration
 
 	// OnToolProgress is called when a tool reports progress during execution.
 	// Tools created via NewTool/NewDynamicTool can report progress via ToolExecutionMeta.Report.
@@ -32,6 +61,12 @@ type BaseRequest struct {
 	// any tool calls and tool results produced by that step).
 	OnStepFinish func(event StepFinishEvent)
 
+	// OnStepToolCalls is called when a step ends with tool calls, before they
+	// run. The step has its ToolCalls but no ToolResults yet, so a UI can show
+	// "running tool…" during execution instead of going silent. OnStepFinish
+	// follows once the tools complete.
+	OnStepToolCalls func(event StepToolCallsEvent)
+
 	// PrepareStep is called before each model generation step in a multi-step tool loop.
 	// It can override the messages and active tools for that step.
 	PrepareStep func(event PrepareStepEvent) (PrepareStepResult, error)
@@ -41,6 +76,83 @@ type BaseRequest struct {
 	TopP        *float32
 	Stop        []string
 
+	// SkipParamValidation disables the client-side range checks on sampling
+	// parameters (Temperature in [0, 2], TopP in [0, 1]), for providers that
+	// accept other ranges.
+	SkipParamValidation bool
+
+	// ReasoningEffort controls reasoning depth on reasoning models
+	// (OpenAI: "low", "medium" or "high"). Empty uses the provider default.
+	ReasoningEffort string
+	// Verbosity controls response length on models that support it
+	// (OpenAI: "low", "medium" or "high"). Empty uses the provider default.
+	Verbosity string
+
+	// RedactOutput, when set, rewrites assistant text before it reaches the
+	// caller (e.g. to scrub secrets). It is applied to the final text, messages
+	// and steps, to the steps passed to callbacks (OnStepFinish,
+	// OnStepToolCalls, PrepareStep, StopWhen), and to streamed deltas;
+	// streaming withholds the trailing 128 bytes until more text arrives so
+	// patterns spanning deltas are caught. PrepareStep's Messages are the
+	// conversation as sent to the model and are not redacted.
+	RedactOutput func(text string) string
+
+	// MaxOutputChars is a safety valve for StreamText: once this many
+	// characters (runes) of assistant text have streamed, the upstream is
+	// closed and the stream ends with FinishLength. Unlike MaxTokens it is
+	// enforced client-side. Zero means no limit; GenerateText ignores it.
+	MaxOutputChars int
+
+	// AssistantPrefix seeds the assistant's reply: the model continues from
+	// this text (e.g. "{" to force a JSON object). Providers with native
+	// prefill send it as-is; OpenAI emulates it with a trailing assistant
+	// message. The response contains only what the model generated. In a
+	// tool loop only the first step is prefixed.
+	AssistantPrefix string
+
+	// Prediction is text the reply is expected to largely match, e.g. the
+	// current version of a file being edited. Providers that support
+	// predicted outputs (OpenAI) use it to generate the unchanged parts
+	// faster; tokens of the prediction that do not appear in the reply are
+	// still billed. Others ignore it.
+	Prediction string
+
+	// BaseURLOverride replaces the provider's configured BaseURL for this
+	// call only, e.g. to route to a regional endpoint. The configured API
+	// key, headers and API prefix still apply. Leave empty to use the
+	// configuration.
+	BaseURLOverride string
+
+	// ResumeOnDisconnect makes StreamText recover from a retryable mid-stream
+	// failure (e.g. a dropped connection): the step is re-issued with the
+	// text streamed so far as an assistant prefix, and the stream continues
+	// where it left off. Partial tool calls are discarded and requested
+	// again. Each step is resumed at most 3 times; GenerateText ignores it.
+	ResumeOnDisconnect bool
+
+	// RetryEmptyStream makes StreamText re-issue a step whose stream ended
+	// without any text or tool calls and with a finish reason other than
+	// "stop", "tool_calls" or "content_filter" (e.g. "length" or "error", or
+	// none at all). Such replies are usually transient upstream failures.
+	// Each step is retried up to MaxRetries times (default 2); GenerateText
+	// ignores it.
+	RetryEmptyStream bool
+
+	// AutoContinue handles replies cut off by the token limit: when a step
+	// finishes with FinishLength (and no tool calls), the request is re-issued
+	// with the text so far as an assistant prefix, and the parts are joined
+	// into one reply. This repeats until the model stops on its own or
+	// MaxContinuations (default 3) re-issues have been made; the last part's
+	// finish reason is reported.
+	AutoContinue     bool
+	MaxContinuations int
+
+	// CoalesceDeltas merges StreamText deltas that arrive within this window
+	// of the first buffered one, so UIs render fewer, larger chunks. Order is
+	// preserved and buffered text is flushed when the stream ends. Zero
+	// forwards every delta as it arrives; GenerateText ignores it.
+	CoalesceDeltas time.Duration
+
 	Metadata map[string]string
 }
 
@@ -57,6 +169,18 @@ type GenerateTextResponse struct {
 
 	Steps    []Step
 	Response Response
+
+	// ModelID is the model that served the final step, as reported by the
+	// provider (e.g. a dated snapshot such as "gpt-4o-2024-08-06").
+	ModelID string
+	// SystemFingerprint identifies the provider backend configuration, when reported.
+	SystemFingerprint string
+
+	// ResponseID is the provider's ID for the final step's response (OpenAI:
+	// "chatcmpl-..."), and RequestID the ID of its HTTP request (OpenAI:
+	// x-request-id header). Log them to make support tickets tractable.
+	ResponseID string
+	RequestID  string
 }
 
 type StreamTextRequest = GenerateTextRequest
@@ -69,15 +193,41 @@ type TextStream struct {
 	finish  func() FinishReason
 	steps   func() []Step
 	resp    func() Response
+	info    func() textStreamInfo
 	err     func() error
 	close   func() error
+
+	hadOutput bool
+}
+
+// textStreamInfo is provider-reported response metadata, available once the
+// stream has finished.
+type textStreamInfo struct {
+	modelID           string
+	systemFingerprint string
+	responseID        string
+	requestID         string
 }
 
 func (s *TextStream) Next() bool {
 	if s == nil || s.next == nil {
 		return false
 	}
-	return s.next()
+	if !s.next() {
+		return false
+	}
+	if !s.hadOutput && s.Delta() != "" {
+		s.hadOutput = true
+	}
+	return true
+}
+
+// HadOutput reports whether Next has delivered any text so far. After a
+// failed stream it tells a mid-stream failure, whose partial text the caller
+// has already received and may want to keep showing, from one that failed
+// before producing anything.
+func (s *TextStream) HadOutput() bool {
+	return s != nil && s.hadOutput
 }
 
 func (s *TextStream) Delta() string {
@@ -122,6 +272,37 @@ func (s *TextStream) Response() Response {
 	return s.resp()
 }
 
+// ModelID returns the provider-reported model that served the final step.
+// It is empty until the stream has finished.
+func (s *TextStream) ModelID() string {
+	return s.streamInfo().modelID
+}
+
+// SystemFingerprint returns the provider-reported system fingerprint, if any.
+// It is empty until the stream has finished.
+func (s *TextStream) SystemFingerprint() string {
+	return s.streamInfo().systemFingerprint
+}
+
+// ResponseID returns the provider's ID for the final step's response, if
+// any. It is empty until the stream has finished.
+func (s *TextStream) ResponseID() string {
+	return s.streamInfo().responseID
+}
+
+// RequestID returns the ID of the final step's HTTP request (OpenAI:
+// x-request-id), if any. It is empty until the stream has finished.
+func (s *TextStream) RequestID() string {
+	return s.streamInfo().requestID
+}
+
+func (s *TextStream) streamInfo() textStreamInfo {
+	if s == nil || s.info == nil {
+		return textStreamInfo{}
+	}
+	return s.info()
+}
+
 func (s *TextStream) Err() error {
 	if s == nil || s.err == nil {
 		return nil
@@ -144,6 +325,7 @@ func newTextStream(
 	finish func() FinishReason,
 	steps func() []Step,
 	resp func() Response,
+	info func() textStreamInfo,
 	err func() error,
 	close func() error,
 ) *TextStream {
@@ -155,6 +337,7 @@ func newTextStream(
 		finish:  finish,
 		steps:   steps,
 		resp:    resp,
+		info:    info,
 		err:     err,
 		close:   close,
 	}
@@ -182,6 +365,35 @@ func (s *TextStream) Reader() io.Reader {
 	return &textStreamReader{stream: s}
 }
 
+// WriteTo writes text deltas to w as they arrive, implementing io.WriterTo.
+// When w is an http.Flusher (e.g. an http.ResponseWriter) it is flushed after
+// every delta, so proxied responses are not buffered. It returns on the first
+// write error, or the stream's error once it ends.
+//
+// Do not call Next() concurrently with WriteTo().
+func (s *TextStream) WriteTo(w io.Writer) (int64, error) {
+	flusher, _ := w.(http.Flusher)
+	var total int64
+	for s.Next() {
+		delta := s.Delta()
+		if delta == "" {
+			continue
+		}
+		n, err := io.WriteString(w, delta)
+		total += int64(n)
+		if err == nil && n < len(delta) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			return total, err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	return total, s.Err()
+}
+
 type textStreamReader struct {
 	stream *TextStream
 	buf    []byte
@@ -213,11 +425,60 @@ func (r *textStreamReader) Read(p []byte) (int, error) {
 type GenerateObjectRequest[T any] struct {
 	BaseRequest
 
-	Schema  Schema
+	Schema Schema
+	// Example, used when Schema is empty, derives the schema from a sample
+	// value (see SchemaFromExample).
 	Example *T
 
 	Strict     *bool
 	MaxRetries *int
+
+	// OnProgress is called with the accumulated raw JSON arguments as the
+	// object is generated (the JSON may be incomplete). GenerateObject streams
+	// from the provider internally when it is set; if the provider delivers the
+	// object in one piece, or cannot stream (its Stream returns
+	// ErrStreamingUnsupported), it fires once at the end. StreamObject calls it
+	// only when the JSON has grown.
+	OnProgress func(raw json.RawMessage)
+
+	// OnField is called when a field's value finishes parsing in the streamed
+	// JSON, with its dot-separated path ("address.city"; array elements use
+	// their index, "tags.0") and decoded value. Nested fields are reported
+	// before their parent. Like OnProgress, it makes GenerateObject stream
+	// internally. Fields may be reported again if the object is regenerated
+	// (validation retry or tool loop step).
+	OnField func(path string, value any)
+
+	// OnRawDelta receives the model's text output as it streams: prose the
+	// model writes around the tool call, or the whole reply when the provider
+	// falls back to JSON-only text mode (delivered in one piece). Useful for
+	// debugging objects that fail to parse. Tool call arguments are reported
+	// via OnProgress and Raw instead. Like OnProgress, it makes GenerateObject
+	// stream internally.
+	OnRawDelta func(text string)
+
+	// AbortOnSchemaViolation makes StreamObject validate each field as soon as
+	// its value is complete and end the stream with a *SchemaViolationError at
+	// the first one that violates the schema (wrong type, enum value,
+	// disallowed property...), instead of paying for the rest of the object.
+	// Fields under oneOf/anyOf/allOf or conditionals are only checked as part
+	// of the final object. GenerateObject ignores it.
+	AbortOnSchemaViolation bool
+
+	// PatchRepair makes GenerateObject repair an object that fails schema
+	// validation (in strict mode) by asking the model for a JSON Patch of
+	// just the invalid fields, instead of regenerating the whole object. Each
+	// patch call counts against MaxRetries; if patching fails, the remaining
+	// retries regenerate the object as usual. It saves most of the retry cost
+	// for large objects with a few bad fields. StreamObject ignores it.
+	PatchRepair bool
+
+	// CorrectionTemplate replaces the (English) message GenerateObject sends
+	// before retrying an object that is not valid JSON or fails the schema.
+	// It receives the validation or parse error and the rejected JSON, and
+	// returns the text of the system message, e.g. in the app's language or
+	// with domain hints. Nil uses the default. StreamObject ignores it.
+	CorrectionTemplate func(err error, raw json.RawMessage) string
 }
 
 type GenerateObjectResponse[T any] struct {
@@ -236,6 +497,7 @@ type ObjectStream[T any] struct {
 	next    func() bool
 	raw     func() json.RawMessage
 	partial func() map[string]any
+	typed   func() *T
 	object  func() *T
 	err     func() error
 	close   func() error
@@ -266,6 +528,19 @@ func (s *ObjectStream[T]) Partial() map[string]any {
 	return s.partial()
 }
 
+// PartialTyped returns the object streamed so far as a T: completed fields
+// are set, while fields still streaming (an unfinished string or number, a
+// key without its value) keep their zero value. Nested objects and arrays are
+// filled as far as they are complete. Values that don't fit T's field types
+// are skipped. It returns nil until the object has started, and the final
+// object once the stream completes. Each call decodes the JSON again.
+func (s *ObjectStream[T]) PartialTyped() *T {
+	if s == nil || s.typed == nil {
+		return nil
+	}
+	return s.typed()
+}
+
 // Object returns the final decoded object once the stream has completed.
 func (s *ObjectStream[T]) Object() *T {
 	if s == nil || s.object == nil {
@@ -292,6 +567,7 @@ func newObjectStream[T any](
 	next func() bool,
 	raw func() json.RawMessage,
 	partial func() map[string]any,
+	typed func() *T,
 	object func() *T,
 	err func() error,
 	close func() error,
@@ -300,6 +576,7 @@ func newObjectStream[T any](
 		next:    next,
 		raw:     raw,
 		partial: partial,
+		typed:   typed,
 		object:  object,
 		err:     err,
 		close:   close,
@@ -320,6 +597,33 @@ type Tool struct {
 	InputSchema Schema
 	Handler     ToolHandler
 
+	// Strict enables provider-side strict schema adherence (OpenAI
+	// function.strict). The schema is normalized to additionalProperties:false
+	// with all properties required, optional ones becoming nullable; nulls the
+	// model sends for them are dropped before the handler runs. Requests fail
+	// if the schema cannot be made strict.
+	Strict bool
+
+	// MarshalResult encodes the handler's return value as the tool result
+	// sent to the model. Defaults to json.Marshal; override it to control
+	// number formatting or produce canonical JSON for stable prompts.
+	MarshalResult func(v any) ([]byte, error)
+
+	// Hosted marks a provider-executed tool (see HostedTool). It is sent to
+	// the provider but never run locally; its calls get no tool result.
+	Hosted bool
+	// HostedConfig holds a hosted tool's provider-specific settings. It must
+	// encode to a JSON object (or be nil).
+	HostedConfig any
+
+	// Annotations describe the tool's side effects (MCP tools carry the
+	// server's hints).
+	Annotations ToolAnnotations
+	// CacheResults memoizes results by tool name and arguments (see
+	// ToolLoopOptions.ResultCache). It only applies to tools annotated
+	// ReadOnly or Idempotent; other tools always run.
+	CacheResults bool
+
 	// Tool input lifecycle hooks (streaming only).
 	// These are called only for StreamText (GenerateText does not stream tool inputs).
 	OnInputStart     func(event ToolInputStartEvent)
@@ -329,6 +633,16 @@ type Tool struct {
 
 type ToolHandler func(ctx context.Context, input json.RawMessage) (any, error)
 
+// ToolAnnotations are hints about a tool's behavior, mirroring MCP tool
+// annotations.
+type ToolAnnotations struct {
+	// ReadOnly tools do not modify their environment.
+	ReadOnly bool
+	// Idempotent tools have no additional effect when called repeatedly with
+	// the same arguments.
+	Idempotent bool
+}
+
 type ToolInputStartEvent struct {
 	ToolName      string
 	ToolCallID    string
@@ -356,6 +670,13 @@ type ToolProgressEvent struct {
 	ToolCallID    string
 	ToolCallIndex int
 
+	// Sequence numbers the reports of one tool call, starting at 1, and
+	// Timestamp is when Report was called. Reports of the same call are
+	// delivered one at a time, in Sequence order, even when the tool reports
+	// from several goroutines.
+	Sequence  int
+	Timestamp time.Time
+
 	Data any
 }
 
@@ -371,12 +692,21 @@ type Step struct {
 	Usage        Usage
 
 	ActiveTools []string
+
+	// ToolTimings is the execution duration of each tool call in this step,
+	// keyed by ToolCallID. Calls answered without running the tool
+	// (deduplicated calls and ResultCache hits) have a zero duration.
+	ToolTimings map[string]time.Duration
 }
 
 type StepFinishEvent struct {
 	Step Step
 }
 
+type StepToolCallsEvent struct {
+	Step Step
+}
+
 type PrepareStepEvent struct {
 	StepNumber int
 	Steps      []Step
@@ -422,6 +752,26 @@ func HasToolCall(toolName string) StopCondition {
 	}
 }
 
+// TextContains stops once the latest step's assistant text contains substr.
+func TextContains(substr string) StopCondition {
+	return func(event StopConditionEvent) bool {
+		if len(event.Steps) == 0 {
+			return false
+		}
+		return strings.Contains(event.Steps[len(event.Steps)-1].Text, substr)
+	}
+}
+
+// TextMatches stops once the latest step's assistant text matches re.
+func TextMatches(re *regexp.Regexp) StopCondition {
+	return func(event StopConditionEvent) bool {
+		if re == nil || len(event.Steps) == 0 {
+			return false
+		}
+		return re.MatchString(event.Steps[len(event.Steps)-1].Text)
+	}
+}
+
 type Response struct {
 	Messages []Message
 }
@@ -429,9 +779,39 @@ type Response struct {
 type ToolLoopOptions struct {
 	MaxIterations int
 
-	// StopWhen determines when to stop the internal tool loop. It is only
-	// evaluated when the last step contains tool results.
+	// StopWhen determines when to stop the internal tool loop. By default it is
+	// only evaluated when the last step contains tool results; a step without
+	// tool calls always ends the loop.
 	StopWhen StopCondition
+
+	// StopWhenEveryStep also evaluates StopWhen after steps without tool calls.
+	// When the condition is not met, the assistant's text is kept in the
+	// conversation and another step runs (use PrepareStep to add a follow-up
+	// message). Once MaxIterations is reached the last step is returned as the
+	// final response.
+	StopWhenEveryStep bool
+
+	// DedupeToolCalls runs identical tool calls (same name and arguments,
+	// ignoring JSON whitespace) within a single step only once. Every
+	// duplicate still gets a tool result message, carrying the shared result
+	// under its own ToolCallID.
+	DedupeToolCalls bool
+
+	// ResultCache stores results of tools with CacheResults set. Share one
+	// across requests (e.g. a chat session) to reuse results between them;
+	// when nil, each request gets its own cache.
+	ResultCache *ToolResultCache
+}
+
+func (o *ToolLoopOptions) dedupeToolCalls() bool {
+	return o != nil && o.DedupeToolCalls
+}
+
+func (o *ToolLoopOptions) resultCache() *ToolResultCache {
+	if o != nil && o.ResultCache != nil {
+		return o.ResultCache
+	}
+	return NewToolResultCache()
 }
 
 type Role string
@@ -449,6 +829,12 @@ type Message struct {
 	Name    string
 
 	ToolCallID string // required for role=tool messages
+
+	// Metadata carries per-message annotations for providers that understand
+	// them and for middleware that tags messages. It is passed to providers
+	// as-is and never sent on the wire by providers that don't use it
+	// (OpenAI ignores it).
+	Metadata map[string]any
 }
 
 type ContentPart interface {
@@ -488,6 +874,14 @@ type AudioPart struct {
 
 func (AudioPart) isContentPart() {}
 
+// ReasoningPart is reasoning content a reasoning model returned alongside its
+// answer (e.g. OpenAI-compatible servers' reasoning_content). Keep it in the
+// assistant message when replaying a turn: providers that accept prior
+// reasoning send it back, others drop it. It is not part of Text.
+type ReasoningPart struct{ Text string }
+
+func (ReasoningPart) isContentPart() {}
+
 func ImageURL(url string) ImagePart { return ImagePart{URL: url} }
 
 func ImageBytes(mediaType string, b []byte) ImagePart {
@@ -514,6 +908,47 @@ func Assistant(text string) Message {
 	return Message{Role: RoleAssistant, Content: []ContentPart{TextPart{Text: text}}}
 }
 
+// AssistantToolCall returns an assistant message with a single tool call.
+// args is JSON-encoded (json.RawMessage is used as-is; nil becomes {}). It
+// panics if args cannot be encoded.
+func AssistantToolCall(id, name string, args any) Message {
+	return AssistantWithToolCalls(ToolCall(id, name, args))
+}
+
+// AssistantWithToolCalls returns an assistant message containing the given
+// tool calls, e.g. for replaying or synthesizing a tool-calling turn.
+func AssistantWithToolCalls(calls ...ToolCallPart) Message {
+	content := make([]ContentPart, len(calls))
+	for i, c := range calls {
+		content[i] = c
+	}
+	return Message{Role: RoleAssistant, Content: content}
+}
+
+// AssistantWithReasoning returns an assistant message with the model's
+// reasoning followed by its answer, e.g. for replaying a reasoning-model turn.
+func AssistantWithReasoning(reasoning, text string) Message {
+	return Message{Role: RoleAssistant, Content: []ContentPart{ReasoningPart{Text: reasoning}, TextPart{Text: text}}}
+}
+
+// ToolCall builds a ToolCallPart, JSON-encoding args like AssistantToolCall.
+func ToolCall(id, name string, args any) ToolCallPart {
+	var raw json.RawMessage
+	switch v := args.(type) {
+	case nil:
+		raw = json.RawMessage(`{}`)
+	case json.RawMessage:
+		raw = v
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			panic(fmt.Sprintf("tool call %q args: %v", name, err))
+		}
+		raw = b
+	}
+	return ToolCallPart{ID: id, Name: name, Args: raw}
+}
+
 func ToolResult(toolName string, value any) Message {
 	raw, err := json.Marshal(value)
 	if err != nil {
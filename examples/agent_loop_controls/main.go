@@ -18,9 +18,10 @@ func main() {
 	}
 
 	openai.Configure(openai.Config{
-		APIKey:    apiKey,
-		BaseURL:   getenv("OPENAI_BASE_URL", ""),
-		APIPrefix: getenv("OPENAI_API_PREFIX", ""),
+		APIKey:       apiKey,
+		BaseURL:      getenv("OPENAI_BASE_URL", ""),
+		APIPrefix:    getenv("OPENAI_API_PREFIX", ""),
+		DefaultModel: getenv("OPENAI_MODEL", "gpt-4o-mini"),
 	})
 
 	search := ai.NewTool("search", ai.ToolSpec[struct {
@@ -61,7 +62,7 @@ func main() {
 	})
 
 	agent := ai.Agent{
-		Model:         openai.Chat(getenv("OPENAI_MODEL", "gpt-4o-mini")),
+		Model:         openai.DefaultChat(),
 		System:        "You are a concise research assistant. Use the tools when helpful.",
 		Tools:         []ai.Tool{search, summarize},
 		MaxIterations: 10,
@@ -18,9 +18,10 @@ func main() {
 	}
 
 	openai.Configure(openai.Config{
-		APIKey:    apiKey,
-		BaseURL:   getenv("OPENAI_BASE_URL", ""),
-		APIPrefix: getenv("OPENAI_API_PREFIX", ""),
+		APIKey:       apiKey,
+		BaseURL:      getenv("OPENAI_BASE_URL", ""),
+		APIPrefix:    getenv("OPENAI_API_PREFIX", ""),
+		DefaultModel: getenv("OPENAI_MODEL", "gpt-4o-mini"),
 	})
 
 	var history []ai.Message
@@ -44,7 +45,7 @@ func main() {
 	})
 
 	agent := ai.Agent{
-		Model:         openai.Chat(getenv("OPENAI_MODEL", "gpt-4o-mini")),
+		Model:         openai.DefaultChat(),
 		Tools:         []ai.Tool{add},
 		MaxIterations: 5,
 		OnToolProgress: func(e ai.ToolProgressEvent) {
@@ -71,7 +72,7 @@ func main() {
 	}
 
 	// Conversation continuation: append assistant/tool messages produced by the call.
-	history = append(history, resp.Response.Messages...)
+	history = resp.AppendTo(history)
 
 	fmt.Println(resp.Text)
 	_ = history
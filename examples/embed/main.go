@@ -30,15 +30,15 @@ func main() {
 		_, _ = fmt.Sscanf(v, "%d", &dims)
 	}
 
-	var opts map[string]any
+	var dimensions *int
 	if dims > 0 {
-		opts = map[string]any{"openai": openai.EmbeddingOptions{Dimensions: &dims}}
+		dimensions = &dims
 	}
 
 	resp, err := ai.Embed(context.Background(), ai.EmbedRequest{
-		Model:           openai.Embed(model),
-		Input:           input,
-		ProviderOptions: opts,
+		Model:      openai.Embed(model),
+		Input:      input,
+		Dimensions: dimensions,
 	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
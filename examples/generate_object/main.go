@@ -23,9 +23,10 @@ func main() {
 	}
 
 	openai.Configure(openai.Config{
-		APIKey:    apiKey,
-		BaseURL:   getenv("OPENAI_BASE_URL", ""),
-		APIPrefix: getenv("OPENAI_API_PREFIX", ""),
+		APIKey:       apiKey,
+		BaseURL:      getenv("OPENAI_BASE_URL", ""),
+		APIPrefix:    getenv("OPENAI_API_PREFIX", ""),
+		DefaultModel: getenv("OPENAI_MODEL", "gpt-5-mini"),
 	})
 
 	schema := []byte(`{
@@ -40,7 +41,7 @@ func main() {
 
 	resp, err := ai.GenerateObject[Holiday](context.Background(), ai.GenerateObjectRequest[Holiday]{
 		BaseRequest: ai.BaseRequest{
-			Model: openai.Chat(getenv("OPENAI_MODEL", "gpt-5-mini")),
+			Model: openai.DefaultChat(),
 			Messages: []ai.Message{
 				ai.User(getenv("PROMPT", "Invent a new holiday and describe its traditions.")),
 			},
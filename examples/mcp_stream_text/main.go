@@ -24,9 +24,10 @@ func main() {
 	}
 
 	openai.Configure(openai.Config{
-		APIKey:    apiKey,
-		BaseURL:   getenv("OPENAI_BASE_URL", ""),
-		APIPrefix: getenv("OPENAI_API_PREFIX", ""),
+		APIKey:       apiKey,
+		BaseURL:      getenv("OPENAI_BASE_URL", ""),
+		APIPrefix:    getenv("OPENAI_API_PREFIX", ""),
+		DefaultModel: getenv("OPENAI_MODEL", "gpt-5.2-chat"),
 	})
 
 	client, err := mcp.NewClient(mcp.ClientOptions{
@@ -46,7 +47,7 @@ func main() {
 
 	stream, err := ai.StreamText(context.Background(), ai.StreamTextRequest{
 		BaseRequest: ai.BaseRequest{
-			Model: openai.Chat(getenv("OPENAI_MODEL", "gpt-5.2-chat")),
+			Model: openai.DefaultChat(),
 			Tools: tools,
 			Messages: []ai.Message{
 				ai.User(getenv("PROMPT", "What is the weather in Brooklyn, New York?")),
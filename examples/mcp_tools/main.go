@@ -24,9 +24,10 @@ func main() {
 	}
 
 	openai.Configure(openai.Config{
-		APIKey:    apiKey,
-		BaseURL:   getenv("OPENAI_BASE_URL", ""),
-		APIPrefix: getenv("OPENAI_API_PREFIX", ""),
+		APIKey:       apiKey,
+		BaseURL:      getenv("OPENAI_BASE_URL", ""),
+		APIPrefix:    getenv("OPENAI_API_PREFIX", ""),
+		DefaultModel: getenv("OPENAI_MODEL", "gpt-5-mini"),
 	})
 
 	client, err := mcp.NewClient(mcp.ClientOptions{
@@ -51,7 +52,7 @@ func main() {
 
 	resp, err := ai.GenerateText(context.Background(), ai.GenerateTextRequest{
 		BaseRequest: ai.BaseRequest{
-			Model: openai.Chat(getenv("OPENAI_MODEL", "gpt-5-mini")),
+			Model: openai.DefaultChat(),
 			Tools: tools,
 			Messages: []ai.Message{
 				ai.User(getenv("PROMPT", "Use tools to answer: what tools do you have?")),
@@ -22,14 +22,15 @@ func main() {
 	}
 
 	openai.Configure(openai.Config{
-		APIKey:    apiKey,
-		BaseURL:   getenv("OPENAI_BASE_URL", ""),
-		APIPrefix: getenv("OPENAI_API_PREFIX", ""),
+		APIKey:       apiKey,
+		BaseURL:      getenv("OPENAI_BASE_URL", ""),
+		APIPrefix:    getenv("OPENAI_API_PREFIX", ""),
+		DefaultModel: getenv("OPENAI_MODEL", "gpt-4o-mini"),
 	})
 
 	resp, err := ai.GenerateText(context.Background(), ai.GenerateTextRequest{
 		BaseRequest: ai.BaseRequest{
-			Model: openai.Chat(getenv("OPENAI_MODEL", "gpt-4o-mini")),
+			Model: openai.DefaultChat(),
 			Messages: []ai.Message{
 				{
 					Role: ai.RoleUser,
@@ -26,9 +26,10 @@ func main() {
 	}
 
 	openai.Configure(openai.Config{
-		APIKey:    apiKey,
-		BaseURL:   getenv("OPENAI_BASE_URL", ""),
-		APIPrefix: getenv("OPENAI_API_PREFIX", ""),
+		APIKey:       apiKey,
+		BaseURL:      getenv("OPENAI_BASE_URL", ""),
+		APIPrefix:    getenv("OPENAI_API_PREFIX", ""),
+		DefaultModel: getenv("OPENAI_MODEL", "gpt-4.1"),
 	})
 
 	schema := []byte(`{
@@ -51,7 +52,7 @@ func main() {
 
 	stream, err := ai.StreamObject[Recipe](context.Background(), ai.StreamObjectRequest[Recipe]{
 		BaseRequest: ai.BaseRequest{
-			Model: openai.Chat(getenv("OPENAI_MODEL", "gpt-4.1")),
+			Model: openai.DefaultChat(),
 			Messages: []ai.Message{
 				ai.User(getenv("PROMPT", "Generate a lasagna recipe.")),
 			},
@@ -17,14 +17,15 @@ func main() {
 	}
 
 	openai.Configure(openai.Config{
-		APIKey:    apiKey,
-		BaseURL:   getenv("OPENAI_BASE_URL", ""),
-		APIPrefix: getenv("OPENAI_API_PREFIX", ""),
+		APIKey:       apiKey,
+		BaseURL:      getenv("OPENAI_BASE_URL", ""),
+		APIPrefix:    getenv("OPENAI_API_PREFIX", ""),
+		DefaultModel: getenv("OPENAI_MODEL", "gpt-5-mini"),
 	})
 
 	stream, err := ai.StreamText(context.Background(), ai.StreamTextRequest{
 		BaseRequest: ai.BaseRequest{
-			Model: openai.Chat(getenv("OPENAI_MODEL", "gpt-5-mini")),
+			Model: openai.DefaultChat(),
 			Messages: []ai.Message{
 				ai.User(getenv("PROMPT", "Invent a new holiday and describe its traditions.")),
 			},
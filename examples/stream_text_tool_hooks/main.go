@@ -17,9 +17,10 @@ func main() {
 	}
 
 	openai.Configure(openai.Config{
-		APIKey:    apiKey,
-		BaseURL:   getenv("OPENAI_BASE_URL", ""),
-		APIPrefix: getenv("OPENAI_API_PREFIX", ""),
+		APIKey:       apiKey,
+		BaseURL:      getenv("OPENAI_BASE_URL", ""),
+		APIPrefix:    getenv("OPENAI_API_PREFIX", ""),
+		DefaultModel: getenv("OPENAI_MODEL", "gpt-4o-mini"),
 	})
 
 	add := ai.NewTool("add", ai.ToolSpec[struct {
@@ -50,7 +51,7 @@ func main() {
 
 	stream, err := ai.StreamText(context.Background(), ai.StreamTextRequest{
 		BaseRequest: ai.BaseRequest{
-			Model: openai.Chat(getenv("OPENAI_MODEL", "gpt-4o-mini")),
+			Model: openai.DefaultChat(),
 			Messages: []ai.Message{
 				ai.User(getenv("PROMPT", "Use the add tool to add 123 and 456. Then respond with just the final number.")),
 			},
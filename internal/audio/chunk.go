@@ -0,0 +1,179 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Chunk is a self-contained piece of a longer recording.
+type Chunk struct {
+	Data []byte
+	// Offset is where the chunk starts in the original audio, in seconds.
+	Offset float64
+	// Duration is the chunk's length in seconds.
+	Duration float64
+}
+
+// ErrUnsupportedChunkFormat reports audio that SplitWAV cannot split.
+var ErrUnsupportedChunkFormat = errors.New("audio chunking supports PCM WAV only")
+
+const (
+	wavHeaderSize = 12 // "RIFF" <size> "WAVE"
+	silenceFrame  = 20 * time.Millisecond
+	// silenceSearch is the share of each chunk, at its end, searched for the
+	// quietest frame to cut at.
+	silenceSearch = 0.2
+)
+
+type wavInfo struct {
+	fmtChunk   []byte // the complete "fmt " chunk, header included
+	pcm        []byte
+	blockAlign int
+	byteRate   int
+	bits       int
+}
+
+// SplitWAV splits a PCM WAV recording into WAV files of at most maxBytes
+// each. Cuts are placed at the quietest frame near the end of each chunk, so
+// words are rarely split; a chunk starts overlap before the previous cut.
+func SplitWAV(data []byte, maxBytes int, overlap time.Duration) ([]Chunk, error) {
+	info, err := parseWAV(data)
+	if err != nil {
+		return nil, err
+	}
+	header := wavHeaderSize + len(info.fmtChunk) + len(info.fmtChunk)%2 + 8
+	maxPCM := (maxBytes - header) / info.blockAlign * info.blockAlign
+	overlapBytes := int(overlap.Seconds()*float64(info.byteRate)) / info.blockAlign * info.blockAlign
+	if maxPCM <= 0 || overlapBytes >= maxPCM/2 {
+		return nil, fmt.Errorf("chunk size %d is too small for the overlap and WAV header", maxBytes)
+	}
+
+	seconds := func(n int) float64 { return float64(n) / float64(info.byteRate) }
+	var chunks []Chunk
+	for start := 0; ; {
+		end := start + maxPCM
+		if end >= len(info.pcm) {
+			end = len(info.pcm)
+		} else {
+			end = info.quietestCut(end-int(float64(maxPCM)*silenceSearch), end)
+		}
+		chunks = append(chunks, Chunk{
+			Data:     info.encode(info.pcm[start:end]),
+			Offset:   seconds(start),
+			Duration: seconds(end - start),
+		})
+		if end == len(info.pcm) {
+			return chunks, nil
+		}
+		start = end - overlapBytes
+	}
+}
+
+func parseWAV(data []byte) (*wavInfo, error) {
+	if len(data) < wavHeaderSize || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, ErrUnsupportedChunkFormat
+	}
+	info := &wavInfo{}
+	for off := wavHeaderSize; off+8 <= len(data); {
+		id := string(data[off : off+4])
+		size := int(binary.LittleEndian.Uint32(data[off+4 : off+8]))
+		body := off + 8
+		if size > len(data)-body {
+			size = len(data) - body // tolerate truncated or streaming-sized data chunks
+		}
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("invalid WAV fmt chunk")
+			}
+			format := binary.LittleEndian.Uint16(data[body:])
+			if format != 1 && format != 0xFFFE {
+				return nil, ErrUnsupportedChunkFormat
+			}
+			info.fmtChunk = data[off : body+size]
+			info.byteRate = int(binary.LittleEndian.Uint32(data[body+8:]))
+			info.blockAlign = int(binary.LittleEndian.Uint16(data[body+12:]))
+			info.bits = int(binary.LittleEndian.Uint16(data[body+14:]))
+		case "data":
+			info.pcm = data[body : body+size]
+		}
+		off = body + size + size%2
+	}
+	if info.fmtChunk == nil || info.pcm == nil || info.blockAlign <= 0 || info.byteRate <= 0 {
+		return nil, fmt.Errorf("invalid WAV: missing fmt or data chunk")
+	}
+	return info, nil
+}
+
+// quietestCut returns the start of the quietest frame in pcm[from:to], or to
+// when the samples are not 16-bit.
+func (w *wavInfo) quietestCut(from, to int) int {
+	frame := int(silenceFrame.Seconds()*float64(w.byteRate)) / w.blockAlign * w.blockAlign
+	if w.bits != 16 || frame == 0 {
+		return to
+	}
+	from = from / w.blockAlign * w.blockAlign
+	best, bestEnergy := to, int64(-1)
+	for off := from; off+frame <= to; off += frame {
+		var energy int64
+		for i := off; i+1 < off+frame; i += 2 {
+			s := int64(int16(binary.LittleEndian.Uint16(w.pcm[i:])))
+			energy += s * s
+		}
+		if bestEnergy < 0 || energy < bestEnergy {
+			best, bestEnergy = off, energy
+		}
+	}
+	return best
+}
+
+// encode wraps pcm in a WAV file with the original format.
+func (w *wavInfo) encode(pcm []byte) []byte {
+	var b bytes.Buffer
+	pad := len(w.fmtChunk) % 2
+	size := 4 + len(w.fmtChunk) + pad + 8 + len(pcm)
+	b.WriteString("RIFF")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(size))
+	b.WriteString("WAVE")
+	b.Write(w.fmtChunk)
+	if pad == 1 {
+		b.WriteByte(0)
+	}
+	b.WriteString("data")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(len(pcm)))
+	b.Write(pcm)
+	return b.Bytes()
+}
+
+// TrimOverlap drops the words at the start of next that repeat the end of
+// prev, as overlapping chunks transcribed without segment timings do. Words
+// are compared ignoring case and punctuation; the longest repeat is dropped.
+func TrimOverlap(prev, next string) string {
+	p, n := strings.Fields(prev), strings.Fields(next)
+	for k := min(len(p), len(n)); k > 0; k-- {
+		if sameWords(p[len(p)-k:], n[:k]) {
+			return strings.Join(n[k:], " ")
+		}
+	}
+	return next
+}
+
+func sameWords(a, b []string) bool {
+	for i := range a {
+		if normalizeWord(a[i]) != normalizeWord(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeWord(w string) string {
+	return strings.ToLower(strings.TrimFunc(w, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	}))
+}
@@ -0,0 +1,70 @@
+package embeddings
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"sync"
+)
+
+// CacheKey returns a content-addressed key for an embedding: SHA256 over the
+// provider, model, dimensions and input text.
+func CacheKey(providerName, model string, dimensions int, input string) string {
+	h := sha256.New()
+	h.Write([]byte(providerName))
+	h.Write([]byte{0})
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.Itoa(dimensions)))
+	h.Write([]byte{0})
+	h.Write([]byte(input))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// MemoryCache is a concurrency-safe in-memory embedding cache.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	vectors map[string][]float32
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{vectors: map[string][]float32{}}
+}
+
+func (c *MemoryCache) Get(key string) ([]float32, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.vectors[key]
+	if !ok {
+		return nil, false
+	}
+	return append([]float32(nil), v...), true
+}
+
+func (c *MemoryCache) Set(key string, vector []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.vectors[key] = append([]float32(nil), vector...)
+}
+
+// Lookup splits inputs into cached vectors and the (deduplicated) inputs that
+// still need embedding. missingIdx maps each missing input to every position it
+// occupies in inputs.
+func Lookup(get func(key string) ([]float32, bool), keys []string, inputs []string) (vectors [][]float32, missing []string, missingIdx [][]int) {
+	vectors = make([][]float32, len(inputs))
+	pos := map[string]int{}
+	for i, in := range inputs {
+		if v, ok := get(keys[i]); ok {
+			vectors[i] = v
+			continue
+		}
+		if j, ok := pos[keys[i]]; ok {
+			missingIdx[j] = append(missingIdx[j], i)
+			continue
+		}
+		pos[keys[i]] = len(missing)
+		missing = append(missing, in)
+		missingIdx = append(missingIdx, []int{i})
+	}
+	return vectors, missing, missingIdx
+}
@@ -9,12 +9,19 @@ import (
 	"github.com/bitop-dev/ai/internal/tools"
 )
 
-func EmbedMany(ctx context.Context, ep provider.EmbeddingProvider, req provider.EmbeddingRequest, maxParallel int) (provider.EmbeddingResponse, error) {
+// EmbedMany embeds req.Inputs, splitting them into up to maxParallel
+// concurrent batches. onProgress, if non-nil, is called with the number of
+// embedded inputs after each batch completes; calls are serialized.
+func EmbedMany(ctx context.Context, ep provider.EmbeddingProvider, req provider.EmbeddingRequest, maxParallel int, onProgress func(done, total int)) (provider.EmbeddingResponse, error) {
 	if len(req.Inputs) == 0 {
 		return provider.EmbeddingResponse{}, fmt.Errorf("input is required")
 	}
 	if maxParallel <= 1 || len(req.Inputs) <= 1 {
-		return ep.Embed(ctx, req)
+		resp, err := ep.Embed(ctx, req)
+		if err == nil && onProgress != nil {
+			onProgress(len(req.Inputs), len(req.Inputs))
+		}
+		return resp, err
 	}
 	if maxParallel < 2 {
 		maxParallel = 2
@@ -32,9 +39,13 @@ func EmbedMany(ctx context.Context, ep provider.EmbeddingProvider, req provider.
 
 	outVectors := make([][]float32, len(req.Inputs))
 	var aggUsage provider.Usage
+	done := 0
 
+	// Every batch shares the same options, so warnings are taken from the
+	// first batch's response only, as are RawResponse and RequestID.
 	var firstRaw []byte
-	var firstRawOnce sync.Once
+	var firstRequestID string
+	var warnings []string
 
 	var mu sync.Mutex
 	var wg sync.WaitGroup
@@ -63,9 +74,16 @@ func EmbedMany(ctx context.Context, ep provider.EmbeddingProvider, req provider.
 				outVectors[b.start+i] = resp.Vectors[i]
 			}
 			aggUsage = tools.AddUsage(aggUsage, resp.Usage)
+			done += len(subReq.Inputs)
+			if onProgress != nil {
+				onProgress(done, len(req.Inputs))
+			}
+			if b.start == 0 {
+				firstRaw = resp.RawResponse
+				firstRequestID = resp.RequestID
+				warnings = resp.Warnings
+			}
 			mu.Unlock()
-
-			firstRawOnce.Do(func() { firstRaw = resp.RawResponse })
 		}(b)
 	}
 
@@ -81,7 +99,9 @@ func EmbedMany(ctx context.Context, ep provider.EmbeddingProvider, req provider.
 	return provider.EmbeddingResponse{
 		Vectors:     outVectors,
 		Usage:       aggUsage,
+		Warnings:    warnings,
 		RawResponse: firstRaw,
+		RequestID:   firstRequestID,
 	}, nil
 }
 
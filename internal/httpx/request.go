@@ -23,9 +23,12 @@ func Do(ctx context.Context, client *http.Client, method, url string, body []byt
 			return nil, err
 		}
 		req.Header = headers.Clone()
+		if err := policy.edit(req); err != nil {
+			return nil, err
+		}
 
 		resp, err := client.Do(req)
-		if err == nil && resp != nil && !shouldRetry(resp.StatusCode) {
+		if err == nil && resp != nil && !policy.retryResponse(resp) {
 			return resp, nil
 		}
 
@@ -40,11 +43,11 @@ func Do(ctx context.Context, client *http.Client, method, url string, body []byt
 		if attempt == policy.MaxRetries {
 			break
 		}
-		if err != nil && !isRetryableNetErr(err) {
+		if err != nil && !policy.retryErr(err) {
 			break
 		}
 
-		sleep := backoffWithJitter(attempt, policy.MinBackoff, policy.MaxBackoff)
+		sleep := BackoffWithJitter(attempt, policy.MinBackoff, policy.MaxBackoff)
 		if resp != nil {
 			if ra, ok := retryAfter(resp.Header.Get("Retry-After")); ok && ra > sleep {
 				sleep = ra
@@ -19,6 +19,57 @@ type RetryPolicy struct {
 	MaxRetries int
 	MinBackoff time.Duration
 	MaxBackoff time.Duration
+
+	// Classify, when set, replaces the default decision of whether a failed
+	// attempt is retried. It gets the status and body of non-2xx responses
+	// (err nil), or the transport error (status 0, body nil). Successful
+	// responses are never retried.
+	Classify func(status int, body []byte, err error) bool
+
+	// Edit, when set, is called with each attempt's request just before it is
+	// sent, after all headers are set. An error aborts the call (no retry)
+	// and is returned wrapped in *EditError.
+	Edit func(req *http.Request) error
+}
+
+// EditError reports a failure of RetryPolicy.Edit.
+type EditError struct {
+	Err error
+}
+
+func (e *EditError) Error() string { return "edit request: " + e.Err.Error() }
+func (e *EditError) Unwrap() error { return e.Err }
+
+func (p RetryPolicy) edit(req *http.Request) error {
+	if p.Edit == nil {
+		return nil
+	}
+	if err := p.Edit(req); err != nil {
+		return &EditError{Err: err}
+	}
+	return nil
+}
+
+// retryResponse reports whether resp should be retried. With a classifier the
+// body of a failed response is buffered, so it stays readable when returned.
+func (p RetryPolicy) retryResponse(resp *http.Response) bool {
+	if p.Classify == nil {
+		return shouldRetry(resp.StatusCode)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return false
+	}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+	return p.Classify(resp.StatusCode, b, nil)
+}
+
+func (p RetryPolicy) retryErr(err error) bool {
+	if p.Classify != nil {
+		return p.Classify(0, nil, err)
+	}
+	return isRetryableNetErr(err)
 }
 
 func DoJSON(ctx context.Context, client *http.Client, method, url string, body []byte, headers http.Header, policy RetryPolicy) (*http.Response, error) {
@@ -51,9 +102,12 @@ func DoJSON(ctx context.Context, client *http.Client, method, url string, body [
 		if req.Header.Get("Accept") == "" {
 			req.Header.Set("Accept", "application/json")
 		}
+		if err := policy.edit(req); err != nil {
+			return nil, err
+		}
 
 		resp, err := client.Do(req)
-		if err == nil && resp != nil && !shouldRetry(resp.StatusCode) {
+		if err == nil && resp != nil && !policy.retryResponse(resp) {
 			return resp, nil
 		}
 
@@ -69,11 +123,11 @@ func DoJSON(ctx context.Context, client *http.Client, method, url string, body [
 			break
 		}
 
-		if err != nil && !isRetryableNetErr(err) {
+		if err != nil && !policy.retryErr(err) {
 			break
 		}
 
-		sleep := backoffWithJitter(attempt, policy.MinBackoff, policy.MaxBackoff)
+		sleep := BackoffWithJitter(attempt, policy.MinBackoff, policy.MaxBackoff)
 		if resp != nil {
 			if ra, ok := retryAfter(resp.Header.Get("Retry-After")); ok && ra > sleep {
 				sleep = ra
@@ -117,7 +171,9 @@ var rng = struct {
 	r: rand.New(rand.NewSource(time.Now().UnixNano())),
 }
 
-func backoffWithJitter(attempt int, min, max time.Duration) time.Duration {
+// BackoffWithJitter returns a random delay of up to min doubled attempt
+// times, capped at max ("full jitter").
+func BackoffWithJitter(attempt int, min, max time.Duration) time.Duration {
 	backoff := min
 	for i := 0; i < attempt; i++ {
 		backoff *= 2
@@ -21,6 +21,9 @@ func DefaultMaxImagesPerCall(model string) int {
 	}
 }
 
+// GenerateBatched generates n images in calls of at most maxPerCall and merges
+// the responses. RawResponse and RequestID are from the first batch's
+// response.
 func GenerateBatched(
 	ctx context.Context,
 	ip provider.ImageProvider,
@@ -28,9 +31,9 @@ func GenerateBatched(
 	n int,
 	maxPerCall int,
 	maxParallel int,
-) ([]provider.Image, []string, map[string]any, []byte, error) {
+) (provider.GenerateImageResponse, error) {
 	if n <= 0 {
-		return nil, nil, nil, nil, fmt.Errorf("n must be > 0")
+		return provider.GenerateImageResponse{}, fmt.Errorf("n must be > 0")
 	}
 	if maxPerCall <= 0 {
 		maxPerCall = 1
@@ -58,6 +61,7 @@ func GenerateBatched(
 	var warnings []string
 	var providerMetadata map[string]any
 	var firstRaw []byte
+	var firstRequestID string
 
 	type batchResult struct {
 		start int
@@ -92,13 +96,14 @@ func GenerateBatched(
 
 	for err := range errCh {
 		if err != nil {
-			return nil, nil, nil, nil, err
+			return provider.GenerateImageResponse{}, err
 		}
 	}
 
 	for r := range resCh {
-		if firstRaw == nil && len(r.resp.RawResponse) > 0 {
+		if r.start == 0 {
 			firstRaw = r.resp.RawResponse
+			firstRequestID = r.resp.RequestID
 		}
 		if r.resp.ProviderMetadata != nil {
 			providerMetadata = mergeProviderMetadata(providerMetadata, r.start, r.resp.ProviderMetadata)
@@ -119,7 +124,14 @@ func GenerateBatched(
 		compact = append(compact, img)
 	}
 
-	return compact, warnings, providerMetadata, firstRaw, nil
+	return provider.GenerateImageResponse{
+		N:                len(compact),
+		Images:           compact,
+		Warnings:         warnings,
+		ProviderMetadata: providerMetadata,
+		RawResponse:      firstRaw,
+		RequestID:        firstRequestID,
+	}, nil
 }
 
 func mergeProviderMetadata(dst map[string]any, start int, src map[string]any) map[string]any {
@@ -0,0 +1,163 @@
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// FieldScanner reports object fields as their values finish parsing in a
+// stream of accumulated JSON. Paths are dot-separated member names, with
+// array elements addressed by index ("items.0.name"). A container is reported
+// after all of its children.
+type FieldScanner struct {
+	onField func(path string, value any)
+
+	buf   []byte
+	stack []scanFrame
+
+	inString    bool
+	escape      bool
+	strStart    int
+	scalarStart int
+}
+
+type scanFrame struct {
+	array   bool
+	path    string
+	start   int
+	key     string
+	haveKey bool
+	index   int
+}
+
+func NewFieldScanner(onField func(path string, value any)) *FieldScanner {
+	return &FieldScanner{onField: onField, scalarStart: -1}
+}
+
+// Feed consumes the accumulated raw JSON. Input that does not extend the
+// previously fed bytes (a new attempt or tool loop step) restarts scanning.
+func (s *FieldScanner) Feed(raw []byte) {
+	if s == nil || s.onField == nil {
+		return
+	}
+	if len(raw) < len(s.buf) || !bytes.Equal(raw[:len(s.buf)], s.buf) {
+		s.reset()
+	}
+	start := len(s.buf)
+	s.buf = append(s.buf, raw[start:]...)
+	for i := start; i < len(s.buf); i++ {
+		s.scan(i)
+	}
+}
+
+func (s *FieldScanner) reset() {
+	s.buf = s.buf[:0]
+	s.stack = s.stack[:0]
+	s.inString = false
+	s.escape = false
+	s.scalarStart = -1
+}
+
+func (s *FieldScanner) scan(i int) {
+	c := s.buf[i]
+	if s.inString {
+		switch {
+		case s.escape:
+			s.escape = false
+		case c == '\\':
+			s.escape = true
+		case c == '"':
+			s.inString = false
+			s.endString(s.buf[s.strStart : i+1])
+		}
+		return
+	}
+	if len(s.stack) == 0 && c != '{' && c != '[' {
+		// Prose before the root container, a scalar root, or trailing text:
+		// none of it holds object fields.
+		return
+	}
+	if s.scalarStart >= 0 {
+		switch c {
+		case ',', '}', ']', ' ', '\t', '\n', '\r':
+			s.emit(s.valuePath(), s.buf[s.scalarStart:i])
+			s.scalarStart = -1
+		default:
+			return
+		}
+	}
+
+	switch c {
+	case '"':
+		s.inString = true
+		s.strStart = i
+	case '{', '[':
+		f := scanFrame{array: c == '[', start: i}
+		if len(s.stack) > 0 {
+			f.path = s.valuePath()
+		}
+		s.stack = append(s.stack, f)
+	case '}', ']':
+		if len(s.stack) == 0 {
+			return
+		}
+		f := s.stack[len(s.stack)-1]
+		s.stack = s.stack[:len(s.stack)-1]
+		s.emit(f.path, s.buf[f.start:i+1])
+	case ',':
+		if len(s.stack) == 0 {
+			return
+		}
+		top := &s.stack[len(s.stack)-1]
+		if top.array {
+			top.index++
+		} else {
+			top.haveKey = false
+		}
+	case ':', ' ', '\t', '\n', '\r':
+	default:
+		s.scalarStart = i
+	}
+}
+
+func (s *FieldScanner) endString(raw []byte) {
+	if len(s.stack) == 0 {
+		return
+	}
+	top := &s.stack[len(s.stack)-1]
+	if !top.array && !top.haveKey {
+		var key string
+		_ = json.Unmarshal(raw, &key)
+		top.key = key
+		top.haveKey = true
+		return
+	}
+	s.emit(s.valuePath(), raw)
+}
+
+// valuePath is the path of the value currently being parsed in the innermost
+// container.
+func (s *FieldScanner) valuePath() string {
+	top := s.stack[len(s.stack)-1]
+	seg := top.key
+	if top.array {
+		seg = strconv.Itoa(top.index)
+	}
+	if top.path == "" {
+		return seg
+	}
+	return top.path + "." + seg
+}
+
+// emit reports a completed value. The root container itself is not a field.
+func (s *FieldScanner) emit(path string, raw []byte) {
+	if len(s.stack) == 0 {
+		return
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return
+	}
+	s.onField(path, v)
+}
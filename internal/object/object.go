@@ -1,14 +1,17 @@
 package object
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"unicode/utf8"
 
 	"github.com/bitop-dev/ai/internal/provider"
 	"github.com/bitop-dev/ai/internal/schema"
 	"github.com/bitop-dev/ai/internal/tools"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 )
 
 const ReturnToolName = "__ai_return_json"
@@ -25,6 +28,37 @@ type Options struct {
 	Strict        bool
 	MaxRetries    int
 	MaxIterations int
+
+	// OnProgress, when set, makes Generate stream each step and report the
+	// accumulated return-tool arguments.
+	OnProgress func(raw json.RawMessage)
+
+	// OnRawDelta receives the model's text output as it arrives (prose,
+	// JSON-only fallback text), for debugging. Like OnProgress, it makes
+	// Generate stream each step.
+	OnRawDelta func(text string)
+
+	// AbortOnSchemaViolation makes Stream validate each field as it
+	// completes and end with a *schema.FieldError at the first violation.
+	AbortOnSchemaViolation bool
+
+	// PatchRepair makes Generate fix a strict-mode schema violation by asking
+	// the model for a JSON Patch of the invalid fields instead of the whole
+	// object. Each patch call counts as a retry; if patching fails, the
+	// remaining retries regenerate the object as usual.
+	PatchRepair bool
+
+	// CorrectionTemplate, when set, builds the system message sent after an
+	// invalid object in place of the default correction prompt.
+	CorrectionTemplate func(err error, raw json.RawMessage) string
+}
+
+// correction returns the retry instruction for an object that failed with err.
+func (o Options) correction(err error, raw json.RawMessage) string {
+	if o.CorrectionTemplate != nil {
+		return o.CorrectionTemplate(err, raw)
+	}
+	return correctionPrompt(err, raw)
 }
 
 func Generate[T any](ctx context.Context, p provider.Provider, req provider.Request, exec tools.Executor, schemaJSON json.RawMessage, opts Options) (GenerateResult[T], error) {
@@ -48,13 +82,23 @@ func Generate[T any](ctx context.Context, p provider.Provider, req provider.Requ
 	msgs := append([]provider.Message(nil), req.Messages...)
 	msgs = prependSystem(msgs)
 
+	returnSchema, wrapped := returnToolSchema(p, schemaJSON)
 	toolsDefs := append([]provider.ToolDefinition(nil), req.Tools...)
 	toolsDefs = append(toolsDefs, provider.ToolDefinition{
 		Name:        ReturnToolName,
 		Description: "Return the final JSON object result.",
-		InputSchema: schemaJSON,
+		InputSchema: returnSchema,
 	})
 
+	onProgress := opts.OnProgress
+	if wrapped && onProgress != nil {
+		onProgress = func(raw json.RawMessage) {
+			if v := unwrapPartialValue(raw); len(v) > 0 {
+				opts.OnProgress(v)
+			}
+		}
+	}
+
 	baseReq := req
 	baseReq.Messages = nil
 	baseReq.Tools = nil
@@ -74,7 +118,7 @@ func Generate[T any](ctx context.Context, p provider.Provider, req provider.Requ
 		callReq.Messages = append(callReq.Messages, retryMessages...)
 		callReq.Tools = append([]provider.ToolDefinition(nil), toolsDefs...)
 
-		resp, err := p.Generate(ctx, callReq)
+		resp, err := generateStep(ctx, p, callReq, onProgress, opts.OnRawDelta)
 		if err != nil {
 			if errors.Is(err, provider.ErrToolsUnsupported) {
 				return generateJSONOnly[T](ctx, p, baseReq, messages, schemaJSON, opts)
@@ -82,13 +126,36 @@ func Generate[T any](ctx context.Context, p provider.Provider, req provider.Requ
 			return GenerateResult[T]{}, err
 		}
 
+		resp.Message = tools.StripHostedCalls(resp.Message, toolsDefs)
 		last = resp
 		agg = tools.AddUsage(agg, resp.Usage)
 		messages = append(messages, resp.Message)
 
 		if raw, ok := findReturnArgs(resp.Message); ok {
+			if wrapped {
+				raw = unwrapValue(raw)
+			}
 			var obj T
-			if err := schema.Validate(schemaJSON, raw); err != nil {
+			err := schema.Validate(schemaJSON, raw)
+			var ve *jsonschema.ValidationError
+			if err != nil && opts.Strict && opts.PatchRepair && retryCount < opts.MaxRetries && errors.As(err, &ve) {
+				// The reply carries a return call without a result; leave it
+				// out and show the model the JSON in the prompt instead.
+				patched, n, usage, perr := repairByPatch(ctx, p, baseReq, messages[:len(messages)-1], schemaJSON, raw, err, opts.MaxRetries-retryCount)
+				retryCount += n
+				agg = tools.AddUsage(agg, usage)
+				switch {
+				case perr == nil:
+					raw, err = patched, nil
+				case !errors.Is(perr, errPatchFailed):
+					return GenerateResult[T]{}, perr
+				case patched != nil:
+					// Regenerate from the partly repaired JSON.
+					raw = patched
+					err = schema.Validate(schemaJSON, raw)
+				}
+			}
+			if err != nil {
 				if !opts.Strict {
 					return GenerateResult[T]{Raw: raw, LastResponse: last, Usage: agg}, err
 				}
@@ -96,7 +163,7 @@ func Generate[T any](ctx context.Context, p provider.Provider, req provider.Requ
 					return GenerateResult[T]{}, fmt.Errorf("invalid json: %w", err)
 				}
 				retryCount++
-				retryMessages = []provider.Message{systemText(correctionPrompt(err, raw))}
+				retryMessages = []provider.Message{systemText(opts.correction(err, raw))}
 				continue
 			}
 			if err := json.Unmarshal(raw, &obj); err != nil {
@@ -107,7 +174,7 @@ func Generate[T any](ctx context.Context, p provider.Provider, req provider.Requ
 					return GenerateResult[T]{}, fmt.Errorf("invalid json: %w", err)
 				}
 				retryCount++
-				retryMessages = []provider.Message{systemText(correctionPrompt(err, raw))}
+				retryMessages = []provider.Message{systemText(opts.correction(err, raw))}
 				continue
 			}
 			return GenerateResult[T]{Object: obj, Raw: raw, LastResponse: last, Usage: agg}, nil
@@ -144,7 +211,7 @@ func Generate[T any](ctx context.Context, p provider.Provider, req provider.Requ
 		if exec == nil {
 			return GenerateResult[T]{}, fmt.Errorf("tool calls requested but no executor provided")
 		}
-		results, err := exec(ctx, nonReturn)
+		results, err := exec(tools.WithStepInfo(ctx, tools.StepInfo{StepNumber: iter, Messages: messages}), nonReturn)
 		if err != nil {
 			return GenerateResult[T]{}, err
 		}
@@ -155,6 +222,66 @@ func Generate[T any](ctx context.Context, p provider.Provider, req provider.Requ
 	return GenerateResult[T]{}, fmt.Errorf("tool loop exceeded max iterations (%d)", opts.MaxIterations)
 }
 
+// generateStep runs one model call. With onProgress or onRawDelta set it
+// streams the call and reports the accumulated return-tool arguments and the
+// text deltas as they arrive. Providers that cannot stream report them once,
+// from the complete response.
+func generateStep(ctx context.Context, p provider.Provider, req provider.Request, onProgress func(raw json.RawMessage), onRawDelta func(text string)) (provider.Response, error) {
+	if onProgress == nil && onRawDelta == nil {
+		return p.Generate(ctx, req)
+	}
+	s, err := p.Stream(ctx, req)
+	if errors.Is(err, provider.ErrStreamingUnsupported) {
+		resp, err := p.Generate(ctx, req)
+		if err != nil {
+			return provider.Response{}, err
+		}
+		if txt := extractText(resp.Message); txt != "" && onRawDelta != nil {
+			onRawDelta(txt)
+		}
+		if args, ok := findReturnArgs(resp.Message); ok && onProgress != nil {
+			onProgress(append(json.RawMessage(nil), args...))
+		}
+		return resp, nil
+	}
+	if err != nil {
+		return provider.Response{}, err
+	}
+	defer s.Close()
+
+	names := map[int]string{}
+	var raw []byte
+	for s.Next() {
+		delta := s.Delta()
+		if delta.Text != "" && onRawDelta != nil {
+			onRawDelta(delta.Text)
+		}
+		for _, d := range delta.ToolCalls {
+			if d.Name != "" {
+				names[d.Index] = d.Name
+			}
+			if names[d.Index] != ReturnToolName || d.ArgumentsDelta == "" {
+				continue
+			}
+			raw = append(raw, d.ArgumentsDelta...)
+			if onProgress != nil {
+				onProgress(append(json.RawMessage(nil), raw...))
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return provider.Response{}, err
+	}
+	final := s.Final()
+	if final == nil {
+		return provider.Response{}, fmt.Errorf("stream ended without final response")
+	}
+	if args, ok := findReturnArgs(final.Message); ok && onProgress != nil && string(args) != string(raw) {
+		onProgress(append(json.RawMessage(nil), args...))
+	}
+	return *final, nil
+}
+
 type Stream[T any] struct {
 	ctx  context.Context
 	p    provider.Provider
@@ -173,6 +300,10 @@ type Stream[T any] struct {
 
 	rawArgs []byte
 	partial map[string]any
+	wrapped bool
+
+	// fields validates completed fields when AbortOnSchemaViolation is set.
+	fields *FieldScanner
 
 	finalObj *T
 	finalRaw json.RawMessage
@@ -203,8 +334,28 @@ func NewStream[T any](ctx context.Context, p provider.Provider, req provider.Req
 	s.baseReq.Messages = nil
 	s.baseReq.Tools = nil
 
+	if toolNameCollides(s.tools, ReturnToolName) {
+		s.err = fmt.Errorf("tool name collision: %q is reserved", ReturnToolName)
+		return s
+	}
+
 	s.messages = prependSystem(s.messages)
-	s.tools = append(s.tools, provider.ToolDefinition{Name: ReturnToolName, Description: "Return the final JSON object result.", InputSchema: schemaJSON})
+	var returnSchema json.RawMessage
+	returnSchema, s.wrapped = returnToolSchema(p, schemaJSON)
+	s.tools = append(s.tools, provider.ToolDefinition{Name: ReturnToolName, Description: "Return the final JSON object result.", InputSchema: returnSchema})
+
+	if opts.AbortOnSchemaViolation {
+		v, err := schema.NewFieldValidator(schemaJSON)
+		if err != nil {
+			s.err = err
+			return s
+		}
+		s.fields = NewFieldScanner(func(path string, value any) {
+			if s.err == nil {
+				s.err = v.ValidateField(path, value)
+			}
+		})
+	}
 
 	return s
 }
@@ -230,11 +381,25 @@ func (s *Stream[T]) Next() bool {
 				s.err = err
 				return false
 			}
+			if s.fallback {
+				s.emitted = true
+				return true
+			}
 		}
 
 		if s.cur.Next() {
 			d := s.cur.Delta()
-			if s.consumeToolDeltas(d.ToolCalls) {
+			if d.Text != "" && s.opts.OnRawDelta != nil {
+				s.opts.OnRawDelta(d.Text)
+			}
+			advanced := s.consumeToolDeltas(d.ToolCalls)
+			if s.err != nil {
+				// A field violated the schema: stop generating.
+				_ = s.cur.Close()
+				s.cur = nil
+				return false
+			}
+			if advanced {
 				return true
 			}
 			continue
@@ -255,9 +420,13 @@ func (s *Stream[T]) Next() bool {
 		}
 
 		s.usage = tools.AddUsage(s.usage, final.Usage)
+		final.Message = tools.StripHostedCalls(final.Message, s.tools)
 		s.messages = append(s.messages, final.Message)
 
 		if raw, ok := findReturnArgs(final.Message); ok {
+			if s.wrapped {
+				raw = unwrapValue(raw)
+			}
 			s.finalRaw = raw
 			if err := schema.Validate(s.schemaJSON, raw); err != nil {
 				if s.opts.Strict {
@@ -287,7 +456,7 @@ func (s *Stream[T]) Next() bool {
 			s.err = fmt.Errorf("tool calls requested but no executor provided")
 			return false
 		}
-		results, err := s.exec(s.ctx, nonReturn)
+		results, err := s.exec(tools.WithStepInfo(s.ctx, tools.StepInfo{StepNumber: s.iter, Messages: s.messages}), nonReturn)
 		if err != nil {
 			s.err = err
 			return false
@@ -308,6 +477,9 @@ func (s *Stream[T]) Raw() json.RawMessage {
 	if s.fallback {
 		return s.finalRaw
 	}
+	if s.wrapped {
+		return unwrapPartialValue(s.rawArgs)
+	}
 	return append(json.RawMessage(nil), s.rawArgs...)
 }
 
@@ -315,6 +487,27 @@ func (s *Stream[T]) Partial() map[string]any {
 	return s.partial
 }
 
+// PartialTyped decodes the completed values of the JSON streamed so far into
+// a T; fields still streaming keep their zero value. Values of the wrong type
+// are skipped. It returns nil while nothing can be decoded yet.
+func (s *Stream[T]) PartialTyped() *T {
+	if s.finalObj != nil {
+		return s.finalObj
+	}
+	raw := CompletedPrefix(s.Raw())
+	if raw == nil {
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			return nil
+		}
+	}
+	return &v
+}
+
 func (s *Stream[T]) Object() *T {
 	return s.finalObj
 }
@@ -339,8 +532,9 @@ func (s *Stream[T]) start() error {
 	cur, err := s.p.Stream(s.ctx, callReq)
 	if err != nil {
 		if errors.Is(err, provider.ErrToolsUnsupported) {
-			// Non-stream fallback: run Generate and expose as a single event.
-			r, err2 := Generate[T](s.ctx, s.p, callReq, s.exec, s.schemaJSON, s.opts)
+			// Non-stream fallback: run the JSON-only mode and expose it as a
+			// single event. s.messages[0] is the return-tool instruction.
+			r, err2 := generateJSONOnly[T](s.ctx, s.p, s.baseReq, s.messages[1:], s.schemaJSON, s.opts)
 			if err2 != nil {
 				var pe *provider.Error
 				if errors.As(err2, &pe) || s.opts.Strict {
@@ -376,9 +570,14 @@ func (s *Stream[T]) consumeToolDeltas(deltas []provider.ToolCallDelta) bool {
 		s.rawArgs = append(s.rawArgs, d.ArgumentsDelta...)
 		advanced = true
 
-		if json.Valid(s.rawArgs) {
+		raw := json.RawMessage(s.rawArgs)
+		if s.wrapped {
+			raw = unwrapPartialValue(raw)
+		}
+		s.fields.Feed(raw)
+		if json.Valid(raw) {
 			var m map[string]any
-			if err := json.Unmarshal(s.rawArgs, &m); err == nil {
+			if err := json.Unmarshal(raw, &m); err == nil {
 				s.partial = m
 			}
 		}
@@ -407,6 +606,12 @@ func generateJSONOnly[T any](ctx context.Context, p provider.Provider, baseReq p
 		agg = tools.AddUsage(agg, resp.Usage)
 
 		raw := json.RawMessage(extractText(resp.Message))
+		if opts.OnRawDelta != nil && len(raw) > 0 {
+			opts.OnRawDelta(string(raw))
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(raw)
+		}
 		var obj T
 		if err := schema.Validate(schemaJSON, raw); err != nil {
 			if !opts.Strict {
@@ -415,7 +620,7 @@ func generateJSONOnly[T any](ctx context.Context, p provider.Provider, baseReq p
 			if attempt == opts.MaxRetries {
 				return GenerateResult[T]{}, fmt.Errorf("invalid json: %w", err)
 			}
-			msgs = append(msgs, systemText(correctionPrompt(err, raw)))
+			msgs = append(msgs, systemText(opts.correction(err, raw)))
 			continue
 		}
 		if err := json.Unmarshal(raw, &obj); err != nil {
@@ -425,7 +630,7 @@ func generateJSONOnly[T any](ctx context.Context, p provider.Provider, baseReq p
 			if attempt == opts.MaxRetries {
 				return GenerateResult[T]{}, fmt.Errorf("invalid json: %w", err)
 			}
-			msgs = append(msgs, systemText(correctionPrompt(err, raw)))
+			msgs = append(msgs, systemText(opts.correction(err, raw)))
 			continue
 		}
 		return GenerateResult[T]{Object: obj, Raw: raw, LastResponse: last, Usage: agg}, nil
@@ -465,6 +670,22 @@ func findReturnArgs(m provider.Message) (json.RawMessage, bool) {
 	return nil, false
 }
 
+// StripReturnCall removes the synthetic return tool call from m, so callers
+// never see the reserved name in results.
+func StripReturnCall(m provider.Message) provider.Message {
+	out := m
+	out.Content = make([]provider.ContentPart, 0, len(m.Content))
+	for _, p := range m.Content {
+		if tc, ok := p.(provider.ToolCallPart); ok && tc.Name == ReturnToolName {
+			continue
+		}
+		out.Content = append(out.Content, p)
+	}
+	return out
+}
+
+// filterNonReturn drops the synthetic return tool call so it is never passed
+// to the executor; its arguments are the result, not a call to run.
 func filterNonReturn(calls []provider.ToolCallPart) []provider.ToolCallPart {
 	out := make([]provider.ToolCallPart, 0, len(calls))
 	for _, c := range calls {
@@ -476,6 +697,66 @@ func filterNonReturn(calls []provider.ToolCallPart) []provider.ToolCallPart {
 	return out
 }
 
+// providerSchema returns the schema to send to p: local $refs are inlined
+// unless the provider accepts them. Recursive schemas cannot be inlined and are
+// sent unchanged. Validation always uses the original schema.
+func providerSchema(p provider.Provider, schemaJSON json.RawMessage) json.RawMessage {
+	if rp, ok := provider.As[provider.SchemaRefsProvider](p); ok && rp.SupportsSchemaRefs() {
+		return schemaJSON
+	}
+	if !schema.HasRefs(schemaJSON) {
+		return schemaJSON
+	}
+	inlined, err := schema.InlineRefs(schemaJSON)
+	if err != nil {
+		return schemaJSON
+	}
+	return inlined
+}
+
+// returnToolSchema returns the return tool's input schema. Function
+// parameters must be an object schema, so any other root (e.g. a oneOf/anyOf
+// union) is wrapped as {"value": ...}; wrapped reports whether the tool's
+// arguments must be unwrapped before validation and decoding.
+func returnToolSchema(p provider.Provider, schemaJSON json.RawMessage) (json.RawMessage, bool) {
+	w, wrapped, err := schema.WrapValue(schemaJSON)
+	if err != nil || !wrapped {
+		return providerSchema(p, schemaJSON), false
+	}
+	return providerSchema(p, w), true
+}
+
+// unwrapValue extracts the "value" member of wrapped return-tool arguments.
+// Arguments without one are returned unchanged so validation reports the
+// mismatch.
+func unwrapValue(raw json.RawMessage) json.RawMessage {
+	var args map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return raw
+	}
+	if v, ok := args["value"]; ok {
+		return v
+	}
+	return raw
+}
+
+// unwrapPartialValue strips the {"value": prefix from streamed wrapped
+// arguments (and the closing brace once complete), so progress reports see
+// the value's own JSON prefix.
+func unwrapPartialValue(raw json.RawMessage) json.RawMessage {
+	if json.Valid(raw) {
+		return unwrapValue(raw)
+	}
+	rest := bytes.TrimLeft(raw, " \t\r\n")
+	for _, tok := range []string{"{", `"value"`, ":"} {
+		if !bytes.HasPrefix(rest, []byte(tok)) {
+			return nil
+		}
+		rest = bytes.TrimLeft(rest[len(tok):], " \t\r\n")
+	}
+	return append(json.RawMessage(nil), rest...)
+}
+
 func prependSystem(msgs []provider.Message) []provider.Message {
 	sys := systemText("You must return the final result by calling the tool " + ReturnToolName + " with arguments matching the provided JSON schema. Do not return the result as plain text.")
 	return append([]provider.Message{sys}, msgs...)
@@ -491,12 +772,25 @@ func mustCallPrompt() string {
 }
 
 func correctionPrompt(err error, raw json.RawMessage) string {
-	const max = 4000
+	return fmt.Sprintf("The previous JSON was invalid or did not match the schema.\nError:\n%s\nPrevious JSON:\n%s\nReturn ONLY corrected JSON (no extra text).", err.Error(), promptJSON(raw))
+}
+
+// promptJSONMax bounds how much of a previous reply is quoted back to the
+// model.
+const promptJSONMax = 4000
+
+// promptJSON returns raw for quoting in a prompt, cut at a rune boundary
+// after promptJSONMax bytes with a marker saying so.
+func promptJSON(raw json.RawMessage) string {
 	s := string(raw)
-	if len(s) > max {
-		s = s[:max] + "…"
+	if len(s) <= promptJSONMax {
+		return s
+	}
+	cut := promptJSONMax
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
 	}
-	return fmt.Sprintf("The previous JSON was invalid or did not match the schema.\nError:\n%s\nPrevious JSON:\n%s\nReturn ONLY corrected JSON (no extra text).", err.Error(), s)
+	return fmt.Sprintf("%s\n[truncated: showing %d of %d bytes]", s[:cut], cut, len(s))
 }
 
 func systemText(text string) provider.Message {
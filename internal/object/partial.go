@@ -0,0 +1,105 @@
+package object
+
+// CompletedPrefix turns the accumulated JSON of a streaming object into valid
+// JSON holding only its completed values: it cuts raw after the last complete
+// value (dropping a trailing key without a value, an unterminated string or a
+// number that may still grow) and closes the containers still open. It
+// returns nil until the root object or array has started.
+func CompletedPrefix(raw []byte) []byte {
+	type frame struct {
+		array   bool
+		haveKey bool
+	}
+	var (
+		stack    []frame
+		inString bool
+		escape   bool
+		// inScalar is set while reading a number or literal.
+		inScalar bool
+
+		cut     = -1
+		closers []byte
+	)
+	// mark records that raw[:end] ends on a complete value in the current
+	// container.
+	mark := func(end int) {
+		cut = end
+		closers = closers[:0]
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].array {
+				closers = append(closers, ']')
+			} else {
+				closers = append(closers, '}')
+			}
+		}
+	}
+	// valueDone handles the end of a value (string, scalar or container) at
+	// raw[:end].
+	valueDone := func(end int) {
+		if len(stack) == 0 {
+			mark(end)
+			return
+		}
+		top := &stack[len(stack)-1]
+		if !top.array && !top.haveKey {
+			// It was a key; the value is still to come.
+			top.haveKey = true
+			return
+		}
+		mark(end)
+	}
+
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if inString {
+			switch {
+			case escape:
+				escape = false
+			case c == '\\':
+				escape = true
+			case c == '"':
+				inString = false
+				valueDone(i + 1)
+			}
+			continue
+		}
+		if inScalar {
+			switch c {
+			case ',', '}', ']', ' ', '\t', '\n', '\r':
+				inScalar = false
+				valueDone(i)
+			default:
+				continue
+			}
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			if len(stack) > 0 && !stack[len(stack)-1].array && !stack[len(stack)-1].haveKey {
+				return nil // a container as an object key: not JSON
+			}
+			stack = append(stack, frame{array: c == '['})
+			mark(i + 1)
+		case '}', ']':
+			if len(stack) == 0 {
+				return nil
+			}
+			stack = stack[:len(stack)-1]
+			valueDone(i + 1)
+		case ',':
+			if len(stack) > 0 && !stack[len(stack)-1].array {
+				stack[len(stack)-1].haveKey = false
+			}
+		case ':', ' ', '\t', '\n', '\r':
+		default:
+			inScalar = true
+		}
+	}
+	if cut < 0 {
+		return nil
+	}
+	out := make([]byte, 0, cut+len(closers))
+	out = append(out, raw[:cut]...)
+	return append(out, closers...)
+}
@@ -0,0 +1,276 @@
+package object
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitop-dev/ai/internal/provider"
+	"github.com/bitop-dev/ai/internal/schema"
+	"github.com/bitop-dev/ai/internal/tools"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// PatchToolName is the synthetic tool the model calls with a JSON Patch
+// during patch repair.
+const PatchToolName = "__ai_return_patch"
+
+var patchToolSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"patch": {
+			"type": "array",
+			"description": "RFC 6902 JSON Patch operations applied to the previous JSON.",
+			"items": {
+				"type": "object",
+				"properties": {
+					"op": {"type": "string", "enum": ["add", "remove", "replace"]},
+					"path": {"type": "string", "description": "JSON Pointer, e.g. /items/0/price"},
+					"value": {}
+				},
+				"required": ["op", "path"]
+			}
+		}
+	},
+	"required": ["patch"]
+}`)
+
+type patchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// errPatchFailed reports a patch repair that did not produce a valid object;
+// the caller falls back to regenerating the whole object.
+var errPatchFailed = errors.New("patch repair failed")
+
+// repairByPatch asks the model for a JSON Patch fixing the fields of raw that
+// violate the schema (verr), instead of the whole object, and applies it. It
+// makes up to attempts calls, each fixing what the previous one left, and
+// returns the valid JSON, the number of calls made and their usage. Errors
+// other than provider failures wrap errPatchFailed.
+func repairByPatch(ctx context.Context, p provider.Provider, req provider.Request, messages []provider.Message, schemaJSON, raw json.RawMessage, verr error, attempts int) (json.RawMessage, int, provider.Usage, error) {
+	var usage provider.Usage
+	for n := 1; n <= attempts; n++ {
+		callReq := req
+		callReq.Messages = append(append([]provider.Message(nil), messages...), systemText(patchPrompt(verr, raw)))
+		callReq.Tools = []provider.ToolDefinition{{
+			Name:        PatchToolName,
+			Description: "Return a JSON Patch that fixes the previous JSON.",
+			InputSchema: patchToolSchema,
+		}}
+		resp, err := p.Generate(ctx, callReq)
+		if err != nil {
+			return nil, n, usage, err
+		}
+		usage = tools.AddUsage(usage, resp.Usage)
+
+		args, ok := findToolArgs(resp.Message, PatchToolName)
+		if !ok {
+			return nil, n, usage, fmt.Errorf("%w: model did not call %q", errPatchFailed, PatchToolName)
+		}
+		var in struct {
+			Patch []patchOp `json:"patch"`
+		}
+		if err := json.Unmarshal(args, &in); err != nil {
+			return nil, n, usage, fmt.Errorf("%w: %v", errPatchFailed, err)
+		}
+		patched, err := applyPatch(raw, in.Patch)
+		if err != nil {
+			return nil, n, usage, fmt.Errorf("%w: %v", errPatchFailed, err)
+		}
+		raw = patched
+		if verr = schema.Validate(schemaJSON, raw); verr == nil {
+			return raw, n, usage, nil
+		}
+	}
+	return raw, attempts, usage, fmt.Errorf("%w: %v", errPatchFailed, verr)
+}
+
+func findToolArgs(m provider.Message, name string) (json.RawMessage, bool) {
+	for _, p := range m.Content {
+		if tc, ok := p.(provider.ToolCallPart); ok && tc.Name == name {
+			return tc.Args, true
+		}
+	}
+	return nil, false
+}
+
+func patchPrompt(err error, raw json.RawMessage) string {
+	var sb strings.Builder
+	sb.WriteString("The previous JSON did not match the schema.\nError:\n")
+	sb.WriteString(err.Error())
+	if paths := violationPaths(err); len(paths) > 0 {
+		sb.WriteString("\nInvalid locations (JSON Pointer):\n")
+		for _, p := range paths {
+			sb.WriteString("- " + p + "\n")
+		}
+	}
+	sb.WriteString("\nPrevious JSON:\n")
+	sb.WriteString(promptJSON(raw))
+	sb.WriteString("\nCall " + PatchToolName + " with a JSON Patch that fixes ONLY the invalid locations. Do not repeat valid content.")
+	return sb.String()
+}
+
+// violationPaths returns the instance locations of the innermost schema
+// violations in err, as JSON Pointers ("" is the root), sorted.
+func violationPaths(err error) []string {
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return nil
+	}
+	seen := map[string]bool{}
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			seen[e.InstanceLocation] = true
+			return
+		}
+		for _, c := range e.Causes {
+			walk(c)
+		}
+	}
+	walk(ve)
+	paths := make([]string, 0, len(seen))
+	for p := range seen {
+		if p == "" {
+			p = `""` + " (the whole document)"
+		}
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// applyPatch applies the add, remove and replace operations of an RFC 6902
+// patch to doc.
+func applyPatch(doc json.RawMessage, ops []patchOp) (json.RawMessage, error) {
+	if len(ops) == 0 {
+		return nil, errors.New("empty patch")
+	}
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var root any
+	if err := dec.Decode(&root); err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		var value any
+		if op.Op != "remove" {
+			if len(op.Value) == 0 {
+				return nil, fmt.Errorf("%s %s: value is required", op.Op, op.Path)
+			}
+			d := json.NewDecoder(bytes.NewReader(op.Value))
+			d.UseNumber()
+			if err := d.Decode(&value); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", op.Op, op.Path, err)
+			}
+		}
+		path, err := splitPointer(op.Path)
+		if err == nil {
+			root, err = patchValue(root, path, op.Op, value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+// splitPointer splits an RFC 6901 JSON Pointer into unescaped reference
+// tokens. "" refers to the whole document and "/" to the member named "".
+func splitPointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(ptr, "/") {
+		return nil, errors.New("path must be empty or start with /")
+	}
+	segs := strings.Split(ptr[1:], "/")
+	for i, s := range segs {
+		segs[i] = strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+	}
+	return segs, nil
+}
+
+// patchValue applies op at path below node and returns the updated node.
+func patchValue(node any, path []string, op string, value any) (any, error) {
+	if len(path) == 0 {
+		if op == "remove" {
+			return nil, errors.New("cannot remove the root")
+		}
+		return value, nil
+	}
+	seg, rest := path[0], path[1:]
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[seg]
+		if len(rest) > 0 {
+			if !ok {
+				return nil, fmt.Errorf("no member %q", seg)
+			}
+			v, err := patchValue(child, rest, op, value)
+			if err != nil {
+				return nil, err
+			}
+			n[seg] = v
+			return n, nil
+		}
+		switch op {
+		case "add":
+			n[seg] = value
+		case "replace", "remove":
+			if !ok {
+				return nil, fmt.Errorf("no member %q", seg)
+			}
+			if op == "remove" {
+				delete(n, seg)
+			} else {
+				n[seg] = value
+			}
+		default:
+			return nil, fmt.Errorf("unsupported op %q", op)
+		}
+		return n, nil
+	case []any:
+		if len(rest) == 0 && op == "add" && seg == "-" {
+			return append(n, value), nil
+		}
+		i, err := strconv.Atoi(seg)
+		limit := len(n)
+		if len(rest) == 0 && op == "add" {
+			limit++
+		}
+		if err != nil || i < 0 || i >= limit {
+			return nil, fmt.Errorf("index %q out of range", seg)
+		}
+		if len(rest) > 0 {
+			v, err := patchValue(n[i], rest, op, value)
+			if err != nil {
+				return nil, err
+			}
+			n[i] = v
+			return n, nil
+		}
+		switch op {
+		case "add":
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+		case "replace":
+			n[i] = value
+		case "remove":
+			n = append(n[:i], n[i+1:]...)
+		default:
+			return nil, fmt.Errorf("unsupported op %q", op)
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("cannot address %q in a scalar", seg)
+}
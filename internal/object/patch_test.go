@@ -0,0 +1,59 @@
+package object
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitPointer(t *testing.T) {
+	cases := []struct {
+		ptr  string
+		want []string
+	}{
+		{ptr: "", want: nil},
+		{ptr: "/", want: []string{""}},
+		{ptr: "/a/0", want: []string{"a", "0"}},
+		{ptr: "/a~1b/c~0d", want: []string{"a/b", "c~d"}},
+		{ptr: "//x", want: []string{"", "x"}},
+	}
+	for _, tc := range cases {
+		got, err := splitPointer(tc.ptr)
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("splitPointer(%q)=%q, %v want %q", tc.ptr, got, err, tc.want)
+		}
+	}
+	if _, err := splitPointer("a/b"); err == nil {
+		t.Fatal("expected error for pointer without leading /")
+	}
+}
+
+func TestApplyPatch_EmptyMemberName(t *testing.T) {
+	got, err := applyPatch(json.RawMessage(`{"":1,"a":2}`), []patchOp{{Op: "replace", Path: "/", Value: json.RawMessage(`3`)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"":3,"a":2}` {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestPatchPrompt_MarksTruncation(t *testing.T) {
+	raw := json.RawMessage(`{"st":"` + strings.Repeat("é", promptJSONMax) + `"}`)
+	prompt := patchPrompt(errors.New("bad"), raw)
+	if !strings.Contains(prompt, " of "+strconv.Itoa(len(raw))+" bytes]") {
+		t.Fatalf("prompt lacks truncation marker: %s", prompt[len(prompt)-200:])
+	}
+	if !utf8.ValidString(prompt) {
+		t.Fatal("cut inside a multi-byte rune")
+	}
+
+	short := patchPrompt(errors.New("bad"), json.RawMessage(`{"a":1}`))
+	if strings.Contains(short, "truncated") {
+		t.Fatalf("short JSON marked truncated: %s", short)
+	}
+}
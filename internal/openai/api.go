@@ -0,0 +1,90 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bitop-dev/ai/internal/httpx"
+	"github.com/bitop-dev/ai/internal/provider"
+	publicopenai "github.com/bitop-dev/ai/openai"
+)
+
+// apiURL joins path to the configured base URL and API prefix.
+func apiURL(cfg publicopenai.Config, path string) (string, error) {
+	base := strings.TrimRight(cfg.BaseURL, "/")
+	prefix := strings.TrimRight(cfg.APIPrefix, "/")
+	u, err := url.Parse(base + prefix + path)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// callAPI sends a request to a REST endpoint of the API (files, batches,
+// models) with the client's auth, headers and retry policy, and returns the
+// response body. Non-2xx responses become *provider.Error. body is sent as
+// JSON unless contentType says otherwise.
+func callAPI(ctx context.Context, cfg publicopenai.Config, method, u string, body []byte, contentType string, headers map[string]string, reqMaxRetries *int) ([]byte, error) {
+	h := make(http.Header)
+	h.Set("Authorization", "Bearer "+cfg.APIKey)
+	if contentType != "" {
+		h.Set("Content-Type", contentType)
+	} else if body != nil {
+		h.Set("Content-Type", "application/json")
+	}
+	for k, v := range cfg.Headers {
+		h.Set(k, v)
+	}
+	for k, v := range headers {
+		h.Set(k, v)
+	}
+
+	maxRetries := cfg.MaxRetries
+	if reqMaxRetries != nil {
+		maxRetries = *reqMaxRetries
+	}
+
+	resp, err := httpx.Do(ctx, cfg.HTTPClient, method, u, body, h, httpx.RetryPolicy{
+		MaxRetries: maxRetries,
+		MinBackoff: cfg.MinBackoff,
+		MaxBackoff: cfg.MaxBackoff,
+		Classify:   cfg.RetryClassifier,
+		Edit:       cfg.RequestEditor,
+	})
+	if err != nil {
+		code, retryable := classifyErr(cfg, err)
+		return nil, &provider.Error{Provider: "openai", Code: code, Message: err.Error(), Retryable: retryable, Cause: err}
+	}
+	defer resp.Body.Close()
+
+	rawBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &provider.Error{Provider: "openai", Code: "read_error", Message: err.Error(), Retryable: true, Cause: err}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var er errorResponse
+		if json.Unmarshal(rawBody, &er) == nil && er.Error.Message != "" {
+			return nil, &provider.Error{
+				Provider:  "openai",
+				Code:      stringifyCode(er.Error.Code, er.Error.Type),
+				Type:      er.Error.Type,
+				Param:     er.Error.Param,
+				Status:    resp.StatusCode,
+				Message:   er.Error.Message,
+				Retryable: retryableStatus(cfg, resp.StatusCode, rawBody),
+			}
+		}
+		return nil, &provider.Error{
+			Provider:  "openai",
+			Code:      "http_error",
+			Status:    resp.StatusCode,
+			Message:   strings.TrimSpace(string(rawBody)),
+			Retryable: retryableStatus(cfg, resp.StatusCode, rawBody),
+		}
+	}
+	return rawBody, nil
+}
@@ -0,0 +1,373 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/bitop-dev/ai/internal/provider"
+	"github.com/bitop-dev/ai/internal/ws"
+	publicopenai "github.com/bitop-dev/ai/openai"
+)
+
+const defaultRealtimeInstructions = "You are a text-to-speech engine. Read the user's text aloud exactly as written, " +
+	"without adding, omitting or answering anything."
+
+var realtimeMediaTypes = map[string]string{
+	"pcm16":     "audio/pcm",
+	"g711_ulaw": "audio/PCMU",
+	"g711_alaw": "audio/PCMA",
+}
+
+// RealtimeSpeech opens a Realtime API session that speaks each text sent to
+// it. Texts become out-of-band responses (conversation "none") so the
+// session keeps no history; they are spoken one at a time, in order.
+func (p *Provider) RealtimeSpeech(ctx context.Context, req provider.RealtimeSpeechRequest) (provider.RealtimeSpeechSession, error) {
+	_, cfg, err := clientAndConfig(ctx, req.ProviderData)
+	if err != nil {
+		return nil, &provider.Error{Provider: "openai", Code: "config_error", Message: err.Error(), Retryable: false, Cause: err}
+	}
+	if req.Model == "" {
+		return nil, &provider.Error{Provider: "openai", Code: "invalid_request", Message: "model is required", Retryable: false}
+	}
+	if req.Voice == "" {
+		return nil, &provider.Error{Provider: "openai", Code: "invalid_request", Message: "voice is required", Retryable: false}
+	}
+	opts, warnings, err := parseOptions[publicopenai.RealtimeSpeechOptions](req.ProviderOptions)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Format == "" {
+		opts.Format = "pcm16"
+	}
+	mediaType, ok := realtimeMediaTypes[opts.Format]
+	if !ok {
+		return nil, optionsError(fmt.Sprintf("unsupported realtime audio format %q", opts.Format))
+	}
+	instructions := req.Instructions
+	if instructions == "" {
+		instructions = defaultRealtimeInstructions
+	}
+
+	u, err := realtimeURL(cfg, req.Model)
+	if err != nil {
+		return nil, &provider.Error{Provider: "openai", Code: "url_error", Message: err.Error(), Retryable: false, Cause: err}
+	}
+	h := make(http.Header)
+	h.Set("Authorization", "Bearer "+cfg.APIKey)
+	h.Set("OpenAI-Beta", "realtime=v1")
+	for k, v := range cfg.Headers {
+		h.Set(k, v)
+	}
+	for k, v := range req.Headers {
+		h.Set(k, v)
+	}
+
+	conn, err := ws.Dial(ctx, cfg.HTTPClient, u, h, cfg.RequestEditor)
+	if err != nil {
+		return nil, realtimeDialError(cfg, err)
+	}
+
+	s := &realtimeSession{
+		cfg:       cfg,
+		conn:      conn,
+		mediaType: mediaType,
+		warnings:  warnings,
+		chunks:    make(chan []byte, 16),
+		closed:    make(chan struct{}),
+	}
+	err = s.send(map[string]any{
+		"type": "session.update",
+		"session": map[string]any{
+			"modalities":          []string{"audio", "text"},
+			"voice":               req.Voice,
+			"instructions":        instructions,
+			"output_audio_format": opts.Format,
+		},
+	})
+	if err != nil {
+		conn.Close()
+		return nil, s.wrapErr(err)
+	}
+	s.stopWatch = context.AfterFunc(ctx, func() { s.fail(ctx.Err()) })
+	go s.read()
+	return s, nil
+}
+
+func realtimeURL(cfg publicopenai.Config, model string) (string, error) {
+	base := strings.TrimRight(cfg.BaseURL, "/")
+	prefix := strings.TrimRight(cfg.APIPrefix, "/")
+	u, err := url.Parse(base + prefix + "/realtime")
+	if err != nil {
+		return "", err
+	}
+	u.RawQuery = url.Values{"model": {model}}.Encode()
+	return u.String(), nil
+}
+
+func realtimeDialError(cfg publicopenai.Config, err error) error {
+	var he *ws.HandshakeError
+	if !errors.As(err, &he) {
+		code, retryable := classifyErr(cfg, err)
+		return &provider.Error{Provider: "openai", Code: code, Message: err.Error(), Retryable: retryable, Cause: err}
+	}
+	var er errorResponse
+	if json.Unmarshal(he.Body, &er) == nil && er.Error.Message != "" {
+		return &provider.Error{
+			Provider:  "openai",
+			Code:      stringifyCode(er.Error.Code, er.Error.Type),
+			Type:      er.Error.Type,
+			Param:     er.Error.Param,
+			Status:    he.StatusCode,
+			Message:   er.Error.Message,
+			Retryable: retryableStatus(cfg, he.StatusCode, he.Body),
+		}
+	}
+	return &provider.Error{
+		Provider:  "openai",
+		Code:      "http_error",
+		Status:    he.StatusCode,
+		Message:   strings.TrimSpace(string(he.Body)),
+		Retryable: retryableStatus(cfg, he.StatusCode, he.Body),
+	}
+}
+
+type realtimeSession struct {
+	cfg       publicopenai.Config
+	conn      *ws.Conn
+	mediaType string
+	warnings  []string
+	stopWatch func() bool
+
+	// mu guards the response queue: only one response may be active at a
+	// time, so texts sent meanwhile wait in queue.
+	mu        sync.Mutex
+	queue     []string
+	active    bool
+	closeSend bool
+	done      bool
+	err       error
+
+	chunks    chan []byte
+	closed    chan struct{}
+	closeOnce sync.Once
+	cur       []byte
+}
+
+type realtimeEvent struct {
+	Type  string `json:"type"`
+	Delta string `json:"delta"`
+	Error *struct {
+		Type    string `json:"type"`
+		Code    any    `json:"code"`
+		Message string `json:"message"`
+		Param   string `json:"param"`
+	} `json:"error"`
+	Response *struct {
+		Status        string `json:"status"`
+		StatusDetails *struct {
+			Error *struct {
+				Type    string `json:"type"`
+				Code    any    `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"status_details"`
+	} `json:"response"`
+}
+
+func (s *realtimeSession) SendText(text string) error {
+	if text == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.err != nil:
+		return s.err
+	case s.closeSend:
+		return errors.New("openai realtime speech: SendText after CloseSend")
+	case s.done:
+		return errors.New("openai realtime speech: session closed")
+	case s.active:
+		s.queue = append(s.queue, text)
+		return nil
+	}
+	s.active = true
+	return s.startResponse(text)
+}
+
+// startResponse asks for text to be spoken. The caller holds s.mu.
+func (s *realtimeSession) startResponse(text string) error {
+	err := s.send(map[string]any{
+		"type": "response.create",
+		"response": map[string]any{
+			"conversation": "none",
+			"modalities":   []string{"audio", "text"},
+			"input": []any{map[string]any{
+				"type":    "message",
+				"role":    "user",
+				"content": []any{map[string]any{"type": "input_text", "text": text}},
+			}},
+		},
+	})
+	if err != nil {
+		return s.wrapErr(err)
+	}
+	return nil
+}
+
+func (s *realtimeSession) CloseSend() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeSend = true
+	if !s.active && len(s.queue) == 0 {
+		s.finishLocked()
+	}
+	return s.err
+}
+
+func (s *realtimeSession) send(event map[string]any) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.conn.WriteMessage(ws.OpText, b)
+}
+
+func (s *realtimeSession) read() {
+	defer close(s.chunks)
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			s.fail(err)
+			return
+		}
+		var ev realtimeEvent
+		if err := json.Unmarshal(data, &ev); err != nil {
+			s.fail(&provider.Error{Provider: "openai", Code: "decode_error", Message: err.Error(), Retryable: false, Cause: err})
+			return
+		}
+		switch ev.Type {
+		case "response.audio.delta", "response.output_audio.delta":
+			audio, err := base64.StdEncoding.DecodeString(ev.Delta)
+			if err != nil {
+				s.fail(&provider.Error{Provider: "openai", Code: "decode_error", Message: err.Error(), Retryable: false, Cause: err})
+				return
+			}
+			select {
+			case s.chunks <- audio:
+			case <-s.closed:
+				return
+			}
+		case "response.done":
+			if ev.Response != nil && ev.Response.Status == "failed" {
+				msg, code := "response failed", "response_failed"
+				if d := ev.Response.StatusDetails; d != nil && d.Error != nil {
+					msg, code = d.Error.Message, stringifyCode(d.Error.Code, d.Error.Type)
+				}
+				s.fail(&provider.Error{Provider: "openai", Code: code, Message: msg, Retryable: false})
+				return
+			}
+			if s.next() {
+				return
+			}
+		case "error":
+			pe := &provider.Error{Provider: "openai", Code: "realtime_error", Message: "realtime error", Retryable: false}
+			if ev.Error != nil {
+				pe.Code = stringifyCode(ev.Error.Code, ev.Error.Type)
+				pe.Type = ev.Error.Type
+				pe.Param = ev.Error.Param
+				pe.Message = ev.Error.Message
+			}
+			s.fail(pe)
+			return
+		}
+	}
+}
+
+// next starts the next queued response after one completed. It reports
+// whether the session is finished.
+func (s *realtimeSession) next() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = false
+	if len(s.queue) > 0 {
+		text := s.queue[0]
+		s.queue = s.queue[1:]
+		s.active = true
+		if err := s.startResponse(text); err != nil {
+			s.err = err
+			s.finishLocked()
+			return true
+		}
+		return false
+	}
+	if s.closeSend {
+		s.finishLocked()
+		return true
+	}
+	return false
+}
+
+// fail records err unless the session already ended, and closes it.
+func (s *realtimeSession) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.done && s.err == nil {
+		s.err = s.wrapErr(err)
+	}
+	s.finishLocked()
+}
+
+func (s *realtimeSession) finishLocked() {
+	s.done = true
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		if s.stopWatch != nil {
+			s.stopWatch()
+		}
+		s.conn.Close()
+	})
+}
+
+func (s *realtimeSession) wrapErr(err error) error {
+	var pe *provider.Error
+	if errors.As(err, &pe) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	code, retryable := classifyErr(s.cfg, err)
+	return &provider.Error{Provider: "openai", Code: code, Message: err.Error(), Retryable: retryable, Cause: err}
+}
+
+// Next returns the next audio chunk. The reader closes chunks once the
+// session ends, after any audio received before that.
+func (s *realtimeSession) Next() bool {
+	b, ok := <-s.chunks
+	s.cur = b
+	return ok
+}
+
+func (s *realtimeSession) Audio() []byte      { return s.cur }
+func (s *realtimeSession) MediaType() string  { return s.mediaType }
+func (s *realtimeSession) Warnings() []string { return s.warnings }
+
+func (s *realtimeSession) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *realtimeSession) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finishLocked()
+	return nil
+}
+
+var _ provider.RealtimeSpeechProvider = (*Provider)(nil)
@@ -0,0 +1,112 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+	"github.com/bitop-dev/ai/internal/ws"
+	publicopenai "github.com/bitop-dev/ai/openai"
+)
+
+func TestRealtimeSpeech(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/realtime" || r.URL.Query().Get("model") != "gpt-realtime" || r.Header.Get("Authorization") != "Bearer k" {
+			t.Errorf("handshake %s %s", r.URL, r.Header.Get("Authorization"))
+		}
+		c, err := ws.Accept(w, r)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		write := func(v any) { b, _ := json.Marshal(v); _ = c.WriteMessage(ws.OpText, b) }
+		for {
+			_, data, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			var ev struct {
+				Type    string `json:"type"`
+				Session struct {
+					Voice  string `json:"voice"`
+					Format string `json:"output_audio_format"`
+				} `json:"session"`
+				Response struct {
+					Conversation string `json:"conversation"`
+					Input        []struct {
+						Content []struct {
+							Text string `json:"text"`
+						} `json:"content"`
+					} `json:"input"`
+				} `json:"response"`
+			}
+			_ = json.Unmarshal(data, &ev)
+			switch ev.Type {
+			case "session.update":
+				if ev.Session.Voice != "alloy" || ev.Session.Format != "pcm16" {
+					t.Errorf("session=%s", data)
+				}
+			case "response.create":
+				text := ev.Response.Input[0].Content[0].Text
+				if text == "fail" {
+					write(map[string]any{"type": "error", "error": map[string]any{"type": "invalid_request_error", "code": "bad_text", "message": "no"}})
+					continue
+				}
+				for _, part := range []string{text[:1], text[1:]} {
+					write(map[string]any{"type": "response.audio.delta", "delta": base64.StdEncoding.EncodeToString([]byte(part))})
+				}
+				write(map[string]any{"type": "response.done", "response": map[string]any{"status": "completed"}})
+			}
+		}
+	}))
+	defer srv.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: srv.URL})
+	p := &Provider{}
+	req := provider.RealtimeSpeechRequest{Model: "gpt-realtime", Voice: "alloy", ProviderData: client}
+
+	s, err := p.RealtimeSpeech(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for _, text := range []string{"one ", "two ", "three"} {
+		if err := s.SendText(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	var audio strings.Builder
+	for s.Next() {
+		audio.Write(s.Audio())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if audio.String() != "one two three" || s.MediaType() != "audio/pcm" {
+		t.Fatalf("audio=%q mediaType=%q", audio.String(), s.MediaType())
+	}
+
+	s, err = p.RealtimeSpeech(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if err := s.SendText("fail"); err != nil {
+		t.Fatal(err)
+	}
+	for s.Next() {
+	}
+	var pe *provider.Error
+	if err := s.Err(); !errors.As(err, &pe) || pe.Code != "bad_text" || pe.Message != "no" {
+		t.Fatalf("err=%v", err)
+	}
+}
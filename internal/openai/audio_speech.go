@@ -22,32 +22,61 @@ type speechRequest struct {
 }
 
 func (p *Provider) GenerateSpeech(ctx context.Context, req provider.SpeechRequest) (provider.SpeechResponse, error) {
-	_, cfg, err := clientAndConfig(req.ProviderData)
+	resp, warnings, err := doSpeech(ctx, req)
 	if err != nil {
-		return provider.SpeechResponse{}, &provider.Error{Provider: "openai", Code: "config_error", Message: err.Error(), Retryable: false, Cause: err}
+		return provider.SpeechResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	rawBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return provider.SpeechResponse{}, &provider.Error{Provider: "openai", Code: "read_error", Message: err.Error(), Retryable: true, Cause: err}
+	}
+
+	mt := resp.Header.Get("Content-Type")
+	return provider.SpeechResponse{
+		AudioBytes:  rawBody,
+		MediaType:   mt,
+		Warnings:    warnings,
+		RawResponse: rawBody,
+		RequestID:   requestID(resp),
+	}, nil
+}
+
+// StreamSpeech returns the response body unbuffered so playback can start
+// before synthesis finishes.
+func (p *Provider) StreamSpeech(ctx context.Context, req provider.SpeechRequest) (provider.SpeechStream, error) {
+	resp, _, err := doSpeech(ctx, req)
+	if err != nil {
+		return provider.SpeechStream{}, err
+	}
+	return provider.SpeechStream{
+		Body:      resp.Body,
+		MediaType: resp.Header.Get("Content-Type"),
+	}, nil
+}
+
+// doSpeech sends the speech request and returns a 2xx response with an unread
+// body, along with any provider options warnings; non-2xx responses are mapped
+// to *provider.Error.
+func doSpeech(ctx context.Context, req provider.SpeechRequest) (*http.Response, []string, error) {
+	_, cfg, err := clientAndConfig(ctx, req.ProviderData)
+	if err != nil {
+		return nil, nil, &provider.Error{Provider: "openai", Code: "config_error", Message: err.Error(), Retryable: false, Cause: err}
 	}
 	if req.Model == "" {
-		return provider.SpeechResponse{}, &provider.Error{Provider: "openai", Code: "invalid_request", Message: "model is required", Retryable: false}
+		return nil, nil, &provider.Error{Provider: "openai", Code: "invalid_request", Message: "model is required", Retryable: false}
 	}
 	if req.Text == "" {
-		return provider.SpeechResponse{}, &provider.Error{Provider: "openai", Code: "invalid_request", Message: "text is required", Retryable: false}
+		return nil, nil, &provider.Error{Provider: "openai", Code: "invalid_request", Message: "text is required", Retryable: false}
 	}
 	if req.Voice == "" {
-		return provider.SpeechResponse{}, &provider.Error{Provider: "openai", Code: "invalid_request", Message: "voice is required", Retryable: false}
-	}
-
-	var opts publicopenai.SpeechOptions
-	if v, ok := req.ProviderOptions.(map[string]any); ok {
-		if raw, ok := v["openai"]; ok {
-			switch o := raw.(type) {
-			case publicopenai.SpeechOptions:
-				opts = o
-			case *publicopenai.SpeechOptions:
-				if o != nil {
-					opts = *o
-				}
-			}
-		}
+		return nil, nil, &provider.Error{Provider: "openai", Code: "invalid_request", Message: "voice is required", Retryable: false}
+	}
+
+	opts, warnings, err := parseOptions[publicopenai.SpeechOptions](req.ProviderOptions)
+	if err != nil {
+		return nil, nil, err
 	}
 	if opts.Format == "" {
 		opts.Format = "mp3"
@@ -61,12 +90,12 @@ func (p *Provider) GenerateSpeech(ctx context.Context, req provider.SpeechReques
 		Speed:  opts.Speed,
 	})
 	if err != nil {
-		return provider.SpeechResponse{}, &provider.Error{Provider: "openai", Code: "marshal_error", Message: err.Error(), Retryable: false, Cause: err}
+		return nil, nil, &provider.Error{Provider: "openai", Code: "marshal_error", Message: err.Error(), Retryable: false, Cause: err}
 	}
 
 	u, err := speechURL(cfg)
 	if err != nil {
-		return provider.SpeechResponse{}, &provider.Error{Provider: "openai", Code: "url_error", Message: err.Error(), Retryable: false, Cause: err}
+		return nil, nil, &provider.Error{Provider: "openai", Code: "url_error", Message: err.Error(), Retryable: false, Cause: err}
 	}
 
 	h := make(http.Header)
@@ -88,44 +117,41 @@ func (p *Provider) GenerateSpeech(ctx context.Context, req provider.SpeechReques
 		MaxRetries: maxRetries,
 		MinBackoff: cfg.MinBackoff,
 		MaxBackoff: cfg.MaxBackoff,
+		Classify:   cfg.RetryClassifier,
+		Edit:       cfg.RequestEditor,
 	})
 	if err != nil {
-		code, retryable := classifyNetworkErr(err)
-		return provider.SpeechResponse{}, &provider.Error{Provider: "openai", Code: code, Message: err.Error(), Retryable: retryable, Cause: err}
+		code, retryable := classifyErr(cfg, err)
+		return nil, nil, &provider.Error{Provider: "openai", Code: code, Message: err.Error(), Retryable: retryable, Cause: err}
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return resp, warnings, nil
 	}
 	defer resp.Body.Close()
 
 	rawBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return provider.SpeechResponse{}, &provider.Error{Provider: "openai", Code: "read_error", Message: err.Error(), Retryable: true, Cause: err}
+		return nil, nil, &provider.Error{Provider: "openai", Code: "read_error", Message: err.Error(), Retryable: true, Cause: err}
 	}
-
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		var er errorResponse
-		if json.Unmarshal(rawBody, &er) == nil && er.Error.Message != "" {
-			return provider.SpeechResponse{}, &provider.Error{
-				Provider:  "openai",
-				Code:      stringifyCode(er.Error.Code, er.Error.Type),
-				Status:    resp.StatusCode,
-				Message:   er.Error.Message,
-				Retryable: shouldRetryStatus(resp.StatusCode),
-			}
-		}
-		return provider.SpeechResponse{}, &provider.Error{
+	var er errorResponse
+	if json.Unmarshal(rawBody, &er) == nil && er.Error.Message != "" {
+		return nil, nil, &provider.Error{
 			Provider:  "openai",
-			Code:      "http_error",
+			Code:      stringifyCode(er.Error.Code, er.Error.Type),
+			Type:      er.Error.Type,
+			Param:     er.Error.Param,
 			Status:    resp.StatusCode,
-			Message:   strings.TrimSpace(string(rawBody)),
-			Retryable: shouldRetryStatus(resp.StatusCode),
+			Message:   er.Error.Message,
+			Retryable: retryableStatus(cfg, resp.StatusCode, rawBody),
 		}
 	}
-
-	mt := resp.Header.Get("Content-Type")
-	return provider.SpeechResponse{
-		AudioBytes:  rawBody,
-		MediaType:   mt,
-		RawResponse: rawBody,
-	}, nil
+	return nil, nil, &provider.Error{
+		Provider:  "openai",
+		Code:      "http_error",
+		Status:    resp.StatusCode,
+		Message:   strings.TrimSpace(string(rawBody)),
+		Retryable: retryableStatus(cfg, resp.StatusCode, rawBody),
+	}
 }
 
 func speechURL(cfg publicopenai.Config) (string, error) {
@@ -138,4 +164,7 @@ func speechURL(cfg publicopenai.Config) (string, error) {
 	return u.String(), nil
 }
 
-var _ provider.SpeechProvider = (*Provider)(nil)
+var (
+	_ provider.SpeechProvider       = (*Provider)(nil)
+	_ provider.SpeechStreamProvider = (*Provider)(nil)
+)
@@ -0,0 +1,68 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+	publicopenai "github.com/bitop-dev/ai/openai"
+)
+
+func TestStreamSpeech_YieldsBytesBeforeResponseEnds(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mpeg")
+		_, _ = w.Write([]byte("abc"))
+		w.(http.Flusher).Flush()
+		<-release
+		_, _ = w.Write([]byte("def"))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: srv.URL})
+	out, err := (&Provider{}).StreamSpeech(context.Background(), provider.SpeechRequest{
+		Model:        "tts-1",
+		Text:         "hi",
+		Voice:        "alloy",
+		ProviderData: client,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Body.Close()
+	if out.MediaType != "audio/mpeg" {
+		t.Fatalf("MediaType=%q", out.MediaType)
+	}
+
+	buf := make([]byte, 3)
+	if _, err := io.ReadFull(out.Body, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "abc" {
+		t.Fatalf("first chunk=%q", buf)
+	}
+}
+
+func TestStreamSpeech_HTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"bad voice","type":"invalid_request_error"}}`))
+	}))
+	defer srv.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: srv.URL})
+	_, err := (&Provider{}).StreamSpeech(context.Background(), provider.SpeechRequest{
+		Model:        "tts-1",
+		Text:         "hi",
+		Voice:        "nope",
+		ProviderData: client,
+	})
+	pe, ok := err.(*provider.Error)
+	if !ok || pe.Status != http.StatusBadRequest || pe.Message != "bad voice" {
+		t.Fatalf("err=%#v", err)
+	}
+}
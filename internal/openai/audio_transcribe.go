@@ -29,7 +29,7 @@ type transcriptionVerboseJSON struct {
 }
 
 func (p *Provider) Transcribe(ctx context.Context, req provider.TranscriptionRequest) (provider.TranscriptionResponse, error) {
-	_, cfg, err := clientAndConfig(req.ProviderData)
+	_, cfg, err := clientAndConfig(ctx, req.ProviderData)
 	if err != nil {
 		return provider.TranscriptionResponse{}, &provider.Error{Provider: "openai", Code: "config_error", Message: err.Error(), Retryable: false, Cause: err}
 	}
@@ -44,18 +44,9 @@ func (p *Provider) Transcribe(ctx context.Context, req provider.TranscriptionReq
 		filename = "audio"
 	}
 
-	var opts publicopenai.TranscriptionOptions
-	if v, ok := req.ProviderOptions.(map[string]any); ok {
-		if raw, ok := v["openai"]; ok {
-			switch o := raw.(type) {
-			case publicopenai.TranscriptionOptions:
-				opts = o
-			case *publicopenai.TranscriptionOptions:
-				if o != nil {
-					opts = *o
-				}
-			}
-		}
+	opts, warnings, err := parseOptions[publicopenai.TranscriptionOptions](req.ProviderOptions)
+	if err != nil {
+		return provider.TranscriptionResponse{}, err
 	}
 	if opts.ResponseFormat == "" {
 		opts.ResponseFormat = "verbose_json"
@@ -114,9 +105,11 @@ func (p *Provider) Transcribe(ctx context.Context, req provider.TranscriptionReq
 		MaxRetries: maxRetries,
 		MinBackoff: cfg.MinBackoff,
 		MaxBackoff: cfg.MaxBackoff,
+		Classify:   cfg.RetryClassifier,
+		Edit:       cfg.RequestEditor,
 	})
 	if err != nil {
-		code, retryable := classifyNetworkErr(err)
+		code, retryable := classifyErr(cfg, err)
 		return provider.TranscriptionResponse{}, &provider.Error{Provider: "openai", Code: code, Message: err.Error(), Retryable: retryable, Cause: err}
 	}
 	defer resp.Body.Close()
@@ -132,9 +125,11 @@ func (p *Provider) Transcribe(ctx context.Context, req provider.TranscriptionReq
 			return provider.TranscriptionResponse{}, &provider.Error{
 				Provider:  "openai",
 				Code:      stringifyCode(er.Error.Code, er.Error.Type),
+				Type:      er.Error.Type,
+				Param:     er.Error.Param,
 				Status:    resp.StatusCode,
 				Message:   er.Error.Message,
-				Retryable: shouldRetryStatus(resp.StatusCode),
+				Retryable: retryableStatus(cfg, resp.StatusCode, rawBody),
 			}
 		}
 		return provider.TranscriptionResponse{}, &provider.Error{
@@ -142,11 +137,11 @@ func (p *Provider) Transcribe(ctx context.Context, req provider.TranscriptionReq
 			Code:      "http_error",
 			Status:    resp.StatusCode,
 			Message:   strings.TrimSpace(string(rawBody)),
-			Retryable: shouldRetryStatus(resp.StatusCode),
+			Retryable: retryableStatus(cfg, resp.StatusCode, rawBody),
 		}
 	}
 
-	out := provider.TranscriptionResponse{RawResponse: rawBody}
+	out := provider.TranscriptionResponse{Warnings: warnings, RawResponse: rawBody, RequestID: requestID(resp)}
 
 	switch opts.ResponseFormat {
 	case "text":
@@ -0,0 +1,262 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bitop-dev/ai/internal/provider"
+	publicopenai "github.com/bitop-dev/ai/openai"
+)
+
+type batchLine struct {
+	CustomID string                `json:"custom_id"`
+	Method   string                `json:"method"`
+	URL      string                `json:"url"`
+	Body     chatCompletionRequest `json:"body"`
+}
+
+type createBatchRequest struct {
+	InputFileID      string            `json:"input_file_id"`
+	Endpoint         string            `json:"endpoint"`
+	CompletionWindow string            `json:"completion_window"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+}
+
+type batchObject struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	OutputFileID  string `json:"output_file_id,omitempty"`
+	ErrorFileID   string `json:"error_file_id,omitempty"`
+	RequestCounts struct {
+		Total     int `json:"total"`
+		Completed int `json:"completed"`
+		Failed    int `json:"failed"`
+	} `json:"request_counts"`
+}
+
+type batchOutputLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int                    `json:"status_code"`
+		RequestID  string                 `json:"request_id"`
+		Body       chatCompletionResponse `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type fileObject struct {
+	ID string `json:"id"`
+}
+
+func (p *Provider) SubmitBatch(ctx context.Context, req provider.BatchSubmitRequest) (provider.BatchInfo, error) {
+	_, cfg, err := clientAndConfig(ctx, req.ProviderData)
+	if err != nil {
+		return provider.BatchInfo{}, &provider.Error{Provider: "openai", Code: "config_error", Message: err.Error(), Retryable: false, Cause: err}
+	}
+	if len(req.Items) == 0 {
+		return provider.BatchInfo{}, &provider.Error{Provider: "openai", Code: "invalid_request", Message: "batch requires at least one request", Retryable: false}
+	}
+
+	endpoint := strings.TrimRight(cfg.APIPrefix, "/") + "/chat/completions"
+	var jsonl bytes.Buffer
+	for i, it := range req.Items {
+		customID := it.CustomID
+		if customID == "" {
+			customID = fmt.Sprintf("request-%d", i)
+		}
+		payload, err := buildRequest(it.Request, false)
+		if err != nil {
+			return provider.BatchInfo{}, &provider.Error{Provider: "openai", Code: "request_error", Message: err.Error(), Retryable: false, Cause: err}
+		}
+		b, err := json.Marshal(batchLine{CustomID: customID, Method: http.MethodPost, URL: endpoint, Body: payload})
+		if err != nil {
+			return provider.BatchInfo{}, &provider.Error{Provider: "openai", Code: "marshal_error", Message: err.Error(), Retryable: false, Cause: err}
+		}
+		jsonl.Write(b)
+		jsonl.WriteByte('\n')
+	}
+
+	fileID, err := uploadBatchFile(ctx, cfg, req.Headers, req.MaxRetries, jsonl.Bytes())
+	if err != nil {
+		return provider.BatchInfo{}, err
+	}
+
+	window := req.CompletionWindow
+	if window == "" {
+		window = "24h"
+	}
+	body, err := json.Marshal(createBatchRequest{
+		InputFileID:      fileID,
+		Endpoint:         endpoint,
+		CompletionWindow: window,
+		Metadata:         req.Metadata,
+	})
+	if err != nil {
+		return provider.BatchInfo{}, &provider.Error{Provider: "openai", Code: "marshal_error", Message: err.Error(), Retryable: false, Cause: err}
+	}
+	u, err := apiURL(cfg, "/batches")
+	if err != nil {
+		return provider.BatchInfo{}, &provider.Error{Provider: "openai", Code: "url_error", Message: err.Error(), Retryable: false, Cause: err}
+	}
+	raw, err := callAPI(ctx, cfg, http.MethodPost, u, body, "", req.Headers, req.MaxRetries)
+	if err != nil {
+		return provider.BatchInfo{}, err
+	}
+	return decodeBatchInfo(raw)
+}
+
+func (p *Provider) BatchStatus(ctx context.Context, req provider.BatchLookupRequest) (provider.BatchInfo, error) {
+	_, cfg, err := clientAndConfig(ctx, req.ProviderData)
+	if err != nil {
+		return provider.BatchInfo{}, &provider.Error{Provider: "openai", Code: "config_error", Message: err.Error(), Retryable: false, Cause: err}
+	}
+	if req.BatchID == "" {
+		return provider.BatchInfo{}, &provider.Error{Provider: "openai", Code: "invalid_request", Message: "batch id is required", Retryable: false}
+	}
+	u, err := apiURL(cfg, "/batches/"+url.PathEscape(req.BatchID))
+	if err != nil {
+		return provider.BatchInfo{}, &provider.Error{Provider: "openai", Code: "url_error", Message: err.Error(), Retryable: false, Cause: err}
+	}
+	raw, err := callAPI(ctx, cfg, http.MethodGet, u, nil, "", req.Headers, req.MaxRetries)
+	if err != nil {
+		return provider.BatchInfo{}, err
+	}
+	return decodeBatchInfo(raw)
+}
+
+func (p *Provider) BatchResults(ctx context.Context, req provider.BatchLookupRequest) ([]provider.BatchResult, error) {
+	info, err := p.BatchStatus(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if info.Status != "completed" {
+		return nil, &provider.Error{Provider: "openai", Code: "batch_not_completed", Message: fmt.Sprintf("batch %s is %s", info.ID, info.Status), Retryable: true}
+	}
+	_, cfg, _ := clientAndConfig(ctx, req.ProviderData)
+
+	var out []provider.BatchResult
+	for _, fileID := range []string{info.OutputFileID, info.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		u, err := apiURL(cfg, "/files/"+url.PathEscape(fileID)+"/content")
+		if err != nil {
+			return nil, &provider.Error{Provider: "openai", Code: "url_error", Message: err.Error(), Retryable: false, Cause: err}
+		}
+		raw, err := callAPI(ctx, cfg, http.MethodGet, u, nil, "", req.Headers, req.MaxRetries)
+		if err != nil {
+			return nil, err
+		}
+		results, err := parseBatchOutput(raw)
+		if err != nil {
+			return nil, &provider.Error{Provider: "openai", Code: "decode_error", Message: err.Error(), Retryable: false, Cause: err}
+		}
+		out = append(out, results...)
+	}
+	return out, nil
+}
+
+func parseBatchOutput(raw []byte) ([]provider.BatchResult, error) {
+	var out []provider.BatchResult
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	sc.Buffer(make([]byte, 0, 64*1024), 32<<20)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var l batchOutputLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			return nil, err
+		}
+		r := provider.BatchResult{CustomID: l.CustomID}
+		switch {
+		case l.Error != nil:
+			r.Err = &provider.Error{Provider: "openai", Code: l.Error.Code, Message: l.Error.Message}
+		case l.Response == nil:
+			r.Err = &provider.Error{Provider: "openai", Code: "invalid_response", Message: "batch line has no response"}
+		case l.Response.StatusCode < 200 || l.Response.StatusCode > 299:
+			r.Err = &provider.Error{Provider: "openai", Code: "http_error", Status: l.Response.StatusCode, Message: fmt.Sprintf("batch request failed with status %d", l.Response.StatusCode)}
+		case len(l.Response.Body.Choices) == 0:
+			r.Err = &provider.Error{Provider: "openai", Code: "invalid_response", Message: "response has no choices"}
+		default:
+			c := l.Response.Body.Choices[0]
+			msg, err := fromChatMessage(c.Message)
+			if err != nil {
+				r.Err = &provider.Error{Provider: "openai", Code: "invalid_response", Message: err.Error(), Cause: err}
+				break
+			}
+			u := l.Response.Body.Usage
+			r.Response = &provider.Response{
+				Message:           msg,
+				Usage:             provider.Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens, TotalTokens: u.TotalTokens},
+				FinishReason:      provider.FinishReason(c.FinishReason),
+				ModelID:           l.Response.Body.Model,
+				SystemFingerprint: l.Response.Body.SystemFingerprint,
+				ResponseID:        l.Response.Body.ID,
+				RequestID:         l.Response.RequestID,
+			}
+		}
+		out = append(out, r)
+	}
+	return out, sc.Err()
+}
+
+func uploadBatchFile(ctx context.Context, cfg publicopenai.Config, headers map[string]string, maxRetries *int, jsonl []byte) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	_ = w.WriteField("purpose", "batch")
+	part, err := w.CreateFormFile("file", "batch.jsonl")
+	if err != nil {
+		return "", &provider.Error{Provider: "openai", Code: "request_error", Message: err.Error(), Retryable: false, Cause: err}
+	}
+	if _, err := part.Write(jsonl); err != nil {
+		return "", &provider.Error{Provider: "openai", Code: "request_error", Message: err.Error(), Retryable: false, Cause: err}
+	}
+	_ = w.Close()
+
+	u, err := apiURL(cfg, "/files")
+	if err != nil {
+		return "", &provider.Error{Provider: "openai", Code: "url_error", Message: err.Error(), Retryable: false, Cause: err}
+	}
+	raw, err := callAPI(ctx, cfg, http.MethodPost, u, body.Bytes(), w.FormDataContentType(), headers, maxRetries)
+	if err != nil {
+		return "", err
+	}
+	var f fileObject
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return "", &provider.Error{Provider: "openai", Code: "decode_error", Message: err.Error(), Retryable: false, Cause: err}
+	}
+	if f.ID == "" {
+		return "", &provider.Error{Provider: "openai", Code: "invalid_response", Message: "file upload returned no id", Retryable: false}
+	}
+	return f.ID, nil
+}
+
+func decodeBatchInfo(raw []byte) (provider.BatchInfo, error) {
+	var b batchObject
+	if err := json.Unmarshal(raw, &b); err != nil {
+		return provider.BatchInfo{}, &provider.Error{Provider: "openai", Code: "decode_error", Message: err.Error(), Retryable: false, Cause: err}
+	}
+	return provider.BatchInfo{
+		ID:           b.ID,
+		Status:       b.Status,
+		Total:        b.RequestCounts.Total,
+		Completed:    b.RequestCounts.Completed,
+		Failed:       b.RequestCounts.Failed,
+		OutputFileID: b.OutputFileID,
+		ErrorFileID:  b.ErrorFileID,
+	}, nil
+}
+
+var _ provider.BatchProvider = (*Provider)(nil)
@@ -0,0 +1,85 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+	publicopenai "github.com/bitop-dev/ai/openai"
+)
+
+func TestBatch_SubmitStatusResults(t *testing.T) {
+	var uploaded string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/files":
+			f, _, err := r.FormFile("file")
+			if err != nil {
+				t.Fatalf("form file: %v", err)
+			}
+			b, _ := io.ReadAll(f)
+			uploaded = string(b)
+			if r.FormValue("purpose") != "batch" {
+				t.Fatalf("purpose=%q", r.FormValue("purpose"))
+			}
+			_, _ = w.Write([]byte(`{"id":"file_in"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/batches":
+			var body createBatchRequest
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body.InputFileID != "file_in" || body.Endpoint != "/v1/chat/completions" || body.CompletionWindow != "24h" {
+				t.Fatalf("create body=%#v", body)
+			}
+			_, _ = w.Write([]byte(`{"id":"batch_1","status":"validating","request_counts":{"total":2}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/batches/batch_1":
+			_, _ = w.Write([]byte(`{"id":"batch_1","status":"completed","output_file_id":"file_out","request_counts":{"total":2,"completed":1,"failed":1}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/files/file_out/content":
+			_, _ = w.Write([]byte(`{"custom_id":"a","response":{"status_code":200,"body":{"choices":[{"message":{"role":"assistant","content":"pos"},"finish_reason":"stop"}],"usage":{"total_tokens":3}}}}
+{"custom_id":"b","error":{"code":"bad","message":"nope"}}
+`))
+		default:
+			t.Fatalf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: srv.URL})
+	p := &Provider{}
+	user := provider.Message{Role: provider.RoleUser, Content: []provider.ContentPart{provider.TextPart{Text: "classify"}}}
+
+	info, err := p.SubmitBatch(context.Background(), provider.BatchSubmitRequest{
+		Items: []provider.BatchItem{
+			{CustomID: "a", Request: provider.Request{Model: "gpt-4o-mini", Messages: []provider.Message{user}}},
+			{CustomID: "b", Request: provider.Request{Model: "gpt-4o-mini", Messages: []provider.Message{user}}},
+		},
+		ProviderData: client,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != "batch_1" {
+		t.Fatalf("id=%q", info.ID)
+	}
+	lines := strings.Split(strings.TrimSpace(uploaded), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"custom_id":"a"`) || !strings.Contains(lines[0], `"url":"/v1/chat/completions"`) {
+		t.Fatalf("jsonl=%s", uploaded)
+	}
+
+	results, err := p.BatchResults(context.Background(), provider.BatchLookupRequest{BatchID: "batch_1", ProviderData: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("results=%d", len(results))
+	}
+	if results[0].Response == nil || results[0].Response.Usage.TotalTokens != 3 {
+		t.Fatalf("result[0]=%#v", results[0])
+	}
+	if results[1].Err == nil {
+		t.Fatalf("expected error for result[1]")
+	}
+}
@@ -37,7 +37,7 @@ type embeddingsResponse struct {
 }
 
 func (p *Provider) Embed(ctx context.Context, req provider.EmbeddingRequest) (provider.EmbeddingResponse, error) {
-	_, cfg, err := clientAndConfig(req.ProviderData)
+	_, cfg, err := clientAndConfig(ctx, req.ProviderData)
 	if err != nil {
 		return provider.EmbeddingResponse{}, &provider.Error{Provider: "openai", Code: "config_error", Message: err.Error(), Retryable: false, Cause: err}
 	}
@@ -48,18 +48,12 @@ func (p *Provider) Embed(ctx context.Context, req provider.EmbeddingRequest) (pr
 		return provider.EmbeddingResponse{}, &provider.Error{Provider: "openai", Code: "invalid_request", Message: "inputs are required", Retryable: false}
 	}
 
-	var opts publicopenai.EmbeddingOptions
-	if v, ok := req.ProviderOptions.(map[string]any); ok {
-		if raw, ok := v["openai"]; ok {
-			switch o := raw.(type) {
-			case publicopenai.EmbeddingOptions:
-				opts = o
-			case *publicopenai.EmbeddingOptions:
-				if o != nil {
-					opts = *o
-				}
-			}
-		}
+	opts, warnings, err := parseOptions[publicopenai.EmbeddingOptions](req.ProviderOptions)
+	if err != nil {
+		return provider.EmbeddingResponse{}, err
+	}
+	if req.Dimensions != nil {
+		opts.Dimensions = req.Dimensions
 	}
 	if opts.EncodingFormat == "" {
 		opts.EncodingFormat = "float"
@@ -98,9 +92,11 @@ func (p *Provider) Embed(ctx context.Context, req provider.EmbeddingRequest) (pr
 		MaxRetries: maxRetries,
 		MinBackoff: cfg.MinBackoff,
 		MaxBackoff: cfg.MaxBackoff,
+		Classify:   cfg.RetryClassifier,
+		Edit:       cfg.RequestEditor,
 	})
 	if err != nil {
-		code, retryable := classifyNetworkErr(err)
+		code, retryable := classifyErr(cfg, err)
 		return provider.EmbeddingResponse{}, &provider.Error{Provider: "openai", Code: code, Message: err.Error(), Retryable: retryable, Cause: err}
 	}
 	defer resp.Body.Close()
@@ -112,9 +108,11 @@ func (p *Provider) Embed(ctx context.Context, req provider.EmbeddingRequest) (pr
 			return provider.EmbeddingResponse{}, &provider.Error{
 				Provider:  "openai",
 				Code:      stringifyCode(er.Error.Code, er.Error.Type),
+				Type:      er.Error.Type,
+				Param:     er.Error.Param,
 				Status:    resp.StatusCode,
 				Message:   er.Error.Message,
-				Retryable: shouldRetryStatus(resp.StatusCode),
+				Retryable: retryableStatus(cfg, resp.StatusCode, b),
 			}
 		}
 		return provider.EmbeddingResponse{}, &provider.Error{
@@ -122,7 +120,7 @@ func (p *Provider) Embed(ctx context.Context, req provider.EmbeddingRequest) (pr
 			Code:      "http_error",
 			Status:    resp.StatusCode,
 			Message:   strings.TrimSpace(string(b)),
-			Retryable: shouldRetryStatus(resp.StatusCode),
+			Retryable: retryableStatus(cfg, resp.StatusCode, b),
 		}
 	}
 
@@ -153,7 +151,9 @@ func (p *Provider) Embed(ctx context.Context, req provider.EmbeddingRequest) (pr
 			PromptTokens: out.Usage.PromptTokens,
 			TotalTokens:  out.Usage.TotalTokens,
 		},
+		Warnings:    warnings,
 		RawResponse: rawBody,
+		RequestID:   requestID(resp),
 	}, nil
 }
 
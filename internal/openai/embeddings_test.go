@@ -0,0 +1,96 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+	publicopenai "github.com/bitop-dev/ai/openai"
+)
+
+func TestEmbed_DimensionsOverridesProviderOptions(t *testing.T) {
+	var body struct {
+		Dimensions *int `json:"dimensions"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[0.5],"index":0}],"usage":{"prompt_tokens":1,"total_tokens":1}}`))
+	}))
+	defer srv.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: srv.URL})
+	optDims, dims := 1024, 64
+	_, err := (&Provider{}).Embed(context.Background(), provider.EmbeddingRequest{
+		Model:           "text-embedding-3-small",
+		Inputs:          []string{"hi"},
+		Dimensions:      &dims,
+		ProviderOptions: map[string]any{"openai": publicopenai.EmbeddingOptions{Dimensions: &optDims}},
+		ProviderData:    client,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body.Dimensions == nil || *body.Dimensions != 64 {
+		t.Fatalf("dimensions=%v", body.Dimensions)
+	}
+}
+
+func TestEmbed_ProviderOptionsValidation(t *testing.T) {
+	var body struct {
+		Dimensions     *int   `json:"dimensions"`
+		EncodingFormat string `json:"encoding_format"`
+	}
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[0.5],"index":0}],"usage":{"prompt_tokens":1,"total_tokens":1}}`))
+	}))
+	defer srv.Close()
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: srv.URL})
+
+	embed := func(opts map[string]any) (provider.EmbeddingResponse, error) {
+		return (&Provider{}).Embed(context.Background(), provider.EmbeddingRequest{
+			Model:           "text-embedding-3-small",
+			Inputs:          []string{"hi"},
+			ProviderOptions: opts,
+			ProviderData:    client,
+		})
+	}
+
+	resp, err := embed(map[string]any{
+		"openia": publicopenai.EmbeddingOptions{},
+		"openai": map[string]any{"dimensions": 256},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body.Dimensions == nil || *body.Dimensions != 256 {
+		t.Fatalf("dimensions=%v", body.Dimensions)
+	}
+	if len(resp.Warnings) != 1 || resp.Warnings[0] != `provider options for "openia" are ignored by the openai provider` {
+		t.Fatalf("warnings=%q", resp.Warnings)
+	}
+
+	for name, opts := range map[string]map[string]any{
+		"wrong type":    {"openai": publicopenai.ImageOptions{Quality: "hd"}},
+		"unknown field": {"openai": map[string]any{"dimension": 256}},
+	} {
+		_, err := embed(opts)
+		var pe *provider.Error
+		if !errors.As(err, &pe) || pe.Code != "invalid_request" {
+			t.Fatalf("%s: err=%v", name, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("calls=%d", calls)
+	}
+}
@@ -1,11 +1,16 @@
 package openai
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/bitop-dev/ai/internal/httpx"
@@ -22,21 +27,31 @@ type imagesRequest struct {
 	Style   string `json:"style,omitempty"`
 	Seed    *int64 `json:"seed,omitempty"`
 
+	// gpt-image output options.
+	Background        string `json:"background,omitempty"`
+	OutputFormat      string `json:"output_format,omitempty"`
+	OutputCompression *int   `json:"output_compression,omitempty"`
+
 	// Prefer base64 so the SDK doesn't need to fetch URLs.
 	ResponseFormat string `json:"response_format,omitempty"`
 }
 
 type imagesResponse struct {
 	Created int64 `json:"created"`
-	Data    []struct {
+	// OutputFormat is reported by gpt-image models.
+	OutputFormat string `json:"output_format,omitempty"`
+	// Seed is not returned by OpenAI itself, but some compatible endpoints echo it.
+	Seed *int64 `json:"seed,omitempty"`
+	Data []struct {
 		B64JSON       string `json:"b64_json,omitempty"`
 		URL           string `json:"url,omitempty"`
 		RevisedPrompt string `json:"revised_prompt,omitempty"`
+		Seed          *int64 `json:"seed,omitempty"`
 	} `json:"data"`
 }
 
 func (p *Provider) GenerateImage(ctx context.Context, req provider.GenerateImageRequest) (provider.GenerateImageResponse, error) {
-	_, cfg, err := clientAndConfig(req.ProviderData)
+	_, cfg, err := clientAndConfig(ctx, req.ProviderData)
 	if err != nil {
 		return provider.GenerateImageResponse{}, &provider.Error{Provider: "openai", Code: "config_error", Message: err.Error(), Retryable: false, Cause: err}
 	}
@@ -51,18 +66,9 @@ func (p *Provider) GenerateImage(ctx context.Context, req provider.GenerateImage
 		return provider.GenerateImageResponse{}, &provider.Error{Provider: "openai", Code: "invalid_request", Message: "aspectRatio is not supported for OpenAI images; use size", Retryable: false}
 	}
 
-	var opts publicopenai.ImageOptions
-	if v, ok := req.ProviderOptions.(map[string]any); ok {
-		if raw, ok := v["openai"]; ok {
-			switch o := raw.(type) {
-			case publicopenai.ImageOptions:
-				opts = o
-			case *publicopenai.ImageOptions:
-				if o != nil {
-					opts = *o
-				}
-			}
-		}
+	opts, warnings, err := parseOptions[publicopenai.ImageOptions](req.ProviderOptions)
+	if err != nil {
+		return provider.GenerateImageResponse{}, err
 	}
 
 	payload := imagesRequest{
@@ -75,9 +81,33 @@ func (p *Provider) GenerateImage(ctx context.Context, req provider.GenerateImage
 		Seed:           req.Seed,
 		ResponseFormat: "b64_json",
 	}
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return provider.GenerateImageResponse{}, &provider.Error{Provider: "openai", Code: "marshal_error", Message: err.Error(), Retryable: false, Cause: err}
+	if req.Background != "" || req.OutputFormat != "" || req.OutputCompression != nil {
+		if supportsOutputOptions(req.Model) {
+			if c := req.OutputCompression; c != nil && (*c < 0 || *c > 100) {
+				return provider.GenerateImageResponse{}, &provider.Error{Provider: "openai", Code: "invalid_request", Message: fmt.Sprintf("output compression %d is out of range 0-100", *c), Retryable: false}
+			}
+			payload.Background = req.Background
+			payload.OutputFormat = req.OutputFormat
+			payload.OutputCompression = req.OutputCompression
+		} else {
+			warnings = append(warnings, fmt.Sprintf("background, output format and output compression are not supported for model %q; ignored", req.Model))
+		}
+	}
+	var body []byte
+	var contentType string
+	if len(req.ReferenceImages) > 0 {
+		if !supportsReferenceImages(req.Model) {
+			return provider.GenerateImageResponse{}, &provider.Error{Provider: "openai", Code: "invalid_request", Message: fmt.Sprintf("reference images are not supported for model %q", req.Model), Retryable: false}
+		}
+		body, contentType, err = imagesMultipartBody(payload, req.ReferenceImages)
+		if err != nil {
+			return provider.GenerateImageResponse{}, &provider.Error{Provider: "openai", Code: "request_error", Message: err.Error(), Retryable: false, Cause: err}
+		}
+	} else {
+		body, err = json.Marshal(payload)
+		if err != nil {
+			return provider.GenerateImageResponse{}, &provider.Error{Provider: "openai", Code: "marshal_error", Message: err.Error(), Retryable: false, Cause: err}
+		}
 	}
 
 	u, err := imagesURL(cfg)
@@ -87,6 +117,9 @@ func (p *Provider) GenerateImage(ctx context.Context, req provider.GenerateImage
 
 	h := make(http.Header)
 	h.Set("Authorization", "Bearer "+cfg.APIKey)
+	if contentType != "" {
+		h.Set("Content-Type", contentType)
+	}
 	for k, v := range cfg.Headers {
 		h.Set(k, v)
 	}
@@ -99,13 +132,19 @@ func (p *Provider) GenerateImage(ctx context.Context, req provider.GenerateImage
 		maxRetries = *req.MaxRetries
 	}
 
-	resp, err := httpx.DoJSON(ctx, cfg.HTTPClient, http.MethodPost, u, body, h, httpx.RetryPolicy{
+	do := httpx.DoJSON
+	if contentType != "" {
+		do = httpx.Do
+	}
+	resp, err := do(ctx, cfg.HTTPClient, http.MethodPost, u, body, h, httpx.RetryPolicy{
 		MaxRetries: maxRetries,
 		MinBackoff: cfg.MinBackoff,
 		MaxBackoff: cfg.MaxBackoff,
+		Classify:   cfg.RetryClassifier,
+		Edit:       cfg.RequestEditor,
 	})
 	if err != nil {
-		code, retryable := classifyNetworkErr(err)
+		code, retryable := classifyErr(cfg, err)
 		return provider.GenerateImageResponse{}, &provider.Error{Provider: "openai", Code: code, Message: err.Error(), Retryable: retryable, Cause: err}
 	}
 	defer resp.Body.Close()
@@ -117,9 +156,11 @@ func (p *Provider) GenerateImage(ctx context.Context, req provider.GenerateImage
 			return provider.GenerateImageResponse{}, &provider.Error{
 				Provider:  "openai",
 				Code:      stringifyCode(er.Error.Code, er.Error.Type),
+				Type:      er.Error.Type,
+				Param:     er.Error.Param,
 				Status:    resp.StatusCode,
 				Message:   er.Error.Message,
-				Retryable: shouldRetryStatus(resp.StatusCode),
+				Retryable: retryableStatus(cfg, resp.StatusCode, b),
 			}
 		}
 		return provider.GenerateImageResponse{}, &provider.Error{
@@ -127,7 +168,7 @@ func (p *Provider) GenerateImage(ctx context.Context, req provider.GenerateImage
 			Code:      "http_error",
 			Status:    resp.StatusCode,
 			Message:   strings.TrimSpace(string(b)),
-			Retryable: shouldRetryStatus(resp.StatusCode),
+			Retryable: retryableStatus(cfg, resp.StatusCode, b),
 		}
 	}
 
@@ -140,13 +181,23 @@ func (p *Provider) GenerateImage(ctx context.Context, req provider.GenerateImage
 		return provider.GenerateImageResponse{}, &provider.Error{Provider: "openai", Code: "decode_error", Message: err.Error(), Retryable: false, Cause: err}
 	}
 
+	mediaType := imageMediaType(out.OutputFormat, payload.OutputFormat)
 	images := make([]provider.Image, 0, len(out.Data))
 	openaiImagesMeta := make([]map[string]any, 0, len(out.Data))
 	for _, d := range out.Data {
 		if d.B64JSON == "" {
 			continue
 		}
-		images = append(images, provider.Image{Base64: d.B64JSON, MediaType: "image/png"})
+		seed := d.Seed
+		if seed == nil {
+			seed = out.Seed
+		}
+		images = append(images, provider.Image{
+			Base64:        d.B64JSON,
+			MediaType:     mediaType,
+			RevisedPrompt: d.RevisedPrompt,
+			Seed:          seed,
+		})
 		openaiImagesMeta = append(openaiImagesMeta, map[string]any{"revisedPrompt": d.RevisedPrompt})
 	}
 
@@ -162,11 +213,109 @@ func (p *Provider) GenerateImage(ctx context.Context, req provider.GenerateImage
 	return provider.GenerateImageResponse{
 		N:                req.N,
 		Images:           images,
+		Warnings:         warnings,
 		ProviderMetadata: md,
 		RawResponse:      rawBody,
+		RequestID:        requestID(resp),
 	}, nil
 }
 
+// supportsReferenceImages reports whether model accepts input images on the
+// generations endpoint. Only the gpt-image family does; dall-e models need
+// the edits endpoint.
+func supportsReferenceImages(model string) bool {
+	return strings.HasPrefix(model, "gpt-image-")
+}
+
+// supportsOutputOptions reports whether model accepts background,
+// output_format and output_compression (the gpt-image family).
+func supportsOutputOptions(model string) bool {
+	return strings.HasPrefix(model, "gpt-image-")
+}
+
+// imageMediaType returns the media type of images in the reported or
+// requested output format, defaulting to PNG.
+func imageMediaType(formats ...string) string {
+	for _, f := range formats {
+		switch strings.ToLower(f) {
+		case "png":
+			return "image/png"
+		case "jpeg", "jpg":
+			return "image/jpeg"
+		case "webp":
+			return "image/webp"
+		}
+	}
+	return "image/png"
+}
+
+// imagesMultipartBody encodes payload as multipart/form-data with the
+// reference images attached as image[] files.
+func imagesMultipartBody(payload imagesRequest, images [][]byte) ([]byte, string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	_ = w.WriteField("model", payload.Model)
+	_ = w.WriteField("prompt", payload.Prompt)
+	if payload.N > 0 {
+		_ = w.WriteField("n", strconv.Itoa(payload.N))
+	}
+	if payload.Size != "" {
+		_ = w.WriteField("size", payload.Size)
+	}
+	if payload.Quality != "" {
+		_ = w.WriteField("quality", payload.Quality)
+	}
+	if payload.Style != "" {
+		_ = w.WriteField("style", payload.Style)
+	}
+	if payload.Seed != nil {
+		_ = w.WriteField("seed", strconv.FormatInt(*payload.Seed, 10))
+	}
+	if payload.Background != "" {
+		_ = w.WriteField("background", payload.Background)
+	}
+	if payload.OutputFormat != "" {
+		_ = w.WriteField("output_format", payload.OutputFormat)
+	}
+	if payload.OutputCompression != nil {
+		_ = w.WriteField("output_compression", strconv.Itoa(*payload.OutputCompression))
+	}
+	if payload.ResponseFormat != "" {
+		_ = w.WriteField("response_format", payload.ResponseFormat)
+	}
+
+	for i, img := range images {
+		if len(img) == 0 {
+			return nil, "", fmt.Errorf("reference image %d is empty", i)
+		}
+		mediaType := http.DetectContentType(img)
+		ext := "png"
+		switch mediaType {
+		case "image/jpeg":
+			ext = "jpg"
+		case "image/webp":
+			ext = "webp"
+		case "image/gif":
+			ext = "gif"
+		}
+		hdr := make(textproto.MIMEHeader)
+		hdr.Set("Content-Disposition", fmt.Sprintf(`form-data; name="image[]"; filename="image%d.%s"`, i, ext))
+		hdr.Set("Content-Type", mediaType)
+		part, err := w.CreatePart(hdr)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(img); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), w.FormDataContentType(), nil
+}
+
 func imagesURL(cfg publicopenai.Config) (string, error) {
 	base := strings.TrimRight(cfg.BaseURL, "/")
 	prefix := strings.TrimRight(cfg.APIPrefix, "/")
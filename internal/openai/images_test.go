@@ -0,0 +1,150 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+	publicopenai "github.com/bitop-dev/ai/openai"
+)
+
+func TestGenerateImage_RevisedPromptAndSeed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"created":1,"seed":7,"data":[{"b64_json":"aGk=","revised_prompt":"a red fox"},{"b64_json":"aGk=","seed":9}]}`))
+	}))
+	defer srv.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: srv.URL})
+	resp, err := (&Provider{}).GenerateImage(context.Background(), provider.GenerateImageRequest{
+		Model:        "dall-e-3",
+		Prompt:       "fox",
+		ProviderData: client,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Images) != 2 {
+		t.Fatalf("images=%d", len(resp.Images))
+	}
+	if resp.Images[0].RevisedPrompt != "a red fox" {
+		t.Fatalf("RevisedPrompt=%q", resp.Images[0].RevisedPrompt)
+	}
+	if s := resp.Images[0].Seed; s == nil || *s != 7 {
+		t.Fatalf("image 0 seed=%v", s)
+	}
+	if s := resp.Images[1].Seed; s == nil || *s != 9 {
+		t.Fatalf("image 1 seed=%v", s)
+	}
+}
+
+func TestGenerateImage_ReferenceImagesMultipart(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/images/generations" {
+			t.Errorf("path=%s", r.URL.Path)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("parse multipart: %v", err)
+		}
+		if got := r.FormValue("model"); got != "gpt-image-1" {
+			t.Errorf("model=%q", got)
+		}
+		if got := r.FormValue("prompt"); got != "in this style" {
+			t.Errorf("prompt=%q", got)
+		}
+		files := r.MultipartForm.File["image[]"]
+		if len(files) != 2 {
+			t.Fatalf("image[] files=%d", len(files))
+		}
+		if ct := files[0].Header.Get("Content-Type"); ct != "image/png" {
+			t.Errorf("content-type=%q", ct)
+		}
+		_, _ = w.Write([]byte(`{"created":1,"data":[{"b64_json":"aGk="}]}`))
+	}))
+	defer srv.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: srv.URL})
+	resp, err := (&Provider{}).GenerateImage(context.Background(), provider.GenerateImageRequest{
+		Model:           "gpt-image-1",
+		Prompt:          "in this style",
+		ReferenceImages: [][]byte{png, png},
+		ProviderData:    client,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Images) != 1 {
+		t.Fatalf("images=%d", len(resp.Images))
+	}
+}
+
+func TestGenerateImage_ReferenceImagesUnsupportedModel(t *testing.T) {
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: "http://127.0.0.1:0"})
+	_, err := (&Provider{}).GenerateImage(context.Background(), provider.GenerateImageRequest{
+		Model:           "dall-e-3",
+		Prompt:          "in this style",
+		ReferenceImages: [][]byte{[]byte("x")},
+		ProviderData:    client,
+	})
+	var pe *provider.Error
+	if !errors.As(err, &pe) || pe.Code != "invalid_request" {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestGenerateImage_OutputOptions(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(`{"created":1,"output_format":"webp","data":[{"b64_json":"aGk="}]}`))
+	}))
+	defer srv.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: srv.URL})
+	compression := 60
+	req := provider.GenerateImageRequest{
+		Model:             "gpt-image-1",
+		Prompt:            "logo",
+		Background:        "transparent",
+		OutputFormat:      "webp",
+		OutputCompression: &compression,
+		ProviderData:      client,
+	}
+	resp, err := (&Provider{}).GenerateImage(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body["background"] != "transparent" || body["output_format"] != "webp" || body["output_compression"] != float64(60) {
+		t.Fatalf("body=%v", body)
+	}
+	if resp.Images[0].MediaType != "image/webp" || len(resp.Warnings) != 0 {
+		t.Fatalf("media type=%q warnings=%v", resp.Images[0].MediaType, resp.Warnings)
+	}
+
+	// Out-of-range compression is rejected before the request is sent.
+	compression = 120
+	body = nil
+	var perr *provider.Error
+	if _, err := (&Provider{}).GenerateImage(context.Background(), req); !errors.As(err, &perr) || perr.Code != "invalid_request" || body != nil {
+		t.Fatalf("err=%v body=%v", err, body)
+	}
+
+	// dall-e models don't accept the options; they are dropped with a warning.
+	req.Model = "dall-e-3"
+	resp, err = (&Provider{}).GenerateImage(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := body["output_format"]; ok {
+		t.Fatalf("body=%v", body)
+	}
+	if len(resp.Warnings) != 1 {
+		t.Fatalf("warnings=%v", resp.Warnings)
+	}
+}
@@ -0,0 +1,51 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+type modelsResponse struct {
+	Data []struct {
+		ID      string `json:"id"`
+		Created int64  `json:"created"`
+		OwnedBy string `json:"owned_by"`
+	} `json:"data"`
+}
+
+func (p *Provider) ListModels(ctx context.Context, req provider.ListModelsRequest) ([]provider.ModelInfo, error) {
+	_, cfg, err := clientAndConfig(ctx, req.ProviderData)
+	if err != nil {
+		return nil, &provider.Error{Provider: "openai", Code: "config_error", Message: err.Error(), Retryable: false, Cause: err}
+	}
+	u, err := apiURL(cfg, "/models")
+	if err != nil {
+		return nil, &provider.Error{Provider: "openai", Code: "url_error", Message: err.Error(), Retryable: false, Cause: err}
+	}
+	raw, err := callAPI(ctx, cfg, http.MethodGet, u, nil, "", req.Headers, req.MaxRetries)
+	if err != nil {
+		return nil, err
+	}
+	var out modelsResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, &provider.Error{Provider: "openai", Code: "decode_error", Message: err.Error(), Retryable: false, Cause: err}
+	}
+	models := make([]provider.ModelInfo, 0, len(out.Data))
+	for _, m := range out.Data {
+		info := provider.ModelInfo{ID: m.ID, OwnedBy: m.OwnedBy}
+		if m.Created > 0 {
+			info.Created = time.Unix(m.Created, 0).UTC()
+		}
+		models = append(models, info)
+	}
+	// The API returns models in no particular order.
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
+var _ provider.ModelLister = (*Provider)(nil)
@@ -0,0 +1,74 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+	publicopenai "github.com/bitop-dev/ai/openai"
+)
+
+func TestListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/models" {
+			t.Errorf("%s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer k" {
+			t.Errorf("Authorization=%q", got)
+		}
+		_, _ = w.Write([]byte(`{"object":"list","data":[
+			{"id":"gpt-4o-mini","object":"model","created":1721172741,"owned_by":"system"},
+			{"id":"dall-e-3","object":"model","created":1698785189,"owned_by":"system"}
+		]}`))
+	}))
+	defer srv.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: srv.URL})
+	models, err := (&Provider{}).ListModels(context.Background(), provider.ListModelsRequest{ProviderData: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 2 || models[0].ID != "dall-e-3" || models[1].ID != "gpt-4o-mini" {
+		t.Fatalf("models=%+v", models)
+	}
+	if models[1].Created.Unix() != 1721172741 || models[1].OwnedBy != "system" {
+		t.Fatalf("model=%+v", models[1])
+	}
+
+	// The public helper goes through the registered provider.
+	listed, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 2 || listed[0].ID != "dall-e-3" || listed[1].Created.Unix() != 1721172741 {
+		t.Fatalf("listed=%+v", listed)
+	}
+}
+
+func TestListModels_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"message":"Incorrect API key provided","type":"invalid_request_error","code":"invalid_api_key"}}`))
+	}))
+	defer srv.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "bad", BaseURL: srv.URL})
+	_, err := (&Provider{}).ListModels(context.Background(), provider.ListModelsRequest{ProviderData: client})
+	var perr *provider.Error
+	if !errors.As(err, &perr) || perr.Status != http.StatusUnauthorized || perr.Code != "invalid_api_key" {
+		t.Fatalf("err=%v", err)
+	}
+
+	// The public helper reports it as the exported *openai.Error.
+	_, err = client.ListModels(context.Background())
+	var oerr *publicopenai.Error
+	if !errors.As(err, &oerr) || oerr.Status != http.StatusUnauthorized || oerr.Code != "invalid_api_key" {
+		t.Fatalf("err=%v", err)
+	}
+	if got := err.Error(); got != "openai: Incorrect API key provided" {
+		t.Fatalf("message=%q", got)
+	}
+}
@@ -82,22 +82,51 @@ func TestBuildRequest_TextOnlyContentString(t *testing.T) {
 	}
 }
 
-func TestBuildRequest_AudioPartUnsupported(t *testing.T) {
+func TestBuildRequest_AudioPartInputAudio(t *testing.T) {
 	req := provider.Request{
-		Model: "gpt-4o-mini",
+		Model: "gpt-4o-audio-preview",
 		Messages: []provider.Message{
 			{
 				Role: provider.RoleUser,
 				Content: []provider.ContentPart{
 					provider.TextPart{Text: "listen"},
-					provider.AudioPart{Format: "wav", Base64: "AA=="},
+					provider.AudioPart{Format: "mp3", Base64: "AA=="},
+					provider.AudioPart{Bytes: []byte{1, 2}},
 				},
 			},
 		},
 	}
 
-	_, err := buildRequest(req, false)
-	if err == nil {
-		t.Fatal("expected error")
+	payload, err := buildRequest(req, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Messages []struct {
+			Content []map[string]any `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	content := decoded.Messages[0].Content
+	if len(content) != 3 || content[1]["type"] != "input_audio" {
+		t.Fatalf("content=%#v", content)
+	}
+	if a := content[1]["input_audio"].(map[string]any); a["data"] != "AA==" || a["format"] != "mp3" {
+		t.Fatalf("input_audio=%#v", a)
+	}
+	// Bytes are base64-encoded; the format defaults to wav.
+	if a := content[2]["input_audio"].(map[string]any); a["data"] != "AQI=" || a["format"] != "wav" {
+		t.Fatalf("input_audio=%#v", a)
+	}
+
+	req.Messages[0].Content = []provider.ContentPart{provider.AudioPart{Format: "wav"}}
+	if _, err := buildRequest(req, false); err == nil {
+		t.Fatal("expected error for empty audio part")
 	}
 }
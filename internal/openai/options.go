@@ -0,0 +1,72 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// parseOptions extracts the "openai" entry of a ProviderOptions map into T.
+//
+// The entry may be a T, a *T, or a JSON object (map[string]any or
+// json.RawMessage) with T's field names. Any other value, or a JSON object
+// with unknown fields, is an invalid_request error. Entries keyed by other
+// providers are ignored and reported as warnings so typos do not go unnoticed.
+func parseOptions[T any](raw any) (T, []string, error) {
+	var opts T
+	m, ok := raw.(map[string]any)
+	if !ok {
+		if raw != nil {
+			return opts, nil, optionsError(fmt.Sprintf("provider options must be a map keyed by provider, got %T", raw))
+		}
+		return opts, nil, nil
+	}
+
+	var warnings []string
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if k != "openai" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		warnings = append(warnings, fmt.Sprintf("provider options for %q are ignored by the openai provider", k))
+	}
+
+	v, ok := m["openai"]
+	if !ok || v == nil {
+		return opts, warnings, nil
+	}
+	switch o := v.(type) {
+	case T:
+		opts = o
+	case *T:
+		if o != nil {
+			opts = *o
+		}
+	case map[string]any, json.RawMessage:
+		b, ok := o.(json.RawMessage)
+		if !ok {
+			var err error
+			if b, err = json.Marshal(o); err != nil {
+				return opts, warnings, optionsError(err.Error())
+			}
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&opts); err != nil {
+			return opts, warnings, optionsError(err.Error())
+		}
+	default:
+		return opts, warnings, optionsError(fmt.Sprintf("expected %T, got %T", opts, v))
+	}
+	return opts, warnings, nil
+}
+
+func optionsError(msg string) error {
+	return &provider.Error{Provider: "openai", Code: "invalid_request", Message: "invalid openai provider options: " + msg, Retryable: false}
+}
@@ -23,10 +23,13 @@ import (
 type Provider struct{}
 
 func (p *Provider) Generate(ctx context.Context, req provider.Request) (provider.Response, error) {
-	_, cfg, err := clientAndConfig(req.ProviderData)
+	_, cfg, err := clientAndConfig(ctx, req.ProviderData)
 	if err != nil {
 		return provider.Response{}, &provider.Error{Provider: "openai", Code: "config_error", Message: err.Error(), Retryable: false, Cause: err}
 	}
+	if req.BaseURLOverride != "" {
+		cfg.BaseURL = req.BaseURLOverride
+	}
 
 	payload, err := buildRequest(req, false)
 	if err != nil {
@@ -60,9 +63,11 @@ func (p *Provider) Generate(ctx context.Context, req provider.Request) (provider
 		MaxRetries: maxRetries,
 		MinBackoff: cfg.MinBackoff,
 		MaxBackoff: cfg.MaxBackoff,
+		Classify:   cfg.RetryClassifier,
+		Edit:       cfg.RequestEditor,
 	})
 	if err != nil {
-		code, retryable := classifyNetworkErr(err)
+		code, retryable := classifyErr(cfg, err)
 		return provider.Response{}, &provider.Error{Provider: "openai", Code: code, Message: err.Error(), Retryable: retryable, Cause: err}
 	}
 	defer resp.Body.Close()
@@ -74,9 +79,11 @@ func (p *Provider) Generate(ctx context.Context, req provider.Request) (provider
 			return provider.Response{}, &provider.Error{
 				Provider:  "openai",
 				Code:      stringifyCode(er.Error.Code, er.Error.Type),
+				Type:      er.Error.Type,
+				Param:     er.Error.Param,
 				Status:    resp.StatusCode,
 				Message:   er.Error.Message,
-				Retryable: shouldRetryStatus(resp.StatusCode),
+				Retryable: retryableStatus(cfg, resp.StatusCode, b),
 			}
 		}
 		return provider.Response{}, &provider.Error{
@@ -84,7 +91,7 @@ func (p *Provider) Generate(ctx context.Context, req provider.Request) (provider
 			Code:      "http_error",
 			Status:    resp.StatusCode,
 			Message:   strings.TrimSpace(string(b)),
-			Retryable: shouldRetryStatus(resp.StatusCode),
+			Retryable: retryableStatus(cfg, resp.StatusCode, b),
 		}
 	}
 
@@ -109,15 +116,22 @@ func (p *Provider) Generate(ctx context.Context, req provider.Request) (provider
 			CompletionTokens: out.Usage.CompletionTokens,
 			TotalTokens:      out.Usage.TotalTokens,
 		},
-		FinishReason: provider.FinishReason(c.FinishReason),
+		FinishReason:      provider.FinishReason(c.FinishReason),
+		ModelID:           out.Model,
+		SystemFingerprint: out.SystemFingerprint,
+		ResponseID:        out.ID,
+		RequestID:         requestID(resp),
 	}, nil
 }
 
 func (p *Provider) Stream(ctx context.Context, req provider.Request) (provider.Stream, error) {
-	_, cfg, err := clientAndConfig(req.ProviderData)
+	_, cfg, err := clientAndConfig(ctx, req.ProviderData)
 	if err != nil {
 		return nil, &provider.Error{Provider: "openai", Code: "config_error", Message: err.Error(), Retryable: false, Cause: err}
 	}
+	if req.BaseURLOverride != "" {
+		cfg.BaseURL = req.BaseURLOverride
+	}
 
 	payload, err := buildRequest(req, true)
 	if err != nil {
@@ -152,9 +166,11 @@ func (p *Provider) Stream(ctx context.Context, req provider.Request) (provider.S
 		MaxRetries: maxRetries,
 		MinBackoff: cfg.MinBackoff,
 		MaxBackoff: cfg.MaxBackoff,
+		Classify:   cfg.RetryClassifier,
+		Edit:       cfg.RequestEditor,
 	})
 	if err != nil {
-		code, retryable := classifyNetworkErr(err)
+		code, retryable := classifyErr(cfg, err)
 		return nil, &provider.Error{Provider: "openai", Code: code, Message: err.Error(), Retryable: retryable, Cause: err}
 	}
 
@@ -166,9 +182,11 @@ func (p *Provider) Stream(ctx context.Context, req provider.Request) (provider.S
 			return nil, &provider.Error{
 				Provider:  "openai",
 				Code:      stringifyCode(er.Error.Code, er.Error.Type),
+				Type:      er.Error.Type,
+				Param:     er.Error.Param,
 				Status:    httpResp.StatusCode,
 				Message:   er.Error.Message,
-				Retryable: shouldRetryStatus(httpResp.StatusCode),
+				Retryable: retryableStatus(cfg, httpResp.StatusCode, b),
 			}
 		}
 		return nil, &provider.Error{
@@ -176,19 +194,37 @@ func (p *Provider) Stream(ctx context.Context, req provider.Request) (provider.S
 			Code:      "http_error",
 			Status:    httpResp.StatusCode,
 			Message:   strings.TrimSpace(string(b)),
-			Retryable: shouldRetryStatus(httpResp.StatusCode),
+			Retryable: retryableStatus(cfg, httpResp.StatusCode, b),
 		}
 	}
 
-	return newStream(httpResp, sse.NewDecoder(httpResp.Body)), nil
+	st := newStream(httpResp, sse.NewDecoder(httpResp.Body))
+	st.cfg = cfg
+	return st, nil
+}
+
+// requestID returns the ID OpenAI assigns to an HTTP request, which support
+// asks for when investigating a call.
+func requestID(resp *http.Response) string {
+	if resp == nil {
+		return ""
+	}
+	return resp.Header.Get("X-Request-Id")
 }
 
-func clientAndConfig(providerData any) (*publicopenai.Client, publicopenai.Config, error) {
+// clientAndConfig returns the client's config with APIKey set to the key
+// chosen for this call.
+func clientAndConfig(ctx context.Context, providerData any) (*publicopenai.Client, publicopenai.Config, error) {
 	c, ok := providerData.(*publicopenai.Client)
 	if !ok || c == nil {
 		return nil, publicopenai.Config{}, fmt.Errorf("openai provider requires a client-bound model ref")
 	}
 	cfg := c.Config()
+	key, err := c.APIKey(ctx)
+	if err != nil {
+		return nil, publicopenai.Config{}, fmt.Errorf("openai API key: %w", err)
+	}
+	cfg.APIKey = key
 	if cfg.APIKey == "" {
 		return nil, publicopenai.Config{}, fmt.Errorf("openai API key is required")
 	}
@@ -214,20 +250,41 @@ func buildRequest(req provider.Request, stream bool) (chatCompletionRequest, err
 		}
 		msgs = append(msgs, cm)
 	}
+	if req.AssistantPrefix != "" {
+		// Chat completions has no native prefill; a trailing assistant
+		// message is the closest equivalent.
+		content, _ := json.Marshal(req.AssistantPrefix)
+		msgs = append(msgs, chatMessage{Role: "assistant", Content: content})
+	}
 
 	var tools []tool
+	var webSearch json.RawMessage
 	if len(req.Tools) > 0 {
 		tools = make([]tool, 0, len(req.Tools))
 		for _, t := range req.Tools {
 			if t.Name == "" {
 				return chatCompletionRequest{}, fmt.Errorf("tool name is required")
 			}
+			if t.Hosted {
+				// Chat completions run only web search themselves, configured
+				// by a request field rather than a tool entry. The other
+				// built-in tools need the Responses API.
+				if t.Name != "web_search" {
+					return chatCompletionRequest{}, fmt.Errorf("hosted tool %q is not supported by this provider: chat completions support only web_search", t.Name)
+				}
+				webSearch = json.RawMessage(`{}`)
+				if len(t.Config) > 0 && string(t.Config) != "null" {
+					webSearch = t.Config
+				}
+				continue
+			}
 			tools = append(tools, tool{
 				Type: "function",
 				Function: toolFunction{
 					Name:        t.Name,
 					Description: t.Description,
 					Parameters:  t.InputSchema,
+					Strict:      t.Strict,
 				},
 			})
 		}
@@ -243,6 +300,14 @@ func buildRequest(req provider.Request, stream bool) (chatCompletionRequest, err
 		Stop:        append([]string(nil), req.Stop...),
 		Metadata:    req.Metadata,
 		Stream:      stream,
+
+		ReasoningEffort: req.ReasoningEffort,
+		Verbosity:       req.Verbosity,
+
+		WebSearchOptions: webSearch,
+	}
+	if req.Prediction != "" {
+		out.Prediction = &prediction{Type: "content", Content: req.Prediction}
 	}
 	if stream {
 		out.StreamOptions = &streamOptions{IncludeUsage: true}
@@ -318,7 +383,21 @@ func splitContentParts(parts []provider.ContentPart) (json.RawMessage, bool, []t
 				}{URL: url},
 			})
 		case provider.AudioPart:
-			return nil, false, nil, fmt.Errorf("openai chat completions does not support audio content parts; use Transcribe/GenerateSpeech instead")
+			hasContent = true
+			hasMultimodal = true
+			data, format, err := audioPartToInput(v)
+			if err != nil {
+				return nil, false, nil, err
+			}
+			contentParts = append(contentParts, chatContentPart{
+				Type: "input_audio",
+				InputAudio: &struct {
+					Data   string `json:"data"`
+					Format string `json:"format,omitempty"`
+				}{Data: data, Format: format},
+			})
+		case provider.ReasoningPart:
+			// Chat completions does not accept prior reasoning.
 		default:
 			return nil, false, nil, fmt.Errorf("unsupported content part %T", p)
 		}
@@ -383,6 +462,9 @@ func fromChatMessage(m chatMessage) (provider.Message, error) {
 		return provider.Message{}, fmt.Errorf("missing role")
 	}
 	var parts []provider.ContentPart
+	if m.ReasoningContent != "" {
+		parts = append(parts, provider.ReasoningPart{Text: m.ReasoningContent})
+	}
 	if len(m.Content) > 0 {
 		// content can be a string or an array of parts
 		var s string
@@ -430,22 +512,49 @@ func fromChatMessage(m chatMessage) (provider.Message, error) {
 	}, nil
 }
 
-var _ provider.Provider = (*Provider)(nil)
+// SupportsSchemaRefs reports that OpenAI accepts $ref/$defs in tool and
+// response schemas.
+func (p *Provider) SupportsSchemaRefs() bool { return true }
+
+// BuildRequestBody renders the chat completions payload for req. Credentials
+// travel in headers, so the body is safe to log.
+func (p *Provider) BuildRequestBody(req provider.Request, stream bool) (json.RawMessage, error) {
+	payload, err := buildRequest(req, stream)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(payload)
+}
+
+var (
+	_ provider.Provider           = (*Provider)(nil)
+	_ provider.SchemaRefsProvider = (*Provider)(nil)
+	_ provider.RequestBodyBuilder = (*Provider)(nil)
+)
 
 type stream struct {
 	httpResp *http.Response
 	dec      *sse.Decoder
+	cfg      publicopenai.Config
 
 	curDelta provider.Delta
 	final    *provider.Response
 	err      error
 
 	// Aggregate final assistant message.
-	textBuilder strings.Builder
+	textBuilder      strings.Builder
+	reasoningBuilder strings.Builder
 
 	toolCallsByIndex map[int]*toolCallAgg
-	finishReason     provider.FinishReason
-	usage            provider.Usage
+	// curToolIndex is the index of the most recent tool call delta; it is the
+	// fallback for continuation chunks that omit the index.
+	curToolIndex int
+	finishReason provider.FinishReason
+	usage        provider.Usage
+
+	modelID           string
+	systemFingerprint string
+	responseID        string
 }
 
 type toolCallAgg struct {
@@ -459,6 +568,7 @@ func newStream(httpResp *http.Response, dec *sse.Decoder) *stream {
 		httpResp:         httpResp,
 		dec:              dec,
 		toolCallsByIndex: map[int]*toolCallAgg{},
+		curToolIndex:     -1,
 	}
 }
 
@@ -486,6 +596,8 @@ func (s *stream) Next() bool {
 				s.err = &provider.Error{
 					Provider:  "openai",
 					Code:      stringifyCode(er.Error.Code, er.Error.Type),
+					Type:      er.Error.Type,
+					Param:     er.Error.Param,
 					Message:   er.Error.Message,
 					Retryable: false,
 				}
@@ -495,11 +607,35 @@ func (s *stream) Next() bool {
 			return false
 		}
 
+		if chunk.Model != "" {
+			s.modelID = chunk.Model
+		}
+		if chunk.SystemFingerprint != "" {
+			s.systemFingerprint = chunk.SystemFingerprint
+		}
+		if chunk.ID != "" {
+			s.responseID = chunk.ID
+		}
+		// Usage may arrive on any chunk, including the choice-less final
+		// chunk sent with stream_options.include_usage.
+		if chunk.Usage != nil {
+			s.usage = provider.Usage{
+				PromptTokens:     chunk.Usage.PromptTokens,
+				CompletionTokens: chunk.Usage.CompletionTokens,
+				TotalTokens:      chunk.Usage.TotalTokens,
+			}
+			u := s.usage
+			s.curDelta.Usage = &u
+		}
 		if len(chunk.Choices) == 0 {
+			if s.curDelta.Usage != nil {
+				return true
+			}
 			continue
 		}
 		c := chunk.Choices[0]
 
+		s.reasoningBuilder.WriteString(c.Delta.ReasoningContent)
 		if c.Delta.Content != nil {
 			s.textBuilder.WriteString(*c.Delta.Content)
 			s.curDelta.Text = *c.Delta.Content
@@ -507,10 +643,12 @@ func (s *stream) Next() bool {
 
 		if len(c.Delta.ToolCalls) > 0 {
 			for _, tc := range c.Delta.ToolCalls {
-				agg, ok := s.toolCallsByIndex[tc.Index]
+				idx := s.toolCallIndex(tc.Index, tc.ID)
+				s.curToolIndex = idx
+				agg, ok := s.toolCallsByIndex[idx]
 				if !ok {
 					agg = &toolCallAgg{}
-					s.toolCallsByIndex[tc.Index] = agg
+					s.toolCallsByIndex[idx] = agg
 				}
 				if tc.ID != "" {
 					agg.id = tc.ID
@@ -520,8 +658,10 @@ func (s *stream) Next() bool {
 				}
 				if tc.Function.Arguments != "" {
 					agg.args.WriteString(tc.Function.Arguments)
+				}
+				if tc.ID != "" || tc.Function.Name != "" || tc.Function.Arguments != "" {
 					s.curDelta.ToolCalls = append(s.curDelta.ToolCalls, provider.ToolCallDelta{
-						Index:          tc.Index,
+						Index:          idx,
 						ID:             tc.ID,
 						Name:           tc.Function.Name,
 						ArgumentsDelta: tc.Function.Arguments,
@@ -533,27 +673,49 @@ func (s *stream) Next() bool {
 		if c.FinishReason != nil && *c.FinishReason != "" {
 			s.finishReason = provider.FinishReason(*c.FinishReason)
 		}
-		if chunk.Usage != nil {
-			s.usage = provider.Usage{
-				PromptTokens:     chunk.Usage.PromptTokens,
-				CompletionTokens: chunk.Usage.CompletionTokens,
-				TotalTokens:      chunk.Usage.TotalTokens,
-			}
-		}
 
-		if s.curDelta.Text != "" || len(s.curDelta.ToolCalls) > 0 {
+		if s.curDelta.Text != "" || len(s.curDelta.ToolCalls) > 0 || s.curDelta.Usage != nil {
 			return true
 		}
 	}
 
 	if err := s.dec.Err(); err != nil {
-		code, retryable := classifyNetworkErr(err)
+		code, retryable := classifyErr(s.cfg, err)
 		s.err = &provider.Error{Provider: "openai", Code: code, Message: err.Error(), Retryable: retryable, Cause: err}
 	}
 	s.finalize()
 	return false
 }
 
+// toolCallIndex resolves the aggregation slot for a tool call delta. Chunks
+// without an index continue the current call, unless they carry an id that
+// belongs to another call (or starts a new one).
+func (s *stream) toolCallIndex(index *int, id string) int {
+	if index != nil {
+		return *index
+	}
+	if id != "" {
+		for i, agg := range s.toolCallsByIndex {
+			if agg.id == id {
+				return i
+			}
+		}
+		if cur, ok := s.toolCallsByIndex[s.curToolIndex]; ok && cur.id != "" {
+			next := 0
+			for i := range s.toolCallsByIndex {
+				if i >= next {
+					next = i + 1
+				}
+			}
+			return next
+		}
+	}
+	if s.curToolIndex >= 0 {
+		return s.curToolIndex
+	}
+	return 0
+}
+
 func shouldRetryStatus(status int) bool {
 	return status == http.StatusRequestTimeout ||
 		status == http.StatusConflict ||
@@ -561,6 +723,29 @@ func shouldRetryStatus(status int) bool {
 		(status >= 500 && status <= 599)
 }
 
+// retryableStatus reports whether a failed response is transient, deferring to
+// Config.RetryClassifier when set.
+func retryableStatus(cfg publicopenai.Config, status int, body []byte) bool {
+	if cfg.RetryClassifier != nil {
+		return cfg.RetryClassifier(status, body, nil)
+	}
+	return shouldRetryStatus(status)
+}
+
+// classifyErr is classifyNetworkErr with Config.RetryClassifier deciding
+// retryability when set.
+func classifyErr(cfg publicopenai.Config, err error) (code string, retryable bool) {
+	var editErr *httpx.EditError
+	if errors.As(err, &editErr) {
+		return "request_editor_error", false
+	}
+	code, retryable = classifyNetworkErr(err)
+	if cfg.RetryClassifier != nil {
+		retryable = cfg.RetryClassifier(0, nil, err)
+	}
+	return code, retryable
+}
+
 func stringifyCode(code any, fallback string) string {
 	switch v := code.(type) {
 	case string:
@@ -616,6 +801,9 @@ func (s *stream) finalize() {
 	}
 
 	var parts []provider.ContentPart
+	if r := s.reasoningBuilder.String(); r != "" {
+		parts = append(parts, provider.ReasoningPart{Text: r})
+	}
 	if txt := s.textBuilder.String(); txt != "" {
 		parts = append(parts, provider.TextPart{Text: txt})
 	}
@@ -646,8 +834,12 @@ func (s *stream) finalize() {
 			Role:    provider.RoleAssistant,
 			Content: parts,
 		},
-		FinishReason: s.finishReason,
-		Usage:        s.usage,
+		FinishReason:      s.finishReason,
+		Usage:             s.usage,
+		ModelID:           s.modelID,
+		SystemFingerprint: s.systemFingerprint,
+		ResponseID:        s.responseID,
+		RequestID:         requestID(s.httpResp),
 	}
 }
 
@@ -0,0 +1,566 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bitop-dev/ai/internal/provider"
+	publicopenai "github.com/bitop-dev/ai/openai"
+)
+
+func TestGenerateAndStream_ResponseMetadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req_1")
+		if r.Header.Get("Accept") == "text/event-stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {\"id\":\"chatcmpl-1\",\"model\":\"gpt-4o-2024-08-06\",\"system_fingerprint\":\"fp_1\",\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n" +
+				"data: {\"model\":\"gpt-4o-2024-08-06\",\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n" +
+				"data: [DONE]\n\n"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","system_fingerprint":"fp_1","choices":[{"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: srv.URL})
+	p := &Provider{}
+	req := provider.Request{
+		Model:        "gpt-4o",
+		Messages:     []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentPart{provider.TextPart{Text: "hi"}}}},
+		ProviderData: client,
+	}
+
+	resp, err := p.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ModelID != "gpt-4o-2024-08-06" || resp.SystemFingerprint != "fp_1" {
+		t.Fatalf("generate ModelID=%q SystemFingerprint=%q", resp.ModelID, resp.SystemFingerprint)
+	}
+	if resp.ResponseID != "chatcmpl-1" || resp.RequestID != "req_1" {
+		t.Fatalf("generate ResponseID=%q RequestID=%q", resp.ResponseID, resp.RequestID)
+	}
+
+	s, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for s.Next() {
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	final := s.Final()
+	if final == nil || final.ModelID != "gpt-4o-2024-08-06" || final.SystemFingerprint != "fp_1" ||
+		final.ResponseID != "chatcmpl-1" || final.RequestID != "req_1" {
+		t.Fatalf("stream final=%#v", final)
+	}
+}
+
+func TestStream_FragmentedToolCallDeltas(t *testing.T) {
+	chunks := []string{
+		// id and name arrive in separate chunks; continuation chunks omit index.
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"arguments":""}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"function":{"name":"lookup"}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"function":{"arguments":"{\"q\":"}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"function":{"arguments":"\"x\"}"}}]}}]}`,
+		// Second call without index, identified only by a new id.
+		`{"choices":[{"delta":{"tool_calls":[{"id":"call_b","function":{"name":"add","arguments":"{\"a\":"}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"function":{"arguments":"1}"}}]}}]}`,
+		`{"choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, c := range chunks {
+			_, _ = w.Write([]byte("data: " + c + "\n\n"))
+		}
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer srv.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: srv.URL})
+	s, err := (&Provider{}).Stream(context.Background(), provider.Request{
+		Model:        "m",
+		Messages:     []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentPart{provider.TextPart{Text: "hi"}}}},
+		ProviderData: client,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var sawName bool
+	for s.Next() {
+		for _, d := range s.Delta().ToolCalls {
+			if d.Index == 0 && d.Name == "lookup" {
+				sawName = true
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !sawName {
+		t.Fatalf("expected a delta carrying the tool name")
+	}
+
+	var calls []provider.ToolCallPart
+	for _, p := range s.Final().Message.Content {
+		if tc, ok := p.(provider.ToolCallPart); ok {
+			calls = append(calls, tc)
+		}
+	}
+	if len(calls) != 2 {
+		t.Fatalf("calls=%#v", calls)
+	}
+	if calls[0].ID != "call_a" || calls[0].Name != "lookup" || string(calls[0].Args) != `{"q":"x"}` {
+		t.Fatalf("call 0=%#v", calls[0])
+	}
+	if calls[1].ID != "call_b" || calls[1].Name != "add" || string(calls[1].Args) != `{"a":1}` {
+		t.Fatalf("call 1=%#v", calls[1])
+	}
+}
+
+func TestBuildRequest_ReasoningEffortAndVerbosity(t *testing.T) {
+	req := provider.Request{
+		Model:    "o4-mini",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentPart{provider.TextPart{Text: "hi"}}}},
+	}
+	payload, err := buildRequest(req, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(payload)
+	if strings.Contains(string(b), "reasoning_effort") || strings.Contains(string(b), "verbosity") {
+		t.Fatalf("expected fields omitted when empty: %s", b)
+	}
+
+	req.ReasoningEffort = "low"
+	req.Verbosity = "high"
+	payload, err = buildRequest(req, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ = json.Marshal(payload)
+	var decoded map[string]any
+	_ = json.Unmarshal(b, &decoded)
+	if decoded["reasoning_effort"] != "low" || decoded["verbosity"] != "high" {
+		t.Fatalf("payload=%s", b)
+	}
+}
+
+func TestBuildRequest_AssistantPrefix(t *testing.T) {
+	req := provider.Request{
+		Model:           "gpt-test",
+		Messages:        []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentPart{provider.TextPart{Text: "hi"}}}},
+		AssistantPrefix: "{",
+	}
+	payload, err := buildRequest(req, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Messages) != 2 {
+		t.Fatalf("messages=%d", len(payload.Messages))
+	}
+	last := payload.Messages[1]
+	if last.Role != "assistant" || string(last.Content) != `"{"` {
+		t.Fatalf("prefill message=%+v (%s)", last, last.Content)
+	}
+}
+
+func TestBuildRequest_Prediction(t *testing.T) {
+	req := provider.Request{
+		Model:    "gpt-test",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentPart{provider.TextPart{Text: "rename x to y"}}}},
+	}
+	payload, err := buildRequest(req, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := json.Marshal(payload); strings.Contains(string(b), "prediction") {
+		t.Fatalf("expected prediction omitted when empty: %s", b)
+	}
+
+	req.Prediction = "func x() {}"
+	payload, err = buildRequest(req, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(payload)
+	if !strings.Contains(string(b), `"prediction":{"type":"content","content":"func x() {}"}`) {
+		t.Fatalf("payload=%s", b)
+	}
+}
+
+func TestBuildRequest_HostedWebSearch(t *testing.T) {
+	req := provider.Request{
+		Model:    "gpt-4o-search-preview",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentPart{provider.TextPart{Text: "hi"}}}},
+		Tools: []provider.ToolDefinition{
+			{Name: "web_search", Hosted: true, Config: json.RawMessage(`{"search_context_size":"low"}`)},
+			{Name: "add", InputSchema: json.RawMessage(`{"type":"object"}`)},
+		},
+	}
+	payload, err := buildRequest(req, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(payload)
+	if !strings.Contains(string(b), `"web_search_options":{"search_context_size":"low"}`) {
+		t.Fatalf("payload=%s", b)
+	}
+	// Web search is a request option, not a tool entry.
+	if len(payload.Tools) != 1 || payload.Tools[0].Function.Name != "add" {
+		t.Fatalf("tools=%+v", payload.Tools)
+	}
+
+	req.Tools = []provider.ToolDefinition{{Name: "web_search", Hosted: true}}
+	payload, err = buildRequest(req, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ = json.Marshal(payload)
+	if !strings.Contains(string(b), `"web_search_options":{}`) || strings.Contains(string(b), `"tools"`) {
+		t.Fatalf("payload=%s", b)
+	}
+}
+
+func TestGenerate_HostedToolUnsupported(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: srv.URL})
+	_, err := (&Provider{}).Generate(context.Background(), provider.Request{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentPart{provider.TextPart{Text: "hi"}}}},
+		Tools: []provider.ToolDefinition{
+			{Name: "file_search", Hosted: true},
+		},
+		ProviderData: client,
+	})
+	var pe *provider.Error
+	if !errors.As(err, &pe) || pe.Code != "request_error" || !strings.Contains(pe.Message, `"file_search"`) {
+		t.Fatalf("err=%v", err)
+	}
+	if calls != 0 {
+		t.Fatalf("request was sent (%d calls)", calls)
+	}
+}
+
+func TestGenerate_ErrorTypeAndParam(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"Invalid value for 'temperature'","type":"invalid_request_error","param":"temperature","code":"invalid_value"}}`))
+	}))
+	defer srv.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: srv.URL})
+	_, err := (&Provider{}).Generate(context.Background(), provider.Request{
+		Model:        "gpt-4o",
+		Messages:     []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentPart{provider.TextPart{Text: "hi"}}}},
+		ProviderData: client,
+	})
+	pe, ok := err.(*provider.Error)
+	if !ok {
+		t.Fatalf("err=%#v", err)
+	}
+	if pe.Code != "invalid_value" || pe.Type != "invalid_request_error" || pe.Param != "temperature" || pe.Status != http.StatusBadRequest {
+		t.Fatalf("err=%#v", pe)
+	}
+}
+
+func TestStream_UsageDeltas(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"a\"}}],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":1,\"total_tokens\":6}}\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"content\":\"b\"},\"finish_reason\":\"stop\"}]}\n\n" +
+			"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":2,\"total_tokens\":7}}\n\n" +
+			"data: [DONE]\n\n"))
+	}))
+	defer srv.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: srv.URL})
+	s, err := (&Provider{}).Stream(context.Background(), provider.Request{
+		Model:        "gpt-4o",
+		Messages:     []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentPart{provider.TextPart{Text: "hi"}}}},
+		ProviderData: client,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var usages []int
+	for s.Next() {
+		if u := s.Delta().Usage; u != nil {
+			usages = append(usages, u.TotalTokens)
+		}
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != 2 || usages[0] != 6 || usages[1] != 7 {
+		t.Fatalf("usage deltas=%v", usages)
+	}
+	if got := s.Final().Usage.TotalTokens; got != 7 {
+		t.Fatalf("final usage=%d", got)
+	}
+}
+
+func TestGenerate_RetryClassifier(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"upstream warming up","type":"gateway"}}`))
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":{"message":"maintenance","type":"gateway"}}`))
+		default:
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{
+		APIKey:     "k",
+		BaseURL:    srv.URL,
+		MinBackoff: time.Millisecond,
+		MaxBackoff: time.Millisecond,
+		RetryClassifier: func(status int, body []byte, err error) bool {
+			return status == http.StatusBadRequest && strings.Contains(string(body), "warming up")
+		},
+	})
+	_, err := (&Provider{}).Generate(context.Background(), provider.Request{
+		Model:        "gpt-4o",
+		Messages:     []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentPart{provider.TextPart{Text: "hi"}}}},
+		ProviderData: client,
+	})
+	var pe *provider.Error
+	if !errors.As(err, &pe) || pe.Status != http.StatusServiceUnavailable || pe.Message != "maintenance" {
+		t.Fatalf("err=%v", err)
+	}
+	if pe.Retryable {
+		t.Fatalf("expected classifier to mark 503 as not retryable")
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("calls=%d, want 2 (400 retried, 503 not)", n)
+	}
+}
+
+func TestGenerate_APIKeyRotation(t *testing.T) {
+	var mu sync.Mutex
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auth = append(auth, r.Header.Get("Authorization"))
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	generate := func(client *publicopenai.Client) error {
+		_, err := (&Provider{}).Generate(context.Background(), provider.Request{
+			Model:        "gpt-4o",
+			Messages:     []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentPart{provider.TextPart{Text: "hi"}}}},
+			ProviderData: client,
+		})
+		return err
+	}
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "ignored", APIKeys: []string{"a", "a", "b"}, BaseURL: srv.URL})
+	for i := 0; i < 6; i++ {
+		if err := generate(client); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"Bearer a", "Bearer a", "Bearer b", "Bearer a", "Bearer a", "Bearer b"}
+	if !reflect.DeepEqual(auth, want) {
+		t.Fatalf("auth=%v", auth)
+	}
+
+	client = publicopenai.NewClient(publicopenai.Config{
+		APIKeys:     []string{"a"},
+		BaseURL:     srv.URL,
+		KeyProvider: func(ctx context.Context) (string, error) { return "", errors.New("all keys exhausted") },
+	})
+	var pe *provider.Error
+	if err := generate(client); !errors.As(err, &pe) || pe.Code != "config_error" || !strings.Contains(pe.Message, "all keys exhausted") {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestGenerate_RequestEditor(t *testing.T) {
+	var calls int
+	var auth, trace string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		auth = r.Header.Get("Authorization")
+		trace = r.Header.Get("X-Trace")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	generate := func(edit func(*http.Request) error) error {
+		client := publicopenai.NewClient(publicopenai.Config{APIKey: "sk", BaseURL: srv.URL, RequestEditor: edit})
+		_, err := (&Provider{}).Generate(context.Background(), provider.Request{
+			Model:        "gpt-4o",
+			Messages:     []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentPart{provider.TextPart{Text: "hi"}}}},
+			ProviderData: client,
+		})
+		return err
+	}
+
+	err := generate(func(req *http.Request) error {
+		if req.Header.Get("Authorization") != "Bearer sk" {
+			t.Errorf("editor saw auth=%q", req.Header.Get("Authorization"))
+		}
+		req.Header.Set("Authorization", "Signed xyz")
+		req.Header.Set("X-Trace", "t1")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Signed xyz" || trace != "t1" {
+		t.Fatalf("auth=%q trace=%q", auth, trace)
+	}
+
+	calls = 0
+	err = generate(func(*http.Request) error { return errors.New("no signing key") })
+	var pe *provider.Error
+	if !errors.As(err, &pe) || pe.Code != "request_editor_error" || pe.Retryable || !strings.Contains(pe.Message, "no signing key") {
+		t.Fatalf("err=%v", err)
+	}
+	if calls != 0 {
+		t.Fatalf("calls=%d", calls)
+	}
+}
+
+func TestGenerateAndStream_BaseURLOverride(t *testing.T) {
+	var hits []string
+	var mu sync.Mutex
+	handler := func(region string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hits = append(hits, region+" "+r.URL.Path+" "+r.Header.Get("Authorization"))
+			mu.Unlock()
+			if r.Header.Get("Accept") == "text/event-stream" {
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"hi\"},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n"))
+				return
+			}
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`))
+		}
+	}
+	us := httptest.NewServer(handler("us"))
+	defer us.Close()
+	eu := httptest.NewServer(handler("eu"))
+	defer eu.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "sk", BaseURL: us.URL, APIPrefix: "/v1"})
+	req := provider.Request{
+		Model:        "gpt-4o",
+		Messages:     []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentPart{provider.TextPart{Text: "hi"}}}},
+		ProviderData: client,
+	}
+	p := &Provider{}
+	if _, err := p.Generate(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	req.BaseURLOverride = eu.URL + "/"
+	if _, err := p.Generate(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	s, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for s.Next() {
+	}
+	_ = s.Close()
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"us /v1/chat/completions Bearer sk",
+		"eu /v1/chat/completions Bearer sk",
+		"eu /v1/chat/completions Bearer sk",
+	}
+	if !reflect.DeepEqual(hits, want) {
+		t.Fatalf("hits=%q", hits)
+	}
+	if client.Config().BaseURL != us.URL {
+		t.Fatalf("client config changed: %q", client.Config().BaseURL)
+	}
+}
+
+func TestReasoningContent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") == "text/event-stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {\"choices\":[{\"delta\":{\"reasoning_content\":\"think \"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"reasoning_content\":\"hard\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"42\"},\"finish_reason\":\"stop\"}]}\n\n" +
+				"data: [DONE]\n\n"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","reasoning_content":"think hard","content":"42"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	client := publicopenai.NewClient(publicopenai.Config{APIKey: "k", BaseURL: srv.URL})
+	p := &Provider{}
+	req := provider.Request{
+		Model:        "gpt-4o",
+		Messages:     []provider.Message{{Role: provider.RoleUser, Content: []provider.ContentPart{provider.TextPart{Text: "hi"}}}},
+		ProviderData: client,
+	}
+	want := []provider.ContentPart{provider.ReasoningPart{Text: "think hard"}, provider.TextPart{Text: "42"}}
+
+	resp, err := p.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resp.Message.Content, want) {
+		t.Fatalf("generate content=%#v", resp.Message.Content)
+	}
+
+	s, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for s.Next() {
+	}
+	if final := s.Final(); final == nil || !reflect.DeepEqual(final.Message.Content, want) {
+		t.Fatalf("stream final=%#v", final)
+	}
+
+	// Replayed reasoning is dropped: chat completions does not accept it.
+	req.Messages = append(req.Messages, provider.Message{Role: provider.RoleAssistant, Content: want})
+	payload, err := buildRequest(req, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(payload.Messages[1])
+	if string(b) != `{"role":"assistant","content":"42"}` {
+		t.Fatalf("replayed message=%s", b)
+	}
+}
@@ -15,6 +15,21 @@ type chatCompletionRequest struct {
 	Metadata      any            `json:"metadata,omitempty"`
 	Stream        bool           `json:"stream,omitempty"`
 	StreamOptions *streamOptions `json:"stream_options,omitempty"`
+
+	ReasoningEffort string `json:"reasoning_effort,omitempty"`
+	Verbosity       string `json:"verbosity,omitempty"`
+
+	Prediction *prediction `json:"prediction,omitempty"`
+
+	// WebSearchOptions enables the built-in web search of the search models
+	// (e.g. gpt-4o-search-preview); see HostedTool("web_search", ...).
+	WebSearchOptions json.RawMessage `json:"web_search_options,omitempty"`
+}
+
+// prediction is a predicted output: text the reply is expected to match.
+type prediction struct {
+	Type    string `json:"type"`
+	Content string `json:"content"`
 }
 
 type streamOptions struct {
@@ -27,6 +42,10 @@ type chatMessage struct {
 	Name       string          `json:"name,omitempty"`
 	ToolCalls  []toolCall      `json:"tool_calls,omitempty"`
 	ToolCallID string          `json:"tool_call_id,omitempty"`
+
+	// ReasoningContent is returned by some OpenAI-compatible servers; it is
+	// never sent.
+	ReasoningContent string `json:"reasoning_content,omitempty"`
 }
 
 type chatContentPart struct {
@@ -53,6 +72,7 @@ type toolFunction struct {
 	Name        string          `json:"name"`
 	Description string          `json:"description,omitempty"`
 	Parameters  json.RawMessage `json:"parameters,omitempty"`
+	Strict      bool            `json:"strict,omitempty"`
 }
 
 type toolCall struct {
@@ -72,6 +92,8 @@ type chatCompletionResponse struct {
 	Created int64  `json:"created"`
 	Model   string `json:"model"`
 
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+
 	Choices []struct {
 		Index        int         `json:"index"`
 		Message      chatMessage `json:"message"`
@@ -91,12 +113,16 @@ type chatCompletionChunk struct {
 	Created int64  `json:"created"`
 	Model   string `json:"model"`
 
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+
 	Choices []struct {
 		Index int `json:"index"`
 		Delta struct {
-			Content   *string `json:"content,omitempty"`
-			ToolCalls []struct {
-				Index    int    `json:"index"`
+			Content          *string `json:"content,omitempty"`
+			ReasoningContent string  `json:"reasoning_content,omitempty"`
+			ToolCalls        []struct {
+				// Index is optional on continuation chunks from some proxies.
+				Index    *int   `json:"index,omitempty"`
 				ID       string `json:"id,omitempty"`
 				Type     string `json:"type,omitempty"`
 				Function struct {
@@ -119,6 +145,7 @@ type errorResponse struct {
 	Error struct {
 		Message string `json:"message"`
 		Type    string `json:"type"`
+		Param   string `json:"param"`
 		Code    any    `json:"code"`
 	} `json:"error"`
 }
@@ -1,6 +1,9 @@
 package provider
 
-import "context"
+import (
+	"context"
+	"io"
+)
 
 type TranscriptionProvider interface {
 	Transcribe(ctx context.Context, req TranscriptionRequest) (TranscriptionResponse, error)
@@ -38,12 +41,19 @@ type TranscriptionResponse struct {
 
 	ProviderMetadata map[string]any
 	RawResponse      []byte
+	RequestID        string
 }
 
 type SpeechProvider interface {
 	GenerateSpeech(ctx context.Context, req SpeechRequest) (SpeechResponse, error)
 }
 
+// SpeechStreamProvider is implemented by providers that can return synthesized
+// audio as it is produced instead of buffering the full response.
+type SpeechStreamProvider interface {
+	StreamSpeech(ctx context.Context, req SpeechRequest) (SpeechStream, error)
+}
+
 type SpeechRequest struct {
 	Model string
 	Text  string
@@ -66,4 +76,48 @@ type SpeechResponse struct {
 
 	ProviderMetadata map[string]any
 	RawResponse      []byte
+	RequestID        string
+}
+
+type SpeechStream struct {
+	// Body yields audio bytes as they arrive. The caller must close it.
+	Body      io.ReadCloser
+	MediaType string
+}
+
+// RealtimeSpeechProvider is implemented by providers that synthesize speech
+// over a persistent connection as text is fed in.
+type RealtimeSpeechProvider interface {
+	RealtimeSpeech(ctx context.Context, req RealtimeSpeechRequest) (RealtimeSpeechSession, error)
+}
+
+type RealtimeSpeechRequest struct {
+	Model string
+	Voice string
+
+	// Instructions replaces the provider's default read-aloud instruction.
+	Instructions string
+
+	Headers map[string]string
+
+	ProviderOptions any
+	ProviderData    any
+}
+
+// RealtimeSpeechSession is one open synthesis connection. SendText and
+// CloseSend may be called concurrently with Next; texts are spoken in order.
+type RealtimeSpeechSession interface {
+	// SendText queues text to be spoken.
+	SendText(text string) error
+	// CloseSend signals that no more text follows; Next returns false once
+	// all queued text has been spoken.
+	CloseSend() error
+
+	Next() bool
+	// Audio is the chunk produced by the last successful Next.
+	Audio() []byte
+	MediaType() string
+	Warnings() []string
+	Err() error
+	Close() error
 }
@@ -0,0 +1,56 @@
+package provider
+
+import "context"
+
+// BatchProvider is implemented by providers that support asynchronous batch
+// processing of chat requests (e.g. the OpenAI Batch API).
+type BatchProvider interface {
+	SubmitBatch(ctx context.Context, req BatchSubmitRequest) (BatchInfo, error)
+	BatchStatus(ctx context.Context, req BatchLookupRequest) (BatchInfo, error)
+	BatchResults(ctx context.Context, req BatchLookupRequest) ([]BatchResult, error)
+}
+
+type BatchItem struct {
+	CustomID string
+	Request  Request
+}
+
+type BatchSubmitRequest struct {
+	Items []BatchItem
+
+	// CompletionWindow defaults to the provider's shortest window (e.g. "24h").
+	CompletionWindow string
+	Metadata         map[string]string
+
+	Headers    map[string]string
+	MaxRetries *int
+
+	ProviderData any
+}
+
+type BatchLookupRequest struct {
+	BatchID string
+
+	Headers    map[string]string
+	MaxRetries *int
+
+	ProviderData any
+}
+
+type BatchInfo struct {
+	ID     string
+	Status string
+
+	Total     int
+	Completed int
+	Failed    int
+
+	OutputFileID string
+	ErrorFileID  string
+}
+
+type BatchResult struct {
+	CustomID string
+	Response *Response
+	Err      error
+}
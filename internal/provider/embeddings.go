@@ -11,6 +11,10 @@ type EmbeddingRequest struct {
 
 	Inputs []string
 
+	// Dimensions requests a reduced output vector size; nil means the model
+	// default. Takes precedence over provider-specific options.
+	Dimensions *int
+
 	Metadata map[string]string
 
 	Headers map[string]string
@@ -27,5 +31,8 @@ type EmbeddingResponse struct {
 	Vectors [][]float32
 	Usage   Usage
 
+	Warnings []string
+
 	RawResponse []byte
+	RequestID   string
 }
@@ -5,6 +5,8 @@ import "fmt"
 type Error struct {
 	Provider  string
 	Code      string
+	Type      string // provider error type/category, when reported
+	Param     string // request parameter the error refers to, when reported
 	Status    int
 	Message   string
 	Retryable bool
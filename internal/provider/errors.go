@@ -3,3 +3,7 @@ package provider
 import "errors"
 
 var ErrToolsUnsupported = errors.New("tools unsupported")
+
+// ErrStreamingUnsupported is returned by Stream when the backend cannot
+// stream; callers that stream only for progress fall back to Generate.
+var ErrStreamingUnsupported = errors.New("streaming unsupported")
@@ -10,6 +10,9 @@ type Image struct {
 	Base64    string
 	Bytes     []byte
 	MediaType string
+
+	RevisedPrompt string
+	Seed          *int64
 }
 
 type GenerateImageRequest struct {
@@ -22,6 +25,16 @@ type GenerateImageRequest struct {
 	N    int
 	Seed *int64
 
+	// ReferenceImages are input images that condition generation (style
+	// transfer, reference-guided generation).
+	ReferenceImages [][]byte
+
+	// Background, OutputFormat and OutputCompression control the encoded
+	// output image (transparency, png/jpeg/webp, compression level).
+	Background        string
+	OutputFormat      string
+	OutputCompression *int
+
 	Headers    map[string]string
 	MaxRetries *int
 
@@ -37,4 +50,5 @@ type GenerateImageResponse struct {
 	ProviderMetadata map[string]any
 
 	RawResponse []byte
+	RequestID   string
 }
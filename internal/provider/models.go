@@ -0,0 +1,28 @@
+package provider
+
+import (
+	"context"
+	"time"
+)
+
+// ModelLister is implemented by providers that can list the models available
+// to the configured credentials (e.g. OpenAI's /models endpoint).
+type ModelLister interface {
+	ListModels(ctx context.Context, req ListModelsRequest) ([]ModelInfo, error)
+}
+
+type ListModelsRequest struct {
+	Headers    map[string]string
+	MaxRetries *int
+
+	ProviderData any
+}
+
+// ModelInfo has the fields of ai.ModelInfo and openai.ModelInfo, which
+// convert from it directly.
+type ModelInfo struct {
+	ID      string
+	OwnedBy string
+	// Created is when the model was published, zero when unknown.
+	Created time.Time
+}
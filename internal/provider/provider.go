@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 )
@@ -11,6 +12,42 @@ type Provider interface {
 	Stream(ctx context.Context, req Request) (Stream, error)
 }
 
+// SchemaRefsProvider is implemented by providers that accept JSON schemas with
+// local $ref/$defs. Schemas sent to other providers have references inlined.
+type SchemaRefsProvider interface {
+	SupportsSchemaRefs() bool
+}
+
+// RequestBodyBuilder is implemented by providers that can render the wire
+// payload for a request without sending it. The body must not contain
+// credentials.
+type RequestBodyBuilder interface {
+	BuildRequestBody(req Request, stream bool) (json.RawMessage, error)
+}
+
+// Wrapper is implemented by providers that decorate another provider (e.g. to
+// bound each call). Look up optional capabilities with As so they are found
+// through wrappers.
+type Wrapper interface {
+	Unwrap() Provider
+}
+
+// As returns p, or the first provider p wraps, that implements T.
+func As[T any](p Provider) (T, bool) {
+	for p != nil {
+		if v, ok := p.(T); ok {
+			return v, true
+		}
+		w, ok := p.(Wrapper)
+		if !ok {
+			break
+		}
+		p = w.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
 type Request struct {
 	Model string
 
@@ -29,6 +66,18 @@ type Request struct {
 	TopP        *float32
 	Stop        []string
 
+	ReasoningEffort string
+	Verbosity       string
+
+	// AssistantPrefix is a partial assistant reply the model should continue.
+	AssistantPrefix string
+
+	// Prediction is the expected content of the reply (predicted outputs).
+	Prediction string
+
+	// BaseURLOverride replaces the configured base URL for this call.
+	BaseURLOverride string
+
 	Metadata map[string]string
 }
 
@@ -36,6 +85,15 @@ type Response struct {
 	Message      Message
 	Usage        Usage
 	FinishReason FinishReason
+
+	// ModelID is the model reported by the provider (may be a dated snapshot).
+	ModelID           string
+	SystemFingerprint string
+
+	// ResponseID is the provider's ID for the response (OpenAI: body "id");
+	// RequestID identifies the HTTP request (OpenAI: x-request-id header).
+	ResponseID string
+	RequestID  string
 }
 
 type Stream interface {
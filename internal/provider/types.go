@@ -27,6 +27,10 @@ type Message struct {
 	// ToolCallID is used for tool result messages (role=tool) to associate the
 	// result with a prior tool call.
 	ToolCallID string
+
+	// Metadata is per-message data for providers that consume it; others
+	// ignore it.
+	Metadata map[string]any
 }
 
 type ContentPart interface {
@@ -66,15 +70,32 @@ type AudioPart struct {
 
 func (AudioPart) isContentPart() {}
 
+// ReasoningPart is reasoning the model produced before its answer. Providers
+// that accept prior reasoning send it back; others drop it.
+type ReasoningPart struct{ Text string }
+
+func (ReasoningPart) isContentPart() {}
+
 type ToolDefinition struct {
 	Name        string
 	Description string
 	InputSchema json.RawMessage
+	Strict      bool
+
+	// Hosted marks a tool executed by the provider (e.g. web_search). Name is
+	// the provider's tool type and Config its JSON settings; InputSchema is
+	// unused.
+	Hosted bool
+	Config json.RawMessage
 }
 
 type Delta struct {
 	Text      string
 	ToolCalls []ToolCallDelta
+
+	// Usage is the running usage of the current call, when the provider
+	// reports it mid-stream. Later values supersede earlier ones.
+	Usage *Usage
 }
 
 type ToolCallDelta struct {
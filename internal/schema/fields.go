@@ -0,0 +1,136 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// FieldError reports a completed field that violates its subschema.
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("schema violation at %q: %v", e.Path, e.Err)
+}
+
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// FieldValidator checks fields of a document that is still being generated
+// against the subschemas at their paths, so a violation is caught as soon as
+// the offending value is complete. Paths are dot-separated, with array
+// elements addressed by index ("items.0.name").
+//
+// Only subschemas reachable through properties, additionalProperties, items
+// and prefixItems are checked. Paths through unions, conditionals, pattern
+// properties or unresolved $refs are accepted; the final document is still
+// validated as a whole.
+type FieldValidator struct {
+	root     any
+	compiled map[string]*jsonschema.Schema
+}
+
+func NewFieldValidator(schemaJSON json.RawMessage) (*FieldValidator, error) {
+	inlined, err := InlineRefs(schemaJSON)
+	if err != nil {
+		// Recursive schemas keep their $refs; paths through them are skipped.
+		inlined = schemaJSON
+	}
+	var root any
+	if err := json.Unmarshal(inlined, &root); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	return &FieldValidator{root: root, compiled: map[string]*jsonschema.Schema{}}, nil
+}
+
+// ValidateField checks value, completed at path, against its subschema.
+func (v *FieldValidator) ValidateField(path string, value any) error {
+	node := v.root
+	for _, seg := range strings.Split(path, ".") {
+		next, err := childSchema(node, seg)
+		if err != nil {
+			return &FieldError{Path: path, Err: err}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	s, err := v.compile(node)
+	if err != nil || s == nil {
+		return nil
+	}
+	if err := s.Validate(value); err != nil {
+		return &FieldError{Path: path, Err: err}
+	}
+	return nil
+}
+
+// childSchema returns the subschema for member or element seg of node, nil
+// when it cannot be determined, or an error when node forbids seg.
+func childSchema(node any, seg string) (any, error) {
+	m, ok := node.(map[string]any)
+	if !ok {
+		return nil, nil
+	}
+	for _, k := range []string{"$ref", "oneOf", "anyOf", "allOf", "not", "if"} {
+		if _, ok := m[k]; ok {
+			return nil, nil
+		}
+	}
+	if i, err := strconv.Atoi(seg); err == nil {
+		if prefix, ok := m["prefixItems"].([]any); ok && i < len(prefix) {
+			return prefix[i], nil
+		}
+		if items, ok := m["items"].(map[string]any); ok {
+			return items, nil
+		}
+		if _, ok := m["items"]; ok || m["type"] == "array" {
+			return nil, nil
+		}
+	}
+	if props, ok := m["properties"].(map[string]any); ok {
+		if p, ok := props[seg]; ok {
+			return p, nil
+		}
+	}
+	if _, ok := m["patternProperties"]; ok {
+		return nil, nil
+	}
+	switch ap := m["additionalProperties"].(type) {
+	case bool:
+		if !ap {
+			return nil, fmt.Errorf("additional property %q is not allowed", seg)
+		}
+	case map[string]any:
+		return ap, nil
+	}
+	return nil, nil
+}
+
+func (v *FieldValidator) compile(node any) (*jsonschema.Schema, error) {
+	b, err := json.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+	key := string(b)
+	if s, ok := v.compiled[key]; ok {
+		return s, nil
+	}
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("field.json", bytes.NewReader(b)); err != nil {
+		return nil, err
+	}
+	s, err := c.Compile("field.json")
+	if err != nil {
+		return nil, err
+	}
+	v.compiled[key] = s
+	return s, nil
+}
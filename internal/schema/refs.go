@@ -0,0 +1,137 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// InlineRefs replaces local $ref pointers (e.g. "#/$defs/Address") with the
+// schemas they reference and drops the root $defs/definitions, for providers
+// that do not accept references. Keywords next to a $ref are kept and take
+// precedence over the referenced schema. Recursive and non-local references
+// cannot be inlined and return an error.
+func InlineRefs(schemaJSON json.RawMessage) (json.RawMessage, error) {
+	if len(schemaJSON) == 0 {
+		return schemaJSON, nil
+	}
+	var root any
+	if err := json.Unmarshal(schemaJSON, &root); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	out, err := inlineRefs(root, root, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := out.(map[string]any); ok {
+		delete(m, "$defs")
+		delete(m, "definitions")
+	}
+	return json.Marshal(out)
+}
+
+// HasRefs reports whether the schema contains any $ref.
+func HasRefs(schemaJSON json.RawMessage) bool {
+	return strings.Contains(string(schemaJSON), `"$ref"`)
+}
+
+func inlineRefs(node, root any, expanding map[string]bool) (any, error) {
+	switch v := node.(type) {
+	case map[string]any:
+		if ref, ok := v["$ref"].(string); ok {
+			if expanding[ref] {
+				return nil, fmt.Errorf("schema: recursive $ref %q cannot be inlined", ref)
+			}
+			target, err := resolvePointer(root, ref)
+			if err != nil {
+				return nil, err
+			}
+			expanding[ref] = true
+			resolved, err := inlineRefs(target, root, expanding)
+			delete(expanding, ref)
+			if err != nil {
+				return nil, err
+			}
+			out := map[string]any{}
+			if m, ok := resolved.(map[string]any); ok {
+				for k, val := range m {
+					out[k] = val
+				}
+			} else if len(v) == 1 {
+				return resolved, nil
+			}
+			for k, val := range v {
+				if k == "$ref" {
+					continue
+				}
+				child, err := inlineRefs(val, root, expanding)
+				if err != nil {
+					return nil, err
+				}
+				out[k] = child
+			}
+			return out, nil
+		}
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			child, err := inlineRefs(val, root, expanding)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = child
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			child, err := inlineRefs(val, root, expanding)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = child
+		}
+		return out, nil
+	default:
+		return node, nil
+	}
+}
+
+// resolvePointer resolves a local JSON pointer reference ("#" or "#/a/b").
+func resolvePointer(root any, ref string) (any, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("schema: non-local $ref %q is not supported", ref)
+	}
+	ptr, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, fmt.Errorf("schema: invalid $ref %q: %w", ref, err)
+	}
+	if ptr == "" {
+		return root, nil
+	}
+	if !strings.HasPrefix(ptr, "/") {
+		return nil, fmt.Errorf("schema: unsupported $ref %q", ref)
+	}
+	cur := root
+	for _, tok := range strings.Split(ptr[1:], "/") {
+		tok = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+		switch c := cur.(type) {
+		case map[string]any:
+			next, ok := c[tok]
+			if !ok {
+				return nil, fmt.Errorf("schema: $ref %q not found", ref)
+			}
+			cur = next
+		case []any:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, fmt.Errorf("schema: $ref %q not found", ref)
+			}
+			cur = c[i]
+		default:
+			return nil, fmt.Errorf("schema: $ref %q not found", ref)
+		}
+	}
+	return cur, nil
+}
@@ -0,0 +1,247 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// MakeStrict rewrites a JSON schema so it satisfies OpenAI strict mode: every
+// object schema gets additionalProperties:false and lists all of its
+// properties as required. Properties that were optional become nullable, so
+// the model can send null rather than invent a value; StripOptionalNulls
+// removes those nulls again. It returns an error when the schema cannot be
+// made strict (e.g. the root is not an object schema or additionalProperties
+// is explicitly allowed).
+func MakeStrict(schemaJSON json.RawMessage) (json.RawMessage, error) {
+	if len(schemaJSON) == 0 {
+		return nil, fmt.Errorf("strict mode requires an input schema")
+	}
+	var root map[string]any
+	if err := json.Unmarshal(schemaJSON, &root); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	if !isObjectSchema(root) {
+		return nil, fmt.Errorf("strict mode requires an object schema at the root")
+	}
+	if err := makeStrict(root, "#"); err != nil {
+		return nil, err
+	}
+	return json.Marshal(root)
+}
+
+func makeStrict(node map[string]any, path string) error {
+	if isObjectSchema(node) {
+		switch ap := node["additionalProperties"].(type) {
+		case nil:
+		case bool:
+			if ap {
+				return fmt.Errorf("strict mode: %s allows additionalProperties", path)
+			}
+		default:
+			return fmt.Errorf("strict mode: %s uses an additionalProperties schema", path)
+		}
+		node["additionalProperties"] = false
+
+		props, _ := node["properties"].(map[string]any)
+		wasRequired := requiredSet(node)
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		required := make([]any, 0, len(names))
+		for _, name := range names {
+			required = append(required, name)
+		}
+		node["required"] = required
+
+		for _, name := range names {
+			if child, ok := props[name].(map[string]any); ok {
+				if err := makeStrict(child, path+"/properties/"+name); err != nil {
+					return err
+				}
+				if !wasRequired[name] {
+					props[name] = nullable(child)
+				}
+			}
+		}
+	}
+
+	if items, ok := node["items"].(map[string]any); ok {
+		if err := makeStrict(items, path+"/items"); err != nil {
+			return err
+		}
+	}
+	for _, key := range []string{"$defs", "definitions"} {
+		defs, _ := node[key].(map[string]any)
+		for name, d := range defs {
+			if child, ok := d.(map[string]any); ok {
+				if err := makeStrict(child, path+"/"+key+"/"+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, key := range []string{"anyOf", "oneOf", "allOf"} {
+		list, _ := node[key].([]any)
+		for i, d := range list {
+			if child, ok := d.(map[string]any); ok {
+				if err := makeStrict(child, fmt.Sprintf("%s/%s/%d", path, key, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func isObjectSchema(node map[string]any) bool {
+	switch t := node["type"].(type) {
+	case string:
+		return t == "object"
+	case []any:
+		for _, v := range t {
+			if v == "object" {
+				return true
+			}
+		}
+		return false
+	}
+	_, hasProps := node["properties"]
+	return hasProps
+}
+
+func requiredSet(node map[string]any) map[string]bool {
+	list, _ := node["required"].([]any)
+	set := make(map[string]bool, len(list))
+	for _, v := range list {
+		if name, ok := v.(string); ok {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+// nullable returns node widened to also accept null: "null" is added to its
+// type (and enum), or to its anyOf; other schemas are wrapped in an anyOf.
+func nullable(node map[string]any) map[string]any {
+	switch t := node["type"].(type) {
+	case string:
+		if t != "null" {
+			node["type"] = []any{t, "null"}
+		}
+		addNullEnum(node)
+		return node
+	case []any:
+		for _, v := range t {
+			if v == "null" {
+				return node
+			}
+		}
+		node["type"] = append(t, "null")
+		addNullEnum(node)
+		return node
+	}
+	null := map[string]any{"type": "null"}
+	if list, ok := node["anyOf"].([]any); ok {
+		node["anyOf"] = append(list, null)
+		return node
+	}
+	return map[string]any{"anyOf": []any{node, null}}
+}
+
+func addNullEnum(node map[string]any) {
+	enum, ok := node["enum"].([]any)
+	if !ok {
+		return
+	}
+	for _, v := range enum {
+		if v == nil {
+			return
+		}
+	}
+	node["enum"] = append(enum, nil)
+}
+
+// StripOptionalNulls removes null members from args where the property is
+// optional in schemaJSON, undoing the nullable widening of MakeStrict so the
+// arguments validate against the original schema. args is returned unchanged
+// when there is nothing to remove or either document cannot be parsed.
+func StripOptionalNulls(schemaJSON, args json.RawMessage) json.RawMessage {
+	var root any
+	if err := json.Unmarshal(schemaJSON, &root); err != nil {
+		return args
+	}
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return args
+	}
+	if !stripNulls(root, root, v) {
+		return args
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return args
+	}
+	return out
+}
+
+func stripNulls(node, root, value any) bool {
+	s, _ := node.(map[string]any)
+	// Follow local $refs; the bound guards against reference cycles that
+	// never reach a schema body.
+	for i := 0; i < 32 && s != nil; i++ {
+		ref, ok := s["$ref"].(string)
+		if !ok {
+			break
+		}
+		target, err := resolvePointer(root, ref)
+		if err != nil {
+			return false
+		}
+		s, _ = target.(map[string]any)
+	}
+	if s == nil {
+		return false
+	}
+
+	changed := false
+	switch v := value.(type) {
+	case map[string]any:
+		props, _ := s["properties"].(map[string]any)
+		required := requiredSet(s)
+		for name, member := range v {
+			prop, ok := props[name]
+			if !ok {
+				continue
+			}
+			if member == nil && !required[name] {
+				delete(v, name)
+				changed = true
+				continue
+			}
+			if stripNulls(prop, root, member) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if stripNulls(s["items"], root, item) {
+				changed = true
+			}
+		}
+	}
+	for _, key := range []string{"anyOf", "oneOf", "allOf"} {
+		list, _ := s[key].([]any)
+		for _, branch := range list {
+			if stripNulls(branch, root, value) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,39 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// WrapValue wraps a schema whose root is not an object schema (a oneOf/anyOf
+// union, an array or a scalar) as the required "value" property of an object
+// schema, for APIs such as function parameters that only accept object roots.
+// Root keywords that must stay at the root ($schema, $id, $defs, definitions)
+// are moved to the wrapper so local $refs still resolve. Object schemas are
+// returned unchanged with wrapped false.
+func WrapValue(schemaJSON json.RawMessage) (out json.RawMessage, wrapped bool, err error) {
+	var root map[string]any
+	if err := json.Unmarshal(schemaJSON, &root); err != nil {
+		return nil, false, fmt.Errorf("parse schema: %w", err)
+	}
+	if isObjectSchema(root) {
+		return schemaJSON, false, nil
+	}
+	wrapper := map[string]any{
+		"type":                 "object",
+		"properties":           map[string]any{"value": root},
+		"required":             []any{"value"},
+		"additionalProperties": false,
+	}
+	for _, key := range []string{"$schema", "$id", "$defs", "definitions"} {
+		if v, ok := root[key]; ok {
+			wrapper[key] = v
+			delete(root, key)
+		}
+	}
+	out, err = json.Marshal(wrapper)
+	if err != nil {
+		return nil, false, err
+	}
+	return out, true, nil
+}
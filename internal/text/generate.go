@@ -3,6 +3,7 @@ package text
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/bitop-dev/ai/internal/provider"
 	"github.com/bitop-dev/ai/internal/tools"
@@ -85,12 +86,22 @@ func Generate(ctx context.Context, p provider.Provider, req provider.Request, ex
 		callReq.Model = stepReq.Model
 		callReq.Messages = append([]provider.Message(nil), stepMessages...)
 		callReq.Tools = append([]provider.ToolDefinition(nil), callTools...)
+		// AssistantPrefix seeds the first reply only; later steps follow tool
+		// results and must not repeat it.
+		req.AssistantPrefix = ""
 
 		resp, err := p.Generate(ctx, callReq)
 		if err != nil {
 			return GenerateResult{}, err
 		}
+		if opts.AutoContinue {
+			resp, err = generateContinuations(ctx, p, callReq, resp, opts.maxContinuations())
+			if err != nil {
+				return GenerateResult{}, err
+			}
+		}
 		agg = tools.AddUsage(agg, resp.Usage)
+		resp.Message = tools.StripHostedCalls(resp.Message, callTools)
 
 		messages = append(messages, resp.Message)
 		responseMessages = append(responseMessages, resp.Message)
@@ -107,6 +118,9 @@ func Generate(ctx context.Context, p provider.Provider, req provider.Request, ex
 			if opts.OnStepFinish != nil {
 				opts.OnStepFinish(StepFinishEvent{Step: step})
 			}
+			if iter+1 < maxIterations && continueAfterTextStep(opts, stepNumber, steps, messages) {
+				continue
+			}
 			return GenerateResult{
 				Response:         resp,
 				AggregatedUsage:  agg,
@@ -117,7 +131,10 @@ func Generate(ctx context.Context, p provider.Provider, req provider.Request, ex
 		if exec == nil {
 			return GenerateResult{}, fmt.Errorf("tool calls requested but no executor provided")
 		}
-		results, err := exec(ctx, calls)
+		if opts.OnStepToolCalls != nil {
+			opts.OnStepToolCalls(StepToolCallsEvent{Step: step})
+		}
+		results, err := exec(tools.WithStepInfo(ctx, tools.StepInfo{StepNumber: stepNumber, Messages: messages}), calls)
 		if err != nil {
 			return GenerateResult{}, err
 		}
@@ -147,3 +164,60 @@ func Generate(ctx context.Context, p provider.Provider, req provider.Request, ex
 
 	return GenerateResult{}, fmt.Errorf("tool loop exceeded max iterations (%d)", maxIterations)
 }
+
+// generateContinuations re-issues a response truncated by the token limit,
+// with the text so far as an assistant prefix, and joins the parts. The result
+// carries the combined usage and the last part's finish reason.
+func generateContinuations(ctx context.Context, p provider.Provider, req provider.Request, resp provider.Response, max int) (provider.Response, error) {
+	prefix := req.AssistantPrefix
+	for i := 0; i < max && shouldContinue(resp); i++ {
+		text := extractText(resp.Message)
+		next := req
+		next.AssistantPrefix = prefix + text
+		cont, err := p.Generate(ctx, next)
+		if err != nil {
+			return provider.Response{}, err
+		}
+		cont.Message = prependText(cont.Message, text)
+		cont.Usage = tools.AddUsage(resp.Usage, cont.Usage)
+		resp = cont
+	}
+	return resp, nil
+}
+
+// shouldContinue reports whether resp was cut off by the token limit before
+// it requested any tools.
+func shouldContinue(resp provider.Response) bool {
+	return resp.FinishReason == "length" && len(tools.ExtractToolCalls(resp.Message)) == 0
+}
+
+func (o Options) maxContinuations() int {
+	if o.MaxContinuations > 0 {
+		return o.MaxContinuations
+	}
+	return 3
+}
+
+func extractText(m provider.Message) string {
+	var sb strings.Builder
+	for _, p := range m.Content {
+		if tp, ok := p.(provider.TextPart); ok {
+			sb.WriteString(tp.Text)
+		}
+	}
+	return sb.String()
+}
+
+// continueAfterTextStep reports whether the loop should run another step after
+// a step without tool calls. That only happens when StopWhenEveryStep is set
+// and StopWhen is not yet satisfied.
+func continueAfterTextStep(opts Options, stepNumber int, steps []Step, messages []provider.Message) bool {
+	if !opts.StopWhenEveryStep || opts.StopWhen == nil {
+		return false
+	}
+	return !opts.StopWhen(StopWhenEvent{
+		StepNumber: stepNumber,
+		Steps:      append([]Step(nil), steps...),
+		Messages:   append([]provider.Message(nil), messages...),
+	})
+}
@@ -46,9 +46,33 @@ type StepFinishEvent struct {
 	Step Step
 }
 
+// StepToolCallsEvent reports a step whose tool calls are about to run; the
+// step has no ToolResults yet.
+type StepToolCallsEvent struct {
+	Step Step
+}
+
 type Options struct {
 	MaxIterations int
 	StopWhen      StopWhenFunc
-	PrepareStep   func(event PrepareStepEvent) (PrepareStepResult, error)
-	OnStepFinish  func(event StepFinishEvent)
+	// StopWhenEveryStep also evaluates StopWhen after text-only steps; when it
+	// returns false and iterations remain, the loop runs another step.
+	StopWhenEveryStep bool
+	// MaxOutputChars ends a stream once this many runes of assistant text
+	// have been streamed (across steps). Zero means no limit.
+	MaxOutputChars int
+	// ResumeOnDisconnect re-issues a stream step that failed with a
+	// retryable error, continuing from the text streamed so far.
+	ResumeOnDisconnect bool
+	// EmptyStreamRetries re-issues a stream step that ended with no content
+	// and an error-like finish reason, up to this many times per step.
+	EmptyStreamRetries int
+	// AutoContinue re-issues a step that finished with "length" (and no tool
+	// calls) with its text as an assistant prefix, up to MaxContinuations
+	// times, concatenating the parts into one response.
+	AutoContinue     bool
+	MaxContinuations int
+	PrepareStep      func(event PrepareStepEvent) (PrepareStepResult, error)
+	OnStepFinish     func(event StepFinishEvent)
+	OnStepToolCalls  func(event StepToolCallsEvent)
 }
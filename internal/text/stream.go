@@ -2,7 +2,10 @@ package text
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/bitop-dev/ai/internal/provider"
 	"github.com/bitop-dev/ai/internal/tools"
@@ -24,11 +27,28 @@ type Stream struct {
 	stepNumber int
 
 	cur provider.Stream
+	// curReq is the request of the current step, kept to resume it.
+	curReq provider.Request
+	// resumes, continues and emptyRetries count how often the current step
+	// was re-issued after a disconnect, a "length" finish or an empty reply;
+	// resumePrefix is the step text
+	// streamed before the latest re-issue, and carryUsage the usage of the
+	// step's earlier parts.
+	resumes      int
+	continues    int
+	emptyRetries int
+	resumePrefix string
+	carryUsage   provider.Usage
 
 	curDelta string
-	final    *provider.Response
-	aggUsage provider.Usage
-	steps    []Step
+	stepText strings.Builder
+	// outputChars counts assistant text runes streamed across all steps.
+	outputChars int
+	final       *provider.Response
+	aggUsage    provider.Usage
+	// stepUsage is the running usage reported mid-stream for the current step.
+	stepUsage provider.Usage
+	steps     []Step
 
 	responseMessages []provider.Message
 	curActiveTools   []string
@@ -64,12 +84,18 @@ func (s *Stream) Next() bool {
 		if s.cur == nil {
 			if err := s.start(); err != nil {
 				s.err = err
+				if s.ctx.Err() != nil {
+					s.flushPartial()
+				}
 				return false
 			}
 		}
 
 		if s.cur.Next() {
 			d := s.cur.Delta()
+			if d.Usage != nil {
+				s.stepUsage = *d.Usage
+			}
 			if s.onDelta != nil && (len(d.ToolCalls) > 0 || d.Text != "") {
 				s.onDelta(d)
 			}
@@ -77,24 +103,80 @@ func (s *Stream) Next() bool {
 			if s.curDelta == "" {
 				continue
 			}
+			if limit := s.opts.MaxOutputChars; limit > 0 {
+				n := utf8.RuneCountInString(s.curDelta)
+				if s.outputChars+n > limit {
+					s.curDelta = truncateRunes(s.curDelta, limit-s.outputChars)
+					s.stepText.WriteString(s.curDelta)
+					s.outputChars = limit
+					s.finishAtLimit()
+					return s.curDelta != ""
+				}
+				s.outputChars += n
+			}
+			s.stepText.WriteString(s.curDelta)
 			return true
 		}
 
 		if err := s.cur.Err(); err != nil {
+			if s.canResume(err) {
+				if err := s.resume(); err != nil {
+					s.err = err
+					return false
+				}
+				continue
+			}
 			s.err = err
+			if s.ctx.Err() != nil {
+				s.flushPartial()
+			}
 			return false
 		}
 
 		final := s.cur.Final()
 		_ = s.cur.Close()
 		s.cur = nil
+		running := s.stepUsage
+		s.stepUsage = provider.Usage{}
 
+		if s.canRetryEmpty(final) {
+			if final != nil && final.Usage != (provider.Usage{}) {
+				running = final.Usage
+			}
+			s.carryUsage = tools.AddUsage(s.carryUsage, running)
+			s.emptyRetries++
+			if err := s.reissue(); err != nil {
+				s.err = err
+				return false
+			}
+			continue
+		}
 		if final == nil {
 			s.final = &provider.Response{Message: provider.Message{Role: provider.RoleAssistant}}
 			return false
 		}
+		if s.resumePrefix != "" {
+			final.Message = prependText(final.Message, s.resumePrefix)
+		}
+
+		if final.Usage == (provider.Usage{}) {
+			final.Usage = running
+		}
+		final.Usage = tools.AddUsage(s.carryUsage, final.Usage)
 
+		if s.opts.AutoContinue && s.continues < s.opts.maxContinuations() && shouldContinue(*final) {
+			s.carryUsage = final.Usage
+			s.continues++
+			if err := s.reissue(); err != nil {
+				s.err = err
+				return false
+			}
+			continue
+		}
+
+		s.carryUsage = provider.Usage{}
 		s.aggUsage = tools.AddUsage(s.aggUsage, final.Usage)
+		final.Message = tools.StripHostedCalls(final.Message, s.tools)
 		s.messages = append(s.messages, final.Message)
 		s.responseMessages = append(s.responseMessages, final.Message)
 
@@ -110,6 +192,10 @@ func (s *Stream) Next() bool {
 			if s.opts.OnStepFinish != nil {
 				s.opts.OnStepFinish(StepFinishEvent{Step: step})
 			}
+			if s.stepNumber+1 < s.opts.MaxIterations && continueAfterTextStep(s.opts, s.stepNumber, s.steps, s.messages) {
+				s.stepNumber++
+				continue
+			}
 			s.final = final
 			return false
 		}
@@ -118,8 +204,11 @@ func (s *Stream) Next() bool {
 			s.err = fmt.Errorf("tool calls requested but no executor provided")
 			return false
 		}
+		if s.opts.OnStepToolCalls != nil {
+			s.opts.OnStepToolCalls(StepToolCallsEvent{Step: step})
+		}
 
-		results, err := s.exec(s.ctx, calls)
+		results, err := s.exec(tools.WithStepInfo(s.ctx, tools.StepInfo{StepNumber: s.stepNumber, Messages: s.messages}), calls)
 		if err != nil {
 			s.err = err
 			return false
@@ -153,8 +242,10 @@ func (s *Stream) Next() bool {
 
 func (s *Stream) Delta() string             { return s.curDelta }
 func (s *Stream) Final() *provider.Response { return s.final }
-func (s *Stream) Usage() provider.Usage     { return s.aggUsage }
-func (s *Stream) Steps() []Step             { return append([]Step(nil), s.steps...) }
+func (s *Stream) Usage() provider.Usage {
+	return tools.AddUsage(tools.AddUsage(s.aggUsage, s.carryUsage), s.stepUsage)
+}
+func (s *Stream) Steps() []Step { return append([]Step(nil), s.steps...) }
 func (s *Stream) ResponseMessages() []provider.Message {
 	return append([]provider.Message(nil), s.responseMessages...)
 }
@@ -166,10 +257,143 @@ func (s *Stream) Close() error {
 	return nil
 }
 
+// finishAtLimit ends the stream once MaxOutputChars is reached: the upstream
+// is closed and the text so far becomes the final step, with finish reason
+// "length".
+func (s *Stream) finishAtLimit() {
+	_ = s.cur.Close()
+	s.cur = nil
+	usage := tools.AddUsage(s.carryUsage, s.stepUsage)
+	s.stepUsage = provider.Usage{}
+	s.carryUsage = provider.Usage{}
+	s.aggUsage = tools.AddUsage(s.aggUsage, usage)
+
+	msg := provider.Message{Role: provider.RoleAssistant}
+	if txt := s.stepText.String(); txt != "" {
+		msg.Content = []provider.ContentPart{provider.TextPart{Text: txt}}
+	}
+	final := &provider.Response{Message: msg, Usage: usage, FinishReason: "length"}
+	s.messages = append(s.messages, msg)
+	s.responseMessages = append(s.responseMessages, msg)
+	step := Step{
+		StepNumber:  s.stepNumber,
+		Response:    *final,
+		ActiveTools: append([]string(nil), s.curActiveTools...),
+	}
+	s.steps = append(s.steps, step)
+	if s.opts.OnStepFinish != nil {
+		s.opts.OnStepFinish(StepFinishEvent{Step: step})
+	}
+	s.final = final
+}
+
+// maxResumes bounds how often a single step is resumed after a disconnect.
+const maxResumes = 3
+
+func (s *Stream) canResume(err error) bool {
+	if !s.opts.ResumeOnDisconnect || s.resumes >= maxResumes || s.ctx.Err() != nil {
+		return false
+	}
+	var pe *provider.Error
+	return errors.As(err, &pe) && pe.Retryable
+}
+
+// resume re-issues the current step after a mid-stream failure; partial tool
+// calls are discarded and requested again.
+func (s *Stream) resume() error {
+	_ = s.cur.Close()
+	s.cur = nil
+	s.carryUsage = tools.AddUsage(s.carryUsage, s.stepUsage)
+	s.stepUsage = provider.Usage{}
+	s.resumes++
+	return s.reissue()
+}
+
+// reissue sends the current step again with the text streamed so far as an
+// assistant prefix, so the model continues where it left off.
+func (s *Stream) reissue() error {
+	s.resumePrefix = s.stepText.String()
+
+	req := s.curReq
+	req.AssistantPrefix += s.resumePrefix
+	cur, err := s.p.Stream(s.ctx, req)
+	if err != nil {
+		return err
+	}
+	s.cur = cur
+	return nil
+}
+
+// canRetryEmpty reports whether a step that ended with final (nil when the
+// stream ended without one) produced nothing and may be re-issued.
+func (s *Stream) canRetryEmpty(final *provider.Response) bool {
+	if s.emptyRetries >= s.opts.EmptyStreamRetries || s.stepText.Len() > 0 || s.ctx.Err() != nil {
+		return false
+	}
+	if final == nil {
+		return true
+	}
+	switch final.FinishReason {
+	case "stop", "tool_calls", "content_filter":
+		return false
+	}
+	for _, p := range final.Message.Content {
+		if tp, ok := p.(provider.TextPart); !ok || tp.Text != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// prependText puts text in front of m's text, keeping other parts in order.
+func prependText(m provider.Message, text string) provider.Message {
+	content := make([]provider.ContentPart, 0, len(m.Content)+1)
+	if len(m.Content) > 0 {
+		if tp, ok := m.Content[0].(provider.TextPart); ok {
+			content = append(content, provider.TextPart{Text: text + tp.Text})
+			m.Content = append(content, m.Content[1:]...)
+			return m
+		}
+	}
+	content = append(content, provider.TextPart{Text: text})
+	m.Content = append(content, m.Content...)
+	return m
+}
+
+func truncateRunes(text string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	for i := range text {
+		if n == 0 {
+			return text[:i]
+		}
+		n--
+	}
+	return text
+}
+
+// flushPartial finalizes the text streamed so far in the current step after
+// the context was cancelled, so callers can keep partial output. Completed
+// steps are left untouched; the partial step is not recorded as a step.
+func (s *Stream) flushPartial() {
+	msg := provider.Message{Role: provider.RoleAssistant}
+	if txt := s.stepText.String(); txt != "" {
+		msg.Content = []provider.ContentPart{provider.TextPart{Text: txt}}
+	}
+	s.final = &provider.Response{Message: msg}
+}
+
 func (s *Stream) start() error {
 	if s.p == nil {
 		return fmt.Errorf("provider is required")
 	}
+	s.stepText.Reset()
+	s.resumes = 0
+	s.continues = 0
+	s.emptyRetries = 0
+	s.resumePrefix = ""
+	s.carryUsage = provider.Usage{}
 	req := s.baseReq
 	req.Messages = append([]provider.Message(nil), s.messages...)
 
@@ -215,6 +439,10 @@ func (s *Stream) start() error {
 		callTools = filtered
 	}
 	req.Tools = append([]provider.ToolDefinition(nil), callTools...)
+	s.curReq = req
+	// AssistantPrefix seeds the first reply only; later steps follow tool
+	// results and must not repeat it.
+	s.baseReq.AssistantPrefix = ""
 
 	cur, err := s.p.Stream(s.ctx, req)
 	if err != nil {
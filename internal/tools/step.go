@@ -0,0 +1,28 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// StepInfo describes the loop step whose tool calls an Executor is running.
+type StepInfo struct {
+	StepNumber int
+	// Messages is the conversation so far, ending with the assistant message
+	// that requested the tool calls.
+	Messages []provider.Message
+}
+
+type stepInfoKey struct{}
+
+// WithStepInfo attaches info to ctx for the Executor.
+func WithStepInfo(ctx context.Context, info StepInfo) context.Context {
+	return context.WithValue(ctx, stepInfoKey{}, info)
+}
+
+// StepInfoFromContext returns the StepInfo attached by the loop, if any.
+func StepInfoFromContext(ctx context.Context) (StepInfo, bool) {
+	info, ok := ctx.Value(stepInfoKey{}).(StepInfo)
+	return info, ok
+}
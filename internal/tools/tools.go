@@ -20,6 +20,29 @@ func ExtractToolCalls(m provider.Message) []provider.ToolCallPart {
 	return out
 }
 
+// StripHostedCalls removes calls to hosted tools from m. The provider runs
+// those itself, so they get no local execution or tool result message.
+func StripHostedCalls(m provider.Message, defs []provider.ToolDefinition) provider.Message {
+	hosted := map[string]bool{}
+	for _, d := range defs {
+		if d.Hosted {
+			hosted[d.Name] = true
+		}
+	}
+	if len(hosted) == 0 {
+		return m
+	}
+	content := make([]provider.ContentPart, 0, len(m.Content))
+	for _, p := range m.Content {
+		if tc, ok := p.(provider.ToolCallPart); ok && hosted[tc.Name] {
+			continue
+		}
+		content = append(content, p)
+	}
+	m.Content = content
+	return m
+}
+
 func AddUsage(a, b provider.Usage) provider.Usage {
 	return provider.Usage{
 		PromptTokens:     a.PromptTokens + b.PromptTokens,
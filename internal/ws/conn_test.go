@@ -0,0 +1,119 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDialAccept(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer k" {
+			http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		c, err := Accept(w, r)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		for {
+			op, data, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			// Echo in two fragments, after a ping.
+			_ = c.writeFrame(opPing, []byte("p"))
+			half := len(data) / 2
+			_ = c.writeRaw(op, false, data[:half])
+			_ = c.writeRaw(opContinuation, true, data[half:])
+		}
+	}))
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+	if _, err := Dial(context.Background(), srv.Client(), wsURL, nil, nil); err == nil {
+		t.Fatal("expected handshake error")
+	} else if he := (*HandshakeError)(nil); !errors.As(err, &he) || he.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("err=%v", err)
+	}
+
+	c, err := Dial(context.Background(), srv.Client(), wsURL, http.Header{"Authorization": {"Bearer k"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	long := strings.Repeat("x", 70000)
+	for _, msg := range []string{"hello", long} {
+		if err := c.WriteMessage(OpText, []byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+		op, data, err := c.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if op != OpText || string(data) != msg {
+			t.Fatalf("op=%d len=%d", op, len(data))
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.ReadMessage(); err == nil || errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("read after close err=%v", err)
+	}
+}
+
+func TestDial_ClientTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Accept(w, r)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		op, data, err := c.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = c.WriteMessage(op, data)
+	}))
+	defer srv.Close()
+
+	client := srv.Client()
+	client.Timeout = time.Minute
+	c, err := Dial(context.Background(), client, "ws"+strings.TrimPrefix(srv.URL, "http"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	// The connection outlives the handshake's timeout context.
+	if err := c.WriteMessage(OpText, []byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if _, data, err := c.ReadMessage(); err != nil || string(data) != "hi" {
+		t.Fatalf("data=%q err=%v", data, err)
+	}
+}
+
+// writeRaw writes a single frame with an explicit FIN bit (tests only).
+func (c *Conn) writeRaw(op int, fin bool, payload []byte) error {
+	frame := []byte{byte(op), 0}
+	if fin {
+		frame[0] |= 0x80
+	}
+	if len(payload) < 126 {
+		frame[1] = byte(len(payload))
+	} else {
+		frame[1] = 126
+		frame = append(frame, byte(len(payload)>>8), byte(len(payload)))
+	}
+	frame = append(frame, payload...)
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err := c.rwc.Write(frame)
+	return err
+}
@@ -1,18 +1,28 @@
 package mcp
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"sort"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/bitop-dev/ai"
+	"github.com/bitop-dev/ai/internal/httpx"
 	"github.com/bitop-dev/ai/internal/sse"
 )
 
+// Client is safe for concurrent use. Request ids are allocated atomically,
+// initialization runs at most once (concurrent callers wait for it), and
+// responses are correlated per request by the transport.
 type Client struct {
 	transport Transport
 	nextID    atomic.Int64
@@ -21,15 +31,41 @@ type Client struct {
 	clientInfo      ClientInfo
 	capabilities    map[string]any
 
-	initialized atomic.Bool
+	// initMu serializes the initialize handshake; protocolVersion and
+	// serverCapabilities are only written while holding it, before initialized
+	// is set.
+	initMu             sync.Mutex
+	initialized        atomic.Bool
+	serverCapabilities map[string]any
 
 	elicitationHandler  atomic.Value // func(context.Context, ElicitationRequest) (ElicitationResponse, error)
 	notificationHandler atomic.Value // func(context.Context, string, json.RawMessage)
+	cancelledHandler    atomic.Value // func(context.Context, CancelledNotification)
 
 	toolCache              atomic.Value // toolCacheEntry
 	resourcesCache         atomic.Value // []ResourceInfo
 	resourceTemplatesCache atomic.Value // []ResourceTemplateInfo
 	promptsCache           atomic.Value // []PromptInfo
+
+	// resourceTTL bounds how long ReadResourceCached keeps contents; zero
+	// keeps them until invalidated. resourceGen is bumped on every
+	// invalidation so fetches started before it do not store stale results.
+	resourceTTL   time.Duration
+	resourceMu    sync.Mutex
+	resourceCache map[string]resourceCacheEntry
+	resourceGen   uint64
+
+	// callSem bounds in-flight requests when MaxConcurrentCalls is set.
+	callSem chan struct{}
+
+	maxRetries             int
+	minBackoff, maxBackoff time.Duration
+
+	// toolNames maps sanitized tool names returned by Tools to server names.
+	toolNames sync.Map
+
+	// fetches shares in-flight cache fills between concurrent *Cached callers.
+	fetches flightGroup
 }
 
 type toolCacheEntry struct {
@@ -37,18 +73,44 @@ type toolCacheEntry struct {
 	tools []ai.Tool
 }
 
-func (c *Client) invalidateCaches(method string) {
+type resourceCacheEntry struct {
+	res     *ReadResourceResult
+	expires time.Time // zero: no expiry
+}
+
+func (c *Client) invalidateCaches(method string, params json.RawMessage) {
 	switch method {
 	case "notifications/tools/list_changed":
 		c.toolCache.Store(toolCacheEntry{})
 	case "notifications/resources/list_changed":
 		c.resourcesCache.Store([]ResourceInfo(nil))
 		c.resourceTemplatesCache.Store([]ResourceTemplateInfo(nil))
+		c.invalidateResources("")
+	case "notifications/resources/updated":
+		var p struct {
+			URI string `json:"uri"`
+		}
+		if json.Unmarshal(params, &p) == nil && p.URI != "" {
+			c.invalidateResources(p.URI)
+		}
 	case "notifications/prompts/list_changed":
 		c.promptsCache.Store([]PromptInfo(nil))
 	}
 }
 
+// invalidateResources drops the cached contents of uri, or of every resource
+// when uri is empty.
+func (c *Client) invalidateResources(uri string) {
+	c.resourceMu.Lock()
+	defer c.resourceMu.Unlock()
+	c.resourceGen++
+	if uri == "" {
+		c.resourceCache = nil
+		return
+	}
+	delete(c.resourceCache, uri)
+}
+
 type AutoRefreshOptions struct {
 	ToolsOptions *ToolsOptions
 
@@ -129,13 +191,49 @@ type ClientOptions struct {
 
 	// Capabilities is sent in the initialize request (e.g. {"elicitation":{}}).
 	Capabilities map[string]any
+
+	// ClientCapabilities is a typed alternative to Capabilities. When both are
+	// set they are merged, with entries in Capabilities taking precedence.
+	ClientCapabilities *Capabilities
+
+	// ResourceCacheTTL is how long ReadResourceCached keeps resource contents.
+	// Zero keeps them until a `notifications/resources/updated` (for that URI)
+	// or `notifications/resources/list_changed` notification is received.
+	ResourceCacheTTL time.Duration
+
+	// MaxConcurrentCalls bounds the requests in flight on this client (tool
+	// calls, List*, reads, ...); excess callers wait until a slot frees up or
+	// their context is done. Zero means no limit. Notifications are not
+	// counted.
+	MaxConcurrentCalls int
+
+	// MaxRetries retries requests that failed with a transient transport
+	// error (network error, HTTP 408, 429 or 5xx), waiting between MinBackoff
+	// (default 250ms) and MaxBackoff (default 5s), doubling with jitter. Only
+	// idempotent requests are retried: initialize, the list methods,
+	// resources/read, prompts/get, and tools/call for tools the server
+	// annotates as read-only or idempotent (tools returned by Tools). Zero
+	// disables retries.
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
 }
 
 func NewClient(opts ClientOptions) (*Client, error) {
 	if opts.Transport == nil {
 		return nil, fmt.Errorf("mcp: transport is required")
 	}
-	c := &Client{transport: opts.Transport}
+	c := &Client{transport: opts.Transport, resourceTTL: opts.ResourceCacheTTL}
+	if opts.MaxConcurrentCalls > 0 {
+		c.callSem = make(chan struct{}, opts.MaxConcurrentCalls)
+	}
+	c.maxRetries, c.minBackoff, c.maxBackoff = opts.MaxRetries, opts.MinBackoff, opts.MaxBackoff
+	if c.minBackoff <= 0 {
+		c.minBackoff = 250 * time.Millisecond
+	}
+	if c.maxBackoff <= 0 {
+		c.maxBackoff = 5 * time.Second
+	}
 	c.nextID.Store(1)
 	c.protocolVersion = opts.ProtocolVersion
 	if c.protocolVersion == "" {
@@ -146,6 +244,13 @@ func NewClient(opts ClientOptions) (*Client, error) {
 		c.clientInfo.Name = "ai-go-mcp-client"
 	}
 	c.capabilities = opts.Capabilities
+	if opts.ClientCapabilities != nil {
+		caps := opts.ClientCapabilities.Map()
+		for k, v := range opts.Capabilities {
+			caps[k] = v
+		}
+		c.capabilities = caps
+	}
 	return c, nil
 }
 
@@ -165,7 +270,13 @@ func (c *Client) Initialize(ctx context.Context) (*InitializeResult, error) {
 		return nil, fmt.Errorf("mcp: client is nil")
 	}
 	if c.initialized.Load() {
-		return &InitializeResult{ProtocolVersion: c.protocolVersion}, nil
+		return &InitializeResult{ProtocolVersion: c.protocolVersion, Capabilities: c.serverCapabilities}, nil
+	}
+
+	c.initMu.Lock()
+	defer c.initMu.Unlock()
+	if c.initialized.Load() {
+		return &InitializeResult{ProtocolVersion: c.protocolVersion, Capabilities: c.serverCapabilities}, nil
 	}
 
 	// Use a short timeout by default for init if caller didn't provide one.
@@ -199,6 +310,7 @@ func (c *Client) Initialize(ctx context.Context) (*InitializeResult, error) {
 		return nil, &ClientError{Op: "initialize", Method: "notifications/initialized", Cause: err}
 	}
 
+	c.serverCapabilities = res.Capabilities
 	c.initialized.Store(true)
 	return &res, nil
 }
@@ -211,10 +323,32 @@ func (c *Client) ensureInitialized(ctx context.Context) error {
 	return err
 }
 
+// requireCapability initializes the client and checks that the server
+// advertised capability. Servers that omit the capabilities object entirely
+// are not gated.
+func (c *Client) requireCapability(ctx context.Context, capability, method string) error {
+	if c == nil || c.transport == nil {
+		return fmt.Errorf("mcp: client is nil")
+	}
+	if err := c.ensureInitialized(ctx); err != nil {
+		return err
+	}
+	if c.serverCapabilities == nil {
+		return nil
+	}
+	if _, ok := c.serverCapabilities[capability]; !ok {
+		return &CapabilityError{Capability: capability, Method: method}
+	}
+	return nil
+}
+
 // OnElicitationRequest registers a handler for MCP elicitation requests.
 //
-// This is currently only supported for transports that can receive server->client
-// JSON-RPC requests (e.g. stdio). For HTTP-based transports, this returns an error.
+// Stdio transports deliver requests to the handler directly. HTTP transports
+// deliver them on the response stream of the request that triggered them
+// (e.g. a tool call) and on the server event stream read by Listen; either
+// way the handler's answer is POSTed back to the server. Declare
+// Capabilities{Elicitation: true} so servers know to send them.
 func (c *Client) OnElicitationRequest(handler func(ctx context.Context, req ElicitationRequest) (ElicitationResponse, error)) error {
 	if c == nil || c.transport == nil {
 		return fmt.Errorf("mcp: client is nil")
@@ -239,6 +373,17 @@ func (c *Client) OnNotification(handler func(ctx context.Context, method string,
 	c.notificationHandler.Store(handler)
 }
 
+// OnCancelled registers a handler for notifications/cancelled, which a server
+// sends when it abandons a request it made to the client. It is invoked from
+// Listen, after the context passed to that request's handler (e.g. an
+// elicitation handler) has been cancelled; no response is sent for it.
+func (c *Client) OnCancelled(handler func(ctx context.Context, n CancelledNotification)) {
+	if c == nil {
+		return
+	}
+	c.cancelledHandler.Store(handler)
+}
+
 // Listen opens a server-to-client event stream (when supported by the transport)
 // and handles incoming notifications and requests.
 //
@@ -260,6 +405,11 @@ func (c *Client) Listen(ctx context.Context) error {
 	}
 	defer rc.Close()
 
+	// Wait for in-flight server requests so their responses are sent.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	var inflight serverRequests
+
 	dec := sse.NewDecoder(rc)
 	for dec.Next() {
 		data := dec.Data()
@@ -281,35 +431,42 @@ func (c *Client) Listen(ctx context.Context) error {
 			continue
 		}
 
-		if probe.Method == "elicitation/create" && probe.ID != nil {
-			hAny := c.elicitationHandler.Load()
-			if hAny == nil {
-				continue
-			}
-			h := hAny.(func(context.Context, ElicitationRequest) (ElicitationResponse, error))
+		if probe.ID != nil {
+			// Server->client request: answer it via POST without blocking
+			// the stream (an elicitation may wait on the user).
+			id := *probe.ID
+			method, params := probe.Method, probe.Params
+			rctx, done := inflight.start(ctx, id)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer done()
+				result, rpcErr := c.serverRequest(rctx, method, params)
+				if rctx.Err() != nil && ctx.Err() == nil {
+					// Cancelled by the server: it expects no response.
+					return
+				}
+				_ = c.sendRPCResponse(ctx, id, result, rpcErr)
+			}()
+			continue
+		}
 
-			var p elicitationCreateParams
-			if err := json.Unmarshal(probe.Params, &p); err != nil {
-				_ = c.sendRPCResponse(ctx, *probe.ID, nil, &rpcError{Code: -32602, Message: "invalid params"})
-				continue
-			}
-			res, err := h(ctx, ElicitationRequest{Message: p.Message, RequestedSchema: p.RequestedSchema})
-			if err != nil {
-				_ = c.sendRPCResponse(ctx, *probe.ID, nil, &rpcError{Code: -32000, Message: err.Error()})
-				continue
+		if probe.Method == "notifications/cancelled" {
+			var n CancelledNotification
+			if json.Unmarshal(probe.Params, &n) == nil {
+				inflight.cancel(n.RequestID)
+				if h, ok := c.cancelledHandler.Load().(func(context.Context, CancelledNotification)); ok && h != nil {
+					h(ctx, n)
+				}
 			}
-			_ = c.sendRPCResponse(ctx, *probe.ID, res, nil)
-			continue
 		}
 
-		c.invalidateCaches(probe.Method)
+		c.invalidateCaches(probe.Method, probe.Params)
 
-		if probe.ID == nil {
-			hAny := c.notificationHandler.Load()
-			if hAny != nil {
-				h := hAny.(func(context.Context, string, json.RawMessage))
-				h(ctx, probe.Method, probe.Params)
-			}
+		hAny := c.notificationHandler.Load()
+		if hAny != nil {
+			h := hAny.(func(context.Context, string, json.RawMessage))
+			h(ctx, probe.Method, probe.Params)
 		}
 	}
 
@@ -319,9 +476,58 @@ func (c *Client) Listen(ctx context.Context) error {
 	return nil
 }
 
+// serverRequests tracks the server->client requests Listen is handling, so a
+// server can cancel them.
+type serverRequests struct {
+	mu      sync.Mutex
+	cancels map[int64]context.CancelFunc
+}
+
+// start returns the context to handle request id with, and a func to call
+// once it is handled.
+func (r *serverRequests) start(ctx context.Context, id int64) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancels == nil {
+		r.cancels = map[int64]context.CancelFunc{}
+	}
+	r.cancels[id] = cancel
+	return ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.cancels, id)
+		cancel()
+	}
+}
+
+func (r *serverRequests) cancel(id int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cancel, ok := r.cancels[id]; ok {
+		cancel()
+	}
+}
+
+// serverRequest handles a server->client request received by Listen and
+// returns the JSON-RPC result or error to send back.
+func (c *Client) serverRequest(ctx context.Context, method string, params json.RawMessage) (any, *rpcError) {
+	switch method {
+	case "ping":
+		return struct{}{}, nil
+	case "elicitation/create":
+		h, _ := c.elicitationHandler.Load().(func(context.Context, ElicitationRequest) (ElicitationResponse, error))
+		return elicit(ctx, h, params)
+	}
+	return nil, &rpcError{Code: -32601, Message: "method not found: " + method}
+}
+
 type ToolsOptions struct {
 	// Prefix is prepended to returned tool names. The MCP server tool name is
 	// preserved internally and used when calling tools/call.
+	//
+	// Returned names are sanitized to match `^[a-zA-Z0-9_-]{1,64}$`; use
+	// Client.ServerToolName to map them back.
 	Prefix string
 
 	// Allowlist/denylist apply to server tool names (before Prefix).
@@ -329,15 +535,59 @@ type ToolsOptions struct {
 	AllowedTools []string
 	DeniedTools  []string
 
+	// OnlyReadOnly returns only tools the server annotates as read-only.
+	// ExcludeDestructive drops tools that may be destructive: those not
+	// annotated read-only unless they declare destructiveHint: false. Tools
+	// without annotations count as destructive, as the MCP spec defaults say.
+	// Annotations are server-provided hints; filter untrusted servers by name
+	// as well.
+	OnlyReadOnly       bool
+	ExcludeDestructive bool
+
+	// Descriptions replaces the server-provided description of tools, by
+	// server tool name. Names the server does not list are ignored.
+	Descriptions map[string]string
+
 	// Schemas optionally restricts which tools are returned and/or overrides the
 	// server-provided schema for specific tools.
 	//
 	// When non-nil, only tools present in the map are returned.
 	Schemas map[string]ai.Schema
+
+	// CacheResults sets ai.Tool.CacheResults on every returned tool. It only
+	// takes effect for tools the server annotates as read-only or idempotent.
+	CacheResults bool
+
+	// InlineResourceResults makes returned tools replace resource links in
+	// their results (parts of type "resource_link", or "resource" parts
+	// carrying only a URI) with the resource contents, read via
+	// resources/read, so the model sees the data rather than a URI it cannot
+	// dereference. Links that cannot be read are left as they are.
+	InlineResourceResults bool
+
+	// Tool input lifecycle hooks set on every returned tool (see ai.Tool), so
+	// StreamText reports remote tool arguments as they stream. Events carry
+	// the returned tool name; use Client.ServerToolName to map it back.
+	OnInputStart     func(event ai.ToolInputStartEvent)
+	OnInputDelta     func(event ai.ToolInputDeltaEvent)
+	OnInputAvailable func(event ai.ToolInputAvailableEvent)
+}
+
+// setInputHooks sets the lifecycle hooks from opts on tools in place.
+func setInputHooks(tools []ai.Tool, opts *ToolsOptions) {
+	for i := range tools {
+		if opts == nil {
+			tools[i].OnInputStart, tools[i].OnInputDelta, tools[i].OnInputAvailable = nil, nil, nil
+			continue
+		}
+		tools[i].OnInputStart = opts.OnInputStart
+		tools[i].OnInputDelta = opts.OnInputDelta
+		tools[i].OnInputAvailable = opts.OnInputAvailable
+	}
 }
 
 func (c *Client) Tools(ctx context.Context, opts *ToolsOptions) ([]ai.Tool, error) {
-	if err := c.ensureInitialized(ctx); err != nil {
+	if err := c.requireCapability(ctx, "tools", "tools/list"); err != nil {
 		return nil, err
 	}
 	infos, err := c.listTools(ctx)
@@ -382,6 +632,7 @@ func (c *Client) Tools(ctx context.Context, opts *ToolsOptions) ([]ai.Tool, erro
 	}
 
 	out := make([]ai.Tool, 0, len(names))
+	serverNames := make([]string, 0, len(names))
 	for _, name := range names {
 		info, ok := byName[name]
 		if !ok {
@@ -398,6 +649,9 @@ func (c *Client) Tools(ctx context.Context, opts *ToolsOptions) ([]ai.Tool, erro
 		if denied[info.Name] {
 			continue
 		}
+		if opts != nil && !opts.annotationsAllow(info.Annotations) {
+			continue
+		}
 
 		schema := info.InputSchema
 		if opts != nil && opts.Schemas != nil {
@@ -405,28 +659,97 @@ func (c *Client) Tools(ctx context.Context, opts *ToolsOptions) ([]ai.Tool, erro
 				schema = s.JSON
 			}
 		}
+		if isEmptySchema(schema) {
+			// Providers reject functions with null parameters; keep the tool callable.
+			schema = json.RawMessage(defaultToolInputSchema)
+		}
+
+		description := info.Description
+		if d, ok := opts.description(info.Name); ok {
+			description = d
+		}
 
 		serverToolName := info.Name
 		publicToolName := serverToolName
 		if opts != nil && opts.Prefix != "" {
 			publicToolName = opts.Prefix + serverToolName
 		}
+		callOpts := toolCallOptions{
+			inlineResources: opts != nil && opts.InlineResourceResults,
+			retry:           retrySafeTool(info.Annotations),
+		}
+		serverNames = append(serverNames, serverToolName)
 		out = append(out, ai.Tool{
-			Name:        publicToolName,
-			Description: info.Description,
-			InputSchema: ai.JSONSchema(schema),
+			Name:         publicToolName,
+			Description:  description,
+			InputSchema:  ai.JSONSchema(schema),
+			Annotations:  toolAnnotations(info.Annotations),
+			CacheResults: opts != nil && opts.CacheResults,
 			Handler: func(ctx context.Context, input json.RawMessage) (any, error) {
-				return c.callTool(ctx, serverToolName, input)
+				return c.callTool(ctx, serverToolName, input, callOpts)
 			},
 		})
 	}
 
+	publicNames := make([]string, len(out))
+	for i, t := range out {
+		publicNames[i] = t.Name
+	}
+	for i, name := range sanitizeToolNames(publicNames) {
+		out[i].Name = name
+		c.toolNames.Store(name, serverNames[i])
+	}
+	setInputHooks(out, opts)
+
 	return out, nil
 }
 
+func (o *ToolsOptions) description(name string) (string, bool) {
+	if o == nil {
+		return "", false
+	}
+	d, ok := o.Descriptions[name]
+	return d, ok
+}
+
+// annotationsAllow reports whether a tool with annotations a passes the
+// OnlyReadOnly and ExcludeDestructive filters.
+func (o *ToolsOptions) annotationsAllow(a *ToolAnnotations) bool {
+	readOnly := a != nil && a.ReadOnlyHint != nil && *a.ReadOnlyHint
+	if o.OnlyReadOnly && !readOnly {
+		return false
+	}
+	if o.ExcludeDestructive && !readOnly {
+		if a == nil || a.DestructiveHint == nil || *a.DestructiveHint {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultToolInputSchema is used for server tools that declare no input schema.
+const defaultToolInputSchema = `{"type":"object","additionalProperties":true}`
+
+// toolAnnotations maps the server's hints onto ai.ToolAnnotations.
+func toolAnnotations(a *ToolAnnotations) ai.ToolAnnotations {
+	if a == nil {
+		return ai.ToolAnnotations{}
+	}
+	return ai.ToolAnnotations{
+		ReadOnly:   a.ReadOnlyHint != nil && *a.ReadOnlyHint,
+		Idempotent: a.IdempotentHint != nil && *a.IdempotentHint,
+	}
+}
+
+func isEmptySchema(schema json.RawMessage) bool {
+	s := strings.TrimSpace(string(schema))
+	return s == "" || s == "null" || s == "{}"
+}
+
 // ToolsCached returns tools like Tools(), but caches the result for identical
 // options. Cache is invalidated automatically when Listen() receives
-// `notifications/tools/list_changed`.
+// `notifications/tools/list_changed`. Concurrent callers with the same options
+// share a single `tools/list` round-trip.
 func (c *Client) ToolsCached(ctx context.Context, opts *ToolsOptions) ([]ai.Tool, error) {
 	if c == nil {
 		return nil, fmt.Errorf("mcp: client is nil")
@@ -440,15 +763,27 @@ func (c *Client) ToolsCached(ctx context.Context, opts *ToolsOptions) ([]ai.Tool
 		if e.key == key && len(e.tools) > 0 {
 			out := make([]ai.Tool, len(e.tools))
 			copy(out, e.tools)
+			setInputHooks(out, opts)
 			return out, nil
 		}
 	}
-	tools, err := c.Tools(ctx, opts)
+	v, err := c.fetches.do(ctx, "tools:"+key, func(ctx context.Context) (any, error) {
+		tools, err := c.Tools(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		c.toolCache.Store(toolCacheEntry{key: key, tools: tools})
+		return tools, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	c.toolCache.Store(toolCacheEntry{key: key, tools: tools})
-	return tools, nil
+	tools := v.([]ai.Tool)
+	out := make([]ai.Tool, len(tools))
+	copy(out, tools)
+	// Hooks are not part of the cache key; each caller gets its own.
+	setInputHooks(out, opts)
+	return out, nil
 }
 
 func toolsOptionsKey(opts *ToolsOptions) (string, error) {
@@ -482,11 +817,23 @@ func toolsOptionsKey(opts *ToolsOptions) (string, error) {
 		Allowed []string      `json:"allowed,omitempty"`
 		Denied  []string      `json:"denied,omitempty"`
 		Schemas []schemaEntry `json:"schemas,omitempty"`
+		Cache   bool          `json:"cache,omitempty"`
+
+		OnlyReadOnly       bool              `json:"onlyReadOnly,omitempty"`
+		ExcludeDestructive bool              `json:"excludeDestructive,omitempty"`
+		Descriptions       map[string]string `json:"descriptions,omitempty"`
+		InlineResources    bool              `json:"inlineResources,omitempty"`
 	}{
 		Prefix:  opts.Prefix,
 		Allowed: allowed,
 		Denied:  denied,
 		Schemas: schemas,
+		Cache:   opts.CacheResults,
+
+		OnlyReadOnly:       opts.OnlyReadOnly,
+		ExcludeDestructive: opts.ExcludeDestructive,
+		Descriptions:       opts.Descriptions,
+		InlineResources:    opts.InlineResourceResults,
 	}
 	b, err := json.Marshal(keyObj)
 	if err != nil {
@@ -503,18 +850,43 @@ func (c *Client) listTools(ctx context.Context) ([]ToolInfo, error) {
 	return result.Tools, nil
 }
 
-func (c *Client) callTool(ctx context.Context, name string, input json.RawMessage) (any, error) {
+// toolCallOptions tune how callTool calls a tool.
+type toolCallOptions struct {
+	// inlineResources replaces resource links in the result with the
+	// resources' contents.
+	inlineResources bool
+	// retry allows retrying the call after transient transport errors.
+	retry bool
+}
+
+// retrySafeTool reports whether calling a tool twice is harmless: the server
+// annotates it read-only or idempotent.
+func retrySafeTool(a *ToolAnnotations) bool {
+	return a != nil && ((a.ReadOnlyHint != nil && *a.ReadOnlyHint) || (a.IdempotentHint != nil && *a.IdempotentHint))
+}
+
+func (c *Client) callTool(ctx context.Context, name string, input json.RawMessage, opts toolCallOptions) (any, error) {
 	var args any
 	if len(input) > 0 {
-		if err := json.Unmarshal(input, &args); err != nil {
+		// Keep numbers as json.Number so large integers reach the server intact.
+		dec := json.NewDecoder(bytes.NewReader(input))
+		dec.UseNumber()
+		if err := dec.Decode(&args); err != nil {
 			return nil, err
 		}
 	}
 
 	var result CallToolResult
-	if err := c.rpcRaw(ctx, "tools/call", callToolParams{Name: name, Arguments: args}, &result); err != nil {
+	if err := c.rpc(ctx, "tools/call", callToolParams{Name: name, Arguments: args}, &result, opts.retry); err != nil {
 		return nil, &CallToolError{ToolName: name, Cause: err}
 	}
+	if result.IsError {
+		// Let the tool loop report the failure to the model.
+		return nil, &CallToolError{ToolName: name, Cause: &ai.ToolResultError{ToolName: name, Message: toolErrorText(result)}}
+	}
+	if opts.inlineResources {
+		result.Content = c.inlineResourceLinks(ctx, result.Content)
+	}
 
 	// Common case: a single text content part -> return plain string for model consumption.
 	if len(result.Content) == 1 && result.Content[0].Type == "text" {
@@ -531,8 +903,82 @@ func (c *Client) callTool(ctx context.Context, name string, input json.RawMessag
 	return result, nil
 }
 
+// inlineResourceLinks replaces resource links in parts with embedded
+// "resource" parts holding the contents read from the server. Links that
+// fail to read are kept.
+func (c *Client) inlineResourceLinks(ctx context.Context, parts []ToolContentPart) []ToolContentPart {
+	out := make([]ToolContentPart, 0, len(parts))
+	for _, p := range parts {
+		uri := resourceLinkURI(p)
+		if uri == "" {
+			out = append(out, p)
+			continue
+		}
+		res, err := c.ReadResource(ctx, uri)
+		if err != nil || len(res.Contents) == 0 {
+			out = append(out, p)
+			continue
+		}
+		for _, rc := range res.Contents {
+			if rc.URI == "" {
+				rc.URI = uri
+			}
+			raw, err := json.Marshal(map[string]any{"type": "resource", "resource": rc})
+			if err != nil {
+				out = append(out, p)
+				break
+			}
+			out = append(out, ToolContentPart{Type: "resource", Raw: raw})
+		}
+	}
+	return out
+}
+
+// resourceLinkURI returns the URI of a content part that only references a
+// resource: a "resource_link", or a "resource" without text or blob.
+func resourceLinkURI(p ToolContentPart) string {
+	switch p.Type {
+	case "resource_link":
+		var link struct {
+			URI string `json:"uri"`
+		}
+		if json.Unmarshal(p.Raw, &link) == nil {
+			return link.URI
+		}
+	case "resource":
+		var embedded struct {
+			Resource *ResourceContent `json:"resource"`
+		}
+		if json.Unmarshal(p.Raw, &embedded) == nil && embedded.Resource != nil &&
+			embedded.Resource.Text == "" && embedded.Resource.BlobBase64 == "" {
+			return embedded.Resource.URI
+		}
+	}
+	return ""
+}
+
+// toolErrorText joins the text parts of a failed tool result.
+func toolErrorText(result CallToolResult) string {
+	var texts []string
+	for _, p := range result.Content {
+		if p.Type != "text" {
+			continue
+		}
+		var t struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(p.Raw, &t); err == nil && t.Text != "" {
+			texts = append(texts, t.Text)
+		}
+	}
+	if len(texts) == 0 {
+		return "tool call failed"
+	}
+	return strings.Join(texts, "\n")
+}
+
 func (c *Client) ListResources(ctx context.Context) ([]ResourceInfo, error) {
-	if err := c.ensureInitialized(ctx); err != nil {
+	if err := c.requireCapability(ctx, "resources", "resources/list"); err != nil {
 		return nil, err
 	}
 	var res ResourcesListResult
@@ -553,16 +999,25 @@ func (c *Client) ListResourcesCached(ctx context.Context) ([]ResourceInfo, error
 			return out, nil
 		}
 	}
-	res, err := c.ListResources(ctx)
+	v, err := c.fetches.do(ctx, "resources", func(ctx context.Context) (any, error) {
+		res, err := c.ListResources(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.resourcesCache.Store(res)
+		return res, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	c.resourcesCache.Store(res)
-	return res, nil
+	res := v.([]ResourceInfo)
+	out := make([]ResourceInfo, len(res))
+	copy(out, res)
+	return out, nil
 }
 
 func (c *Client) ListResourceTemplates(ctx context.Context) ([]ResourceTemplateInfo, error) {
-	if err := c.ensureInitialized(ctx); err != nil {
+	if err := c.requireCapability(ctx, "resources", "resources/templates/list"); err != nil {
 		return nil, err
 	}
 	var res ResourceTemplatesListResult
@@ -583,16 +1038,25 @@ func (c *Client) ListResourceTemplatesCached(ctx context.Context) ([]ResourceTem
 			return out, nil
 		}
 	}
-	res, err := c.ListResourceTemplates(ctx)
+	v, err := c.fetches.do(ctx, "resourceTemplates", func(ctx context.Context) (any, error) {
+		res, err := c.ListResourceTemplates(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.resourceTemplatesCache.Store(res)
+		return res, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	c.resourceTemplatesCache.Store(res)
-	return res, nil
+	res := v.([]ResourceTemplateInfo)
+	out := make([]ResourceTemplateInfo, len(res))
+	copy(out, res)
+	return out, nil
 }
 
 func (c *Client) ReadResource(ctx context.Context, uri string) (*ReadResourceResult, error) {
-	if err := c.ensureInitialized(ctx); err != nil {
+	if err := c.requireCapability(ctx, "resources", "resources/read"); err != nil {
 		return nil, err
 	}
 	var res ReadResourceResult
@@ -602,8 +1066,87 @@ func (c *Client) ReadResource(ctx context.Context, uri string) (*ReadResourceRes
 	return &res, nil
 }
 
+// ReadResourceCached returns ReadResource results from a per-URI cache. Entries
+// expire after ClientOptions.ResourceCacheTTL (if set) and are dropped when
+// Listen receives `notifications/resources/updated` for the URI or
+// `notifications/resources/list_changed`. Concurrent callers for the same URI
+// share one round-trip to the server.
+func (c *Client) ReadResourceCached(ctx context.Context, uri string) (*ReadResourceResult, error) {
+	if c == nil {
+		return nil, fmt.Errorf("mcp: client is nil")
+	}
+	c.resourceMu.Lock()
+	e, ok := c.resourceCache[uri]
+	gen := c.resourceGen
+	c.resourceMu.Unlock()
+	if ok && (e.expires.IsZero() || time.Now().Before(e.expires)) {
+		return copyReadResourceResult(e.res), nil
+	}
+
+	v, err := c.fetches.do(ctx, "resource:"+uri, func(ctx context.Context) (any, error) {
+		res, err := c.ReadResource(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		entry := resourceCacheEntry{res: res}
+		if c.resourceTTL > 0 {
+			entry.expires = time.Now().Add(c.resourceTTL)
+		}
+		c.resourceMu.Lock()
+		if c.resourceGen == gen {
+			if c.resourceCache == nil {
+				c.resourceCache = map[string]resourceCacheEntry{}
+			}
+			c.resourceCache[uri] = entry
+		}
+		c.resourceMu.Unlock()
+		return res, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyReadResourceResult(v.(*ReadResourceResult)), nil
+}
+
+func copyReadResourceResult(res *ReadResourceResult) *ReadResourceResult {
+	return &ReadResourceResult{Contents: append([]ResourceContent(nil), res.Contents...)}
+}
+
+// ReadResourceBytes reads a resource and returns its content as bytes, decoding
+// base64 blobs and returning text content as UTF-8. When the server returns
+// several contents, the one matching uri is preferred (else the first).
+func (c *Client) ReadResourceBytes(ctx context.Context, uri string) ([]byte, string, error) {
+	res, err := c.ReadResource(ctx, uri)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(res.Contents) == 0 {
+		return nil, "", &ClientError{Op: "parse", Method: "resources/read", Cause: fmt.Errorf("resource %q has no contents", uri)}
+	}
+	content := res.Contents[0]
+	for _, rc := range res.Contents {
+		if rc.URI == uri {
+			content = rc
+			break
+		}
+	}
+	b, err := content.Bytes()
+	if err != nil {
+		return nil, "", &ClientError{Op: "parse", Method: "resources/read", Cause: err}
+	}
+	mediaType := content.MediaType
+	if mediaType == "" {
+		if content.BlobBase64 != "" {
+			mediaType = "application/octet-stream"
+		} else {
+			mediaType = "text/plain"
+		}
+	}
+	return b, mediaType, nil
+}
+
 func (c *Client) ListPrompts(ctx context.Context) ([]PromptInfo, error) {
-	if err := c.ensureInitialized(ctx); err != nil {
+	if err := c.requireCapability(ctx, "prompts", "prompts/list"); err != nil {
 		return nil, err
 	}
 	var res PromptsListResult
@@ -624,16 +1167,25 @@ func (c *Client) ListPromptsCached(ctx context.Context) ([]PromptInfo, error) {
 			return out, nil
 		}
 	}
-	res, err := c.ListPrompts(ctx)
+	v, err := c.fetches.do(ctx, "prompts", func(ctx context.Context) (any, error) {
+		res, err := c.ListPrompts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.promptsCache.Store(res)
+		return res, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	c.promptsCache.Store(res)
-	return res, nil
+	res := v.([]PromptInfo)
+	out := make([]PromptInfo, len(res))
+	copy(out, res)
+	return out, nil
 }
 
 func (c *Client) GetPrompt(ctx context.Context, name string, args map[string]string) (*GetPromptResult, error) {
-	if err := c.ensureInitialized(ctx); err != nil {
+	if err := c.requireCapability(ctx, "prompts", "prompts/get"); err != nil {
 		return nil, err
 	}
 	var res GetPromptResult
@@ -643,10 +1195,63 @@ func (c *Client) GetPrompt(ctx context.Context, name string, args map[string]str
 	return &res, nil
 }
 
+// rpcRaw sends a request, retrying idempotent methods (see ClientOptions.MaxRetries).
 func (c *Client) rpcRaw(ctx context.Context, method string, params any, out any) error {
+	return c.rpc(ctx, method, params, out, idempotentMethods[method])
+}
+
+// idempotentMethods are the requests that are safe to send again after a
+// transport failure. tools/call is decided per tool.
+var idempotentMethods = map[string]bool{
+	"initialize":               true,
+	"tools/list":               true,
+	"resources/list":           true,
+	"resources/templates/list": true,
+	"resources/read":           true,
+	"prompts/list":             true,
+	"prompts/get":              true,
+}
+
+// rpc sends a request; with retry, transient transport errors are retried up
+// to the client's MaxRetries. Each attempt uses a new request ID.
+func (c *Client) rpc(ctx context.Context, method string, params any, out any, retry bool) error {
 	if c == nil || c.transport == nil {
 		return &ClientError{Op: "request", Method: method, Cause: fmt.Errorf("client is nil")}
 	}
+	for attempt := 0; ; attempt++ {
+		err := c.rpcOnce(ctx, method, params, out)
+		if !retry || attempt >= c.maxRetries || ctx.Err() != nil || !transientError(err) {
+			return err
+		}
+		timer := time.NewTimer(httpx.BackoffWithJitter(attempt, c.minBackoff, c.maxBackoff))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// transientError reports whether a request failed in the transport in a way
+// that may succeed when retried. Server replies (RPC errors) are final.
+func transientError(err error) bool {
+	var ce *ClientError
+	if !errors.As(err, &ce) || ce.Op != "request" {
+		return false
+	}
+	var se *HTTPStatusError
+	if errors.As(err, &se) {
+		return se.StatusCode == http.StatusRequestTimeout || se.StatusCode == http.StatusTooManyRequests || se.StatusCode >= 500
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var ne net.Error
+	return errors.As(err, &ne) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+func (c *Client) rpcOnce(ctx context.Context, method string, params any, out any) error {
 	id := c.nextID.Add(1)
 	idPtr := &id
 	req := rpcRequest{
@@ -659,8 +1264,21 @@ func (c *Client) rpcRaw(ctx context.Context, method string, params any, out any)
 	if err != nil {
 		return err
 	}
+	if c.callSem != nil {
+		select {
+		case c.callSem <- struct{}{}:
+		case <-ctx.Done():
+			return &ClientError{Op: "request", Method: method, Cause: ctx.Err()}
+		}
+	}
 	rawResp, err := c.transport.Call(ctx, b)
+	if c.callSem != nil {
+		<-c.callSem
+	}
 	if err != nil {
+		if ctx.Err() != nil && method != "initialize" {
+			c.cancelRequest(ctx, id)
+		}
 		return &ClientError{Op: "request", Method: method, Cause: err}
 	}
 	return parseRPCResult(rawResp, out, method)
@@ -686,6 +1304,20 @@ func parseRPCResult(rawResp json.RawMessage, out any, method string) error {
 	return nil
 }
 
+// cancelRequestTimeout bounds sending notifications/cancelled.
+const cancelRequestTimeout = 5 * time.Second
+
+// cancelRequest tells the server, in the background, that the client gave up
+// on request id (ctx is done) so it can stop working on it.
+func (c *Client) cancelRequest(ctx context.Context, id int64) {
+	n := CancelledNotification{RequestID: id, Reason: ctx.Err().Error()}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cancelRequestTimeout)
+	go func() {
+		defer cancel()
+		_ = c.notify(ctx, "notifications/cancelled", n)
+	}()
+}
+
 func (c *Client) notify(ctx context.Context, method string, params any) error {
 	req := rpcRequest{
 		JSONRPC: "2.0",
@@ -3,8 +3,15 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/bitop-dev/ai"
 )
@@ -12,10 +19,18 @@ import (
 type fakeTransport struct {
 	tools []ToolInfo
 	calls int
+	// toolError makes tools/call return an isError result with this text.
+	toolError string
+	// toolResult, when set, is the content tools/call returns.
+	toolResult []ToolContentPart
 
 	resources []ResourceInfo
 	templates []ResourceTemplateInfo
 	prompts   []PromptInfo
+	contents  map[string][]ResourceContent
+
+	capabilities map[string]any
+	initParams   json.RawMessage
 }
 
 func (t *fakeTransport) Call(ctx context.Context, req json.RawMessage) (json.RawMessage, error) {
@@ -27,6 +42,7 @@ func (t *fakeTransport) Call(ctx context.Context, req json.RawMessage) (json.Raw
 	}
 	switch r.Method {
 	case "initialize":
+		t.initParams = mustJSON(r.Params)
 		id := int64(1)
 		if r.ID != nil {
 			id = *r.ID
@@ -34,7 +50,7 @@ func (t *fakeTransport) Call(ctx context.Context, req json.RawMessage) (json.Raw
 		out, _ := json.Marshal(rpcResponse{
 			JSONRPC: "2.0",
 			ID:      id,
-			Result:  mustJSON(InitializeResult{ProtocolVersion: "2025-06-18", ServerInfo: ServerInfo{Name: "s"}}),
+			Result:  mustJSON(InitializeResult{ProtocolVersion: "2025-06-18", Capabilities: t.capabilities, ServerInfo: ServerInfo{Name: "s"}}),
 		})
 		return out, nil
 	case "notifications/initialized":
@@ -59,10 +75,17 @@ func (t *fakeTransport) Call(ctx context.Context, req json.RawMessage) (json.Raw
 		if r.ID != nil {
 			id = *r.ID
 		}
+		result := CallToolResult{Content: []ToolContentPart{{Type: "text", Raw: mustJSON(map[string]any{"type": "text", "text": "ok"})}}}
+		if t.toolResult != nil {
+			result = CallToolResult{Content: t.toolResult}
+		}
+		if t.toolError != "" {
+			result = CallToolResult{Content: []ToolContentPart{{Type: "text", Raw: mustJSON(map[string]any{"type": "text", "text": t.toolError})}}, IsError: true}
+		}
 		out, _ := json.Marshal(rpcResponse{
 			JSONRPC: "2.0",
 			ID:      id,
-			Result:  mustJSON(CallToolResult{Content: []ToolContentPart{{Type: "text", Raw: mustJSON(map[string]any{"type": "text", "text": "ok"})}}}),
+			Result:  mustJSON(result),
 		})
 		return out, nil
 	case "resources/list":
@@ -87,6 +110,16 @@ func (t *fakeTransport) Call(ctx context.Context, req json.RawMessage) (json.Raw
 			Result:  mustJSON(ResourceTemplatesListResult{ResourceTemplates: t.templates}),
 		})
 		return out, nil
+	case "resources/read":
+		var params ReadResourceParams
+		b, _ := json.Marshal(r.Params)
+		_ = json.Unmarshal(b, &params)
+		out, _ := json.Marshal(rpcResponse{
+			JSONRPC: "2.0",
+			ID:      *r.ID,
+			Result:  mustJSON(ReadResourceResult{Contents: t.contents[params.URI]}),
+		})
+		return out, nil
 	case "prompts/list":
 		id := int64(1)
 		if r.ID != nil {
@@ -143,11 +176,108 @@ func TestClientTools_PrefixAndAllowDeny(t *testing.T) {
 	if len(tools) != 1 {
 		t.Fatalf("tools=%d", len(tools))
 	}
-	if tools[0].Name != "mcp.a" {
+	if tools[0].Name != "mcp_a" {
 		t.Fatalf("tool name=%q", tools[0].Name)
 	}
 }
 
+func TestClientTools_AnnotationsAndCacheResults(t *testing.T) {
+	yes := true
+	ft := &fakeTransport{
+		tools: []ToolInfo{
+			{Name: "read", Annotations: &ToolAnnotations{ReadOnlyHint: &yes}},
+			{Name: "put", Annotations: &ToolAnnotations{IdempotentHint: &yes}},
+			{Name: "plain"},
+		},
+	}
+	c, err := NewClient(ClientOptions{Transport: ft})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools, err := c.Tools(context.Background(), &ToolsOptions{CacheResults: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ai.ToolAnnotations{{ReadOnly: true}, {Idempotent: true}, {}}
+	for i, tt := range tools {
+		if tt.Annotations != want[i] || !tt.CacheResults {
+			t.Fatalf("tool %q annotations=%+v cache=%v", tt.Name, tt.Annotations, tt.CacheResults)
+		}
+	}
+}
+
+func TestClientTools_Descriptions(t *testing.T) {
+	ft := &fakeTransport{
+		tools: []ToolInfo{
+			{Name: "search", Description: "Searches."},
+			{Name: "fetch", Description: "Fetches."},
+		},
+	}
+	c, err := NewClient(ClientOptions{Transport: ft})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &ToolsOptions{
+		Prefix:       "kb_",
+		Descriptions: map[string]string{"search": "Full-text search over the knowledge base.", "gone": "ignored"},
+	}
+	tools, err := c.ToolsCached(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tools[0].Name != "kb_search" || tools[0].Description != "Full-text search over the knowledge base." || tools[1].Description != "Fetches." {
+		t.Fatalf("tools=%+v", tools)
+	}
+
+	// Different descriptions are a different cache entry.
+	opts.Descriptions = map[string]string{"search": "Search."}
+	tools, err = c.ToolsCached(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tools[0].Description != "Search." {
+		t.Fatalf("description=%q", tools[0].Description)
+	}
+}
+
+func TestClientTools_AnnotationFilters(t *testing.T) {
+	yes, no := true, false
+	ft := &fakeTransport{
+		tools: []ToolInfo{
+			{Name: "read", Annotations: &ToolAnnotations{ReadOnlyHint: &yes}},
+			{Name: "append", Annotations: &ToolAnnotations{DestructiveHint: &no}},
+			{Name: "delete", Annotations: &ToolAnnotations{DestructiveHint: &yes}},
+			{Name: "titled", Annotations: &ToolAnnotations{Title: "Defaults"}},
+			{Name: "plain"},
+		},
+	}
+	c, err := NewClient(ClientOptions{Transport: ft})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		opts ToolsOptions
+		want []string
+	}{
+		{ToolsOptions{}, []string{"read", "append", "delete", "titled", "plain"}},
+		{ToolsOptions{OnlyReadOnly: true}, []string{"read"}},
+		{ToolsOptions{ExcludeDestructive: true}, []string{"read", "append"}},
+		{ToolsOptions{ExcludeDestructive: true, DeniedTools: []string{"append"}}, []string{"read"}},
+	} {
+		tools, err := c.ToolsCached(context.Background(), &tc.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, tt := range tools {
+			got = append(got, tt.Name)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%+v: tools=%v, want %v", tc.opts, got, tc.want)
+		}
+	}
+}
+
 func TestClientTools_SchemasOrderingDeterministic(t *testing.T) {
 	ft := &fakeTransport{
 		tools: []ToolInfo{
@@ -204,7 +334,7 @@ func TestClientToolsCached_CachesAndInvalidatesOnListChanged(t *testing.T) {
 	}
 
 	// Simulate server list change notification.
-	c.invalidateCaches("notifications/tools/list_changed")
+	c.invalidateCaches("notifications/tools/list_changed", nil)
 
 	_, err = c.ToolsCached(context.Background(), nil)
 	if err != nil {
@@ -238,8 +368,8 @@ func TestListCaches_InvalidateOnNotifications(t *testing.T) {
 		t.Fatalf("expected cached lists, calls=%d before=%d", ft.calls, callsBefore)
 	}
 
-	c.invalidateCaches("notifications/resources/list_changed")
-	c.invalidateCaches("notifications/prompts/list_changed")
+	c.invalidateCaches("notifications/resources/list_changed", nil)
+	c.invalidateCaches("notifications/prompts/list_changed", nil)
 
 	_, _ = c.ListResourcesCached(context.Background())
 	_, _ = c.ListResourceTemplatesCached(context.Background())
@@ -248,3 +378,694 @@ func TestListCaches_InvalidateOnNotifications(t *testing.T) {
 		t.Fatalf("expected cache misses after invalidation")
 	}
 }
+
+func TestReadResourceCached(t *testing.T) {
+	ft := &fakeTransport{
+		contents: map[string][]ResourceContent{
+			"file:///a.txt": {{URI: "file:///a.txt", Text: "v1"}},
+			"file:///b.txt": {{URI: "file:///b.txt", Text: "b"}},
+		},
+	}
+	c, err := NewClient(ClientOptions{Transport: ft})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	read := func(uri string) string {
+		t.Helper()
+		res, err := c.ReadResourceCached(ctx, uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Contents[0].Text
+	}
+
+	_ = read("file:///a.txt")
+	_ = read("file:///b.txt")
+	callsBefore := ft.calls
+	ft.contents["file:///a.txt"] = []ResourceContent{{URI: "file:///a.txt", Text: "v2"}}
+	if got := read("file:///a.txt"); got != "v1" || ft.calls != callsBefore {
+		t.Fatalf("expected cached contents, got %q calls=%d before=%d", got, ft.calls, callsBefore)
+	}
+
+	c.invalidateCaches("notifications/resources/updated", json.RawMessage(`{"uri":"file:///a.txt"}`))
+	if got := read("file:///a.txt"); got != "v2" {
+		t.Fatalf("expected refetch after update, got %q", got)
+	}
+	callsBefore = ft.calls
+	_ = read("file:///b.txt")
+	if ft.calls != callsBefore {
+		t.Fatalf("unrelated URI should stay cached")
+	}
+
+	c.invalidateCaches("notifications/resources/list_changed", nil)
+	_ = read("file:///b.txt")
+	if ft.calls == callsBefore {
+		t.Fatalf("expected cache miss after list_changed")
+	}
+}
+
+func TestReadResourceCached_TTL(t *testing.T) {
+	ft := &fakeTransport{
+		contents: map[string][]ResourceContent{"file:///a.txt": {{URI: "file:///a.txt", Text: "v1"}}},
+	}
+	c, err := NewClient(ClientOptions{Transport: ft, ResourceCacheTTL: time.Nanosecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ReadResourceCached(context.Background(), "file:///a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	callsBefore := ft.calls
+	time.Sleep(time.Millisecond)
+	if _, err := c.ReadResourceCached(context.Background(), "file:///a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if ft.calls == callsBefore {
+		t.Fatalf("expected expired entry to be refetched")
+	}
+}
+
+func TestReadResourceCached_CancelledCallerDoesNotFailWaiters(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	tr := NewInProcessTransport(func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+		if method != "resources/read" {
+			return mustJSON(InitializeResult{ProtocolVersion: "2025-06-18"}), nil
+		}
+		close(started)
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return mustJSON(ReadResourceResult{Contents: []ResourceContent{{URI: "file:///a.txt", Text: "a"}}}), nil
+	})
+	c, err := NewClient(ClientOptions{Transport: tr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	joined := make(chan struct{}, 1)
+	c.fetches.joined = func(string) { joined <- struct{}{} }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.ReadResourceCached(ctx, "file:///a.txt")
+		first <- err
+	}()
+	<-started
+	second := make(chan error, 1)
+	go func() {
+		res, err := c.ReadResourceCached(context.Background(), "file:///a.txt")
+		if err == nil && res.Contents[0].Text != "a" {
+			err = fmt.Errorf("contents=%v", res.Contents)
+		}
+		second <- err
+	}()
+	<-joined
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first err=%v", err)
+	}
+	close(release)
+	if err := <-second; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadResourceBytes_DecodesBlobAndText(t *testing.T) {
+	ft := &fakeTransport{
+		contents: map[string][]ResourceContent{
+			"file:///img.png": {{URI: "file:///img.png", BlobBase64: "iVBORw==", MediaType: "image/png"}},
+			"file:///a.txt":   {{URI: "file:///a.txt", Text: "hello"}},
+			"file:///bad":     {{URI: "file:///bad", BlobBase64: "%%%"}},
+		},
+	}
+	c, err := NewClient(ClientOptions{Transport: ft})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, mt, err := c.ReadResourceBytes(context.Background(), "file:///img.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mt != "image/png" || !reflect.DeepEqual(b, []byte{0x89, 'P', 'N', 'G'}) {
+		t.Fatalf("blob=%v mediaType=%q", b, mt)
+	}
+
+	b, mt, err = c.ReadResourceBytes(context.Background(), "file:///a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello" || mt != "text/plain" {
+		t.Fatalf("text=%q mediaType=%q", b, mt)
+	}
+
+	if _, _, err := c.ReadResourceBytes(context.Background(), "file:///bad"); err == nil {
+		t.Fatalf("expected decode error")
+	}
+	if _, _, err := c.ReadResourceBytes(context.Background(), "file:///missing"); err == nil {
+		t.Fatalf("expected error for empty contents")
+	}
+}
+
+func TestClientTools_DefaultSchemaForSchemalessTools(t *testing.T) {
+	ft := &fakeTransport{
+		tools: []ToolInfo{
+			{Name: "none"},
+			{Name: "null", InputSchema: json.RawMessage(`null`)},
+			{Name: "typed", InputSchema: json.RawMessage(`{"type":"object","properties":{"q":{"type":"string"}}}`)},
+		},
+	}
+	c, err := NewClient(ClientOptions{Transport: ft})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools, err := c.Tools(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tools[:2] {
+		var params map[string]any
+		if err := json.Unmarshal(tt.InputSchema.JSON, &params); err != nil || params == nil {
+			t.Fatalf("%s: parameters=%s", tt.Name, tt.InputSchema.JSON)
+		}
+		if params["type"] != "object" || params["additionalProperties"] != true {
+			t.Fatalf("%s: parameters=%s", tt.Name, tt.InputSchema.JSON)
+		}
+	}
+	if string(tools[2].InputSchema.JSON) != `{"type":"object","properties":{"q":{"type":"string"}}}` {
+		t.Fatalf("typed schema changed: %s", tools[2].InputSchema.JSON)
+	}
+}
+
+func TestClient_CapabilityGating(t *testing.T) {
+	ft := &fakeTransport{
+		capabilities: map[string]any{"tools": map[string]any{}},
+		prompts:      []PromptInfo{{Name: "p"}},
+	}
+	c, err := NewClient(ClientOptions{Transport: ft})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Tools(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	calls := ft.calls
+
+	_, err = c.ListPrompts(context.Background())
+	if !errors.Is(err, ErrCapabilityUnsupported) || !IsCapabilityUnsupported(err) {
+		t.Fatalf("err=%v", err)
+	}
+	var ce *CapabilityError
+	if !errors.As(err, &ce) || ce.Capability != "prompts" || ce.Method != "prompts/list" {
+		t.Fatalf("err=%#v", err)
+	}
+	if _, err := c.ReadResource(context.Background(), "file:///x"); !IsCapabilityUnsupported(err) {
+		t.Fatalf("err=%v", err)
+	}
+	if ft.calls != calls {
+		t.Fatalf("expected no requests for unsupported capabilities, got %d", ft.calls-calls)
+	}
+}
+
+func TestClient_NoCapabilitiesNotGated(t *testing.T) {
+	ft := &fakeTransport{prompts: []PromptInfo{{Name: "p"}}}
+	c, err := NewClient(ClientOptions{Transport: ft})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompts, err := c.ListPrompts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prompts) != 1 {
+		t.Fatalf("prompts=%v", prompts)
+	}
+}
+
+// gatedListTransport serializes access to a fakeTransport and holds list
+// requests until release is closed.
+type gatedListTransport struct {
+	mu      sync.Mutex
+	inner   *fakeTransport
+	release chan struct{}
+	lists   atomic.Int32
+}
+
+func (t *gatedListTransport) Call(ctx context.Context, req json.RawMessage) (json.RawMessage, error) {
+	var r rpcRequest
+	if err := json.Unmarshal(req, &r); err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(r.Method, "/list") {
+		t.lists.Add(1)
+		<-t.release
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.inner.Call(ctx, req)
+}
+
+func (t *gatedListTransport) Close() error { return nil }
+
+func TestToolsCached_ConcurrentCallersShareFetch(t *testing.T) {
+	tr := &gatedListTransport{
+		inner:   &fakeTransport{tools: []ToolInfo{{Name: "a"}}, prompts: []PromptInfo{{Name: "p"}}},
+		release: make(chan struct{}),
+	}
+	c, err := NewClient(ClientOptions{Transport: tr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 20
+	joined := make(chan struct{}, 2*n)
+	c.fetches.joined = func(string) { joined <- struct{}{} }
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*n)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			tools, err := c.ToolsCached(context.Background(), nil)
+			if err == nil && len(tools) != 1 {
+				err = fmt.Errorf("tools=%d", len(tools))
+			}
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			prompts, err := c.ListPromptsCached(context.Background())
+			if err == nil && len(prompts) != 1 {
+				err = fmt.Errorf("prompts=%d", len(prompts))
+			}
+			errs <- err
+		}()
+	}
+	// One caller per key fetches; hold the fetches until the rest have joined.
+	for i := 0; i < 2*n-2; i++ {
+		<-joined
+	}
+	close(tr.release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := tr.lists.Load(); got != 2 {
+		t.Fatalf("list requests=%d, want 2", got)
+	}
+}
+
+func TestFlightGroup_CallerCancelDoesNotFailOthers(t *testing.T) {
+	var g flightGroup
+	joined := make(chan struct{}, 1)
+	g.joined = func(string) { joined <- struct{}{} }
+	started, release := make(chan struct{}), make(chan struct{})
+	fetch := func(ctx context.Context) (any, error) {
+		close(started)
+		select {
+		case <-release:
+			return "v", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := g.do(firstCtx, "k", fetch)
+		first <- err
+	}()
+	<-started
+	second := make(chan any, 1)
+	go func() {
+		v, err := g.do(context.Background(), "k", fetch)
+		if err != nil {
+			v = err
+		}
+		second <- v
+	}()
+	<-joined
+
+	cancelFirst()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first err=%v", err)
+	}
+	close(release)
+	if v := <-second; v != "v" {
+		t.Fatalf("second=%v", v)
+	}
+}
+
+func TestFlightGroup_CancelsFetchWithoutWaiters(t *testing.T) {
+	var g flightGroup
+	started, stopped := make(chan struct{}), make(chan error, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := g.do(ctx, "k", func(ctx context.Context) (any, error) {
+			close(started)
+			<-ctx.Done()
+			stopped <- ctx.Err()
+			return nil, ctx.Err()
+		})
+		done <- err
+	}()
+	<-started
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err=%v", err)
+	}
+	if err := <-stopped; !errors.Is(err, context.Canceled) {
+		t.Fatalf("fetch ctx err=%v", err)
+	}
+}
+
+func TestNewClient_TypedCapabilities(t *testing.T) {
+	ft := &fakeTransport{}
+	c, err := NewClient(ClientOptions{
+		Transport:          ft,
+		ClientCapabilities: &Capabilities{Elicitation: true, Roots: &RootsCapability{ListChanged: true}},
+		Capabilities:       map[string]any{"sampling": map[string]any{"custom": true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var params struct {
+		Capabilities map[string]json.RawMessage `json:"capabilities"`
+	}
+	if err := json.Unmarshal(ft.initParams, &params); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"elicitation": `{}`,
+		"roots":       `{"listChanged":true}`,
+		"sampling":    `{"custom":true}`,
+	}
+	if len(params.Capabilities) != len(want) {
+		t.Fatalf("capabilities=%s", ft.initParams)
+	}
+	for k, v := range want {
+		if string(params.Capabilities[k]) != v {
+			t.Fatalf("%s=%s, want %s", k, params.Capabilities[k], v)
+		}
+	}
+}
+
+func TestClientTools_SanitizesNames(t *testing.T) {
+	long := strings.Repeat("x", 70)
+	ft := &fakeTransport{
+		tools: []ToolInfo{
+			{Name: "fs.read"},
+			{Name: "fs_read"},
+			{Name: "git/log file"},
+			{Name: long},
+		},
+	}
+	c, err := NewClient(ClientOptions{Transport: ft})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tools, err := c.Tools(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, tt := range tools {
+		got = append(got, tt.Name)
+	}
+	want := []string{"fs_read_2", "fs_read", "git_log_file", long[:64]}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("names=%v, want %v", got, want)
+	}
+
+	for i, server := range []string{"fs.read", "fs_read", "git/log file", long} {
+		if name, ok := c.ServerToolName(want[i]); !ok || name != server {
+			t.Fatalf("ServerToolName(%q)=%q,%v want %q", want[i], name, ok, server)
+		}
+	}
+	if _, ok := c.ServerToolName("missing"); ok {
+		t.Fatalf("expected unknown name to be unmapped")
+	}
+}
+
+func TestClientTools_InputHooks(t *testing.T) {
+	ft := &fakeTransport{tools: []ToolInfo{{Name: "a"}}}
+	c, err := NewClient(ClientOptions{Transport: ft})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	opts := func(tag string) *ToolsOptions {
+		return &ToolsOptions{
+			OnInputDelta: func(e ai.ToolInputDeltaEvent) { got = append(got, tag+":"+e.ToolName+":"+e.InputTextDelta) },
+		}
+	}
+
+	tools, err := c.ToolsCached(context.Background(), opts("first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tools[0].OnInputDelta == nil || tools[0].OnInputStart != nil {
+		t.Fatalf("hooks not set as configured")
+	}
+	tools[0].OnInputDelta(ai.ToolInputDeltaEvent{ToolName: tools[0].Name, InputTextDelta: `{"x"`})
+
+	// Cached tools take the hooks of the current caller.
+	cached, err := c.ToolsCached(context.Background(), opts("second"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached[0].OnInputDelta(ai.ToolInputDeltaEvent{ToolName: cached[0].Name, InputTextDelta: `:1}`})
+
+	plain, err := c.ToolsCached(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain[0].OnInputDelta != nil {
+		t.Fatalf("expected no hooks without options")
+	}
+
+	if want := []string{`first:a:{"x"`, `second:a::1}`}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("events=%v, want %v", got, want)
+	}
+}
+
+func TestClientTools_IsErrorResultIsReportedToModel(t *testing.T) {
+	ft := &fakeTransport{tools: []ToolInfo{{Name: "a"}}, toolError: "file not found"}
+	c, err := NewClient(ClientOptions{Transport: ft})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools, err := c.Tools(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = tools[0].Handler(context.Background(), json.RawMessage(`{}`))
+	if !IsCallToolError(err) {
+		t.Fatalf("expected CallToolError, got %v", err)
+	}
+	var resultErr *ai.ToolResultError
+	if !errors.As(err, &resultErr) || resultErr.Message != "file not found" {
+		t.Fatalf("expected ToolResultError with server text, got %v", err)
+	}
+}
+
+func TestClientTools_InlineResourceResults(t *testing.T) {
+	ft := &fakeTransport{
+		tools: []ToolInfo{{Name: "report"}},
+		toolResult: []ToolContentPart{
+			{Type: "text", Raw: mustJSON(map[string]any{"type": "text", "text": "see the report"})},
+			{Type: "resource_link", Raw: mustJSON(map[string]any{"type": "resource_link", "uri": "file:///report.csv", "name": "report"})},
+			{Type: "resource_link", Raw: mustJSON(map[string]any{"type": "resource_link", "uri": "file:///missing"})},
+		},
+		contents: map[string][]ResourceContent{
+			"file:///report.csv": {{URI: "file:///report.csv", Text: "a,b\n1,2", MediaType: "text/csv"}},
+		},
+	}
+	c, err := NewClient(ClientOptions{Transport: ft})
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(opts *ToolsOptions) CallToolResult {
+		t.Helper()
+		tools, err := c.ToolsCached(context.Background(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := tools[0].Handler(context.Background(), json.RawMessage(`{}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out.(CallToolResult)
+	}
+
+	if res := call(&ToolsOptions{}); res.Content[1].Type != "resource_link" {
+		t.Fatalf("content=%s", mustJSON(res.Content))
+	}
+
+	res := call(&ToolsOptions{InlineResourceResults: true})
+	if len(res.Content) != 3 || res.Content[0].Type != "text" || res.Content[2].Type != "resource_link" {
+		t.Fatalf("content=%s", mustJSON(res.Content))
+	}
+	var inlined struct {
+		Type     string          `json:"type"`
+		Resource ResourceContent `json:"resource"`
+	}
+	if err := json.Unmarshal(res.Content[1].Raw, &inlined); err != nil {
+		t.Fatal(err)
+	}
+	if inlined.Type != "resource" || inlined.Resource.Text != "a,b\n1,2" || inlined.Resource.MediaType != "text/csv" {
+		t.Fatalf("inlined=%+v", inlined)
+	}
+}
+
+func TestClient_MaxConcurrentCalls(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	const n = 6
+	entered, release := make(chan struct{}, n), make(chan struct{})
+	tr := NewInProcessTransport(func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+		if method != "tools/call" {
+			return mustJSON(InitializeResult{ProtocolVersion: "2025-06-18"}), nil
+		}
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		entered <- struct{}{}
+		<-release
+		return mustJSON(CallToolResult{}), nil
+	})
+	c, err := NewClient(ClientOptions{Transport: tr, MaxConcurrentCalls: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			errs <- c.rpcRaw(context.Background(), "tools/call", nil, nil)
+		}()
+	}
+	<-entered
+	<-entered
+	// Both slots are taken, so the rest stay queued until release.
+	if got := inFlight.Load(); got != 2 {
+		t.Fatalf("in flight=%d, want 2", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.rpcRaw(ctx, "tools/call", nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("queued call err=%v", err)
+	}
+
+	close(release)
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if peak.Load() != 2 {
+		t.Fatalf("peak=%d", peak.Load())
+	}
+}
+
+// flakyTransport fails the first failures[method] calls of each method with a
+// network error before passing them on.
+type flakyTransport struct {
+	*fakeTransport
+	mu       sync.Mutex
+	failures map[string]int
+	attempts map[string]int
+}
+
+func (t *flakyTransport) Call(ctx context.Context, req json.RawMessage) (json.RawMessage, error) {
+	var r rpcRequest
+	_ = json.Unmarshal(req, &r)
+	method := r.Method
+	if method == "tools/call" {
+		var p callToolParams
+		b, _ := json.Marshal(r.Params)
+		_ = json.Unmarshal(b, &p)
+		method += " " + p.Name
+	}
+	t.mu.Lock()
+	t.attempts[method]++
+	fail := t.attempts[method] <= t.failures[method]
+	t.mu.Unlock()
+	if fail {
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+	}
+	return t.fakeTransport.Call(ctx, req)
+}
+
+func TestClient_RetriesIdempotentRequests(t *testing.T) {
+	yes := true
+	ft := &flakyTransport{
+		fakeTransport: &fakeTransport{tools: []ToolInfo{
+			{Name: "search", Annotations: &ToolAnnotations{ReadOnlyHint: &yes}},
+			{Name: "send"},
+		}},
+		failures: map[string]int{"tools/list": 2, "tools/call search": 1, "tools/call send": 1},
+		attempts: map[string]int{},
+	}
+	c, err := NewClient(ClientOptions{Transport: ft, MaxRetries: 2, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools, err := c.Tools(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ft.attempts["tools/list"] != 3 {
+		t.Fatalf("tools/list attempts=%d", ft.attempts["tools/list"])
+	}
+
+	// Read-only tools are retried; others fail on the first transport error.
+	if _, err := tools[0].Handler(context.Background(), json.RawMessage(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	_, err = tools[1].Handler(context.Background(), json.RawMessage(`{}`))
+	var ne net.Error
+	if !errors.As(err, &ne) || ft.attempts["tools/call send"] != 1 {
+		t.Fatalf("err=%v attempts=%d", err, ft.attempts["tools/call send"])
+	}
+	if ft.attempts["tools/call search"] != 2 {
+		t.Fatalf("search attempts=%d", ft.attempts["tools/call search"])
+	}
+
+	// Without MaxRetries nothing is retried.
+	ft.failures["resources/list"] = 1
+	c2, _ := NewClient(ClientOptions{Transport: ft})
+	if _, err := c2.ListResources(context.Background()); err == nil || ft.attempts["resources/list"] != 1 {
+		t.Fatalf("err=%v attempts=%d", err, ft.attempts["resources/list"])
+	}
+}
@@ -1,6 +1,9 @@
 package mcp
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 type RPCError struct {
 	Code    int64
@@ -74,3 +77,22 @@ func (e *CallToolError) Error() string {
 }
 
 func (e *CallToolError) Unwrap() error { return e.Cause }
+
+// ErrCapabilityUnsupported is matched (via errors.Is) by a CapabilityError.
+var ErrCapabilityUnsupported = errors.New("mcp: capability not supported by server")
+
+// CapabilityError is returned when a method requires a server capability that
+// was not advertised in the initialize result.
+type CapabilityError struct {
+	Capability string // e.g. "prompts", "resources", "tools"
+	Method     string // JSON-RPC method that was not sent
+}
+
+func (e *CapabilityError) Error() string {
+	if e == nil {
+		return ""
+	}
+	return fmt.Sprintf("mcp %s: server does not support the %q capability", e.Method, e.Capability)
+}
+
+func (e *CapabilityError) Is(target error) bool { return target == ErrCapabilityUnsupported }
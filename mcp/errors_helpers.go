@@ -43,3 +43,7 @@ func IsServerError(err error) bool {
 	var e *HTTPStatusError
 	return errors.As(err, &e) && e.StatusCode >= 500 && e.StatusCode <= 599
 }
+
+func IsCapabilityUnsupported(err error) bool {
+	return errors.Is(err, ErrCapabilityUnsupported)
+}
@@ -62,6 +62,28 @@ func (p *OAuthClientCredentialsProvider) AuthorizationHeader(ctx context.Context
 	return formatAuthHeader(tt, tok), nil
 }
 
+// ForceRefresh discards the cached token and fetches a new one. HTTPTransport
+// calls it after a 401 response.
+func (p *OAuthClientCredentialsProvider) ForceRefresh(ctx context.Context) (string, error) {
+	if p == nil {
+		return "", fmt.Errorf("mcp oauth: provider is nil")
+	}
+	now := time.Now
+	if p.Clock != nil {
+		now = p.Clock
+	}
+	tt, tok, exp, err := p.fetch(ctx, now())
+	if err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	p.tokenType, p.token, p.expiresAt = tt, tok, exp
+	p.mu.Unlock()
+
+	return formatAuthHeader(tt, tok), nil
+}
+
 func formatAuthHeader(tokenType, token string) string {
 	if token == "" {
 		return ""
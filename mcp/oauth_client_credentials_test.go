@@ -2,6 +2,7 @@ package mcp
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -40,3 +41,24 @@ func TestOAuthClientCredentialsProvider_CachesToken(t *testing.T) {
 		t.Fatalf("token calls=%d", calls)
 	}
 }
+
+func TestOAuthClientCredentialsProvider_ForceRefresh(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"t%d","token_type":"Bearer","expires_in":3600}`, calls)
+	}))
+	defer srv.Close()
+
+	p := &OAuthClientCredentialsProvider{TokenURL: srv.URL, ClientID: "id", ClientSecret: "secret"}
+	if h, err := p.AuthorizationHeader(context.Background()); err != nil || h != "Bearer t1" {
+		t.Fatalf("header=%q err=%v", h, err)
+	}
+	if h, err := p.ForceRefresh(context.Background()); err != nil || h != "Bearer t2" {
+		t.Fatalf("refreshed=%q err=%v", h, err)
+	}
+	if h, _ := p.AuthorizationHeader(context.Background()); h != "Bearer t2" {
+		t.Fatalf("cached header after refresh=%q", h)
+	}
+}
@@ -0,0 +1,81 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// flightTimeout bounds a shared fetch. The fetch outlives the caller that
+// started it, so it cannot use that caller's deadline.
+const flightTimeout = time.Minute
+
+// flightGroup deduplicates concurrent fetches: callers with the same key while
+// a fetch is in flight wait for and share its result.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+
+	// joined, if set, is called when a caller starts waiting on a fetch
+	// another caller started. Tests use it to line callers up.
+	joined func(key string)
+}
+
+type flightCall struct {
+	done    chan struct{}
+	val     any
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// do runs fn once per key at a time. fn gets a context detached from the
+// callers' (keeping the first caller's values) and bounded by flightTimeout,
+// so one caller giving up does not fail the others. Each caller stops early if
+// its own ctx is done; the fetch is cancelled once no caller is waiting.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(context.Context) (any, error)) (any, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*flightCall{}
+	}
+	call, ok := g.calls[key]
+	if ok {
+		call.waiters++
+		g.mu.Unlock()
+		if g.joined != nil {
+			g.joined(key)
+		}
+	} else {
+		fctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flightTimeout)
+		call = &flightCall{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		g.calls[key] = call
+		g.mu.Unlock()
+		go func() {
+			call.val, call.err = fn(fctx)
+			cancel()
+			g.mu.Lock()
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+			close(call.done)
+		}()
+	}
+
+	select {
+	case <-call.done:
+		return call.val, call.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			// Nobody is left to use the result; later callers start afresh.
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			call.cancel()
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
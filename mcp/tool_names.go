@@ -0,0 +1,91 @@
+package mcp
+
+import (
+	"strconv"
+	"strings"
+)
+
+// maxToolNameLen is the longest tool name OpenAI accepts
+// (`^[a-zA-Z0-9_-]{1,64}$`).
+const maxToolNameLen = 64
+
+// sanitizeToolName maps name onto the provider-safe alphabet: characters
+// outside [a-zA-Z0-9_-] become '_' and the result is truncated to 64 bytes.
+// Valid names are returned unchanged.
+func sanitizeToolName(name string) string {
+	var b strings.Builder
+	b.Grow(len(name))
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	out := b.String()
+	if out == "" {
+		out = "_"
+	}
+	if len(out) > maxToolNameLen {
+		out = out[:maxToolNameLen]
+	}
+	return out
+}
+
+// sanitizeToolNames sanitizes each name and resolves collisions. Names that
+// are already valid keep their spelling; rewritten names get a numeric suffix
+// when they would clash.
+func sanitizeToolNames(names []string) []string {
+	out := make([]string, len(names))
+	taken := make(map[string]bool, len(names))
+	for i, name := range names {
+		if sanitizeToolName(name) == name && !taken[name] {
+			out[i] = name
+			taken[name] = true
+		}
+	}
+	for i, name := range names {
+		if out[i] != "" {
+			continue
+		}
+		out[i] = uniqueToolName(sanitizeToolName(name), taken)
+		taken[out[i]] = true
+	}
+	return out
+}
+
+// uniqueToolName returns name, or name with a numeric suffix ("_2", "_3", ...)
+// when it is already in taken. The suffix replaces trailing characters if
+// needed to stay within maxToolNameLen.
+func uniqueToolName(name string, taken map[string]bool) string {
+	if !taken[name] {
+		return name
+	}
+	for i := 2; ; i++ {
+		suffix := "_" + strconv.Itoa(i)
+		base := name
+		if len(base)+len(suffix) > maxToolNameLen {
+			base = base[:maxToolNameLen-len(suffix)]
+		}
+		if candidate := base + suffix; !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// ServerToolName returns the MCP server tool name behind a tool name returned
+// by Tools or ToolsCached. Names are sanitized for provider constraints
+// (characters outside [a-zA-Z0-9_-] become '_', at most 64 characters, a
+// numeric suffix on collisions), so the model-facing name may differ from the
+// server's.
+func (c *Client) ServerToolName(name string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	v, ok := c.toolNames.Load(name)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
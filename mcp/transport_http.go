@@ -14,6 +14,19 @@ import (
 	"github.com/bitop-dev/ai/internal/sse"
 )
 
+// AuthRefresher is optionally implemented by an HTTPTransport.AuthProvider
+// whose credentials can expire mid-session. ForceRefresh must bypass any cached
+// token and return a fresh Authorization header value.
+type AuthRefresher interface {
+	ForceRefresh(ctx context.Context) (string, error)
+}
+
+// HTTPTransport implements the MCP Streamable HTTP transport.
+//
+// It is safe for concurrent use: each Call is an independent HTTP request whose
+// response (JSON or SSE) is matched to the request id, and the session id and
+// protocol version are guarded by a mutex. Headers must not be mutated after
+// the first call.
 type HTTPTransport struct {
 	URL     string
 	Headers map[string]string
@@ -21,6 +34,9 @@ type HTTPTransport struct {
 	// AuthProvider can provide (and refresh) an authorization header value
 	// (e.g. OAuth bearer token). If set and the request does not already specify
 	// Authorization, it will be added.
+	//
+	// If the provider also implements AuthRefresher, a 401 response triggers
+	// ForceRefresh and the request is retried once with the new value.
 	AuthProvider interface {
 		AuthorizationHeader(ctx context.Context) (string, error)
 	}
@@ -37,6 +53,8 @@ type HTTPTransport struct {
 	protocolVersion string
 	// sessionID is sent via Mcp-Session-Id header after initialization when provided by server.
 	sessionID string
+
+	elicitationHandler func(ctx context.Context, req ElicitationRequest) (ElicitationResponse, error)
 }
 
 func (t *HTTPTransport) Call(ctx context.Context, req json.RawMessage) (json.RawMessage, error) {
@@ -48,50 +66,7 @@ func (t *HTTPTransport) Call(ctx context.Context, req json.RawMessage) (json.Raw
 		client = &http.Client{Timeout: 60 * time.Second}
 	}
 
-	r, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(req))
-	if err != nil {
-		return nil, err
-	}
-	r.Header.Set("Content-Type", "application/json")
-	// Streamable HTTP requires clients advertise both response types.
-	r.Header.Set("Accept", "application/json, text/event-stream")
-
-	t.mu.Lock()
-	if t.protocolVersion != "" {
-		r.Header.Set("MCP-Protocol-Version", t.protocolVersion)
-	}
-	if t.sessionID != "" {
-		r.Header.Set("Mcp-Session-Id", t.sessionID)
-	}
-	t.mu.Unlock()
-
-	for k, v := range t.Headers {
-		if v != "" {
-			r.Header.Set(k, v)
-		}
-	}
-	if t.AuthProvider != nil && r.Header.Get("Authorization") == "" {
-		v, err := t.AuthProvider.AuthorizationHeader(ctx)
-		if err != nil {
-			return nil, err
-		}
-		if v != "" {
-			r.Header.Set("Authorization", v)
-		}
-	}
-	if t.HeaderProvider != nil {
-		h, err := t.HeaderProvider(ctx)
-		if err != nil {
-			return nil, err
-		}
-		for k, v := range h {
-			if v != "" {
-				r.Header.Set(k, v)
-			}
-		}
-	}
-
-	resp, err := client.Do(r)
+	resp, err := t.do(ctx, client, http.MethodPost, req, "application/json, text/event-stream")
 	if err != nil {
 		return nil, err
 	}
@@ -122,7 +97,7 @@ func (t *HTTPTransport) Call(ctx context.Context, req json.RawMessage) (json.Raw
 				ProtocolVersion: pv,
 			}
 		}
-		return t.readSSEResponse(resp.Body, req)
+		return t.readSSEResponse(ctx, resp.Body, req)
 	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -240,48 +215,7 @@ func (t *HTTPTransport) OpenSSEStream(ctx context.Context) (io.ReadCloser, error
 		client = &http.Client{Timeout: 0} // let ctx control lifetime
 	}
 
-	r, err := http.NewRequestWithContext(ctx, http.MethodGet, t.URL, nil)
-	if err != nil {
-		return nil, err
-	}
-	r.Header.Set("Accept", "text/event-stream")
-
-	t.mu.Lock()
-	if t.protocolVersion != "" {
-		r.Header.Set("MCP-Protocol-Version", t.protocolVersion)
-	}
-	if t.sessionID != "" {
-		r.Header.Set("Mcp-Session-Id", t.sessionID)
-	}
-	t.mu.Unlock()
-
-	for k, v := range t.Headers {
-		if v != "" {
-			r.Header.Set(k, v)
-		}
-	}
-	if t.AuthProvider != nil && r.Header.Get("Authorization") == "" {
-		v, err := t.AuthProvider.AuthorizationHeader(ctx)
-		if err != nil {
-			return nil, err
-		}
-		if v != "" {
-			r.Header.Set("Authorization", v)
-		}
-	}
-	if t.HeaderProvider != nil {
-		h, err := t.HeaderProvider(ctx)
-		if err != nil {
-			return nil, err
-		}
-		for k, v := range h {
-			if v != "" {
-				r.Header.Set(k, v)
-			}
-		}
-	}
-
-	resp, err := client.Do(r)
+	resp, err := t.do(ctx, client, http.MethodGet, nil, "text/event-stream")
 	if err != nil {
 		return nil, err
 	}
@@ -331,6 +265,96 @@ func (t *HTTPTransport) OpenSSEStream(ctx context.Context) (io.ReadCloser, error
 	return resp.Body, nil
 }
 
+// do sends a request with the transport's session and auth headers. When the
+// server answers 401 and the Authorization header came from an AuthProvider
+// implementing AuthRefresher, the token is force-refreshed and the request is
+// retried once.
+func (t *HTTPTransport) do(ctx context.Context, client *http.Client, method string, body []byte, accept string) (*http.Response, error) {
+	var forcedAuth string
+	for attempt := 0; ; attempt++ {
+		r, authFromProvider, err := t.newRequest(ctx, method, body, accept, forcedAuth)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(r)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 0 || !authFromProvider {
+			return resp, nil
+		}
+		refresher, ok := t.AuthProvider.(AuthRefresher)
+		if !ok {
+			return resp, nil
+		}
+		v, err := refresher.ForceRefresh(ctx)
+		if err != nil || v == "" {
+			return resp, nil
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+		_ = resp.Body.Close()
+		forcedAuth = v
+	}
+}
+
+// newRequest builds a request carrying the session, static, auth and dynamic
+// headers. authFromProvider reports whether Authorization came from
+// AuthProvider (or forcedAuth, which replaces its value).
+func (t *HTTPTransport) newRequest(ctx context.Context, method string, body []byte, accept, forcedAuth string) (r *http.Request, authFromProvider bool, err error) {
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	r, err = http.NewRequestWithContext(ctx, method, t.URL, rd)
+	if err != nil {
+		return nil, false, err
+	}
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	r.Header.Set("Accept", accept)
+
+	t.mu.Lock()
+	if t.protocolVersion != "" {
+		r.Header.Set("MCP-Protocol-Version", t.protocolVersion)
+	}
+	if t.sessionID != "" {
+		r.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	t.mu.Unlock()
+
+	for k, v := range t.Headers {
+		if v != "" {
+			r.Header.Set(k, v)
+		}
+	}
+	if t.AuthProvider != nil && r.Header.Get("Authorization") == "" {
+		v := forcedAuth
+		if v == "" {
+			v, err = t.AuthProvider.AuthorizationHeader(ctx)
+			if err != nil {
+				return nil, false, err
+			}
+		}
+		if v != "" {
+			r.Header.Set("Authorization", v)
+			authFromProvider = true
+		}
+	}
+	if t.HeaderProvider != nil {
+		h, err := t.HeaderProvider(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		for k, v := range h {
+			if v != "" {
+				r.Header.Set(k, v)
+			}
+		}
+	}
+	return r, authFromProvider, nil
+}
+
 func (t *HTTPTransport) SessionID() string {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -354,7 +378,15 @@ func cloneStringMap(m map[string]string) map[string]string {
 	return out
 }
 
-func (t *HTTPTransport) readSSEResponse(r io.Reader, req json.RawMessage) (json.RawMessage, error) {
+// SetElicitationHandler sets the handler for elicitation/create requests the
+// server sends on a call's response stream.
+func (t *HTTPTransport) SetElicitationHandler(h func(ctx context.Context, req ElicitationRequest) (ElicitationResponse, error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.elicitationHandler = h
+}
+
+func (t *HTTPTransport) readSSEResponse(ctx context.Context, r io.Reader, req json.RawMessage) (json.RawMessage, error) {
 	// Determine expected response id from request.
 	var probe struct {
 		ID *int64 `json:"id"`
@@ -369,18 +401,49 @@ func (t *HTTPTransport) readSSEResponse(r io.Reader, req json.RawMessage) (json.
 		}
 		// data payload is JSON-RPC message.
 		var msg struct {
-			ID *int64 `json:"id,omitempty"`
+			ID     *int64          `json:"id,omitempty"`
+			Method string          `json:"method,omitempty"`
+			Params json.RawMessage `json:"params,omitempty"`
 		}
 		if err := json.Unmarshal(data, &msg); err != nil {
 			continue
 		}
-		if probe.ID != nil && msg.ID != nil && *msg.ID == *probe.ID {
+		if msg.Method == "elicitation/create" && msg.ID != nil {
+			// The server waits for the answer before finishing this call.
+			t.mu.Lock()
+			h := t.elicitationHandler
+			t.mu.Unlock()
+			result, rpcErr := elicit(ctx, h, msg.Params)
+			if err := t.respond(ctx, *msg.ID, result, rpcErr); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if msg.Method == "" && probe.ID != nil && msg.ID != nil && *msg.ID == *probe.ID {
 			return append(json.RawMessage(nil), data...), nil
 		}
-		// Ignore other messages (requests/notifications) for now.
+		// Ignore other messages (notifications, other requests).
 	}
 	if err := dec.Err(); err != nil {
 		return nil, err
 	}
 	return nil, fmt.Errorf("mcp: sse stream ended without response")
 }
+
+// respond POSTs the answer to a server->client request.
+func (t *HTTPTransport) respond(ctx context.Context, id int64, result any, rpcErr *rpcError) error {
+	msg := rpcResponse{JSONRPC: "2.0", ID: id, Error: rpcErr}
+	if rpcErr == nil {
+		b, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		msg.Result = b
+	}
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = t.Call(ctx, b)
+	return err
+}
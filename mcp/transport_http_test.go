@@ -4,9 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestHTTPTransport_HTTPStatusErrorIncludesHeadersAndSession(t *testing.T) {
@@ -39,3 +44,336 @@ func TestHTTPTransport_HTTPStatusErrorIncludesHeadersAndSession(t *testing.T) {
 		t.Fatalf("headers missing")
 	}
 }
+
+func TestHTTPTransport_ConcurrentToolsAndCalls(t *testing.T) {
+	var initCount atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     *int64          `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Method == "initialize" {
+			initCount.Add(1)
+			w.Header().Set("Mcp-Session-Id", "sess_1")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"protocolVersion":"2025-06-18","serverInfo":{"name":"s"}}}`, *req.ID)
+			return
+		}
+		if r.Header.Get("Mcp-Session-Id") != "sess_1" || r.Header.Get("MCP-Protocol-Version") != "2025-06-18" {
+			http.Error(w, "bad session", http.StatusBadRequest)
+			return
+		}
+		if req.ID == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		var result string
+		switch req.Method {
+		case "tools/list":
+			result = `{"tools":[{"name":"echo","inputSchema":{"type":"object"}}]}`
+		case "tools/call":
+			var p struct {
+				Arguments struct {
+					N int `json:"n"`
+				} `json:"arguments"`
+			}
+			_ = json.Unmarshal(req.Params, &p)
+			result = fmt.Sprintf(`{"content":[{"type":"text","text":"%d"}]}`, p.Arguments.N)
+		default:
+			http.Error(w, "unknown method", http.StatusBadRequest)
+			return
+		}
+		// Respond via SSE with an unrelated message first to exercise id correlation.
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprintf(w, "data: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+		_, _ = fmt.Fprintf(w, "data: {\"jsonrpc\":\"2.0\",\"id\":%d,\"result\":%s}\n\n", *req.ID, result)
+	}))
+	defer srv.Close()
+
+	tr := &HTTPTransport{URL: srv.URL}
+	c, err := NewClient(ClientOptions{Transport: tr})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tools, err := c.Tools(context.Background(), nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if len(tools) != 1 {
+				errs <- fmt.Errorf("tools=%d", len(tools))
+				return
+			}
+			out, err := tools[0].Handler(context.Background(), json.RawMessage(fmt.Sprintf(`{"n":%d}`, i)))
+			if err != nil {
+				errs <- err
+				return
+			}
+			if out != fmt.Sprint(i) {
+				errs <- fmt.Errorf("call %d routed result %v", i, out)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if got := initCount.Load(); got != 1 {
+		t.Fatalf("initialize calls=%d", got)
+	}
+	if tr.SessionID() != "sess_1" || tr.ProtocolVersion() != "2025-06-18" {
+		t.Fatalf("session=%q version=%q", tr.SessionID(), tr.ProtocolVersion())
+	}
+}
+
+type refreshingAuth struct {
+	token     string
+	refreshes int
+}
+
+func (a *refreshingAuth) AuthorizationHeader(ctx context.Context) (string, error) {
+	return "Bearer " + a.token, nil
+}
+
+func (a *refreshingAuth) ForceRefresh(ctx context.Context) (string, error) {
+	a.refreshes++
+	a.token = fmt.Sprintf("fresh%d", a.refreshes)
+	return "Bearer " + a.token, nil
+}
+
+func TestHTTPTransport_RefreshesTokenOn401(t *testing.T) {
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		seen = append(seen, auth)
+		if auth != "Bearer fresh1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+	}))
+	defer srv.Close()
+
+	auth := &refreshingAuth{token: "expired"}
+	tr := &HTTPTransport{URL: srv.URL, AuthProvider: auth}
+	if _, err := tr.Call(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"ping"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if auth.refreshes != 1 || len(seen) != 2 || seen[0] != "Bearer expired" {
+		t.Fatalf("refreshes=%d seen=%v", auth.refreshes, seen)
+	}
+
+	// A second 401 after refreshing is returned instead of retrying forever.
+	auth.token = "expired"
+	auth.refreshes = 1
+	seen = nil
+	_, err := tr.Call(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":2,"method":"ping"}`))
+	var se *HTTPStatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("err=%v", err)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("requests=%d", len(seen))
+	}
+}
+
+func TestHTTPTransport_No401RetryForStaticAuthorization(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	auth := &refreshingAuth{token: "unused"}
+	tr := &HTTPTransport{URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer static"}, AuthProvider: auth}
+	if _, err := tr.Call(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"ping"}`)); err == nil {
+		t.Fatalf("expected error")
+	}
+	if requests != 1 || auth.refreshes != 0 {
+		t.Fatalf("requests=%d refreshes=%d", requests, auth.refreshes)
+	}
+}
+
+func TestHTTPTransport_Elicitation(t *testing.T) {
+	answers := make(chan json.RawMessage, 2)
+	listened := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		elicitAndWait := func(id int) string {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprintf(w, "data: {\"jsonrpc\":\"2.0\",\"id\":%d,\"method\":\"elicitation/create\",\"params\":{\"message\":\"name?\",\"requestedSchema\":{\"type\":\"object\"}}}\n\n", id)
+			w.(http.Flusher).Flush()
+			select {
+			case a := <-answers:
+				return string(a)
+			case <-r.Context().Done():
+				return ""
+			}
+		}
+		if r.Method == http.MethodGet {
+			listened <- elicitAndWait(2)
+			return
+		}
+		var req struct {
+			ID     *int64          `json:"id"`
+			Method string          `json:"method"`
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch {
+		case req.Method == "initialize":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"protocolVersion":"2025-06-18","serverInfo":{"name":"s"},"capabilities":{"tools":{}}}}`, *req.ID)
+		case req.Method == "" && req.ID != nil:
+			// The client's answer to an elicitation.
+			answers <- append(json.RawMessage(fmt.Sprintf(`%d:`, *req.ID)), req.Result...)
+			w.WriteHeader(http.StatusAccepted)
+		case req.Method == "tools/call":
+			// The first elicitation id deliberately collides with the call's.
+			answer := elicitAndWait(int(*req.ID))
+			text, _ := json.Marshal(answer)
+			_, _ = fmt.Fprintf(w, "data: {\"jsonrpc\":\"2.0\",\"id\":%d,\"result\":{\"content\":[{\"type\":\"text\",\"text\":%s}]}}\n\n", *req.ID, text)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(ClientOptions{
+		Transport:          &HTTPTransport{URL: srv.URL},
+		ClientCapabilities: &Capabilities{Elicitation: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.OnElicitationRequest(func(ctx context.Context, req ElicitationRequest) (ElicitationResponse, error) {
+		return ElicitationResponse{Action: ElicitationAccept, Content: map[string]string{"name": "ada"}}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	out, err := c.callTool(ctx, "greet", nil, toolCallOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(fmt.Sprint(out), `:{"action":"accept","content":{"name":"ada"}}`) {
+		t.Fatalf("tool result=%v", out)
+	}
+
+	if err := c.Listen(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-listened; got != `2:{"action":"accept","content":{"name":"ada"}}` {
+		t.Fatalf("Listen answer=%s", got)
+	}
+}
+
+func TestHTTPTransport_Cancellation(t *testing.T) {
+	callStarted := make(chan int64, 1)
+	cancelled := make(chan CancelledNotification, 1)
+	var answered atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			// Ask for input, then give up on it.
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprint(w, "data: {\"jsonrpc\":\"2.0\",\"id\":7,\"method\":\"elicitation/create\",\"params\":{\"message\":\"name?\",\"requestedSchema\":{\"type\":\"object\"}}}\n\n")
+			_, _ = fmt.Fprint(w, "data: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/cancelled\",\"params\":{\"requestId\":7,\"reason\":\"timeout\"}}\n\n")
+			return
+		}
+		var req struct {
+			ID     *int64          `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch req.Method {
+		case "initialize":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"protocolVersion":"2025-06-18","serverInfo":{"name":"s"},"capabilities":{"tools":{}}}}`, *req.ID)
+		case "tools/call":
+			callStarted <- *req.ID
+			<-r.Context().Done()
+		case "notifications/cancelled":
+			var n CancelledNotification
+			_ = json.Unmarshal(req.Params, &n)
+			cancelled <- n
+			w.WriteHeader(http.StatusAccepted)
+		case "":
+			answered.Store(true)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(ClientOptions{
+		Transport:          &HTTPTransport{URL: srv.URL},
+		ClientCapabilities: &Capabilities{Elicitation: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Client side: cancelling a tool call tells the server.
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-callStarted
+		cancel()
+	}()
+	if _, err := c.callTool(ctx, "slow", nil, toolCallOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err=%v", err)
+	}
+	select {
+	case n := <-cancelled:
+		if n.RequestID != c.nextID.Load() || n.Reason != context.Canceled.Error() {
+			t.Fatalf("cancelled=%+v", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notifications/cancelled sent")
+	}
+
+	// Server side: a cancelled elicitation gets no answer.
+	var handlerErr error
+	if err := c.OnElicitationRequest(func(ctx context.Context, req ElicitationRequest) (ElicitationResponse, error) {
+		<-ctx.Done()
+		handlerErr = ctx.Err()
+		return ElicitationResponse{}, ctx.Err()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var got CancelledNotification
+	c.OnCancelled(func(ctx context.Context, n CancelledNotification) { got = n })
+	if err := c.Listen(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got.RequestID != 7 || got.Reason != "timeout" || handlerErr == nil || answered.Load() {
+		t.Fatalf("cancelled=%+v handlerErr=%v answered=%v", got, handlerErr, answered.Load())
+	}
+}
@@ -0,0 +1,76 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Handler serves MCP requests in-process. It receives the JSON-RPC method and
+// raw params and returns the raw result. Return an *RPCError to control the
+// error code (e.g. -32601 for unknown methods); other errors are reported as
+// internal errors (-32603). Notifications are delivered too; their result and
+// error are discarded.
+type Handler func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error)
+
+// InProcessTransport calls a Handler directly, without any network or
+// subprocess. It is meant for tests and for servers embedded in the same
+// binary. It is safe for concurrent use if the handler is.
+type InProcessTransport struct {
+	handler Handler
+	closed  atomic.Bool
+}
+
+func NewInProcessTransport(handler Handler) *InProcessTransport {
+	return &InProcessTransport{handler: handler}
+}
+
+func (t *InProcessTransport) Call(ctx context.Context, req json.RawMessage) (json.RawMessage, error) {
+	if t == nil || t.handler == nil {
+		return nil, fmt.Errorf("mcp: in-process transport handler is required")
+	}
+	if t.closed.Load() {
+		return nil, fmt.Errorf("mcp: in-process transport closed")
+	}
+	var msg struct {
+		ID     *int64          `json:"id,omitempty"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params,omitempty"`
+	}
+	if err := json.Unmarshal(req, &msg); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result, err := t.handler(ctx, msg.Method, msg.Params)
+	if msg.ID == nil {
+		return nil, nil
+	}
+	resp := rpcResponse{JSONRPC: "2.0", ID: *msg.ID}
+	var rpcErr *RPCError
+	switch {
+	case errors.As(err, &rpcErr):
+		resp.Error = &rpcError{Code: rpcErr.Code, Message: rpcErr.Message, Data: rpcErr.Data}
+	case err != nil:
+		resp.Error = &rpcError{Code: -32603, Message: err.Error()}
+	case len(result) == 0:
+		resp.Result = json.RawMessage(`{}`)
+	default:
+		resp.Result = result
+	}
+	return json.Marshal(resp)
+}
+
+// Close makes subsequent calls fail. It does not affect the handler.
+func (t *InProcessTransport) Close() error {
+	if t != nil {
+		t.closed.Store(true)
+	}
+	return nil
+}
+
+var _ Transport = (*InProcessTransport)(nil)
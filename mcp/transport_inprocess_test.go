@@ -0,0 +1,66 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestInProcessTransport(t *testing.T) {
+	var notified []string
+	tr := NewInProcessTransport(func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+		switch method {
+		case "initialize":
+			return mustJSON(InitializeResult{ProtocolVersion: "2025-06-18", Capabilities: map[string]any{"tools": map[string]any{}}}), nil
+		case "notifications/initialized":
+			notified = append(notified, method)
+			return nil, nil
+		case "tools/list":
+			return mustJSON(toolListResult{Tools: []ToolInfo{{Name: "echo", InputSchema: json.RawMessage(`{"type":"object"}`)}}}), nil
+		case "tools/call":
+			var p callToolParams
+			if err := json.Unmarshal(params, &p); err != nil {
+				return nil, err
+			}
+			text, _ := json.Marshal(p.Arguments)
+			return mustJSON(CallToolResult{Content: []ToolContentPart{{Type: "text", Raw: mustJSON(map[string]any{"type": "text", "text": string(text)})}}}), nil
+		case "prompts/list":
+			return nil, errors.New("boom")
+		}
+		return nil, &RPCError{Code: -32601, Message: "method not found"}
+	})
+	c, err := NewClient(ClientOptions{Transport: tr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	tools, err := c.Tools(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 1 || len(notified) != 1 {
+		t.Fatalf("tools=%d notified=%v", len(tools), notified)
+	}
+	out, err := tools[0].Handler(ctx, json.RawMessage(`{"msg":"hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != `{"msg":"hi"}` {
+		t.Fatalf("result=%#v", out)
+	}
+
+	var rpcErr *RPCError
+	if err := c.rpcRaw(ctx, "resources/list", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Fatalf("err=%v", err)
+	}
+	if err := c.rpcRaw(ctx, "prompts/list", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != -32603 || rpcErr.Message != "boom" {
+		t.Fatalf("err=%v", err)
+	}
+
+	_ = c.Close()
+	if _, err := c.Tools(ctx, nil); err == nil {
+		t.Fatalf("expected error after Close")
+	}
+}
@@ -150,22 +150,8 @@ func (t *StdioTransport) handleServerRequest(line []byte) {
 		return
 	}
 
-	var params elicitationCreateParams
-	if err := json.Unmarshal(req.Params, &params); err != nil {
-		_ = t.writeServerResponse(*req.ID, nil, &rpcError{Code: -32602, Message: "invalid params"})
-		return
-	}
-
-	resp, err := h(context.Background(), ElicitationRequest{
-		Message:         params.Message,
-		RequestedSchema: params.RequestedSchema,
-	})
-	if err != nil {
-		_ = t.writeServerResponse(*req.ID, nil, &rpcError{Code: -32000, Message: err.Error()})
-		return
-	}
-
-	_ = t.writeServerResponse(*req.ID, resp, nil)
+	result, rpcErr := elicit(context.Background(), h, req.Params)
+	_ = t.writeServerResponse(*req.ID, result, rpcErr)
 }
 
 func (t *StdioTransport) writeServerResponse(id int64, result any, rpcErr *rpcError) error {
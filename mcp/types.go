@@ -1,6 +1,11 @@
 package mcp
 
-import "encoding/json"
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
 
 // JSON-RPC 2.0 envelope types (subset used by MCP).
 
@@ -27,9 +32,21 @@ type rpcError struct {
 // MCP server types (subset).
 
 type ToolInfo struct {
-	Name        string          `json:"name"`
-	Description string          `json:"description,omitempty"`
-	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	InputSchema json.RawMessage  `json:"inputSchema,omitempty"`
+	Annotations *ToolAnnotations `json:"annotations,omitempty"`
+}
+
+// ToolAnnotations are the server's (untrusted) hints about a tool's behavior.
+// Unset hints take the spec defaults: not read-only, destructive, not
+// idempotent, open-world.
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"`
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`
 }
 
 type toolListResult struct {
@@ -65,6 +82,17 @@ func (p *ToolContentPart) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MarshalJSON writes the original payload, so parts round-trip intact (e.g.
+// when a CallToolResult is encoded into a tool result message).
+func (p ToolContentPart) MarshalJSON() ([]byte, error) {
+	if len(p.Raw) > 0 {
+		return p.Raw, nil
+	}
+	return json.Marshal(struct {
+		Type string `json:"type"`
+	}{p.Type})
+}
+
 type ResourcesListResult struct {
 	Resources []ResourceInfo `json:"resources"`
 }
@@ -103,6 +131,18 @@ type ResourceContent struct {
 	MediaType  string `json:"mimeType,omitempty"`
 }
 
+// Bytes returns the decoded blob, or the text content when no blob is set.
+func (rc ResourceContent) Bytes() ([]byte, error) {
+	if rc.BlobBase64 == "" {
+		return []byte(rc.Text), nil
+	}
+	b, err := base64.StdEncoding.DecodeString(rc.BlobBase64)
+	if err != nil {
+		return nil, fmt.Errorf("decode blob for %q: %w", rc.URI, err)
+	}
+	return b, nil
+}
+
 type PromptsListResult struct {
 	Prompts []PromptInfo `json:"prompts"`
 }
@@ -134,6 +174,46 @@ type ClientInfo struct {
 	Version string `json:"version,omitempty"`
 }
 
+// Capabilities declares the optional features this client supports. It is
+// serialized into the initialize request's capabilities object.
+type Capabilities struct {
+	// Elicitation declares support for elicitation/create requests.
+	Elicitation bool
+	// Sampling declares support for sampling/createMessage requests.
+	Sampling bool
+	// Roots declares support for roots/list; ListChanged additionally promises
+	// notifications/roots/list_changed.
+	Roots *RootsCapability
+	// Experimental carries non-standard capabilities as-is.
+	Experimental map[string]any
+}
+
+type RootsCapability struct {
+	ListChanged bool
+}
+
+// Map returns the wire form of c, e.g. {"elicitation":{},"roots":{"listChanged":true}}.
+func (c Capabilities) Map() map[string]any {
+	m := map[string]any{}
+	if c.Elicitation {
+		m["elicitation"] = map[string]any{}
+	}
+	if c.Sampling {
+		m["sampling"] = map[string]any{}
+	}
+	if c.Roots != nil {
+		roots := map[string]any{}
+		if c.Roots.ListChanged {
+			roots["listChanged"] = true
+		}
+		m["roots"] = roots
+	}
+	if len(c.Experimental) > 0 {
+		m["experimental"] = c.Experimental
+	}
+	return m
+}
+
 type InitializeRequest struct {
 	ProtocolVersion string         `json:"protocolVersion"`
 	Capabilities    map[string]any `json:"capabilities,omitempty"`
@@ -153,6 +233,13 @@ type InitializeResult struct {
 	Instructions    string         `json:"instructions,omitempty"`
 }
 
+// CancelledNotification is the params of notifications/cancelled: the sender
+// abandoned request RequestID and expects no response to it.
+type CancelledNotification struct {
+	RequestID int64  `json:"requestId"`
+	Reason    string `json:"reason,omitempty"`
+}
+
 // Elicitation (server -> client request).
 
 type elicitationCreateParams struct {
@@ -177,3 +264,20 @@ type ElicitationResponse struct {
 	Action  ElicitationAction `json:"action"`
 	Content any               `json:"content,omitempty"`
 }
+
+// elicit runs h for an elicitation/create request and returns the JSON-RPC
+// result or error to send back to the server.
+func elicit(ctx context.Context, h func(context.Context, ElicitationRequest) (ElicitationResponse, error), params json.RawMessage) (any, *rpcError) {
+	if h == nil {
+		return nil, &rpcError{Code: -32601, Message: "elicitation not supported"}
+	}
+	var p elicitationCreateParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &rpcError{Code: -32602, Message: "invalid params"}
+	}
+	res, err := h(ctx, ElicitationRequest{Message: p.Message, RequestedSchema: p.RequestedSchema})
+	if err != nil {
+		return nil, &rpcError{Code: -32000, Message: err.Error()}
+	}
+	return res, nil
+}
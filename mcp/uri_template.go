@@ -0,0 +1,171 @@
+package mcp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ExpandTemplate expands an RFC 6570 URI template (as used by
+// ResourceTemplateInfo.URITemplate) with string variables, producing a URI for
+// ReadResource.
+//
+// Levels 1-3 are supported, plus the level 4 prefix modifier ({var:3}):
+// simple ({var}), reserved ({+var}), fragment ({#var}), label ({.var}), path
+// segment ({/var}), path parameter ({;var}) and query ({?var}, {&var})
+// expressions with comma-separated variable lists. Variables missing from vars
+// are omitted, as the RFC specifies. Note that simple expansion
+// percent-encodes '/', so `file:///{path}` with path "a/b" yields
+// `file:///a%2Fb`; templates meant to take paths use {+path}.
+func ExpandTemplate(tmpl string, vars map[string]string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(tmpl); {
+		switch c := tmpl[i]; c {
+		case '{':
+			end := strings.IndexByte(tmpl[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("mcp: uri template %q: unclosed expression at offset %d", tmpl, i)
+			}
+			if err := expandExpression(&b, tmpl[i+1:i+end], vars); err != nil {
+				return "", fmt.Errorf("mcp: uri template %q: %w", tmpl, err)
+			}
+			i += end + 1
+		case '}':
+			return "", fmt.Errorf("mcp: uri template %q: unexpected '}' at offset %d", tmpl, i)
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String(), nil
+}
+
+type templateOp struct {
+	first         string
+	sep           string
+	named         bool
+	ifEmpty       string
+	allowReserved bool
+}
+
+var templateOps = map[byte]templateOp{
+	'+': {sep: ",", allowReserved: true},
+	'#': {first: "#", sep: ",", allowReserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "="},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "="},
+}
+
+func expandExpression(b *strings.Builder, expr string, vars map[string]string) error {
+	if expr == "" {
+		return fmt.Errorf("empty expression")
+	}
+	op := templateOp{sep: ","}
+	if o, ok := templateOps[expr[0]]; ok {
+		op = o
+		expr = expr[1:]
+	} else if strings.ContainsRune("=,!@|", rune(expr[0])) {
+		return fmt.Errorf("unsupported operator %q", expr[0])
+	}
+
+	first := true
+	for _, spec := range strings.Split(expr, ",") {
+		name, prefix, err := parseVarSpec(spec)
+		if err != nil {
+			return err
+		}
+		value, ok := vars[name]
+		if !ok {
+			continue
+		}
+		if prefix > 0 {
+			value = truncateRunes(value, prefix)
+		}
+
+		if first {
+			b.WriteString(op.first)
+			first = false
+		} else {
+			b.WriteString(op.sep)
+		}
+		if op.named {
+			b.WriteString(name)
+			if value == "" {
+				b.WriteString(op.ifEmpty)
+				continue
+			}
+			b.WriteByte('=')
+		}
+		writeTemplateValue(b, value, op.allowReserved)
+	}
+	return nil
+}
+
+// parseVarSpec splits "name", "name:3" or "name*" into the name and prefix
+// length. Explode has no effect on string values and is accepted as-is.
+func parseVarSpec(spec string) (name string, prefix int, err error) {
+	name = strings.TrimSuffix(spec, "*")
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		prefix, err = strconv.Atoi(name[i+1:])
+		if err != nil || prefix <= 0 || prefix >= 10000 {
+			return "", 0, fmt.Errorf("invalid prefix modifier in %q", spec)
+		}
+		name = name[:i]
+	}
+	if name == "" {
+		return "", 0, fmt.Errorf("empty variable name in %q", spec)
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !isTemplateUnreserved(c) || c == '-' || c == '~' {
+			if c == '%' && i+2 < len(name) && isHex(name[i+1]) && isHex(name[i+2]) {
+				i += 2
+				continue
+			}
+			return "", 0, fmt.Errorf("invalid variable name %q", name)
+		}
+	}
+	return name, prefix, nil
+}
+
+func writeTemplateValue(b *strings.Builder, value string, allowReserved bool) {
+	const hex = "0123456789ABCDEF"
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case isTemplateUnreserved(c):
+			b.WriteByte(c)
+		case allowReserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0:
+			b.WriteByte(c)
+		case allowReserved && c == '%' && i+2 < len(value) && isHex(value[i+1]) && isHex(value[i+2]):
+			// Keep existing percent-encoded triplets in reserved expansion.
+			b.WriteString(value[i : i+3])
+			i += 2
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0x0f])
+		}
+	}
+}
+
+func isTemplateUnreserved(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func truncateRunes(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
@@ -0,0 +1,49 @@
+package mcp
+
+import "testing"
+
+func TestExpandTemplate(t *testing.T) {
+	vars := map[string]string{
+		"path":  "docs/read me.md",
+		"var":   "value",
+		"hello": "Hello World!",
+		"x":     "1024",
+		"y":     "768",
+		"empty": "",
+	}
+	cases := []struct {
+		tmpl string
+		want string
+	}{
+		{"file:///{path}", "file:///docs%2Fread%20me.md"},
+		{"file:///{+path}", "file:///docs/read%20me.md"},
+		{"{hello}", "Hello%20World%21"},
+		{"{+hello}", "Hello%20World!"},
+		{"{#var}", "#value"},
+		{"map?{x,y}", "map?1024,768"},
+		{"X{.var}", "X.value"},
+		{"repo{/var,x}", "repo/value/1024"},
+		{"{;x,y,empty}", ";x=1024;y=768;empty"},
+		{"search{?x,y,empty}", "search?x=1024&y=768&empty="},
+		{"?fixed=yes{&x}", "?fixed=yes&x=1024"},
+		{"{var:3}", "val"},
+		{"db://{missing}/t{?missing}", "db:///t"},
+	}
+	for _, tc := range cases {
+		got, err := ExpandTemplate(tc.tmpl, vars)
+		if err != nil {
+			t.Fatalf("ExpandTemplate(%q): %v", tc.tmpl, err)
+		}
+		if got != tc.want {
+			t.Fatalf("ExpandTemplate(%q)=%q, want %q", tc.tmpl, got, tc.want)
+		}
+	}
+}
+
+func TestExpandTemplate_Errors(t *testing.T) {
+	for _, tmpl := range []string{"file:///{path", "a}b", "{}", "{=x}", "{x:0}", "{bad-name}"} {
+		if _, err := ExpandTemplate(tmpl, map[string]string{"path": "p", "x": "1"}); err == nil {
+			t.Fatalf("ExpandTemplate(%q): expected error", tmpl)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package ai
+
+// Example is a single few-shot demonstration: a user input and the assistant
+// output the model should imitate.
+type Example struct {
+	Input  string
+	Output string
+}
+
+// FewShot builds a few-shot prompt: an optional system message followed by
+// alternating user/assistant messages, one pair per example.
+type FewShot struct {
+	System   string
+	Examples []Example
+}
+
+// Messages returns the prompt messages. Append the real user message to the
+// result before calling GenerateText:
+//
+//	msgs := append(fs.Messages(), ai.User(question))
+func (f FewShot) Messages() []Message {
+	msgs := make([]Message, 0, 1+2*len(f.Examples))
+	if f.System != "" {
+		msgs = append(msgs, System(f.System))
+	}
+	for _, ex := range f.Examples {
+		msgs = append(msgs, User(ex.Input), Assistant(ex.Output))
+	}
+	return msgs
+}
@@ -0,0 +1,31 @@
+package ai
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFewShotMessages(t *testing.T) {
+	fs := FewShot{
+		System: "Classify sentiment.",
+		Examples: []Example{
+			{Input: "I love it", Output: "positive"},
+			{Input: "Terrible", Output: "negative"},
+		},
+	}
+	want := []Message{
+		System("Classify sentiment."),
+		User("I love it"),
+		Assistant("positive"),
+		User("Terrible"),
+		Assistant("negative"),
+	}
+	if got := fs.Messages(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Messages()=%#v", got)
+	}
+
+	fs.System = ""
+	if got := fs.Messages(); len(got) != 4 || got[0].Role != RoleUser {
+		t.Fatalf("without system: %#v", got)
+	}
+}
@@ -0,0 +1,100 @@
+package ai
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+)
+
+// FingerprintMessages returns a stable, order-sensitive SHA256 hex digest of
+// msgs, suitable as a cache key or for detecting shared prompt prefixes.
+//
+// Every field and content part contributes to the hash. Equivalent encodings
+// hash identically: image/audio Bytes and Base64 of the same data, and tool
+// call arguments that differ only in JSON whitespace.
+func FingerprintMessages(msgs []Message) string {
+	h := sha256.New()
+	for _, m := range msgs {
+		fpField(h, 'M', nil)
+		fpField(h, 'r', []byte(m.Role))
+		fpField(h, 'n', []byte(m.Name))
+		fpField(h, 'i', []byte(m.ToolCallID))
+		if len(m.Metadata) > 0 {
+			// encoding/json sorts map keys, so equal maps hash the same.
+			b, err := json.Marshal(m.Metadata)
+			if err != nil {
+				b = []byte(fmt.Sprintf("%#v", m.Metadata))
+			}
+			fpField(h, 'd', b)
+		}
+		for _, p := range m.Content {
+			fpPart(h, p)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func fpPart(h hash.Hash, p ContentPart) {
+	switch v := p.(type) {
+	case TextPart:
+		fpField(h, 'T', []byte(v.Text))
+	case *TextPart:
+		fpPart(h, *v)
+	case ToolCallPart:
+		fpField(h, 'C', []byte(v.ID))
+		fpField(h, 'c', []byte(v.Name))
+		fpField(h, 'a', fpCompactJSON(v.Args))
+	case *ToolCallPart:
+		fpPart(h, *v)
+	case ImagePart:
+		fpField(h, 'I', []byte(v.URL))
+		fpField(h, 'm', []byte(v.MediaType))
+		fpField(h, 'b', fpBinary(v.Bytes, v.Base64))
+	case *ImagePart:
+		fpPart(h, *v)
+	case AudioPart:
+		fpField(h, 'A', []byte(v.Format))
+		fpField(h, 'b', fpBinary(v.Bytes, v.Base64))
+	case *AudioPart:
+		fpPart(h, *v)
+	case ReasoningPart:
+		fpField(h, 'R', []byte(v.Text))
+	case *ReasoningPart:
+		fpPart(h, *v)
+	default:
+		fpField(h, '?', []byte(fmt.Sprintf("%T:%#v", p, p)))
+	}
+}
+
+// fpField writes a tagged, length-prefixed field so adjacent values cannot
+// collide.
+func fpField(h hash.Hash, tag byte, data []byte) {
+	var hdr [9]byte
+	hdr[0] = tag
+	binary.BigEndian.PutUint64(hdr[1:], uint64(len(data)))
+	h.Write(hdr[:])
+	h.Write(data)
+}
+
+func fpBinary(b []byte, b64 string) []byte {
+	if len(b) > 0 || b64 == "" {
+		return b
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(b64); err == nil {
+		return decoded
+	}
+	return []byte(b64)
+}
+
+func fpCompactJSON(raw json.RawMessage) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return raw
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,51 @@
+package ai
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestFingerprintMessages(t *testing.T) {
+	img := []byte{0x89, 'P', 'N', 'G'}
+	a := []Message{
+		System("sys"),
+		{Role: RoleUser, Content: []ContentPart{TextPart{Text: "look"}, ImagePart{Bytes: img, MediaType: "image/png"}}},
+		{Role: RoleAssistant, Content: []ContentPart{ToolCallPart{ID: "1", Name: "t", Args: []byte(`{"x": 1}`)}}},
+	}
+	b := []Message{
+		System("sys"),
+		{Role: RoleUser, Content: []ContentPart{TextPart{Text: "look"}, ImagePart{Base64: base64.StdEncoding.EncodeToString(img), MediaType: "image/png"}}},
+		{Role: RoleAssistant, Content: []ContentPart{ToolCallPart{ID: "1", Name: "t", Args: []byte(`{"x":1}`)}}},
+	}
+	if FingerprintMessages(a) != FingerprintMessages(b) {
+		t.Fatalf("expected equivalent encodings to share a fingerprint")
+	}
+	if FingerprintMessages(a) != FingerprintMessages(a) {
+		t.Fatalf("fingerprint not stable")
+	}
+
+	reordered := []Message{a[1], a[0], a[2]}
+	if FingerprintMessages(a) == FingerprintMessages(reordered) {
+		t.Fatalf("fingerprint should be order-sensitive")
+	}
+	// Field boundaries must not collide ("ab"+"c" vs "a"+"bc").
+	x := []Message{{Role: RoleUser, Content: []ContentPart{TextPart{Text: "ab"}, TextPart{Text: "c"}}}}
+	y := []Message{{Role: RoleUser, Content: []ContentPart{TextPart{Text: "a"}, TextPart{Text: "bc"}}}}
+	if FingerprintMessages(x) == FingerprintMessages(y) {
+		t.Fatalf("fingerprint collision across part boundaries")
+	}
+	// Metadata counts; equal maps hash the same.
+	tagged := func(md map[string]any) []Message {
+		m := User("hi")
+		m.Metadata = md
+		return []Message{m}
+	}
+	if FingerprintMessages(tagged(map[string]any{"a": 1, "b": 2})) != FingerprintMessages(tagged(map[string]any{"b": 2, "a": 1})) ||
+		FingerprintMessages(tagged(map[string]any{"a": 1})) == FingerprintMessages(tagged(nil)) {
+		t.Fatalf("metadata fingerprint")
+	}
+	// A prefix fingerprints differently from the full conversation.
+	if FingerprintMessages(a[:2]) == FingerprintMessages(a) {
+		t.Fatalf("prefix should differ")
+	}
+}
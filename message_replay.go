@@ -0,0 +1,75 @@
+package ai
+
+import "sort"
+
+// AppendTo appends the messages of this turn to history and returns the
+// extended slice, ready to be sent as the next request's Messages:
+//
+//	history = resp.AppendTo(history)
+//	history = append(history, ai.User(next))
+//
+// Every assistant message with tool calls is immediately followed by the
+// results of those calls, in call order. Responses without Response.Messages
+// (e.g. built by hand) are rebuilt from Steps, or from Message alone.
+func (r *GenerateTextResponse) AppendTo(history []Message) []Message {
+	if r == nil {
+		return history
+	}
+	msgs := r.Response.Messages
+	if len(msgs) == 0 {
+		msgs = r.turnMessages()
+	}
+	n := len(history)
+	history = append(history, msgs...)
+	orderToolResults(history[n:])
+	return history
+}
+
+// turnMessages rebuilds the turn's messages when Response.Messages is empty.
+func (r *GenerateTextResponse) turnMessages() []Message {
+	if len(r.Steps) > 0 {
+		var msgs []Message
+		for _, s := range r.Steps {
+			msgs = append(msgs, s.Message)
+			msgs = append(msgs, s.ToolResults...)
+		}
+		return msgs
+	}
+	if r.Message.Role == "" && len(r.Message.Content) == 0 {
+		return nil
+	}
+	return []Message{r.Message}
+}
+
+// orderToolResults sorts the tool messages following each assistant message
+// into the order of its tool calls. Results answering no call keep their
+// relative order, after the others.
+func orderToolResults(msgs []Message) {
+	for i := 0; i < len(msgs); i++ {
+		if msgs[i].Role != RoleAssistant {
+			continue
+		}
+		pos := map[string]int{}
+		for _, p := range msgs[i].Content {
+			if c, ok := p.(ToolCallPart); ok {
+				pos[c.ID] = len(pos)
+			}
+		}
+		j := i + 1
+		for j < len(msgs) && msgs[j].Role == RoleTool {
+			j++
+		}
+		if len(pos) == 0 || j-i < 3 {
+			continue
+		}
+		results := msgs[i+1 : j]
+		rank := func(m Message) int {
+			if p, ok := pos[m.ToolCallID]; ok {
+				return p
+			}
+			return len(pos)
+		}
+		sort.SliceStable(results, func(a, b int) bool { return rank(results[a]) < rank(results[b]) })
+		i = j - 1
+	}
+}
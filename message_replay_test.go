@@ -0,0 +1,58 @@
+package ai
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerateTextResponse_AppendTo(t *testing.T) {
+	calls := AssistantWithToolCalls(
+		ToolCall("c1", "weather", map[string]string{"city": "Rome"}),
+		ToolCall("c2", "weather", map[string]string{"city": "Oslo"}),
+	)
+	r1 := ToolResultForCall("c1", "weather", "sunny")
+	r2 := ToolResultForCall("c2", "weather", "snow")
+	final := Assistant("Sunny in Rome, snow in Oslo.")
+	history := []Message{User("Weather in Rome and Oslo?")}
+
+	t.Run("response messages", func(t *testing.T) {
+		resp := &GenerateTextResponse{Response: Response{Messages: []Message{calls, r2, r1, final}}}
+		got := resp.AppendTo(append([]Message(nil), history...))
+		want := []Message{history[0], calls, r1, r2, final}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %#v", got)
+		}
+		if resp.Response.Messages[1].ToolCallID != "c2" {
+			t.Fatal("response messages were reordered in place")
+		}
+	})
+
+	t.Run("steps", func(t *testing.T) {
+		resp := &GenerateTextResponse{
+			Message: final,
+			Steps: []Step{
+				{Message: calls, ToolResults: []Message{r1, r2}},
+				{Message: final},
+			},
+		}
+		want := []Message{history[0], calls, r1, r2, final}
+		if got := resp.AppendTo(append([]Message(nil), history...)); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %#v", got)
+		}
+	})
+
+	t.Run("message only", func(t *testing.T) {
+		resp := &GenerateTextResponse{Message: final}
+		want := []Message{history[0], final}
+		if got := resp.AppendTo(append([]Message(nil), history...)); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %#v", got)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var resp *GenerateTextResponse
+		if got := resp.AppendTo(history); len(got) != 1 {
+			t.Fatalf("got %#v", got)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package openai
+
+import (
+	"errors"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// Error is returned by the package's own API calls (such as
+// Client.ListModels) when the request fails. It carries the same details as
+// ai.Error; calls made through the ai package return *ai.Error instead.
+type Error struct {
+	Code      string
+	Type      string
+	Param     string
+	Status    int
+	Message   string
+	Retryable bool
+	Cause     error
+}
+
+func (e *Error) Error() string {
+	if e == nil {
+		return ""
+	}
+	if e.Message != "" {
+		return ProviderName + ": " + e.Message
+	}
+	return ProviderName + ": error"
+}
+
+func (e *Error) Unwrap() error { return e.Cause }
+
+func mapProviderError(err error) error {
+	var pe *provider.Error
+	if errors.As(err, &pe) {
+		return &Error{
+			Code:      pe.Code,
+			Type:      pe.Type,
+			Param:     pe.Param,
+			Status:    pe.Status,
+			Message:   pe.Message,
+			Retryable: pe.Retryable,
+			Cause:     pe.Cause,
+		}
+	}
+	return err
+}
@@ -0,0 +1,48 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// ModelInfo describes a model listed by the API.
+type ModelInfo struct {
+	ID      string
+	OwnedBy string
+	// Created is when the model was published, zero when unknown.
+	Created time.Time
+}
+
+// ListModels returns the models available to the default client's
+// credentials (GET /models), sorted by ID. See Client.ListModels.
+func ListModels(ctx context.Context) ([]ModelInfo, error) {
+	return defaultClient.Load().ListModels(ctx)
+}
+
+// ListModels returns the models available to c's credentials, sorted by ID.
+// API failures are returned as *Error. It is ai.ListModels without a model
+// ref, and like every call it needs the OpenAI provider, which is registered
+// by importing github.com/bitop-dev/ai (a blank import will do); without it,
+// ListModels fails.
+func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	p, ok := provider.Get(ProviderName)
+	if !ok {
+		return nil, fmt.Errorf("openai: provider not registered (import github.com/bitop-dev/ai)")
+	}
+	ml, ok := provider.As[provider.ModelLister](p)
+	if !ok {
+		return nil, fmt.Errorf("openai: provider does not support listing models")
+	}
+	models, err := ml.ListModels(ctx, provider.ListModelsRequest{ProviderData: c})
+	if err != nil {
+		return nil, mapProviderError(err)
+	}
+	out := make([]ModelInfo, len(models))
+	for i, m := range models {
+		out[i] = ModelInfo(m)
+	}
+	return out, nil
+}
@@ -1,6 +1,7 @@
 package openai
 
 import (
+	"context"
 	"net/http"
 	"sync/atomic"
 	"time"
@@ -9,7 +10,24 @@ import (
 const ProviderName = "openai"
 
 type Config struct {
-	APIKey     string
+	APIKey string
+
+	// APIKeys spreads requests round-robin across several keys, e.g. to
+	// raise throughput under per-key rate limits. List a key more than once
+	// to give it a larger share. When set, APIKey is ignored. The keys should
+	// reach the same resources, as batch lookups may use a different key than
+	// the submit.
+	APIKeys []string
+
+	// KeyProvider, when set, returns the key for each request and takes
+	// precedence over APIKey and APIKeys. Use it for custom policies such as
+	// skipping keys whose rate limit is exhausted.
+	KeyProvider func(ctx context.Context) (string, error)
+
+	// DefaultModel is the chat model used by DefaultChat, and by Chat when
+	// given an empty name, so an app can choose its model in one place.
+	DefaultModel string
+
 	BaseURL    string
 	APIPrefix  string
 	Headers    map[string]string
@@ -18,10 +36,24 @@ type Config struct {
 	MaxRetries int
 	MinBackoff time.Duration
 	MaxBackoff time.Duration
+
+	// RetryClassifier overrides which failures are retried (and reported as
+	// retryable). It is called with the status and body of non-2xx responses
+	// (err nil), or with a transport error (status 0, body nil). When nil,
+	// 408, 409, 429 and 5xx responses and timeouts are retried.
+	RetryClassifier func(status int, body []byte, err error) bool
+
+	// RequestEditor is called with every outgoing HTTP request (each retry
+	// attempt included) just before it is sent, after the SDK has set its
+	// own headers, so it can add signing or tracing headers, override
+	// defaults, or replace the body (update Body, GetBody and ContentLength
+	// together). A returned error fails the call without retrying.
+	RequestEditor func(req *http.Request) error
 }
 
 type Client struct {
-	cfg Config
+	cfg     Config
+	nextKey atomic.Uint64
 }
 
 func NewClient(cfg Config) *Client {
@@ -43,12 +75,24 @@ func Chat(modelName string) ModelRef {
 }
 
 func (c *Client) Chat(modelName string) ModelRef {
+	if modelName == "" {
+		modelName = c.cfg.DefaultModel
+	}
 	return ModelRef{
 		modelName: modelName,
 		client:    c,
 	}
 }
 
+// DefaultChat returns the chat model set by Config.DefaultModel.
+func DefaultChat() ModelRef {
+	return defaultClient.Load().DefaultChat()
+}
+
+func (c *Client) DefaultChat() ModelRef {
+	return c.Chat("")
+}
+
 func Embed(modelName string) ModelRef {
 	return defaultClient.Load().Embed(modelName)
 }
@@ -101,11 +145,28 @@ type ModelRef struct {
 func (m ModelRef) Provider() string { return ProviderName }
 func (m ModelRef) Name() string     { return m.modelName }
 
+// String returns "openai:<model>".
+func (m ModelRef) String() string { return ProviderName + ":" + m.modelName }
+
 func (m ModelRef) Client() *Client { return m.client }
 
 func (c *Client) Config() Config { return c.cfg }
 
+// APIKey returns the key for the next request: from KeyProvider if set, else
+// the next of APIKeys in round-robin order, else Config.APIKey.
+func (c *Client) APIKey(ctx context.Context) (string, error) {
+	if c.cfg.KeyProvider != nil {
+		return c.cfg.KeyProvider(ctx)
+	}
+	if n := len(c.cfg.APIKeys); n > 0 {
+		i := c.nextKey.Add(1) - 1
+		return c.cfg.APIKeys[i%uint64(n)], nil
+	}
+	return c.cfg.APIKey, nil
+}
+
 func normalizeConfig(cfg Config) Config {
+	cfg.APIKeys = append([]string(nil), cfg.APIKeys...)
 	if cfg.BaseURL == "" {
 		cfg.BaseURL = "https://api.openai.com"
 	}
@@ -6,3 +6,10 @@ type SpeechOptions struct {
 	Format string   `json:"format,omitempty"` // e.g. "mp3", "wav"
 	Speed  *float32 `json:"speed,omitempty"`
 }
+
+// RealtimeSpeechOptions provides OpenAI-specific options for ai.RealtimeSpeech.
+type RealtimeSpeechOptions struct {
+	// Format is the output audio format: "pcm16" (default; 24 kHz mono
+	// little-endian), "g711_ulaw" or "g711_alaw".
+	Format string `json:"format,omitempty"`
+}
@@ -0,0 +1,147 @@
+package ai
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// redactHoldback is how many trailing bytes of streamed text are withheld until
+// more text arrives, so patterns split across deltas are still redacted.
+const redactHoldback = 128
+
+// streamRedactor applies a redaction func to streamed text. Text is emitted
+// once it is at least redactHoldback bytes behind the end; only the pending
+// (not yet emitted) text is redacted on each push, so the cost per delta is
+// bounded. flush emits the remainder.
+type streamRedactor struct {
+	redact  func(string) string
+	pending string
+}
+
+func (r *streamRedactor) push(delta string) string {
+	r.pending += delta
+	cut := len(r.pending) - redactHoldback
+	for cut > 0 && !utf8.RuneStart(r.pending[cut]) {
+		cut--
+	}
+	if cut <= 0 {
+		return ""
+	}
+	// The head can go out on its own only if redacting it alone agrees with
+	// redacting all pending text; otherwise a match spans the cut and is
+	// held back until the cut has moved past it.
+	head := r.redact(r.pending[:cut])
+	if !strings.HasPrefix(r.redact(r.pending), head) {
+		return ""
+	}
+	r.pending = r.pending[cut:]
+	return head
+}
+
+func (r *streamRedactor) flush() string {
+	out := r.redact(r.pending)
+	r.pending = ""
+	return out
+}
+
+// redactMessage returns a copy of an assistant message with redact applied to
+// each text part. Other roles are returned unchanged.
+func redactMessage(m Message, redact func(string) string) Message {
+	if m.Role != RoleAssistant {
+		return m
+	}
+	content := make([]ContentPart, len(m.Content))
+	for i, p := range m.Content {
+		switch v := p.(type) {
+		case TextPart:
+			content[i] = TextPart{Text: redact(v.Text)}
+		case *TextPart:
+			if v != nil {
+				content[i] = TextPart{Text: redact(v.Text)}
+				continue
+			}
+			content[i] = p
+		case ReasoningPart:
+			content[i] = ReasoningPart{Text: redact(v.Text)}
+		default:
+			content[i] = p
+		}
+	}
+	m.Content = content
+	return m
+}
+
+func redactMessages(msgs []Message, redact func(string) string) []Message {
+	if msgs == nil {
+		return nil
+	}
+	out := make([]Message, len(msgs))
+	for i, m := range msgs {
+		out[i] = redactMessage(m, redact)
+	}
+	return out
+}
+
+func redactSteps(steps []Step, redact func(string) string) []Step {
+	if steps == nil || redact == nil {
+		return steps
+	}
+	out := make([]Step, len(steps))
+	for i, s := range steps {
+		out[i] = redactStep(s, redact)
+	}
+	return out
+}
+
+// redactStep redacts a step's text and message. A nil redact returns the
+// step unchanged, so callbacks can pass BaseRequest.RedactOutput as-is.
+func redactStep(s Step, redact func(string) string) Step {
+	if redact == nil {
+		return s
+	}
+	s.Text = redact(s.Text)
+	s.Message = redactMessage(s.Message, redact)
+	return s
+}
+
+// redactTextStream wraps a stream so deltas, the final message, steps and
+// response messages are all redacted.
+func redactTextStream(inner *TextStream, redact func(string) string) *TextStream {
+	r := &streamRedactor{redact: redact}
+	var cur string
+	flushed := false
+	return newTextStream(
+		func() bool {
+			for inner.Next() {
+				if cur = r.push(inner.Delta()); cur != "" {
+					return true
+				}
+			}
+			// Held-back text is flushed even when the stream failed, as
+			// the caller already has the text before it.
+			if flushed {
+				cur = ""
+				return false
+			}
+			flushed = true
+			cur = r.flush()
+			return cur != ""
+		},
+		func() string { return cur },
+		func() *Message {
+			m := inner.Message()
+			if m == nil {
+				return nil
+			}
+			out := redactMessage(*m, redact)
+			return &out
+		},
+		inner.Usage,
+		inner.FinishReason,
+		func() []Step { return redactSteps(inner.Steps(), redact) },
+		func() Response { return Response{Messages: redactMessages(inner.Response().Messages, redact)} },
+		inner.streamInfo,
+		inner.Err,
+		inner.Close,
+	)
+}
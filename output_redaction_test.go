@@ -0,0 +1,189 @@
+package ai
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+var testSSN = regexp.MustCompile(`\d{3}-\d{2}-\d{4}`)
+
+func redactSSN(s string) string { return testSSN.ReplaceAllString(s, "[REDACTED]") }
+
+func TestGenerateText_RedactOutput(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		return provider.Response{
+			Message: provider.Message{
+				Role:    provider.RoleAssistant,
+				Content: []provider.ContentPart{provider.TextPart{Text: "ssn is 123-45-6789."}},
+			},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	resp, err := GenerateText(context.Background(), GenerateTextRequest{BaseRequest: BaseRequest{
+		Model:        testModel{provider: providerName, name: "m"},
+		Messages:     []Message{User("hi")},
+		RedactOutput: redactSSN,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "ssn is [REDACTED]."
+	if resp.Text != want {
+		t.Fatalf("Text=%q", resp.Text)
+	}
+	if got := extractTextFromMessage(resp.Response.Messages[0]); got != want {
+		t.Fatalf("response message=%q", got)
+	}
+	if resp.Steps[0].Text != want {
+		t.Fatalf("step text=%q", resp.Steps[0].Text)
+	}
+}
+
+func TestStreamText_RedactOutputAcrossDeltas(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		return &fakeStream{
+			deltas: []provider.Delta{{Text: "ssn is 12"}, {Text: "3-4"}, {Text: "5-67"}, {Text: "89. bye"}},
+			final: &provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.TextPart{Text: "ssn is 123-45-6789. bye"}},
+				},
+				FinishReason: "stop",
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	stream, err := StreamText(context.Background(), StreamTextRequest{BaseRequest: BaseRequest{
+		Model:        testModel{provider: providerName, name: "m"},
+		Messages:     []Message{User("hi")},
+		RedactOutput: redactSSN,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var b strings.Builder
+	for stream.Next() {
+		b.WriteString(stream.Delta())
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := "ssn is [REDACTED]. bye"
+	if b.String() != want {
+		t.Fatalf("streamed=%q", b.String())
+	}
+	if got := extractTextFromMessage(*stream.Message()); got != want {
+		t.Fatalf("message=%q", got)
+	}
+}
+
+func TestStreamRedactor_EmitsBeyondHoldback(t *testing.T) {
+	r := &streamRedactor{redact: redactSSN}
+	long := strings.Repeat("a", redactHoldback+10)
+	if got := r.push(long); got != strings.Repeat("a", 10) {
+		t.Fatalf("push=%q", got)
+	}
+	if got := r.flush(); got != strings.Repeat("a", redactHoldback) {
+		t.Fatalf("flush=%q", got)
+	}
+}
+
+func TestGenerateText_RedactOutputInStepCallbacks(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		return provider.Response{
+			Message: provider.Message{
+				Role:    provider.RoleAssistant,
+				Content: []provider.ContentPart{provider.TextPart{Text: "ssn is 123-45-6789."}},
+			},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	var got []string
+	_, err := GenerateText(context.Background(), GenerateTextRequest{BaseRequest: BaseRequest{
+		Model:        testModel{provider: providerName, name: "m"},
+		Messages:     []Message{User("hi")},
+		RedactOutput: redactSSN,
+		OnStepFinish: func(e StepFinishEvent) {
+			got = append(got, e.Step.Text, extractTextFromMessage(e.Step.Message))
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range got {
+		if s != "ssn is [REDACTED]." {
+			t.Fatalf("OnStepFinish saw %q", got)
+		}
+	}
+	if len(got) != 2 {
+		t.Fatalf("OnStepFinish calls=%d", len(got)/2)
+	}
+}
+
+func TestStreamText_RedactOutputFlushesOnError(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		return &droppingStream{
+			deltas: []provider.Delta{{Text: "ssn is 123-45-6789, more"}},
+			err:    &provider.Error{Provider: "fake", Code: "network_error", Message: "connection reset"},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	stream, err := StreamText(context.Background(), StreamTextRequest{BaseRequest: BaseRequest{
+		Model:        testModel{provider: providerName, name: "m"},
+		Messages:     []Message{User("hi")},
+		RedactOutput: redactSSN,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var b strings.Builder
+	for stream.Next() {
+		b.WriteString(stream.Delta())
+	}
+	if stream.Err() == nil {
+		t.Fatal("expected error")
+	}
+	if want := "ssn is [REDACTED], more"; b.String() != want {
+		t.Fatalf("streamed=%q", b.String())
+	}
+}
+
+func TestStreamRedactor_LongStream(t *testing.T) {
+	var raw strings.Builder
+	for i := 0; i < 200; i++ {
+		raw.WriteString("text 123-45-6789 ")
+	}
+	r := &streamRedactor{redact: redactSSN}
+	var out strings.Builder
+	// Odd-sized deltas put matches across every kind of cut.
+	for s := raw.String(); s != ""; {
+		n := min(7, len(s))
+		out.WriteString(r.push(s[:n]))
+		if len(r.pending) > 2*redactHoldback {
+			t.Fatalf("pending grew to %d bytes", len(r.pending))
+		}
+		s = s[n:]
+	}
+	out.WriteString(r.flush())
+	if want := redactSSN(raw.String()); out.String() != want {
+		t.Fatalf("streamed=%q", out.String())
+	}
+}
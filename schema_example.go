@@ -0,0 +1,269 @@
+package ai
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaFromExample infers a JSON schema from a sample value, for callers
+// that have an example at hand rather than a hand-written schema. It follows
+// encoding/json (field names and tags, embedded structs, custom marshalers):
+//
+//   - Structs become closed objects (additionalProperties: false). Fields are
+//     required unless they are pointers or tagged omitempty; pointer fields
+//     also accept null.
+//   - Maps become objects whose values all follow the first entry's schema.
+//   - Slices and arrays take their item schema from the first element, or
+//     from the element type when empty. []byte is a (base64) string.
+//   - Integer kinds become "integer" and float kinds "number". Values with a
+//     custom MarshalJSON/MarshalText are typed by what they marshal to (e.g.
+//     time.Time is a string), numbers then being "integer" when whole.
+//   - nil interfaces and unsupported kinds are left unconstrained ({}).
+//
+// Values inside the example only pick types; they are not used as enums or
+// defaults. Nil pointers and empty containers are typed from their Go type,
+// as is a pointer, map or slice met again inside itself (a cyclic value).
+func SchemaFromExample(v any) Schema {
+	b, _ := json.Marshal(schemaOf(reflect.ValueOf(v), &exampleWalk{}))
+	return Schema{JSON: b}
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// exampleWalk keeps SchemaFromExample finite on recursive types and cyclic
+// values.
+type exampleWalk struct {
+	// types holds the struct types being expanded from their zero value.
+	types map[reflect.Type]bool
+	// refs holds the pointers, maps and slices on the current path. One seen
+	// again is typed from its Go type instead of followed.
+	refs map[exampleRef]bool
+}
+
+type exampleRef struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+// enter marks the reference v as being walked and reports false if it already
+// is; leave must be called once it is done.
+func (w *exampleWalk) enter(v reflect.Value) bool {
+	ref := exampleRef{v.Pointer(), v.Type()}
+	if w.refs[ref] {
+		return false
+	}
+	if w.refs == nil {
+		w.refs = map[exampleRef]bool{}
+	}
+	w.refs[ref] = true
+	return true
+}
+
+func (w *exampleWalk) leave(v reflect.Value) {
+	delete(w.refs, exampleRef{v.Pointer(), v.Type()})
+}
+
+// schemaOf returns the schema for v.
+func schemaOf(v reflect.Value, w *exampleWalk) map[string]any {
+	if !v.IsValid() {
+		return map[string]any{}
+	}
+	t := v.Type()
+	if t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface &&
+		(t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType)) {
+		return schemaOfMarshaled(v)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || !w.enter(v) {
+			return schemaOf(reflect.New(t.Elem()).Elem(), w)
+		}
+		defer w.leave(v)
+		return schemaOf(v.Elem(), w)
+	case reflect.Interface:
+		if v.IsNil() {
+			return map[string]any{}
+		}
+		return schemaOf(v.Elem(), w)
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return map[string]any{"type": "string"}
+		}
+		elem := reflect.New(t.Elem()).Elem()
+		if v.Len() > 0 && (t.Kind() == reflect.Array || w.enter(v)) {
+			if t.Kind() == reflect.Slice {
+				defer w.leave(v)
+			}
+			elem = v.Index(0)
+		}
+		return map[string]any{"type": "array", "items": schemaOf(elem, w)}
+	case reflect.Map:
+		elem := reflect.New(t.Elem()).Elem()
+		if v.Len() > 0 && w.enter(v) {
+			defer w.leave(v)
+			keys := v.MapKeys()
+			sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+			elem = v.MapIndex(keys[0])
+		}
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(elem, w)}
+	case reflect.Struct:
+		return schemaOfStruct(v, w)
+	}
+	return map[string]any{}
+}
+
+func schemaOfStruct(v reflect.Value, w *exampleWalk) map[string]any {
+	t := v.Type()
+	if v.IsZero() {
+		if w.types[t] {
+			return map[string]any{}
+		}
+		if w.types == nil {
+			w.types = map[reflect.Type]bool{}
+		}
+		w.types[t] = true
+		defer delete(w.types, t)
+	}
+
+	// Promoted fields of embedded structs lose to shallower ones of the same
+	// name, as in encoding/json.
+	type field struct {
+		schema   map[string]any
+		optional bool
+		depth    int
+	}
+	fields := map[string]field{}
+	var walk func(v reflect.Value, depth int)
+	walk = func(v reflect.Value, depth int) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			fv := v.Field(i)
+			if f.Anonymous && name == "" {
+				ft := f.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+					if fv.IsNil() {
+						fv = reflect.New(ft).Elem()
+					} else {
+						fv = fv.Elem()
+					}
+				}
+				if ft.Kind() == reflect.Struct {
+					walk(fv, depth+1)
+					continue
+				}
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			if prev, ok := fields[name]; ok && prev.depth <= depth {
+				continue
+			}
+			s := schemaOf(fv, w)
+			opts = "," + opts + ","
+			optional := strings.Contains(opts, ",omitempty,") || strings.Contains(opts, ",omitzero,")
+			if f.Type.Kind() == reflect.Pointer {
+				optional = true
+				if typ, ok := s["type"].(string); ok {
+					s["type"] = []string{typ, "null"}
+				}
+			}
+			fields[name] = field{schema: s, optional: optional, depth: depth}
+		}
+	}
+	walk(v, 0)
+
+	props := map[string]any{}
+	required := []string{}
+	for name, f := range fields {
+		props[name] = f.schema
+		if !f.optional {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// schemaOfMarshaled types a value with a custom marshaler by its JSON form.
+func schemaOfMarshaled(v reflect.Value) map[string]any {
+	if !v.CanInterface() {
+		// Reached through an unexported embedded struct; its type's zero
+		// value marshals to the same kind of JSON.
+		v = reflect.New(v.Type()).Elem()
+	}
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return map[string]any{}
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return map[string]any{}
+	}
+	return schemaOfJSON(doc)
+}
+
+func schemaOfJSON(doc any) map[string]any {
+	switch d := doc.(type) {
+	case bool:
+		return map[string]any{"type": "boolean"}
+	case string:
+		return map[string]any{"type": "string"}
+	case json.Number:
+		if strings.ContainsAny(d.String(), ".eE") {
+			return map[string]any{"type": "number"}
+		}
+		return map[string]any{"type": "integer"}
+	case []any:
+		var items map[string]any
+		if len(d) > 0 {
+			items = schemaOfJSON(d[0])
+		} else {
+			items = map[string]any{}
+		}
+		return map[string]any{"type": "array", "items": items}
+	case map[string]any:
+		props := map[string]any{}
+		required := make([]string, 0, len(d))
+		for k, e := range d {
+			props[k] = schemaOfJSON(e)
+			required = append(required, k)
+		}
+		sort.Strings(required)
+		return map[string]any{"type": "object", "properties": props, "required": required, "additionalProperties": false}
+	}
+	return map[string]any{}
+}
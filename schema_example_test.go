@@ -0,0 +1,189 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitop-dev/ai/internal/provider"
+	internalSchema "github.com/bitop-dev/ai/internal/schema"
+)
+
+type exampleAudit struct {
+	CreatedBy string `json:"created_by"`
+	Note      string `json:"note"`
+}
+
+type exampleNode struct {
+	Name     string         `json:"name"`
+	Children []*exampleNode `json:"children,omitempty"`
+}
+
+type exampleRecipe struct {
+	exampleAudit
+	Note        string            `json:"note,omitempty"`
+	Title       string            `json:"title"`
+	Servings    int               `json:"servings"`
+	Rating      float64           `json:"rating"`
+	Vegetarian  bool              `json:"vegetarian"`
+	Ingredients []exampleItem     `json:"ingredients"`
+	Tags        []string          `json:"tags"`
+	Nutrition   map[string]int    `json:"nutrition"`
+	Source      *string           `json:"source"`
+	Published   time.Time         `json:"published"`
+	Extra       any               `json:"extra,omitempty"`
+	Labels      map[string]string `json:"-"`
+	internal    int
+}
+
+type exampleItem struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+}
+
+func TestSchemaFromExample(t *testing.T) {
+	ex := exampleRecipe{
+		Title:       "Pancakes",
+		Ingredients: []exampleItem{{Name: "flour", Amount: 200}},
+		Tags:        []string{"breakfast"},
+		Nutrition:   map[string]int{"kcal": 250},
+	}
+	var got map[string]any
+	if err := json.Unmarshal(SchemaFromExample(ex).JSON, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"created_by": map[string]any{"type": "string"},
+			"note":       map[string]any{"type": "string"},
+			"title":      map[string]any{"type": "string"},
+			"servings":   map[string]any{"type": "integer"},
+			"rating":     map[string]any{"type": "number"},
+			"vegetarian": map[string]any{"type": "boolean"},
+			"ingredients": map[string]any{"type": "array", "items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name":   map[string]any{"type": "string"},
+					"amount": map[string]any{"type": "number"},
+				},
+				"required":             []any{"amount", "name"},
+				"additionalProperties": false,
+			}},
+			"tags":      map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"nutrition": map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "integer"}},
+			"source":    map[string]any{"type": []any{"string", "null"}},
+			"published": map[string]any{"type": "string"},
+			"extra":     map[string]any{},
+		},
+		"required": []any{
+			"created_by", "ingredients", "nutrition", "published", "rating",
+			"servings", "tags", "title", "vegetarian",
+		},
+		"additionalProperties": false,
+	}
+	if !reflect.DeepEqual(got, want) {
+		b, _ := json.MarshalIndent(got, "", "  ")
+		t.Fatalf("schema:\n%s", b)
+	}
+
+	// The derived schema accepts the example itself.
+	raw, _ := json.Marshal(ex)
+	if err := internalSchema.Validate(SchemaFromExample(ex).JSON, raw); err != nil {
+		t.Fatalf("example does not validate: %v", err)
+	}
+}
+
+func TestSchemaFromExample_Recursive(t *testing.T) {
+	var got map[string]any
+	if err := json.Unmarshal(SchemaFromExample(exampleNode{}).JSON, &got); err != nil {
+		t.Fatal(err)
+	}
+	children := got["properties"].(map[string]any)["children"].(map[string]any)
+	if children["type"] != "array" || !reflect.DeepEqual(children["items"], map[string]any{}) {
+		t.Fatalf("children=%v", children)
+	}
+
+	// A populated example is followed as deep as it goes.
+	deep := exampleNode{Name: "a", Children: []*exampleNode{{Name: "b"}}}
+	if err := json.Unmarshal(SchemaFromExample(deep).JSON, &got); err != nil {
+		t.Fatal(err)
+	}
+	items := got["properties"].(map[string]any)["children"].(map[string]any)["items"].(map[string]any)
+	if items["type"] != "object" {
+		t.Fatalf("items=%v", items)
+	}
+}
+
+func TestSchemaFromExample_CyclicValues(t *testing.T) {
+	type node struct {
+		Name string `json:"name"`
+		Next *node  `json:"next"`
+	}
+	n := &node{Name: "a"}
+	n.Next = &node{Name: "b", Next: n}
+	var got map[string]any
+	if err := json.Unmarshal(SchemaFromExample(n).JSON, &got); err != nil {
+		t.Fatal(err)
+	}
+	// a -> b is followed; b -> a is typed from node, whose own Next is left
+	// unconstrained.
+	next := got["properties"].(map[string]any)["next"].(map[string]any)
+	again := next["properties"].(map[string]any)["next"].(map[string]any)
+	if again["type"] == nil || !reflect.DeepEqual(again["properties"].(map[string]any)["next"], map[string]any{}) {
+		t.Fatalf("next.next=%v", again)
+	}
+
+	m := map[string]any{}
+	m["self"] = m
+	s := []any{nil}
+	s[0] = s
+	for _, v := range []any{m, s} {
+		if !json.Valid(SchemaFromExample(v).JSON) {
+			t.Fatalf("invalid schema for %T", v)
+		}
+	}
+}
+
+func TestGenerateObject_SchemaFromExample(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		return provider.Response{
+			Message: provider.Message{
+				Role: provider.RoleAssistant,
+				Content: []provider.ContentPart{
+					provider.ToolCallPart{ID: "c1", Name: "__ai_return_json", Args: []byte(`{"name":"flour","amount":2.5}`)},
+				},
+			},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	resp, err := GenerateObject[exampleItem](context.Background(), GenerateObjectRequest[exampleItem]{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("one ingredient")},
+		},
+		Example: &exampleItem{Name: "sugar", Amount: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Object.Name != "flour" || resp.Object.Amount != 2.5 {
+		t.Fatalf("object=%+v", resp.Object)
+	}
+	reqs := fp.Requests()
+	if len(reqs) != 1 || len(reqs[0].Tools) != 1 {
+		t.Fatalf("requests=%+v", reqs)
+	}
+	var s map[string]any
+	if err := json.Unmarshal(reqs[0].Tools[0].InputSchema, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s["additionalProperties"] != false || len(s["required"].([]any)) != 2 {
+		t.Fatalf("schema=%v", s)
+	}
+}
@@ -0,0 +1,50 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DecodeUnion decodes a JSON object into one of several Go types, chosen by
+// the string value of its discriminator field. variants maps each
+// discriminator value to a constructor returning a pointer to decode into,
+// typically as values of a shared interface:
+//
+//	type Action interface{ isAction() }
+//
+//	func (a *ActionResult) UnmarshalJSON(b []byte) error {
+//		v, err := ai.DecodeUnion[Action](b, "type", map[string]func() Action{
+//			"search": func() Action { return &Search{} },
+//			"answer": func() Action { return &Answer{} },
+//		})
+//		a.Action = v
+//		return err
+//	}
+//
+// It is meant for GenerateObject/StreamObject results whose schema is a
+// oneOf/anyOf union of object schemas; T itself cannot be an interface, so
+// wrap it in a struct with an UnmarshalJSON like the one above.
+func DecodeUnion[T any](raw []byte, discriminator string, variants map[string]func() T) (T, error) {
+	var zero T
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return zero, fmt.Errorf("decode union: %w", err)
+	}
+	d, ok := fields[discriminator]
+	if !ok {
+		return zero, fmt.Errorf("decode union: missing discriminator %q", discriminator)
+	}
+	var name string
+	if err := json.Unmarshal(d, &name); err != nil {
+		return zero, fmt.Errorf("decode union: discriminator %q must be a string", discriminator)
+	}
+	newVariant, ok := variants[name]
+	if !ok {
+		return zero, fmt.Errorf("decode union: unknown %s %q", discriminator, name)
+	}
+	v := newVariant()
+	if err := json.Unmarshal(raw, any(v)); err != nil {
+		return zero, fmt.Errorf("decode union %s %q: %w", discriminator, name, err)
+	}
+	return v, nil
+}
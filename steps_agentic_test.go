@@ -3,7 +3,10 @@ package ai
 import (
 	"context"
 	"encoding/json"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/bitop-dev/ai/internal/provider"
 )
@@ -164,3 +167,279 @@ func TestGenerateText_StopWhen(t *testing.T) {
 		t.Fatalf("FinishReason=%q", got)
 	}
 }
+
+func TestGenerateText_StepToolTimings(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		if call == 0 {
+			return provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.ToolCallPart{ID: "call_1", Name: "slow", Args: []byte(`{}`)}},
+				},
+				FinishReason: "tool_calls",
+			}, nil
+		}
+		return provider.Response{
+			Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "ok"}}},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	var onFinish []Step
+	resp, err := GenerateText(context.Background(), GenerateTextRequest{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("go")},
+			Tools: []Tool{{
+				Name: "slow",
+				Handler: func(ctx context.Context, input json.RawMessage) (any, error) {
+					time.Sleep(5 * time.Millisecond)
+					return "done", nil
+				},
+			}},
+			OnStepFinish: func(event StepFinishEvent) { onFinish = append(onFinish, event.Step) },
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := resp.Steps[0].ToolTimings["call_1"]; d < 5*time.Millisecond {
+		t.Fatalf("step timing=%v", d)
+	}
+	if d := onFinish[0].ToolTimings["call_1"]; d < 5*time.Millisecond {
+		t.Fatalf("OnStepFinish timing=%v", d)
+	}
+	if resp.Steps[1].ToolTimings != nil {
+		t.Fatalf("expected no timings for text step")
+	}
+}
+
+func TestGenerateText_StopWhenTextContains(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		text := "working"
+		if call == 1 {
+			text = "all DONE"
+		}
+		if call > 1 {
+			t.Fatalf("unexpected call %d", call)
+		}
+		return provider.Response{
+			Message: provider.Message{
+				Role: provider.RoleAssistant,
+				Content: []provider.ContentPart{
+					provider.TextPart{Text: text},
+					provider.ToolCallPart{ID: "call", Name: "noop", Args: []byte(`{}`)},
+				},
+			},
+			FinishReason: "tool_calls",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	resp, err := GenerateText(context.Background(), GenerateTextRequest{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("go")},
+			Tools: []Tool{{
+				Name:    "noop",
+				Handler: func(ctx context.Context, input json.RawMessage) (any, error) { return "ok", nil },
+			}},
+			ToolLoop: &ToolLoopOptions{MaxIterations: 10, StopWhen: TextContains("DONE")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Steps) != 2 {
+		t.Fatalf("Steps=%d", len(resp.Steps))
+	}
+}
+
+func TestTextMatches(t *testing.T) {
+	cond := TextMatches(regexp.MustCompile(`(?i)\bfinished\b`))
+	if cond(StopConditionEvent{}) {
+		t.Fatalf("expected false without steps")
+	}
+	if !cond(StopConditionEvent{Steps: []Step{{Text: "x"}, {Text: "Task Finished."}}}) {
+		t.Fatalf("expected match on latest step")
+	}
+	if cond(StopConditionEvent{Steps: []Step{{Text: "finished"}, {Text: "more"}}}) {
+		t.Fatalf("expected only the latest step to be inspected")
+	}
+}
+
+func TestGenerateText_StopWhenEveryStep(t *testing.T) {
+	replies := []string{"thinking", "still thinking", "FINAL: 42"}
+	textReply := func(call int) provider.Response {
+		return provider.Response{
+			Message: provider.Message{
+				Role:    provider.RoleAssistant,
+				Content: []provider.ContentPart{provider.TextPart{Text: replies[call]}},
+			},
+			FinishReason: "stop",
+		}
+	}
+
+	t.Run("default stops after text-only step", func(t *testing.T) {
+		fp := &fakeProvider{}
+		fp.generate = func(call int, req provider.Request) (provider.Response, error) { return textReply(call), nil }
+		providerName := registerFakeProvider(t, fp)
+
+		resp, err := GenerateText(context.Background(), GenerateTextRequest{
+			BaseRequest: BaseRequest{
+				Model:    testModel{provider: providerName, name: "m"},
+				Messages: []Message{User("go")},
+				ToolLoop: &ToolLoopOptions{MaxIterations: 10, StopWhen: TextContains("FINAL:")},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text != "thinking" || len(fp.Requests()) != 1 {
+			t.Fatalf("Text=%q calls=%d", resp.Text, len(fp.Requests()))
+		}
+	})
+
+	t.Run("continues until condition", func(t *testing.T) {
+		fp := &fakeProvider{}
+		fp.generate = func(call int, req provider.Request) (provider.Response, error) { return textReply(call), nil }
+		providerName := registerFakeProvider(t, fp)
+
+		resp, err := GenerateText(context.Background(), GenerateTextRequest{
+			BaseRequest: BaseRequest{
+				Model:    testModel{provider: providerName, name: "m"},
+				Messages: []Message{User("go")},
+				ToolLoop: &ToolLoopOptions{MaxIterations: 10, StopWhen: TextContains("FINAL:"), StopWhenEveryStep: true},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text != "FINAL: 42" || len(resp.Steps) != 3 {
+			t.Fatalf("Text=%q Steps=%d", resp.Text, len(resp.Steps))
+		}
+		reqs := fp.Requests()
+		if got := len(reqs[2].Messages); got != 3 {
+			t.Fatalf("third step messages=%d, want prior assistant text kept", got)
+		}
+	})
+
+	t.Run("returns last step at max iterations", func(t *testing.T) {
+		fp := &fakeProvider{}
+		fp.generate = func(call int, req provider.Request) (provider.Response, error) { return textReply(call), nil }
+		providerName := registerFakeProvider(t, fp)
+
+		resp, err := GenerateText(context.Background(), GenerateTextRequest{
+			BaseRequest: BaseRequest{
+				Model:    testModel{provider: providerName, name: "m"},
+				Messages: []Message{User("go")},
+				ToolLoop: &ToolLoopOptions{MaxIterations: 2, StopWhen: TextContains("FINAL:"), StopWhenEveryStep: true},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text != "still thinking" || len(resp.Steps) != 2 {
+			t.Fatalf("Text=%q Steps=%d", resp.Text, len(resp.Steps))
+		}
+	})
+}
+
+func TestStreamText_StopWhenEveryStep(t *testing.T) {
+	replies := []string{"draft", "FINAL: ok"}
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		return &fakeStream{
+			deltas: []provider.Delta{{Text: replies[call]}},
+			final: &provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.TextPart{Text: replies[call]}},
+				},
+				FinishReason: "stop",
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	stream, err := StreamText(context.Background(), StreamTextRequest{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("go")},
+			ToolLoop: &ToolLoopOptions{MaxIterations: 5, StopWhen: TextContains("FINAL:"), StopWhenEveryStep: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var deltas []string
+	for stream.Next() {
+		deltas = append(deltas, stream.Delta())
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(deltas) != 2 || len(stream.Steps()) != 2 {
+		t.Fatalf("deltas=%q steps=%d", deltas, len(stream.Steps()))
+	}
+}
+
+func TestStreamText_OnStepToolCalls(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		if call == 0 {
+			return &fakeStream{final: &provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.ToolCallPart{ID: "c1", Name: "lookup", Args: json.RawMessage(`{}`)}},
+				},
+				FinishReason: "tool_calls",
+			}}, nil
+		}
+		return &fakeStream{
+			deltas: []provider.Delta{{Text: "done"}},
+			final: &provider.Response{
+				Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "done"}}},
+				FinishReason: "stop",
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	var events []string
+	stream, err := StreamText(context.Background(), StreamTextRequest{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("go")},
+			Tools: []Tool{{
+				Name:        "lookup",
+				InputSchema: JSONSchema([]byte(`{"type":"object"}`)),
+				Handler: func(ctx context.Context, input json.RawMessage) (any, error) {
+					events = append(events, "run")
+					return "ok", nil
+				},
+			}},
+			OnStepToolCalls: func(e StepToolCallsEvent) {
+				if len(e.Step.ToolCalls) != 1 || e.Step.ToolCalls[0].ID != "c1" || len(e.Step.ToolResults) != 0 {
+					t.Errorf("step=%+v", e.Step)
+				}
+				events = append(events, "calls")
+			},
+			OnStepFinish: func(e StepFinishEvent) { events = append(events, "finish") },
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for stream.Next() {
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(events, ","); got != "calls,run,finish,finish" {
+		t.Fatalf("events=%s", got)
+	}
+}
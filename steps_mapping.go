@@ -1,6 +1,9 @@
 package ai
 
 import (
+	"sync"
+	"time"
+
 	"github.com/bitop-dev/ai/internal/provider"
 	internalText "github.com/bitop-dev/ai/internal/text"
 )
@@ -37,13 +40,50 @@ func stepFromProviderStep(s internalText.Step) (Step, error) {
 	}, nil
 }
 
-func stepsFromProviderSteps(steps []internalText.Step) ([]Step, error) {
+// toolTimings records tool execution durations (by tool call id) for a single
+// request and attaches them to the public steps.
+type toolTimings struct {
+	mu   sync.Mutex
+	byID map[string]time.Duration
+}
+
+func newToolTimings() *toolTimings {
+	return &toolTimings{byID: map[string]time.Duration{}}
+}
+
+func (t *toolTimings) record(toolCallID string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.byID[toolCallID] = d
+}
+
+func (t *toolTimings) step(s internalText.Step) (Step, error) {
+	step, err := stepFromProviderStep(s)
+	if err != nil || t == nil {
+		return step, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, r := range s.ToolResults {
+		d, ok := t.byID[r.ToolCallID]
+		if !ok {
+			continue
+		}
+		if step.ToolTimings == nil {
+			step.ToolTimings = map[string]time.Duration{}
+		}
+		step.ToolTimings[r.ToolCallID] = d
+	}
+	return step, nil
+}
+
+func (t *toolTimings) steps(steps []internalText.Step) ([]Step, error) {
 	if len(steps) == 0 {
 		return nil, nil
 	}
 	out := make([]Step, 0, len(steps))
 	for _, s := range steps {
-		step, err := stepFromProviderStep(s)
+		step, err := t.step(s)
 		if err != nil {
 			return nil, err
 		}
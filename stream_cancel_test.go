@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// cancelingStream yields its deltas, then cancels the context and fails like a
+// provider stream interrupted mid-flight.
+type cancelingStream struct {
+	deltas []provider.Delta
+	cancel context.CancelFunc
+	i      int
+	err    error
+}
+
+func (s *cancelingStream) Next() bool {
+	if s.i >= len(s.deltas) {
+		s.cancel()
+		s.err = context.Canceled
+		return false
+	}
+	s.i++
+	return true
+}
+func (s *cancelingStream) Delta() provider.Delta     { return s.deltas[s.i-1] }
+func (s *cancelingStream) Final() *provider.Response { return nil }
+func (s *cancelingStream) Err() error                { return s.err }
+func (s *cancelingStream) Close() error              { return nil }
+
+func TestStreamText_CancelFlushesPartialMessage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		_ = req
+		if call != 0 {
+			t.Fatalf("unexpected stream call %d", call)
+		}
+		return &cancelingStream{
+			deltas: []provider.Delta{{Text: "Hello, "}, {Text: "wor"}},
+			cancel: cancel,
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	stream, err := StreamText(ctx, StreamTextRequest{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("hi")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	for stream.Next() {
+	}
+	if !IsCanceled(stream.Err()) {
+		t.Fatalf("Err=%v", stream.Err())
+	}
+	m := stream.Message()
+	if m == nil {
+		t.Fatal("expected partial message")
+	}
+	if got := extractTextFromMessage(*m); got != "Hello, wor" {
+		t.Fatalf("partial=%q", got)
+	}
+	if got := len(stream.Steps()); got != 0 {
+		t.Fatalf("steps=%d", got)
+	}
+	if got := stream.FinishReason(); got != FinishUnknown {
+		t.Fatalf("FinishReason=%q", got)
+	}
+}
@@ -0,0 +1,118 @@
+package ai
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// coalesceTextStream merges the deltas of inner that arrive within window of
+// the first buffered one. inner.Next runs on a helper goroutine so a stalled
+// upstream (e.g. a tool call in progress) does not hold back buffered text;
+// at most one such call is in flight at a time. The other accessors wait for
+// it, as inner is not safe for concurrent use. interrupt cancels the context
+// inner reads with, so Close can cut such a call short.
+func coalesceTextStream(inner *TextStream, window time.Duration, interrupt func()) *TextStream {
+	c := &deltaCoalescer{inner: inner, window: window, interrupt: interrupt}
+	return newTextStream(
+		c.next,
+		func() string { return c.cur },
+		lockedCall(&c.mu, inner.Message),
+		lockedCall(&c.mu, inner.Usage),
+		lockedCall(&c.mu, inner.FinishReason),
+		lockedCall(&c.mu, inner.Steps),
+		lockedCall(&c.mu, inner.Response),
+		lockedCall(&c.mu, inner.streamInfo),
+		lockedCall(&c.mu, inner.Err),
+		c.close,
+	)
+}
+
+type deltaCoalescer struct {
+	inner     *TextStream
+	window    time.Duration
+	interrupt func()
+
+	// mu is held while inner is in use, by the helper goroutine for the
+	// duration of inner.Next.
+	mu      sync.Mutex
+	pending chan coalescedDelta // result of the in-flight inner.Next, if any
+	done    bool
+	cur     string
+}
+
+type coalescedDelta struct {
+	ok    bool
+	delta string
+}
+
+func (c *deltaCoalescer) next() bool {
+	c.cur = ""
+	if c.done {
+		return false
+	}
+	var buf strings.Builder
+	var expired <-chan time.Time
+	for {
+		if c.pending == nil {
+			ch := make(chan coalescedDelta, 1)
+			c.pending = ch
+			go func() {
+				c.mu.Lock()
+				var d coalescedDelta
+				if d.ok = c.inner.Next(); d.ok {
+					d.delta = c.inner.Delta()
+				}
+				c.mu.Unlock()
+				ch <- d
+			}()
+		}
+		select {
+		case d := <-c.pending:
+			c.pending = nil
+			if !d.ok {
+				c.done = true
+				c.cur = buf.String()
+				return c.cur != ""
+			}
+			buf.WriteString(d.delta)
+			if expired == nil {
+				t := time.NewTimer(c.window)
+				defer t.Stop()
+				expired = t.C
+			}
+		case <-expired:
+			c.cur = buf.String()
+			return true
+		}
+	}
+}
+
+// close interrupts the in-flight inner.Next, if any, and returns without
+// waiting for it: inner is then closed by a goroutine once that call is done,
+// so a stalled upstream cannot hold up Close.
+func (c *deltaCoalescer) close() error {
+	c.done = true
+	c.interrupt()
+	if ch := c.pending; ch != nil {
+		c.pending = nil
+		go func() {
+			<-ch
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			_ = c.inner.Close()
+		}()
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.inner.Close()
+}
+
+func lockedCall[T any](mu *sync.Mutex, f func() T) func() T {
+	return func() T {
+		mu.Lock()
+		defer mu.Unlock()
+		return f()
+	}
+}
@@ -0,0 +1,188 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// gatedStream blocks before delta index gateAt until gate is closed.
+type gatedStream struct {
+	fakeStream
+	gateAt int
+	gate   chan struct{}
+}
+
+func (s *gatedStream) Next() bool {
+	if s.i == s.gateAt {
+		<-s.gate
+	}
+	return s.fakeStream.Next()
+}
+
+func TestStreamText_CoalesceDeltas(t *testing.T) {
+	gate := make(chan struct{})
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		return &gatedStream{
+			fakeStream: fakeStream{
+				deltas: []provider.Delta{{Text: "H"}, {Text: "e"}, {Text: "l"}, {Text: "lo", Usage: &provider.Usage{CompletionTokens: 4}}, {Text: "!"}},
+				final: &provider.Response{
+					Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "Hello!"}}},
+					FinishReason: "stop",
+				},
+			},
+			gateAt: 3,
+			gate:   gate,
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	stream, err := StreamText(context.Background(), StreamTextRequest{
+		BaseRequest: BaseRequest{
+			Model:          testModel{provider: providerName, name: "m"},
+			Messages:       []Message{User("hi")},
+			CoalesceDeltas: 10 * time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var got []string
+	for stream.Next() {
+		got = append(got, stream.Delta())
+		if len(got) == 1 {
+			close(gate)
+		}
+		// Accessors are safe to call while the next delta is being read.
+		_ = stream.Usage()
+		_ = stream.Steps()
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	// The first window is cut short by the stalled upstream; the rest is
+	// flushed when the stream ends.
+	if want := []string{"Hel", "lo!"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("deltas=%q want %q", got, want)
+	}
+	if m := stream.Message(); m == nil || extractTextFromMessage(*m) != "Hello!" {
+		t.Fatalf("Message=%#v", m)
+	}
+}
+
+func TestStreamText_CoalesceDeltasCloseMidStream(t *testing.T) {
+	gate := make(chan struct{})
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		return &gatedStream{
+			fakeStream: fakeStream{deltas: []provider.Delta{{Text: "a"}, {Text: "b"}}},
+			gateAt:     1,
+			gate:       gate,
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	stream, err := StreamText(context.Background(), StreamTextRequest{
+		BaseRequest: BaseRequest{
+			Model:          testModel{provider: providerName, name: "m"},
+			Messages:       []Message{User("hi")},
+			CoalesceDeltas: time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stream.Next() || stream.Delta() != "a" {
+		t.Fatalf("Delta=%q", stream.Delta())
+	}
+	// The helper goroutine is blocked reading "b"; Close does not wait for it.
+	closed := make(chan error, 1)
+	go func() { closed <- stream.Close() }()
+	close(gate)
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// stallingStream yields one delta, then blocks in Next until release is
+// closed, ignoring its context. It reports the context's cancellation and its
+// own Close.
+type stallingStream struct {
+	ctx      context.Context
+	n        int
+	release  chan struct{}
+	canceled chan struct{}
+	closed   chan struct{}
+}
+
+func (s *stallingStream) Next() bool {
+	s.n++
+	if s.n == 1 {
+		return true
+	}
+	go func() {
+		<-s.ctx.Done()
+		close(s.canceled)
+	}()
+	<-s.release
+	return false
+}
+
+func (s *stallingStream) Delta() provider.Delta     { return provider.Delta{Text: "a"} }
+func (s *stallingStream) Final() *provider.Response { return nil }
+func (s *stallingStream) Err() error                { return s.ctx.Err() }
+func (s *stallingStream) Close() error {
+	close(s.closed)
+	return nil
+}
+
+type stallingProvider struct{ stream *stallingStream }
+
+func (p *stallingProvider) Generate(ctx context.Context, req provider.Request) (provider.Response, error) {
+	return provider.Response{}, errors.New("not implemented")
+}
+
+func (p *stallingProvider) Stream(ctx context.Context, req provider.Request) (provider.Stream, error) {
+	p.stream.ctx = ctx
+	return p.stream, nil
+}
+
+func TestStreamText_CoalesceDeltasCloseStalledUpstream(t *testing.T) {
+	inner := &stallingStream{release: make(chan struct{}), canceled: make(chan struct{}), closed: make(chan struct{})}
+	t.Cleanup(func() { close(inner.release) })
+	providerName := registerFakeProvider(t, &stallingProvider{stream: inner})
+
+	stream, err := StreamText(context.Background(), StreamTextRequest{
+		BaseRequest: BaseRequest{
+			Model:          testModel{provider: providerName, name: "m"},
+			Messages:       []Message{User("hi")},
+			CoalesceDeltas: time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stream.Next() || stream.Delta() != "a" {
+		t.Fatalf("Delta=%q", stream.Delta())
+	}
+	// The helper goroutine is now stuck in the upstream's Next; Close must
+	// return anyway and cancel the upstream's context.
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	<-inner.canceled
+	if stream.Next() {
+		t.Fatal("Next after Close")
+	}
+
+	// Once the stalled read returns, the upstream is closed.
+	inner.release <- struct{}{}
+	<-inner.closed
+}
@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// endlessStream repeats text until closed, like a model stuck in a loop.
+type endlessStream struct {
+	text   string
+	next   int
+	closed bool
+}
+
+func (s *endlessStream) Next() bool {
+	if s.closed {
+		return false
+	}
+	s.next++
+	return true
+}
+func (s *endlessStream) Delta() provider.Delta     { return provider.Delta{Text: s.text} }
+func (s *endlessStream) Final() *provider.Response { return nil }
+func (s *endlessStream) Err() error                { return nil }
+func (s *endlessStream) Close() error              { s.closed = true; return nil }
+
+func TestStreamText_MaxOutputChars(t *testing.T) {
+	upstream := &endlessStream{text: "héllo "}
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		if call != 0 {
+			t.Fatalf("unexpected stream call %d", call)
+		}
+		return upstream, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	stream, err := StreamText(context.Background(), StreamTextRequest{
+		BaseRequest: BaseRequest{
+			Model:          testModel{provider: providerName, name: "m"},
+			Messages:       []Message{User("loop")},
+			MaxOutputChars: 15,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var sb strings.Builder
+	for stream.Next() {
+		sb.WriteString(stream.Delta())
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sb.String(); got != "héllo héllo hél" {
+		t.Fatalf("streamed=%q", got)
+	}
+	if !upstream.closed {
+		t.Fatalf("upstream not closed")
+	}
+	if got := stream.FinishReason(); got != FinishLength {
+		t.Fatalf("FinishReason=%q", got)
+	}
+	if m := stream.Message(); m == nil || extractTextFromMessage(*m) != "héllo héllo hél" {
+		t.Fatalf("Message=%#v", m)
+	}
+	if got := len(stream.Steps()); got != 1 {
+		t.Fatalf("steps=%d", got)
+	}
+}
@@ -0,0 +1,239 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// droppingStream yields its deltas and then fails, like a connection that
+// drops mid-generation.
+type droppingStream struct {
+	deltas []provider.Delta
+	err    error
+	i      int
+}
+
+func (s *droppingStream) Next() bool {
+	if s.i >= len(s.deltas) {
+		return false
+	}
+	s.i++
+	return true
+}
+func (s *droppingStream) Delta() provider.Delta     { return s.deltas[s.i-1] }
+func (s *droppingStream) Final() *provider.Response { return nil }
+func (s *droppingStream) Err() error {
+	if s.i >= len(s.deltas) {
+		return s.err
+	}
+	return nil
+}
+func (s *droppingStream) Close() error { return nil }
+
+func TestStreamText_HadOutput(t *testing.T) {
+	drop := &provider.Error{Provider: "fake", Code: "network_error", Message: "connection reset"}
+	for _, tc := range []struct {
+		name   string
+		deltas []provider.Delta
+		want   bool
+	}{
+		{"before output", nil, false},
+		{"tool call deltas only", []provider.Delta{{ToolCalls: []provider.ToolCallDelta{{Index: 0, ID: "c1", Name: "t"}}}}, false},
+		{"mid-stream", []provider.Delta{{Text: "Once upon "}}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+				return &droppingStream{deltas: tc.deltas, err: drop}, nil
+			}
+			providerName := registerFakeProvider(t, fp)
+
+			stream, err := StreamText(context.Background(), StreamTextRequest{
+				BaseRequest: BaseRequest{Model: testModel{provider: providerName, name: "m"}, Messages: []Message{User("story")}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stream.Close()
+			if stream.HadOutput() {
+				t.Fatal("HadOutput before Next")
+			}
+			for stream.Next() {
+			}
+			if stream.Err() == nil {
+				t.Fatal("expected error")
+			}
+			if stream.HadOutput() != tc.want {
+				t.Fatalf("HadOutput=%v", stream.HadOutput())
+			}
+		})
+	}
+}
+
+func TestStreamText_ResumeOnDisconnect(t *testing.T) {
+	drop := &provider.Error{Provider: "fake", Code: "network_error", Message: "connection reset", Retryable: true}
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		switch call {
+		case 0:
+			return &droppingStream{deltas: []provider.Delta{{Text: "Once upon "}}, err: drop}, nil
+		case 1:
+			return &droppingStream{deltas: []provider.Delta{{Text: "a "}}, err: drop}, nil
+		}
+		return &fakeStream{
+			deltas: []provider.Delta{{Text: "time."}},
+			final: &provider.Response{
+				Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "time."}}},
+				FinishReason: "stop",
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	stream, err := StreamText(context.Background(), StreamTextRequest{
+		BaseRequest: BaseRequest{
+			Model:              testModel{provider: providerName, name: "m"},
+			Messages:           []Message{User("story")},
+			ResumeOnDisconnect: true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var sb strings.Builder
+	for stream.Next() {
+		sb.WriteString(stream.Delta())
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sb.String(); got != "Once upon a time." {
+		t.Fatalf("streamed=%q", got)
+	}
+	if m := stream.Message(); m == nil || extractTextFromMessage(*m) != "Once upon a time." {
+		t.Fatalf("Message=%#v", m)
+	}
+
+	reqs := fp.Requests()
+	if len(reqs) != 3 {
+		t.Fatalf("stream calls=%d", len(reqs))
+	}
+	if reqs[0].AssistantPrefix != "" || reqs[1].AssistantPrefix != "Once upon " || reqs[2].AssistantPrefix != "Once upon a " {
+		t.Fatalf("prefixes=%q, %q, %q", reqs[0].AssistantPrefix, reqs[1].AssistantPrefix, reqs[2].AssistantPrefix)
+	}
+	if len(reqs[2].Messages) != len(reqs[0].Messages) {
+		t.Fatalf("resumed request messages=%d, want %d", len(reqs[2].Messages), len(reqs[0].Messages))
+	}
+}
+
+func TestStreamText_ResumeOnDisconnectSkipsPermanentErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		resume bool
+		err    error
+	}{
+		"disabled":  {resume: false, err: &provider.Error{Provider: "fake", Code: "network_error", Retryable: true}},
+		"permanent": {resume: true, err: &provider.Error{Provider: "fake", Code: "decode_error", Retryable: false}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fp := &fakeProvider{}
+			fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+				return &droppingStream{deltas: []provider.Delta{{Text: "partial"}}, err: tc.err}, nil
+			}
+			providerName := registerFakeProvider(t, fp)
+
+			stream, err := StreamText(context.Background(), StreamTextRequest{
+				BaseRequest: BaseRequest{
+					Model:              testModel{provider: providerName, name: "m"},
+					Messages:           []Message{User("story")},
+					ResumeOnDisconnect: tc.resume,
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stream.Close()
+			for stream.Next() {
+			}
+			if stream.Err() == nil {
+				t.Fatalf("expected error")
+			}
+			if n := len(fp.Requests()); n != 1 {
+				t.Fatalf("stream calls=%d", n)
+			}
+		})
+	}
+}
+
+func TestStreamText_RetryEmptyStream(t *testing.T) {
+	empty := func(call int, req provider.Request) (provider.Stream, error) {
+		if call < 2 {
+			return &fakeStream{final: &provider.Response{
+				Message:      provider.Message{Role: provider.RoleAssistant},
+				FinishReason: "length",
+				Usage:        provider.Usage{PromptTokens: 4, TotalTokens: 4},
+			}}, nil
+		}
+		return &fakeStream{
+			deltas: []provider.Delta{{Text: "hi"}},
+			final: &provider.Response{
+				Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "hi"}}},
+				FinishReason: "stop",
+				Usage:        provider.Usage{PromptTokens: 4, CompletionTokens: 1, TotalTokens: 5},
+			},
+		}, nil
+	}
+	run := func(t *testing.T, retry bool, maxRetries *int) (*TextStream, int) {
+		fp := &fakeProvider{stream: empty}
+		providerName := registerFakeProvider(t, fp)
+		stream, err := StreamText(context.Background(), StreamTextRequest{
+			BaseRequest: BaseRequest{
+				Model:            testModel{provider: providerName, name: "m"},
+				Messages:         []Message{User("hello")},
+				RetryEmptyStream: retry,
+				MaxRetries:       maxRetries,
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for stream.Next() {
+		}
+		if err := stream.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return stream, len(fp.Requests())
+	}
+
+	t.Run("retried", func(t *testing.T) {
+		stream, calls := run(t, true, nil)
+		if m := stream.Message(); m == nil || extractTextFromMessage(*m) != "hi" {
+			t.Fatalf("Message=%#v", m)
+		}
+		if calls != 3 {
+			t.Fatalf("stream calls=%d", calls)
+		}
+		if u := stream.Usage(); u.TotalTokens != 13 {
+			t.Fatalf("Usage=%+v", u)
+		}
+	})
+
+	// MaxRetries bounds the retries; the empty reply is then returned as is.
+	t.Run("max retries", func(t *testing.T) {
+		one := 1
+		stream, calls := run(t, true, &one)
+		if calls != 2 || stream.FinishReason() != FinishLength {
+			t.Fatalf("stream calls=%d FinishReason=%q", calls, stream.FinishReason())
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		if _, calls := run(t, false, nil); calls != 1 {
+			t.Fatalf("stream calls=%d", calls)
+		}
+	})
+}
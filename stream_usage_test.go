@@ -0,0 +1,49 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+func TestStreamText_RunningUsage(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		return &fakeStream{
+			deltas: []provider.Delta{
+				{Text: "a", Usage: &provider.Usage{PromptTokens: 5, CompletionTokens: 1, TotalTokens: 6}},
+				{Text: "b", Usage: &provider.Usage{PromptTokens: 5, CompletionTokens: 2, TotalTokens: 7}},
+			},
+			final: &provider.Response{
+				Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "ab"}}},
+				FinishReason: "stop",
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	stream, err := StreamText(context.Background(), StreamTextRequest{BaseRequest: BaseRequest{
+		Model:    testModel{provider: providerName, name: "m"},
+		Messages: []Message{User("hi")},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var running []int
+	for stream.Next() {
+		running = append(running, stream.Usage().CompletionTokens)
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(running) != 2 || running[0] != 1 || running[1] != 2 {
+		t.Fatalf("running usage=%v", running)
+	}
+	// The final response reports no usage, so the last running value is kept.
+	if got := stream.Usage().TotalTokens; got != 7 {
+		t.Fatalf("final usage=%d", got)
+	}
+}
@@ -0,0 +1,67 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// flushCounter records how often it was flushed.
+type flushCounter struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (w *flushCounter) Flush() { w.flushes++; w.ResponseRecorder.Flush() }
+
+// failingWriter fails every write.
+type failingWriter struct{ writes int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("client went away")
+}
+
+func TestTextStream_WriteTo(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.stream = func(call int, req provider.Request) (provider.Stream, error) {
+		return &fakeStream{
+			deltas: []provider.Delta{{Text: "Hel"}, {Text: "lo, "}, {Text: "world"}},
+			final: &provider.Response{
+				Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "Hello, world"}}},
+				FinishReason: "stop",
+			},
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+	stream := func() *TextStream {
+		s, err := StreamText(context.Background(), StreamTextRequest{
+			BaseRequest: BaseRequest{Model: testModel{provider: providerName, name: "m"}, Messages: []Message{User("hi")}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		return s
+	}
+
+	var _ io.WriterTo = (*TextStream)(nil)
+	w := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	n, err := stream().WriteTo(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 12 || w.Body.String() != "Hello, world" || w.flushes != 3 {
+		t.Fatalf("n=%d body=%q flushes=%d", n, w.Body.String(), w.flushes)
+	}
+
+	// The first write error ends the copy.
+	fw := &failingWriter{}
+	if _, err := stream().WriteTo(fw); err == nil || fw.writes != 1 {
+		t.Fatalf("err=%v writes=%d", err, fw.writes)
+	}
+}
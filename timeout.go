@@ -3,6 +3,8 @@ package ai
 import (
 	"context"
 	"time"
+
+	"github.com/bitop-dev/ai/internal/provider"
 )
 
 func applyTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
@@ -11,3 +13,49 @@ func applyTimeout(ctx context.Context, timeout time.Duration) (context.Context,
 	}
 	return context.WithTimeout(ctx, timeout)
 }
+
+// withPerCallTimeout bounds each Generate/Stream call of p by d. The deadline
+// composes with the caller's context, so each call gets the smaller of d and
+// whatever remains of the overall Timeout.
+func withPerCallTimeout(p provider.Provider, d time.Duration) provider.Provider {
+	if d <= 0 || p == nil {
+		return p
+	}
+	return &perCallTimeoutProvider{p: p, d: d}
+}
+
+type perCallTimeoutProvider struct {
+	p provider.Provider
+	d time.Duration
+}
+
+func (t *perCallTimeoutProvider) Generate(ctx context.Context, req provider.Request) (provider.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.d)
+	defer cancel()
+	return t.p.Generate(ctx, req)
+}
+
+func (t *perCallTimeoutProvider) Stream(ctx context.Context, req provider.Request) (provider.Stream, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.d)
+	s, err := t.p.Stream(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &cancelOnCloseStream{Stream: s, cancel: cancel}, nil
+}
+
+// Unwrap exposes the wrapped provider's optional capabilities to provider.As.
+func (t *perCallTimeoutProvider) Unwrap() provider.Provider { return t.p }
+
+// cancelOnCloseStream releases the per-call deadline once the stream is closed.
+type cancelOnCloseStream struct {
+	provider.Stream
+	cancel context.CancelFunc
+}
+
+func (s *cancelOnCloseStream) Close() error {
+	err := s.Stream.Close()
+	s.cancel()
+	return err
+}
@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// deadlineProvider records each call's deadline and blocks on the first call
+// until its context is done.
+type deadlineProvider struct {
+	deadlines []time.Duration
+}
+
+func (p *deadlineProvider) Generate(ctx context.Context, req provider.Request) (provider.Response, error) {
+	dl, ok := ctx.Deadline()
+	if !ok {
+		return provider.Response{}, errors.New("no deadline")
+	}
+	p.deadlines = append(p.deadlines, time.Until(dl))
+	if len(p.deadlines) == 1 {
+		<-ctx.Done()
+		return provider.Response{}, ctx.Err()
+	}
+	return provider.Response{
+		Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "ok"}}},
+		FinishReason: "stop",
+	}, nil
+}
+
+func (p *deadlineProvider) Stream(ctx context.Context, req provider.Request) (provider.Stream, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestGenerateText_PerCallTimeout(t *testing.T) {
+	dp := &deadlineProvider{}
+	providerName := registerFakeProvider(t, dp)
+	req := GenerateTextRequest{BaseRequest: BaseRequest{
+		Model:          testModel{provider: providerName, name: "m"},
+		Messages:       []Message{User("hi")},
+		Timeout:        time.Minute,
+		PerCallTimeout: 20 * time.Millisecond,
+	}}
+
+	start := time.Now()
+	_, err := GenerateText(context.Background(), req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err=%v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("call not bounded by PerCallTimeout: %v", elapsed)
+	}
+
+	// The earlier of the two deadlines wins.
+	req.Timeout = 10 * time.Millisecond
+	req.PerCallTimeout = time.Minute
+	if _, err := GenerateText(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got := dp.deadlines[1]; got > 10*time.Millisecond {
+		t.Fatalf("deadline=%v, want <= overall Timeout", got)
+	}
+}
+
+func TestPerCallTimeout_ForwardsCapabilities(t *testing.T) {
+	inner := &listingProvider{fakeProvider: &fakeProvider{}, models: []provider.ModelInfo{{ID: "m"}}}
+	p := withPerCallTimeout(inner, time.Minute)
+	if _, ok := p.(provider.ModelLister); ok {
+		t.Fatal("wrapper should not implement capabilities itself")
+	}
+	ml, ok := provider.As[provider.ModelLister](p)
+	if !ok {
+		t.Fatal("ModelLister not found through the wrapper")
+	}
+	if models, err := ml.ListModels(context.Background(), provider.ListModelsRequest{}); err != nil || len(models) != 1 {
+		t.Fatalf("models=%v err=%v", models, err)
+	}
+	if _, ok := provider.As[provider.ImageProvider](p); ok {
+		t.Fatal("ImageProvider reported for a provider without it")
+	}
+}
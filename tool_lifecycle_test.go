@@ -3,6 +3,7 @@ package ai
 import (
 	"context"
 	"encoding/json"
+	"sync"
 	"testing"
 
 	"github.com/bitop-dev/ai/internal/provider"
@@ -133,4 +134,72 @@ func TestStreamText_ToolInputLifecycleHooksAndProgress(t *testing.T) {
 	if progress[0].ToolCallID != "call_1" || progress[0].ToolName != "add" {
 		t.Fatalf("progress[0]=%#v", progress[0])
 	}
+	if progress[0].Sequence != 1 || progress[1].Sequence != 2 || progress[0].Timestamp.IsZero() || progress[1].Timestamp.Before(progress[0].Timestamp) {
+		t.Fatalf("progress order=%#v", progress)
+	}
+}
+
+func TestToolProgress_SequencePerCallUnderConcurrentReports(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		if call == 0 {
+			return provider.Response{
+				Message: provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{
+					provider.ToolCallPart{ID: "a", Name: "work", Args: []byte(`{}`)},
+					provider.ToolCallPart{ID: "b", Name: "work", Args: []byte(`{"n":1}`)},
+				}},
+				FinishReason: "tool_calls",
+			}, nil
+		}
+		return provider.Response{
+			Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "done"}}},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	const reports = 20
+	work := NewDynamicTool("work", DynamicToolSpec{
+		Execute: func(ctx context.Context, input json.RawMessage, meta ToolExecutionMeta) (any, error) {
+			var wg sync.WaitGroup
+			for i := 0; i < reports; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					meta.Report(i)
+				}()
+			}
+			wg.Wait()
+			return "ok", nil
+		},
+	})
+
+	var mu sync.Mutex
+	seqs := map[string][]int{}
+	_, err := GenerateText(context.Background(), GenerateTextRequest{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("go")},
+			Tools:    []Tool{work},
+			OnToolProgress: func(e ToolProgressEvent) {
+				mu.Lock()
+				defer mu.Unlock()
+				seqs[e.ToolCallID] = append(seqs[e.ToolCallID], e.Sequence)
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a", "b"} {
+		got := seqs[id]
+		if len(got) != reports {
+			t.Fatalf("%s: %d reports", id, len(got))
+		}
+		for i, s := range got {
+			if s != i+1 {
+				t.Fatalf("%s: sequences %v", id, got)
+			}
+		}
+	}
 }
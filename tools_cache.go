@@ -0,0 +1,74 @@
+package ai
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"github.com/bitop-dev/ai/internal/provider"
+)
+
+// ToolResultCache memoizes tool results by tool name and a hash of the
+// arguments. It is safe for concurrent use.
+type ToolResultCache struct {
+	mu      sync.Mutex
+	results map[string]provider.Message
+}
+
+func NewToolResultCache() *ToolResultCache {
+	return &ToolResultCache{results: map[string]provider.Message{}}
+}
+
+// Len reports the number of cached results.
+func (c *ToolResultCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.results)
+}
+
+// Clear drops every cached result.
+func (c *ToolResultCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.results)
+}
+
+func (c *ToolResultCache) get(key string) (provider.Message, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.results[key]
+	return m, ok
+}
+
+func (c *ToolResultCache) put(key string, m provider.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results[key] = m
+}
+
+// cacheable reports whether t's results may be memoized: it must opt in and
+// be free of side effects that a repeated call would have.
+func (t Tool) cacheable() bool {
+	return t.CacheResults && (t.Annotations.ReadOnly || t.Annotations.Idempotent)
+}
+
+// toolCacheKey hashes the tool name and arguments. Arguments are
+// canonicalized (whitespace and object key order ignored) when they parse.
+func toolCacheKey(name string, args json.RawMessage) string {
+	canonical := fpCompactJSON(args)
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err == nil {
+		if b, err := json.Marshal(v); err == nil {
+			canonical = b
+		}
+	}
+	h := sha256.New()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write(canonical)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -1,6 +1,9 @@
 package ai
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 type NoSuchToolError struct {
 	ToolName string
@@ -58,3 +61,32 @@ func (e *ToolExecutionError) Error() string {
 }
 
 func (e *ToolExecutionError) Unwrap() error { return e.Cause }
+
+// ToolResultError reports a tool failure to the model instead of aborting the
+// run: when a handler returns it (possibly wrapped), the tool loop sends
+// {"error": Message} as the tool result so the model can react.
+type ToolResultError struct {
+	ToolName string
+	Message  string
+}
+
+func (e *ToolResultError) Error() string {
+	if e == nil {
+		return ""
+	}
+	return fmt.Sprintf("tool %s reported an error: %s", e.ToolName, e.Message)
+}
+
+// toolPanicError records a recovered panic from a tool handler. The executor
+// reports it to the model as a tool error result rather than aborting the run.
+type toolPanicError struct {
+	ToolName string
+	Value    any
+}
+
+func (e *toolPanicError) Error() string {
+	if e == nil {
+		return ""
+	}
+	return fmt.Sprintf("tool %s panicked: %v", e.ToolName, e.Value)
+}
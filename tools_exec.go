@@ -3,9 +3,16 @@ package ai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
 
 	"github.com/bitop-dev/ai/internal/provider"
+	internalSchema "github.com/bitop-dev/ai/internal/schema"
+	internalTools "github.com/bitop-dev/ai/internal/tools"
 )
 
 func findTool(tools []Tool, name string) (Tool, bool) {
@@ -21,6 +28,13 @@ type toolExecOptions struct {
 	toolCallIndexByID func(toolCallID string) int
 	onInputAvailable  func(tool Tool, call provider.ToolCallPart, toolCallIndex int)
 	onProgress        func(event ToolProgressEvent)
+	onToolTiming      func(toolCallID string, d time.Duration)
+
+	// dedupe runs identical (name + args) calls once and copies the result
+	// to the duplicates' tool call IDs.
+	dedupe bool
+	// cache memoizes results of cacheable tools (see Tool.CacheResults).
+	cache *ToolResultCache
 }
 
 func executeToolCallsProvider(ctx context.Context, tools []Tool, calls []provider.ToolCallPart) ([]provider.Message, error) {
@@ -35,7 +49,22 @@ func executeToolCallsProviderWithOptions(ctx context.Context, tools []Tool, call
 		return nil, fmt.Errorf("model requested tool calls but no tools were provided")
 	}
 
+	var stepNumber int
+	var history []Message
+	if info, ok := internalTools.StepInfoFromContext(ctx); ok {
+		stepNumber = info.StepNumber
+		msgs, err := messagesFromProviderMessages(info.Messages)
+		if err != nil {
+			return nil, err
+		}
+		history = msgs
+	}
+
 	results := make([]provider.Message, 0, len(calls))
+	var seen map[string]provider.Message
+	if opts.dedupe {
+		seen = map[string]provider.Message{}
+	}
 	for _, call := range calls {
 		if call.ID == "" {
 			return nil, fmt.Errorf("tool call missing id")
@@ -53,6 +82,11 @@ func executeToolCallsProviderWithOptions(ctx context.Context, tools []Tool, call
 			toolCallIndex = opts.toolCallIndexByID(call.ID)
 		}
 
+		if t.Strict && len(t.InputSchema.JSON) > 0 {
+			// The model saw optional properties as nullable (see
+			// MakeStrict); a null there means "not set".
+			call.Args = internalSchema.StripOptionalNulls(t.InputSchema.JSON, call.Args)
+		}
 		if len(t.InputSchema.JSON) > 0 {
 			if err := validateJSONAgainstSchema(t.InputSchema, call.Args); err != nil {
 				return nil, &InvalidToolInputError{ToolName: t.Name, ToolCallID: call.ID, Cause: err}
@@ -63,17 +97,55 @@ func executeToolCallsProviderWithOptions(ctx context.Context, tools []Tool, call
 			opts.onInputAvailable(t, call, toolCallIndex)
 		}
 
+		var dedupeKey string
+		if seen != nil {
+			dedupeKey = call.Name + "\x00" + string(fpCompactJSON(call.Args))
+			if prev, ok := seen[dedupeKey]; ok {
+				prev.ToolCallID = call.ID
+				results = append(results, prev)
+				if opts.onToolTiming != nil {
+					opts.onToolTiming(call.ID, 0)
+				}
+				continue
+			}
+		}
+
+		var cacheKey string
+		if opts.cache != nil && t.cacheable() {
+			cacheKey = toolCacheKey(t.Name, call.Args)
+			if prev, ok := opts.cache.get(cacheKey); ok {
+				prev.ToolCallID = call.ID
+				if seen != nil {
+					seen[dedupeKey] = prev
+				}
+				results = append(results, prev)
+				if opts.onToolTiming != nil {
+					opts.onToolTiming(call.ID, 0)
+				}
+				continue
+			}
+		}
+
 		meta := ToolExecutionMeta{
 			ToolName:      t.Name,
 			ToolCallID:    call.ID,
 			ToolCallIndex: toolCallIndex,
+			StepNumber:    stepNumber,
+			Messages:      append([]Message(nil), history...),
 		}
 		if opts.onProgress != nil {
+			var mu sync.Mutex
+			seq := 0
 			meta.Report = func(data any) {
+				mu.Lock()
+				defer mu.Unlock()
+				seq++
 				opts.onProgress(ToolProgressEvent{
 					ToolName:      t.Name,
 					ToolCallID:    call.ID,
 					ToolCallIndex: toolCallIndex,
+					Sequence:      seq,
+					Timestamp:     time.Now(),
 					Data:          data,
 				})
 			}
@@ -81,17 +153,51 @@ func executeToolCallsProviderWithOptions(ctx context.Context, tools []Tool, call
 
 		execCtx := context.WithValue(ctx, toolExecutionMetaKey{}, meta)
 
-		val, err := t.Handler(execCtx, call.Args)
-		if err != nil {
+		start := time.Now()
+		val, err := callToolHandler(execCtx, t, call)
+		if opts.onToolTiming != nil {
+			opts.onToolTiming(call.ID, time.Since(start))
+		}
+		var panicErr *toolPanicError
+		var resultErr *ToolResultError
+		if errors.As(err, &panicErr) {
+			// Report the panic to the model as a tool error instead of aborting the run.
+			val = map[string]string{"error": panicErr.Error()}
+		} else if errors.As(err, &resultErr) {
+			val = map[string]string{"error": resultErr.Message}
+		} else if err != nil {
 			return nil, &ToolExecutionError{ToolName: t.Name, ToolCallID: call.ID, Cause: err}
 		}
-		results = append(results, toolResultProvider(call.ID, t.Name, val))
+		res := toolResultProvider(call.ID, t.Name, val, t.MarshalResult)
+		if cacheKey != "" && err == nil {
+			opts.cache.put(cacheKey, res)
+		}
+		if seen != nil {
+			seen[dedupeKey] = res
+		}
+		results = append(results, res)
 	}
 	return results, nil
 }
 
-func toolResultProvider(toolCallID, toolName string, value any) provider.Message {
-	raw, err := json.Marshal(value)
+// callToolHandler invokes the tool handler, converting a panic into a
+// *toolPanicError (logged with its stack via log/slog).
+func callToolHandler(ctx context.Context, t Tool, call provider.ToolCallPart) (val any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			slog.Error("ai: tool handler panicked", "tool", t.Name, "toolCallId", call.ID, "panic", r, "stack", string(stack))
+			err = &toolPanicError{ToolName: t.Name, Value: r}
+		}
+	}()
+	return t.Handler(ctx, call.Args)
+}
+
+func toolResultProvider(toolCallID, toolName string, value any, marshal func(v any) ([]byte, error)) provider.Message {
+	if marshal == nil {
+		marshal = json.Marshal
+	}
+	raw, err := marshal(value)
 	if err != nil {
 		raw = json.RawMessage(fmt.Sprintf(`{"error":%q}`, err.Error()))
 	}
@@ -1,9 +1,11 @@
 package ai
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 )
 
 type ToolExecutionMeta struct {
@@ -11,14 +13,33 @@ type ToolExecutionMeta struct {
 	ToolCallID    string
 	ToolCallIndex int
 
+	// StepNumber is the tool loop step that requested the call (0-based).
+	StepNumber int
+	// Messages is the conversation so far, ending with the assistant message
+	// that requested this call. It is a copy; modifying it has no effect.
+	Messages []Message
+
 	// Report emits a progress event during tool execution (if enabled on the request).
 	Report func(data any)
 }
 
+// ToolSpec describes a tool for NewTool. Strict, MarshalResult, Annotations
+// and CacheResults are copied to the Tool fields of the same name;
+// CacheResults also requires Annotations.ReadOnly or Annotations.Idempotent.
 type ToolSpec[Input any, Output any] struct {
 	Description string
 	InputSchema Schema
 	Execute     func(ctx context.Context, input Input, meta ToolExecutionMeta) (Output, error)
+
+	Strict        bool
+	MarshalResult func(v any) ([]byte, error)
+	Annotations   ToolAnnotations
+	CacheResults  bool
+
+	// UseNumber decodes numbers held in interface values of Input (any,
+	// map[string]any, ...) as json.Number instead of float64, so large
+	// integers such as IDs or amounts keep full precision.
+	UseNumber bool
 }
 
 type toolExecutionMetaKey struct{}
@@ -42,16 +63,21 @@ func NewTool[Input any, Output any](name string, spec ToolSpec[Input, Output]) T
 	if spec.Execute == nil {
 		panic(fmt.Sprintf("tool %q Execute is required", name))
 	}
+	checkCacheResults(name, spec.CacheResults, spec.Annotations)
 	return Tool{
-		Name:        name,
-		Description: spec.Description,
-		InputSchema: spec.InputSchema,
+		Name:          name,
+		Description:   spec.Description,
+		InputSchema:   spec.InputSchema,
+		Strict:        spec.Strict,
+		MarshalResult: spec.MarshalResult,
+		Annotations:   spec.Annotations,
+		CacheResults:  spec.CacheResults,
 		Handler: func(ctx context.Context, input json.RawMessage) (any, error) {
 			if err := validateJSONAgainstSchema(spec.InputSchema, input); err != nil {
 				return nil, err
 			}
 			var v Input
-			if err := json.Unmarshal(input, &v); err != nil {
+			if err := decodeToolInput(input, &v, spec.UseNumber); err != nil {
 				return nil, err
 			}
 			return spec.Execute(ctx, v, toolExecutionMetaFromContext(ctx))
@@ -59,10 +85,34 @@ func NewTool[Input any, Output any](name string, spec ToolSpec[Input, Output]) T
 	}
 }
 
+// decodeToolInput unmarshals input into v, optionally keeping numbers as
+// json.Number.
+func decodeToolInput(input json.RawMessage, v any, useNumber bool) error {
+	if !useNumber {
+		return json.Unmarshal(input, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(input))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("invalid tool input: unexpected data after JSON value")
+	}
+	return nil
+}
+
+// DynamicToolSpec describes a tool for NewDynamicTool. Fields shared with
+// ToolSpec mean the same.
 type DynamicToolSpec struct {
 	Description string
 	InputSchema Schema
 	Execute     func(ctx context.Context, input json.RawMessage, meta ToolExecutionMeta) (any, error)
+
+	Strict        bool
+	MarshalResult func(v any) ([]byte, error)
+	Annotations   ToolAnnotations
+	CacheResults  bool
 }
 
 // NewDynamicTool creates a Tool where input is left as json.RawMessage for runtime
@@ -74,10 +124,15 @@ func NewDynamicTool(name string, spec DynamicToolSpec) Tool {
 	if spec.Execute == nil {
 		panic(fmt.Sprintf("tool %q Execute is required", name))
 	}
+	checkCacheResults(name, spec.CacheResults, spec.Annotations)
 	return Tool{
-		Name:        name,
-		Description: spec.Description,
-		InputSchema: spec.InputSchema,
+		Name:          name,
+		Description:   spec.Description,
+		InputSchema:   spec.InputSchema,
+		Strict:        spec.Strict,
+		MarshalResult: spec.MarshalResult,
+		Annotations:   spec.Annotations,
+		CacheResults:  spec.CacheResults,
 		Handler: func(ctx context.Context, input json.RawMessage) (any, error) {
 			if err := validateJSONAgainstSchema(spec.InputSchema, input); err != nil {
 				return nil, err
@@ -86,3 +141,105 @@ func NewDynamicTool(name string, spec DynamicToolSpec) Tool {
 		},
 	}
 }
+
+// StreamingToolSpec describes a tool whose output arrives incrementally, such
+// as a remote tool that streams partial results. Fields shared with ToolSpec
+// mean the same.
+type StreamingToolSpec[Input any] struct {
+	Description string
+	InputSchema Schema
+	// Execute starts the tool and returns a channel of its outputs, which it
+	// closes when done. Every value is forwarded to OnToolProgress as it
+	// arrives; the last value before the channel closes is the tool result
+	// sent to the model. Sending an error value fails the call with that
+	// error. Execute should stop sending once ctx is done.
+	Execute func(ctx context.Context, input Input, meta ToolExecutionMeta) (<-chan any, error)
+
+	Strict        bool
+	MarshalResult func(v any) ([]byte, error)
+	Annotations   ToolAnnotations
+	CacheResults  bool
+	UseNumber     bool
+}
+
+// NewStreamingTool creates a Tool from a StreamingToolSpec. Its Handler
+// validates and decodes the input like NewTool, then collects the outputs of
+// Execute, reporting each one through ToolExecutionMeta.Report.
+func NewStreamingTool[Input any](name string, spec StreamingToolSpec[Input]) Tool {
+	if name == "" {
+		panic("tool name is required")
+	}
+	if spec.Execute == nil {
+		panic(fmt.Sprintf("tool %q Execute is required", name))
+	}
+	checkCacheResults(name, spec.CacheResults, spec.Annotations)
+	return Tool{
+		Name:          name,
+		Description:   spec.Description,
+		InputSchema:   spec.InputSchema,
+		Strict:        spec.Strict,
+		MarshalResult: spec.MarshalResult,
+		Annotations:   spec.Annotations,
+		CacheResults:  spec.CacheResults,
+		Handler: func(ctx context.Context, input json.RawMessage) (any, error) {
+			if err := validateJSONAgainstSchema(spec.InputSchema, input); err != nil {
+				return nil, err
+			}
+			var v Input
+			if err := decodeToolInput(input, &v, spec.UseNumber); err != nil {
+				return nil, err
+			}
+			meta := toolExecutionMetaFromContext(ctx)
+			ch, err := spec.Execute(ctx, v, meta)
+			if err != nil {
+				return nil, err
+			}
+			return collectToolStream(ctx, ch, meta.Report)
+		},
+	}
+}
+
+// collectToolStream reads ch until it is closed and returns the last value,
+// passing each value to report (if non-nil).
+func collectToolStream(ctx context.Context, ch <-chan any, report func(any)) (any, error) {
+	var last any
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case val, ok := <-ch:
+			if !ok {
+				return last, nil
+			}
+			if err, isErr := val.(error); isErr {
+				return nil, err
+			}
+			if report != nil {
+				report(val)
+			}
+			last = val
+		}
+	}
+}
+
+func checkCacheResults(name string, cache bool, ann ToolAnnotations) {
+	if cache && !ann.ReadOnly && !ann.Idempotent {
+		panic(fmt.Sprintf("tool %q CacheResults requires a ReadOnly or Idempotent annotation", name))
+	}
+}
+
+// HostedTool creates a tool that the provider executes itself, such as a
+// built-in web search. name is the provider's tool type and config its
+// settings (nil for defaults). The OpenAI provider supports "web_search" with
+// search models such as gpt-4o-search-preview, config being its
+// web_search_options; providers reject hosted tools they cannot run.
+//
+// Hosted tools need no Handler. The tool loop skips their calls: nothing runs
+// locally and no tool result is sent back, as the provider has already folded
+// the outcome into its response.
+func HostedTool(name string, config any) Tool {
+	if name == "" {
+		panic("tool name is required")
+	}
+	return Tool{Name: name, Hosted: true, HostedConfig: config}
+}
@@ -2,6 +2,9 @@ package ai
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -29,3 +32,127 @@ func TestToolHelper_ValidatesSchemaAndUnmarshals(t *testing.T) {
 		t.Fatalf("expected schema validation error")
 	}
 }
+
+func TestToolHelper_UseNumber(t *testing.T) {
+	type args struct {
+		Filter map[string]any `json:"filter"`
+	}
+	for _, useNumber := range []bool{false, true} {
+		var got any
+		tool := NewTool("lookup", ToolSpec[args, string]{
+			UseNumber: useNumber,
+			Execute: func(ctx context.Context, input args, meta ToolExecutionMeta) (string, error) {
+				got = input.Filter["id"]
+				return "ok", nil
+			},
+		})
+		if _, err := tool.Handler(context.Background(), []byte(`{"filter":{"id":9007199254740993}}`)); err != nil {
+			t.Fatal(err)
+		}
+		n, isNumber := got.(json.Number)
+		if isNumber != useNumber || (useNumber && n.String() != "9007199254740993") {
+			t.Fatalf("UseNumber=%v: id=%#v", useNumber, got)
+		}
+		if _, err := tool.Handler(context.Background(), []byte(`{"filter":{}} {}`)); err == nil {
+			t.Fatalf("UseNumber=%v: expected error for trailing data", useNumber)
+		}
+	}
+}
+
+func TestWrapTool_RewritesInputAndOutputAndKeepsHooks(t *testing.T) {
+	var sawInput string
+	base := NewDynamicTool("echo", DynamicToolSpec{
+		Execute: func(ctx context.Context, input json.RawMessage, meta ToolExecutionMeta) (any, error) {
+			_ = ctx
+			_ = meta
+			sawInput = string(input)
+			return "raw", nil
+		},
+	})
+	base.OnInputStart = func(e ToolInputStartEvent) {}
+
+	wrapped := WrapTool(base, WrapOptions{
+		Before: func(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+			_ = ctx
+			return json.RawMessage(`{"rewritten":true}`), nil
+		},
+		After: func(ctx context.Context, output any, err error) (any, error) {
+			_ = ctx
+			if err != nil {
+				return nil, err
+			}
+			return output.(string) + "!", nil
+		},
+	})
+
+	if wrapped.Name != "echo" || wrapped.OnInputStart == nil {
+		t.Fatalf("wrapped tool lost fields: %#v", wrapped)
+	}
+	out, err := wrapped.Handler(context.Background(), []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "raw!" {
+		t.Fatalf("out=%v", out)
+	}
+	if sawInput != `{"rewritten":true}` {
+		t.Fatalf("input=%s", sawInput)
+	}
+
+	failing := WrapTool(base, WrapOptions{
+		Before: func(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+			return nil, errors.New("denied")
+		},
+	})
+	sawInput = ""
+	if _, err := failing.Handler(context.Background(), []byte(`{}`)); err == nil || err.Error() != "denied" {
+		t.Fatalf("err=%v", err)
+	}
+	if sawInput != "" {
+		t.Fatalf("handler should not run when Before fails")
+	}
+}
+
+func TestWrapTool_WithoutHandlerUnchanged(t *testing.T) {
+	opts := WrapOptions{
+		Before: func(ctx context.Context, input json.RawMessage) (json.RawMessage, error) { return input, nil },
+	}
+	hosted := WrapTool(HostedTool("web_search", nil), opts)
+	if !hosted.Hosted || hosted.Handler != nil || hosted.Name != "web_search" {
+		t.Fatalf("hosted=%#v", hosted)
+	}
+	bare := WrapTool(Tool{Name: "declared"}, opts)
+	if bare.Name != "declared" || bare.Handler != nil {
+		t.Fatalf("bare=%#v", bare)
+	}
+}
+
+func TestMergeTools(t *testing.T) {
+	handler := func(name string) ToolHandler {
+		return func(ctx context.Context, input json.RawMessage) (any, error) { return name, nil }
+	}
+	local := []Tool{{Name: "search", Handler: handler("local")}}
+	remote := []Tool{{Name: "search", Handler: handler("remote")}, {Name: "fetch", Handler: handler("fetch")}}
+
+	if _, err := MergeTools(local, remote); err == nil || !strings.Contains(err.Error(), `"search"`) {
+		t.Fatalf("expected collision error, got %v", err)
+	}
+
+	merged, err := MergeTools(local, PrefixTools("gh_", remote))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tool := range merged {
+		names = append(names, tool.Name)
+	}
+	if strings.Join(names, ",") != "search,gh_search,gh_fetch" {
+		t.Fatalf("names=%v", names)
+	}
+	if v, _ := merged[1].Handler(context.Background(), nil); v != "remote" {
+		t.Fatalf("handler result=%v", v)
+	}
+	if remote[0].Name != "search" {
+		t.Fatalf("PrefixTools modified its input: %q", remote[0].Name)
+	}
+}
@@ -3,6 +3,9 @@ package ai
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/bitop-dev/ai/internal/provider"
@@ -157,3 +160,556 @@ func TestStreamText_UsageAggregatesAcrossIterations(t *testing.T) {
 		t.Fatalf("FinishReason=%q", got)
 	}
 }
+
+func TestGenerateText_ToolPanicBecomesToolError(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		if call == 0 {
+			return provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.ToolCallPart{ID: "call_1", Name: "boom", Args: []byte(`{}`)}},
+				},
+				FinishReason: "tool_calls",
+			}, nil
+		}
+		last := req.Messages[len(req.Messages)-1]
+		if last.Role != provider.RoleTool || last.ToolCallID != "call_1" {
+			t.Fatalf("expected tool result, got %#v", last)
+		}
+		if txt := last.Content[0].(provider.TextPart).Text; !strings.Contains(txt, "panicked: kaboom") {
+			t.Fatalf("tool result=%s", txt)
+		}
+		return provider.Response{
+			Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "sorry"}}},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	resp, err := GenerateText(context.Background(), GenerateTextRequest{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("go")},
+			Tools: []Tool{{
+				Name: "boom",
+				Handler: func(ctx context.Context, input json.RawMessage) (any, error) {
+					panic("kaboom")
+				},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "sorry" {
+		t.Fatalf("Text=%q", resp.Text)
+	}
+}
+
+func TestGenerateText_StreamingTool(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		if call == 0 {
+			return provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.ToolCallPart{ID: "call_1", Name: "fetch", Args: []byte(`{"url":"x"}`)}},
+				},
+				FinishReason: "tool_calls",
+			}, nil
+		}
+		last := req.Messages[len(req.Messages)-1]
+		if txt := last.Content[0].(provider.TextPart).Text; txt != `{"body":"full"}` {
+			t.Fatalf("tool result=%s", txt)
+		}
+		return provider.Response{
+			Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "done"}}},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	type args struct {
+		URL string `json:"url"`
+	}
+	fetch := NewStreamingTool("fetch", StreamingToolSpec[args]{
+		Execute: func(ctx context.Context, input args, meta ToolExecutionMeta) (<-chan any, error) {
+			ch := make(chan any)
+			go func() {
+				defer close(ch)
+				for _, v := range []any{"50%", "90%", map[string]string{"body": "full"}} {
+					select {
+					case ch <- v:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+			return ch, nil
+		},
+	})
+	var progress []any
+	_, err := GenerateText(context.Background(), GenerateTextRequest{
+		BaseRequest: BaseRequest{
+			Model:          testModel{provider: providerName, name: "m"},
+			Messages:       []Message{User("go")},
+			Tools:          []Tool{fetch},
+			OnToolProgress: func(e ToolProgressEvent) { progress = append(progress, e.Data) },
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{"50%", "90%", map[string]string{"body": "full"}}
+	if !reflect.DeepEqual(progress, want) {
+		t.Fatalf("progress=%#v", progress)
+	}
+
+	// An error value fails the call.
+	failing := NewStreamingTool("fetch", StreamingToolSpec[args]{
+		Execute: func(ctx context.Context, input args, meta ToolExecutionMeta) (<-chan any, error) {
+			ch := make(chan any, 2)
+			ch <- "partial"
+			ch <- fmt.Errorf("connection reset")
+			close(ch)
+			return ch, nil
+		},
+	})
+	if _, err := failing.Handler(context.Background(), []byte(`{"url":"x"}`)); err == nil || err.Error() != "connection reset" {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestGenerateText_DedupeToolCalls(t *testing.T) {
+	for _, dedupe := range []bool{false, true} {
+		t.Run(fmt.Sprintf("dedupe=%v", dedupe), func(t *testing.T) {
+			testDedupeToolCalls(t, dedupe)
+		})
+	}
+}
+
+func testDedupeToolCalls(t *testing.T, dedupe bool) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		if call == 0 {
+			return provider.Response{
+				Message: provider.Message{
+					Role: provider.RoleAssistant,
+					Content: []provider.ContentPart{
+						provider.ToolCallPart{ID: "call_1", Name: "lookup", Args: []byte(`{"q":"go"}`)},
+						provider.ToolCallPart{ID: "call_2", Name: "lookup", Args: []byte(`{ "q": "go" }`)},
+						provider.ToolCallPart{ID: "call_3", Name: "lookup", Args: []byte(`{"q":"rust"}`)},
+					},
+				},
+				FinishReason: "tool_calls",
+			}, nil
+		}
+		return provider.Response{
+			Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "done"}}},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	executions := 0
+	resp, err := GenerateText(context.Background(), GenerateTextRequest{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("search")},
+			Tools: []Tool{{
+				Name: "lookup",
+				Handler: func(ctx context.Context, input json.RawMessage) (any, error) {
+					executions++
+					return map[string]int{"n": executions}, nil
+				},
+			}},
+			ToolLoop: &ToolLoopOptions{MaxIterations: 3, DedupeToolCalls: dedupe},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := 3
+	if dedupe {
+		want = 2
+	}
+	if executions != want {
+		t.Fatalf("dedupe=%v executions=%d, want %d", dedupe, executions, want)
+	}
+	results := resp.Steps[0].ToolResults
+	if len(results) != 3 {
+		t.Fatalf("dedupe=%v tool results=%d", dedupe, len(results))
+	}
+	if dedupe {
+		if results[1].ToolCallID != "call_2" || !reflect.DeepEqual(results[0].Content, results[1].Content) {
+			t.Fatalf("duplicate result=%#v, first=%#v", results[1], results[0])
+		}
+	}
+	if timings := resp.Steps[0].ToolTimings; len(timings) != 3 || (dedupe && timings["call_2"] != 0) {
+		t.Fatalf("dedupe=%v tool timings=%v", dedupe, timings)
+	}
+}
+
+func TestGenerateText_CacheResults(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		if call < 2 {
+			args := `{"q":"go","n":1}`
+			if call == 1 {
+				args = `{"n":1, "q":"go"}`
+			}
+			return provider.Response{
+				Message: provider.Message{
+					Role: provider.RoleAssistant,
+					Content: []provider.ContentPart{
+						provider.ToolCallPart{ID: fmt.Sprintf("s%d", call), Name: "search", Args: []byte(args)},
+						provider.ToolCallPart{ID: fmt.Sprintf("w%d", call), Name: "write", Args: []byte(`{"x":1}`)},
+					},
+				},
+				FinishReason: "tool_calls",
+			}, nil
+		}
+		return provider.Response{
+			Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "done"}}},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	searches, writes := 0, 0
+	search := NewDynamicTool("search", DynamicToolSpec{
+		Annotations:  ToolAnnotations{ReadOnly: true},
+		CacheResults: true,
+		Execute: func(ctx context.Context, input json.RawMessage, meta ToolExecutionMeta) (any, error) {
+			searches++
+			return map[string]int{"hits": searches}, nil
+		},
+	})
+	// Not annotated, so CacheResults must be ignored.
+	write := Tool{
+		Name:         "write",
+		CacheResults: true,
+		Handler: func(ctx context.Context, input json.RawMessage) (any, error) {
+			writes++
+			return "ok", nil
+		},
+	}
+	cache := NewToolResultCache()
+	resp, err := GenerateText(context.Background(), GenerateTextRequest{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("search")},
+			Tools:    []Tool{search, write},
+			ToolLoop: &ToolLoopOptions{MaxIterations: 3, ResultCache: cache},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if searches != 1 || writes != 2 {
+		t.Fatalf("searches=%d writes=%d, want 1 and 2", searches, writes)
+	}
+	cached := resp.Steps[1].ToolResults[0]
+	if cached.ToolCallID != "s1" || !reflect.DeepEqual(cached.Content, resp.Steps[0].ToolResults[0].Content) {
+		t.Fatalf("cached result=%#v", cached)
+	}
+	if cache.Len() != 1 {
+		t.Fatalf("cache.Len()=%d", cache.Len())
+	}
+	if d, ok := resp.Steps[1].ToolTimings["s1"]; !ok || d != 0 {
+		t.Fatalf("cache hit timing=%v, %v", d, ok)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for CacheResults without annotations")
+		}
+	}()
+	NewDynamicTool("bad", DynamicToolSpec{
+		CacheResults: true,
+		Execute:      func(context.Context, json.RawMessage, ToolExecutionMeta) (any, error) { return nil, nil },
+	})
+}
+
+func TestGenerateText_ToolExecutionMetaCarriesStepContext(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		if call < 2 {
+			return provider.Response{
+				Message: provider.Message{
+					Role: provider.RoleAssistant,
+					Content: []provider.ContentPart{
+						provider.ToolCallPart{ID: fmt.Sprintf("call_%d", call), Name: "inspect", Args: []byte(`{}`)},
+					},
+				},
+				FinishReason: "tool_calls",
+			}, nil
+		}
+		return provider.Response{
+			Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "done"}}},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	var metas []ToolExecutionMeta
+	_, err := GenerateText(context.Background(), GenerateTextRequest{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("go")},
+			Tools: []Tool{
+				NewTool("inspect", ToolSpec[map[string]any, string]{
+					Execute: func(ctx context.Context, input map[string]any, meta ToolExecutionMeta) (string, error) {
+						metas = append(metas, meta)
+						return "ok", nil
+					},
+				}),
+			},
+			ToolLoop: &ToolLoopOptions{MaxIterations: 5},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metas) != 2 {
+		t.Fatalf("executions=%d", len(metas))
+	}
+	for i, meta := range metas {
+		if meta.ToolName != "inspect" || meta.ToolCallID != fmt.Sprintf("call_%d", i) || meta.StepNumber != i {
+			t.Fatalf("meta[%d]=%+v", i, meta)
+		}
+	}
+	// Step 0: user + assistant call. Step 1 adds the tool result and the next call.
+	if len(metas[0].Messages) != 2 || len(metas[1].Messages) != 4 {
+		t.Fatalf("history lengths=%d,%d", len(metas[0].Messages), len(metas[1].Messages))
+	}
+	last := metas[1].Messages[3]
+	if last.Role != RoleAssistant || len(last.Content) != 1 {
+		t.Fatalf("last message=%#v", last)
+	}
+	if tc, ok := last.Content[0].(ToolCallPart); !ok || tc.ID != "call_1" {
+		t.Fatalf("last content=%#v", last.Content[0])
+	}
+}
+
+func TestGenerateText_ToolMarshalResult(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		if call == 0 {
+			return provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.ToolCallPart{ID: "call_1", Name: "price", Args: []byte(`{}`)}},
+				},
+				FinishReason: "tool_calls",
+			}, nil
+		}
+		last := req.Messages[len(req.Messages)-1]
+		if txt := last.Content[0].(provider.TextPart).Text; txt != `{"amount":"0.1"}` {
+			t.Fatalf("tool result=%s", txt)
+		}
+		return provider.Response{
+			Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "ok"}}},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	type out struct {
+		Amount float64 `json:"amount"`
+	}
+	tool := NewTool[struct{}, out]("price", ToolSpec[struct{}, out]{
+		Execute: func(ctx context.Context, input struct{}, meta ToolExecutionMeta) (out, error) {
+			return out{Amount: 0.1}, nil
+		},
+		MarshalResult: func(v any) ([]byte, error) {
+			return []byte(fmt.Sprintf(`{"amount":"%v"}`, v.(out).Amount)), nil
+		},
+	})
+
+	if _, err := GenerateText(context.Background(), GenerateTextRequest{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("go")},
+			Tools:    []Tool{tool},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateText_ToolResultErrorIsReportedToModel(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		if call == 0 {
+			return provider.Response{
+				Message: provider.Message{
+					Role:    provider.RoleAssistant,
+					Content: []provider.ContentPart{provider.ToolCallPart{ID: "call_1", Name: "remote", Args: []byte(`{}`)}},
+				},
+				FinishReason: "tool_calls",
+			}, nil
+		}
+		last := req.Messages[len(req.Messages)-1]
+		if txt := last.Content[0].(provider.TextPart).Text; txt != `{"error":"quota exceeded"}` {
+			t.Fatalf("tool result=%s", txt)
+		}
+		return provider.Response{
+			Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "the tool failed"}}},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	resp, err := GenerateText(context.Background(), GenerateTextRequest{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("go")},
+			Tools: []Tool{{
+				Name: "remote",
+				Handler: func(ctx context.Context, input json.RawMessage) (any, error) {
+					return nil, fmt.Errorf("wrapped: %w", &ToolResultError{ToolName: "remote", Message: "quota exceeded"})
+				},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "the tool failed" {
+		t.Fatalf("Text=%q", resp.Text)
+	}
+}
+
+func TestGenerateText_HostedToolCallsAreNotExecuted(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		switch call {
+		case 0:
+			if len(req.Tools) != 2 || !req.Tools[0].Hosted || string(req.Tools[0].Config) != `{"search_context_size":"low"}` {
+				t.Fatalf("tools=%+v", req.Tools)
+			}
+			return provider.Response{
+				Message: provider.Message{
+					Role: provider.RoleAssistant,
+					Content: []provider.ContentPart{
+						provider.ToolCallPart{ID: "ws_1", Name: "web_search", Args: []byte(`{"query":"go"}`)},
+						provider.ToolCallPart{ID: "call_1", Name: "echo", Args: []byte(`{}`)},
+					},
+				},
+				FinishReason: "tool_calls",
+			}, nil
+		case 1:
+			for _, m := range req.Messages {
+				if m.ToolCallID == "ws_1" {
+					t.Fatalf("hosted call got a tool result")
+				}
+				for _, p := range m.Content {
+					if tc, ok := p.(provider.ToolCallPart); ok && tc.Name == "web_search" {
+						t.Fatalf("hosted call was sent back to the provider")
+					}
+				}
+			}
+			return provider.Response{
+				Message: provider.Message{
+					Role: provider.RoleAssistant,
+					Content: []provider.ContentPart{
+						provider.ToolCallPart{ID: "ws_2", Name: "web_search", Args: []byte(`{"query":"more"}`)},
+						provider.TextPart{Text: "done"},
+					},
+				},
+				FinishReason: "stop",
+			}, nil
+		default:
+			t.Fatalf("unexpected call %d", call)
+			return provider.Response{}, nil
+		}
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	executed := 0
+	resp, err := GenerateText(context.Background(), GenerateTextRequest{
+		BaseRequest: BaseRequest{
+			Model:    testModel{provider: providerName, name: "m"},
+			Messages: []Message{User("search")},
+			Tools: []Tool{
+				HostedTool("web_search", map[string]any{"search_context_size": "low"}),
+				NewDynamicTool("echo", DynamicToolSpec{
+					Execute: func(ctx context.Context, input json.RawMessage, meta ToolExecutionMeta) (any, error) {
+						executed++
+						return "ok", nil
+					},
+				}),
+			},
+			ToolLoop: &ToolLoopOptions{MaxIterations: 3},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "done" || executed != 1 || len(resp.Steps) != 2 {
+		t.Fatalf("text=%q executed=%d steps=%d", resp.Text, executed, len(resp.Steps))
+	}
+	if got := PrefixTools("mcp_", []Tool{HostedTool("web_search", nil)}); got[0].Name != "web_search" {
+		t.Fatalf("hosted tool renamed to %q", got[0].Name)
+	}
+}
+
+func TestGenerateText_StrictToolOptionalNull(t *testing.T) {
+	fp := &fakeProvider{}
+	fp.generate = func(call int, req provider.Request) (provider.Response, error) {
+		if call == 0 {
+			return provider.Response{
+				Message: provider.Message{
+					Role: provider.RoleAssistant,
+					Content: []provider.ContentPart{
+						// Strict mode makes the model fill every property,
+						// with null for the optional ones.
+						provider.ToolCallPart{ID: "call_1", Name: "search", Args: []byte(`{"query":"go","filter":{"lang":null,"year":2024},"limit":null}`)},
+					},
+				},
+				FinishReason: "tool_calls",
+			}, nil
+		}
+		return provider.Response{
+			Message:      provider.Message{Role: provider.RoleAssistant, Content: []provider.ContentPart{provider.TextPart{Text: "done"}}},
+			FinishReason: "stop",
+		}, nil
+	}
+	providerName := registerFakeProvider(t, fp)
+
+	var got string
+	search := NewDynamicTool("search", DynamicToolSpec{
+		InputSchema: JSONSchema([]byte(`{"type":"object","properties":{
+			"query":{"type":"string"},
+			"limit":{"type":"integer"},
+			"filter":{"type":"object","properties":{"lang":{"type":"string","enum":["go","rust"]},"year":{"type":"integer"}}}
+		},"required":["query"]}`)),
+		Strict: true,
+		Execute: func(ctx context.Context, input json.RawMessage, meta ToolExecutionMeta) (any, error) {
+			got = string(input)
+			return "ok", nil
+		},
+	})
+	if _, err := GenerateText(context.Background(), GenerateTextRequest{BaseRequest: BaseRequest{
+		Model:    testModel{provider: providerName, name: "m"},
+		Messages: []Message{User("find")},
+		Tools:    []Tool{search},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"filter":{"year":2024},"query":"go"}`; got != want {
+		t.Fatalf("input=%s want %s", got, want)
+	}
+
+	var sent map[string]any
+	if err := json.Unmarshal(fp.Requests()[0].Tools[0].InputSchema, &sent); err != nil {
+		t.Fatal(err)
+	}
+	lang := sent["properties"].(map[string]any)["filter"].(map[string]any)["properties"].(map[string]any)["lang"].(map[string]any)
+	if !reflect.DeepEqual(lang["enum"], []any{"go", "rust", nil}) {
+		t.Fatalf("lang=%v", lang)
+	}
+}
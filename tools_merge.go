@@ -0,0 +1,42 @@
+package ai
+
+import "fmt"
+
+// MergeTools concatenates tool sets (e.g. local tools plus tools from several
+// MCP servers) in order. It returns an error if a name is empty or appears
+// more than once; use PrefixTools to namespace sets whose names may overlap.
+func MergeTools(sets ...[]Tool) ([]Tool, error) {
+	n := 0
+	for _, set := range sets {
+		n += len(set)
+	}
+	out := make([]Tool, 0, n)
+	seen := make(map[string]int, n)
+	for i, set := range sets {
+		for _, t := range set {
+			if t.Name == "" {
+				return nil, fmt.Errorf("merge tools: set %d has a tool without a name", i)
+			}
+			if prev, ok := seen[t.Name]; ok {
+				return nil, fmt.Errorf("merge tools: tool name collision: %q in sets %d and %d", t.Name, prev, i)
+			}
+			seen[t.Name] = i
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// PrefixTools returns copies of tools with prefix prepended to each name.
+// Handlers, schemas and hooks are preserved. Hosted tools keep their names,
+// which identify the tool type to the provider.
+func PrefixTools(prefix string, tools []Tool) []Tool {
+	out := make([]Tool, len(tools))
+	for i, t := range tools {
+		if !t.Hosted {
+			t.Name = prefix + t.Name
+		}
+		out[i] = t
+	}
+	return out
+}
@@ -0,0 +1,47 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// WrapOptions configures WrapTool.
+type WrapOptions struct {
+	// Before is called with the model-provided input before the wrapped handler
+	// runs. The returned input is passed to the wrapped handler; returning an
+	// error skips the handler (After still observes the error).
+	Before func(ctx context.Context, input json.RawMessage) (json.RawMessage, error)
+
+	// After is called with the wrapped handler's output and error. The returned
+	// value/error replace the handler result.
+	After func(ctx context.Context, output any, err error) (any, error)
+}
+
+// WrapTool decorates an existing Tool (including MCP-adapted tools) with
+// pre/post hooks. Name, description, schema and streaming input hooks are
+// preserved. Tools without a Handler (such as hosted tools) have nothing to
+// wrap and are returned unchanged.
+func WrapTool(t Tool, opts WrapOptions) Tool {
+	if t.Handler == nil {
+		return t
+	}
+	next := t.Handler
+	out := t
+	out.Handler = func(ctx context.Context, input json.RawMessage) (any, error) {
+		var (
+			val any
+			err error
+		)
+		if opts.Before != nil {
+			input, err = opts.Before(ctx, input)
+		}
+		if err == nil {
+			val, err = next(ctx, input)
+		}
+		if opts.After != nil {
+			return opts.After(ctx, val, err)
+		}
+		return val, err
+	}
+	return out
+}